@@ -59,6 +59,39 @@ func (c *Client) callResult(ctx context.Context, method string, params, result a
 	return err
 }
 
+func (c *Client) GetAccountSequence(ctx context.Context,
+	request protocol.GetAccountSequenceRequest,
+) (protocol.GetAccountSequenceResponse, error) {
+	var result protocol.GetAccountSequenceResponse
+	err := c.callResult(ctx, protocol.GetAccountSequenceMethodName, request, &result)
+	if err != nil {
+		return protocol.GetAccountSequenceResponse{}, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetContractData(ctx context.Context,
+	request protocol.GetContractDataRequest,
+) (protocol.GetContractDataResponse, error) {
+	var result protocol.GetContractDataResponse
+	err := c.callResult(ctx, protocol.GetContractDataMethodName, request, &result)
+	if err != nil {
+		return protocol.GetContractDataResponse{}, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetContractInterface(ctx context.Context,
+	request protocol.GetContractInterfaceRequest,
+) (protocol.GetContractInterfaceResponse, error) {
+	var result protocol.GetContractInterfaceResponse
+	err := c.callResult(ctx, protocol.GetContractInterfaceMethodName, request, &result)
+	if err != nil {
+		return protocol.GetContractInterfaceResponse{}, err
+	}
+	return result, nil
+}
+
 func (c *Client) GetEvents(ctx context.Context,
 	request protocol.GetEventsRequest,
 ) (protocol.GetEventsResponse, error) {
@@ -70,6 +103,17 @@ func (c *Client) GetEvents(ctx context.Context,
 	return result, nil
 }
 
+func (c *Client) GetEventsTip(ctx context.Context,
+	request protocol.GetEventsTipRequest,
+) (protocol.GetEventsTipResponse, error) {
+	var result protocol.GetEventsTipResponse
+	err := c.callResult(ctx, protocol.GetEventsTipMethodName, request, &result)
+	if err != nil {
+		return protocol.GetEventsTipResponse{}, err
+	}
+	return result, nil
+}
+
 func (c *Client) GetFeeStats(ctx context.Context) (protocol.GetFeeStatsResponse, error) {
 	var result protocol.GetFeeStatsResponse
 	err := c.callResult(ctx, protocol.GetFeeStatsMethodName, nil, &result)
@@ -108,6 +152,17 @@ func (c *Client) GetLedgerEntries(ctx context.Context,
 	return result, nil
 }
 
+func (c *Client) GetLedgerCloseMeta(ctx context.Context,
+	request protocol.GetLedgerCloseMetaRequest,
+) (protocol.GetLedgerCloseMetaResponse, error) {
+	var result protocol.GetLedgerCloseMetaResponse
+	err := c.callResult(ctx, protocol.GetLedgerCloseMetaMethodName, request, &result)
+	if err != nil {
+		return protocol.GetLedgerCloseMetaResponse{}, err
+	}
+	return result, nil
+}
+
 func (c *Client) GetLedgers(ctx context.Context,
 	request protocol.GetLedgersRequest,
 ) (protocol.GetLedgersResponse, error) {
@@ -131,6 +186,17 @@ func (c *Client) GetNetwork(ctx context.Context,
 	return result, nil
 }
 
+func (c *Client) GetResourceFee(ctx context.Context,
+	request protocol.GetResourceFeeRequest,
+) (protocol.GetResourceFeeResponse, error) {
+	var result protocol.GetResourceFeeResponse
+	err := c.callResult(ctx, protocol.GetResourceFeeMethodName, request, &result)
+	if err != nil {
+		return protocol.GetResourceFeeResponse{}, err
+	}
+	return result, nil
+}
+
 func (c *Client) GetTransaction(ctx context.Context,
 	request protocol.GetTransactionRequest,
 ) (protocol.GetTransactionResponse, error) {
@@ -153,6 +219,50 @@ func (c *Client) GetTransactions(ctx context.Context,
 	return result, nil
 }
 
+func (c *Client) GetTransactionProof(ctx context.Context,
+	request protocol.GetTransactionProofRequest,
+) (protocol.GetTransactionProofResponse, error) {
+	var result protocol.GetTransactionProofResponse
+	err := c.callResult(ctx, protocol.GetTransactionProofMethodName, request, &result)
+	if err != nil {
+		return protocol.GetTransactionProofResponse{}, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetTransactionsByHash(ctx context.Context,
+	request protocol.GetTransactionsByHashRequest,
+) (protocol.GetTransactionsByHashResponse, error) {
+	var result protocol.GetTransactionsByHashResponse
+	err := c.callResult(ctx, protocol.GetTransactionsByHashMethodName, request, &result)
+	if err != nil {
+		return protocol.GetTransactionsByHashResponse{}, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetTransactionsByContract(ctx context.Context,
+	request protocol.GetTransactionsByContractRequest,
+) (protocol.GetTransactionsByContractResponse, error) {
+	var result protocol.GetTransactionsByContractResponse
+	err := c.callResult(ctx, protocol.GetTransactionsByContractMethodName, request, &result)
+	if err != nil {
+		return protocol.GetTransactionsByContractResponse{}, err
+	}
+	return result, nil
+}
+
+func (c *Client) GetOperations(ctx context.Context,
+	request protocol.GetOperationsRequest,
+) (protocol.GetOperationsResponse, error) {
+	var result protocol.GetOperationsResponse
+	err := c.callResult(ctx, protocol.GetOperationsMethodName, request, &result)
+	if err != nil {
+		return protocol.GetOperationsResponse{}, err
+	}
+	return result, nil
+}
+
 func (c *Client) GetVersionInfo(ctx context.Context) (protocol.GetVersionInfoResponse, error) {
 	var result protocol.GetVersionInfoResponse
 	err := c.callResult(ctx, protocol.GetVersionInfoMethodName, nil, &result)
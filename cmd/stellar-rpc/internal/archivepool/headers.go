@@ -0,0 +1,129 @@
+package archivepool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/support/storage"
+)
+
+// Connect connects to the history archive at archiveURL. The given headers
+// are sent along with every request, which is only supported for HTTP
+// archives. The timeout then bounds the wait for the response to each request,
+// but not the download of the files, which can be large buckets.
+func Connect(
+	archiveURL string, headers map[string]string, timeout time.Duration, opts historyarchive.ArchiveOptions,
+) (*historyarchive.Archive, error) {
+	if len(headers) > 0 {
+		base, err := url.Parse(archiveURL)
+		if err != nil {
+			return nil, err
+		}
+		if base.Scheme != "http" && base.Scheme != "https" {
+			return nil, fmt.Errorf("headers are not supported by archive %s", archiveURL)
+		}
+		ctx := opts.ConnectOptions.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		userAgent := opts.ConnectOptions.UserAgent
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = timeout
+		opts.ConnectOptions.Wrap = func(inner storage.Storage) (storage.Storage, error) {
+			return &headersStorage{
+				Storage:   inner,
+				ctx:       ctx,
+				client:    http.Client{Transport: transport},
+				base:      *base,
+				userAgent: userAgent,
+				headers:   headers,
+			}, nil
+		}
+	}
+	return historyarchive.Connect(archiveURL, opts)
+}
+
+// headersStorage is an HTTP storage which sends extra headers along with its
+// requests. The operations which don't involve requests are delegated to the
+// wrapped storage.
+type headersStorage struct {
+	storage.Storage
+	ctx       context.Context //nolint:containedctx
+	client    http.Client
+	base      url.URL
+	userAgent string
+	headers   map[string]string
+}
+
+func (s *headersStorage) GetFile(pth string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, pth)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad HTTP response '%s' for GET '%s'", resp.Status, resp.Request.URL)
+	}
+	return resp.Body, nil
+}
+
+func (s *headersStorage) Exists(pth string) (bool, error) {
+	resp, err := s.head(pth)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 400:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown status code=%d", resp.StatusCode)
+	}
+}
+
+func (s *headersStorage) Size(pth string) (int64, error) {
+	resp, err := s.head(pth)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 400:
+		return resp.ContentLength, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unknown status code=%d", resp.StatusCode)
+	}
+}
+
+func (s *headersStorage) head(pth string) (*http.Response, error) {
+	resp, err := s.do(http.MethodHead, pth)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+func (s *headersStorage) do(method string, pth string) (*http.Response, error) {
+	derived := s.base
+	derived.Path = path.Join(derived.Path, pth)
+	req, err := http.NewRequestWithContext(s.ctx, method, derived.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.userAgent != "" {
+		req.Header.Set("User-Agent", s.userAgent)
+	}
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+	return s.client.Do(req)
+}
@@ -0,0 +1,260 @@
+package archivepool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+)
+
+// Archive is a history archive of a Pool, together with its weight.
+type Archive struct {
+	historyarchive.ArchiveInterface
+	Name string
+	// Weight is the relative share of the requests sent to the archive
+	Weight uint
+}
+
+// Pool is a historyarchive.ArchiveInterface which distributes the requests
+// among archives according to their weights. When a request to an archive
+// fails, it is retried on the other archives (again, picked according to
+// their weights).
+type Pool struct {
+	logger   *log.Entry
+	archives []Archive
+	// newBackoff returns the retry strategy of a request
+	newBackoff func() backoff.BackOff
+	// randN returns a random number in [0, n)
+	randN func(n uint) uint
+}
+
+var _ historyarchive.ArchiveInterface = &Pool{}
+
+// NewPool creates a Pool from the given archives. Archives with a weight of 0
+// are given a weight of 1.
+func NewPool(archives []Archive, logger *log.Entry) (*Pool, error) {
+	if len(archives) == 0 {
+		return nil, errors.New("no history archives provided")
+	}
+	archives = append([]Archive(nil), archives...)
+	for i := range archives {
+		if archives[i].Weight == 0 {
+			archives[i].Weight = 1
+		}
+	}
+	return &Pool{
+		logger:   logger,
+		archives: archives,
+		newBackoff: func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewConstantBackOff(250*time.Millisecond), 3)
+		},
+		randN: rand.N[uint],
+	}, nil
+}
+
+// order returns the archives in a random order, where archives with higher
+// weights are more likely to come first.
+func (p *Pool) order() []Archive {
+	remaining := append([]Archive(nil), p.archives...)
+	var totalWeight uint
+	for _, archive := range remaining {
+		totalWeight += archive.Weight
+	}
+	result := make([]Archive, 0, len(remaining))
+	for len(remaining) > 0 {
+		target := p.randN(totalWeight)
+		i := 0
+		for ; i < len(remaining)-1; i++ {
+			if target < remaining[i].Weight {
+				break
+			}
+			target -= remaining[i].Weight
+		}
+		result = append(result, remaining[i])
+		totalWeight -= remaining[i].Weight
+		remaining = append(remaining[:i], remaining[i+1:]...)
+	}
+	return result
+}
+
+func (p *Pool) next() historyarchive.ArchiveInterface {
+	return p.order()[0]
+}
+
+// run runs the action on the archives, in weighted order, until it succeeds or
+// the retries are exhausted.
+func (p *Pool) run(runner func(ai historyarchive.ArchiveInterface) error) error {
+	archives := p.order()
+	attempt := 0
+	return backoff.Retry(func() error {
+		archive := archives[attempt%len(archives)]
+		attempt++
+		err := runner(archive)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return backoff.Permanent(err)
+		}
+		if p.logger != nil {
+			p.logger.WithError(err).Warnf("Encountered an error with archive '%s'", archive.Name)
+		}
+		return err
+	}, p.newBackoff())
+}
+
+func (p *Pool) GetPathHAS(path string) (historyarchive.HistoryArchiveState, error) {
+	var has historyarchive.HistoryArchiveState
+	return has, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		has, err = ai.GetPathHAS(path)
+		return err
+	})
+}
+
+func (p *Pool) PutPathHAS(
+	path string, has historyarchive.HistoryArchiveState, opts *historyarchive.CommandOptions,
+) error {
+	return p.run(func(ai historyarchive.ArchiveInterface) error {
+		return ai.PutPathHAS(path, has, opts)
+	})
+}
+
+func (p *Pool) BucketExists(bucket historyarchive.Hash) (bool, error) {
+	var exists bool
+	return exists, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		exists, err = ai.BucketExists(bucket)
+		return err
+	})
+}
+
+func (p *Pool) BucketSize(bucket historyarchive.Hash) (int64, error) {
+	var size int64
+	return size, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		size, err = ai.BucketSize(bucket)
+		return err
+	})
+}
+
+func (p *Pool) CategoryCheckpointExists(cat string, chk uint32) (bool, error) {
+	var exists bool
+	return exists, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		exists, err = ai.CategoryCheckpointExists(cat, chk)
+		return err
+	})
+}
+
+func (p *Pool) GetLedgerHeader(chk uint32) (xdr.LedgerHeaderHistoryEntry, error) {
+	var entry xdr.LedgerHeaderHistoryEntry
+	return entry, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		entry, err = ai.GetLedgerHeader(chk)
+		return err
+	})
+}
+
+func (p *Pool) GetRootHAS() (historyarchive.HistoryArchiveState, error) {
+	var has historyarchive.HistoryArchiveState
+	return has, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		has, err = ai.GetRootHAS()
+		return err
+	})
+}
+
+func (p *Pool) GetLedgers(start, end uint32) (map[uint32]*historyarchive.Ledger, error) {
+	var ledgers map[uint32]*historyarchive.Ledger
+	return ledgers, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		ledgers, err = ai.GetLedgers(start, end)
+		return err
+	})
+}
+
+func (p *Pool) GetLatestLedgerSequence() (uint32, error) {
+	has, err := p.GetRootHAS()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve the latest ledger sequence from history archive: %w", err)
+	}
+	return has.CurrentLedger, nil
+}
+
+func (p *Pool) GetCheckpointHAS(chk uint32) (historyarchive.HistoryArchiveState, error) {
+	var has historyarchive.HistoryArchiveState
+	return has, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		has, err = ai.GetCheckpointHAS(chk)
+		return err
+	})
+}
+
+func (p *Pool) PutCheckpointHAS(
+	chk uint32, has historyarchive.HistoryArchiveState, opts *historyarchive.CommandOptions,
+) error {
+	return p.run(func(ai historyarchive.ArchiveInterface) error {
+		return ai.PutCheckpointHAS(chk, has, opts)
+	})
+}
+
+func (p *Pool) PutRootHAS(has historyarchive.HistoryArchiveState, opts *historyarchive.CommandOptions) error {
+	return p.run(func(ai historyarchive.ArchiveInterface) error {
+		return ai.PutRootHAS(has, opts)
+	})
+}
+
+func (p *Pool) GetXdrStreamForHash(hash historyarchive.Hash) (*xdr.Stream, error) {
+	var stream *xdr.Stream
+	return stream, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		stream, err = ai.GetXdrStreamForHash(hash)
+		return err
+	})
+}
+
+func (p *Pool) GetXdrStream(pth string) (*xdr.Stream, error) {
+	var stream *xdr.Stream
+	return stream, p.run(func(ai historyarchive.ArchiveInterface) error {
+		var err error
+		stream, err = ai.GetXdrStream(pth)
+		return err
+	})
+}
+
+func (p *Pool) GetCheckpointManager() historyarchive.CheckpointManager {
+	return p.next().GetCheckpointManager()
+}
+
+func (p *Pool) GetStats() []historyarchive.ArchiveStats {
+	var stats []historyarchive.ArchiveStats
+	for _, archive := range p.archives {
+		stats = append(stats, archive.GetStats()...)
+	}
+	return stats
+}
+
+// The channel-based methods are not retried.
+
+func (p *Pool) ListBucket(dp historyarchive.DirPrefix) (chan string, chan error) {
+	return p.next().ListBucket(dp)
+}
+
+func (p *Pool) ListAllBuckets() (chan string, chan error) {
+	return p.next().ListAllBuckets()
+}
+
+func (p *Pool) ListAllBucketHashes() (chan historyarchive.Hash, chan error) {
+	return p.next().ListAllBucketHashes()
+}
+
+func (p *Pool) ListCategoryCheckpoints(cat string, pth string) (chan uint32, chan error) {
+	return p.next().ListCategoryCheckpoints(cat, pth)
+}
@@ -0,0 +1,114 @@
+package archivepool
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+)
+
+type fakeArchive struct {
+	historyarchive.ArchiveInterface
+	calls int
+	err   error
+}
+
+func (a *fakeArchive) GetRootHAS() (historyarchive.HistoryArchiveState, error) {
+	a.calls++
+	return historyarchive.HistoryArchiveState{CurrentLedger: 63}, a.err
+}
+
+func newTestPool(t *testing.T, archives ...Archive) *Pool {
+	pool, err := NewPool(archives, nil)
+	require.NoError(t, err)
+	pool.newBackoff = func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3)
+	}
+	return pool
+}
+
+func TestPoolWeights(t *testing.T) {
+	light, heavy := &fakeArchive{}, &fakeArchive{}
+	pool := newTestPool(t,
+		Archive{ArchiveInterface: light, Name: "light"},
+		Archive{ArchiveInterface: heavy, Name: "heavy", Weight: 3},
+	)
+	// iterate over all the possible random values
+	for i := range uint(4) {
+		pool.randN = func(uint) uint { return i }
+		seq, err := pool.GetLatestLedgerSequence()
+		require.NoError(t, err)
+		assert.Equal(t, uint32(63), seq)
+	}
+	assert.Equal(t, 1, light.calls)
+	assert.Equal(t, 3, heavy.calls)
+}
+
+func TestPoolFailover(t *testing.T) {
+	failing, healthy := &fakeArchive{err: errors.New("boom")}, &fakeArchive{}
+	pool := newTestPool(t,
+		Archive{ArchiveInterface: failing, Name: "failing", Weight: 100},
+		Archive{ArchiveInterface: healthy, Name: "healthy"},
+	)
+	pool.randN = func(uint) uint { return 0 }
+	_, err := pool.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 1, healthy.calls)
+
+	healthy.err = errors.New("boom")
+	_, err = pool.GetRootHAS()
+	require.ErrorContains(t, err, "boom")
+	// the initial attempt plus 3 retries, alternating between archives
+	assert.Equal(t, 3, failing.calls)
+	assert.Equal(t, 3, healthy.calls)
+}
+
+func TestConnectWithHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("User-Agent") != "test-agent" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"version": 1, "currentLedger": 63}`))
+	}))
+	defer server.Close()
+
+	opts := historyarchive.ArchiveOptions{CheckpointFrequency: 64}
+	opts.ConnectOptions.UserAgent = "test-agent"
+	archive, err := Connect(server.URL, map[string]string{"Authorization": "Bearer token"}, time.Second, opts)
+	require.NoError(t, err)
+	has, err := archive.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(63), has.CurrentLedger)
+
+	archive, err = Connect(server.URL, nil, time.Second, opts)
+	require.NoError(t, err)
+	_, err = archive.GetRootHAS()
+	require.ErrorContains(t, err, "401")
+
+	_, err = Connect("s3://bucket", map[string]string{"Authorization": "Bearer token"}, time.Second, opts)
+	require.ErrorContains(t, err, "headers are not supported")
+}
+
+func TestConnectWithHeadersTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	opts := historyarchive.ArchiveOptions{CheckpointFrequency: 64}
+	archive, err := Connect(server.URL, map[string]string{"Authorization": "Bearer token"}, 10*time.Millisecond, opts)
+	require.NoError(t, err)
+	_, err = archive.GetRootHAS()
+	require.ErrorContains(t, err, "timeout")
+}
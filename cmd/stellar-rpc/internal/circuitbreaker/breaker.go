@@ -0,0 +1,140 @@
+// Package circuitbreaker provides a circuit breaker, which makes calls to an
+// unavailable dependency fail fast instead of waiting for them to time out.
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Breaker.Allow while the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	// StateClosed lets all the calls through.
+	StateClosed State = iota
+	// StateHalfOpen lets a single probe call through after the cooldown,
+	// which decides whether the breaker closes or opens again.
+	StateHalfOpen
+	// StateOpen rejects all the calls until the cooldown elapses.
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker opens after a number of consecutive failures and then rejects the
+// calls for a cooldown period, after which a probe call is let through to
+// find out whether the dependency recovered.
+//
+// A nil Breaker is valid and lets all the calls through.
+type Breaker struct {
+	threshold uint
+	cooldown  time.Duration
+	now       func() time.Time
+
+	lock     sync.Mutex
+	state    State
+	failures uint
+	openedAt time.Time
+	probing  bool
+}
+
+// New creates a Breaker which opens after threshold consecutive failures.
+// A zero threshold disables the breaker, in which case nil is returned.
+func New(threshold uint, cooldown time.Duration) *Breaker {
+	if threshold == 0 {
+		return nil
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow returns ErrOpen if the call must be rejected. Otherwise, the outcome
+// of the call must be reported with Done, or the call released with Release.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Done records the outcome of a call allowed by Allow.
+func (b *Breaker) Done(success bool) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == StateHalfOpen {
+		b.probing = false
+	}
+	if success {
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Release ends a call allowed by Allow without recording an outcome, like
+// when the call is aborted by the caller. A released probe lets the next call
+// through as the probe.
+func (b *Breaker) Release() {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == StateHalfOpen {
+		b.probing = false
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	if b == nil {
+		return StateClosed
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
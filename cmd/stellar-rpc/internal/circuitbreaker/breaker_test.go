@@ -0,0 +1,84 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(100, 0)
+	breaker := New(3, time.Second)
+	breaker.now = func() time.Time { return now }
+
+	fail := func() {
+		require.NoError(t, breaker.Allow())
+		breaker.Done(false)
+	}
+
+	fail()
+	fail()
+	// a success resets the consecutive failures
+	require.NoError(t, breaker.Allow())
+	breaker.Done(true)
+	fail()
+	fail()
+	assert.Equal(t, StateClosed, breaker.State())
+	fail()
+	assert.Equal(t, StateOpen, breaker.State())
+	require.ErrorIs(t, breaker.Allow(), ErrOpen)
+
+	// after the cooldown a single probe is let through
+	now = now.Add(time.Second)
+	require.NoError(t, breaker.Allow())
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	require.ErrorIs(t, breaker.Allow(), ErrOpen)
+
+	// a failed probe opens the breaker again
+	breaker.Done(false)
+	assert.Equal(t, StateOpen, breaker.State())
+	require.ErrorIs(t, breaker.Allow(), ErrOpen)
+
+	// and a successful one closes it
+	now = now.Add(time.Second)
+	require.NoError(t, breaker.Allow())
+	breaker.Done(true)
+	assert.Equal(t, StateClosed, breaker.State())
+	require.NoError(t, breaker.Allow())
+}
+
+func TestBreakerRelease(t *testing.T) {
+	now := time.Unix(100, 0)
+	breaker := New(1, time.Second)
+	breaker.now = func() time.Time { return now }
+
+	// released calls don't count as successes
+	require.NoError(t, breaker.Allow())
+	breaker.Release()
+	require.NoError(t, breaker.Allow())
+	breaker.Done(false)
+	assert.Equal(t, StateOpen, breaker.State())
+
+	// nor leave the breaker waiting for the outcome of a probe
+	now = now.Add(time.Second)
+	require.NoError(t, breaker.Allow())
+	breaker.Release()
+	assert.Equal(t, StateHalfOpen, breaker.State())
+	require.NoError(t, breaker.Allow())
+	require.ErrorIs(t, breaker.Allow(), ErrOpen)
+	breaker.Done(true)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	breaker := New(0, time.Second)
+	require.Nil(t, breaker)
+	for range 10 {
+		require.NoError(t, breaker.Allow())
+		breaker.Done(false)
+		breaker.Release()
+	}
+	assert.Equal(t, StateClosed, breaker.State())
+}
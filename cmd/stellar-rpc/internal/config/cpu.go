@@ -0,0 +1,84 @@
+package config
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// cgroupV2CPUMaxPath holds the CPU quota and period of the cgroup (v2) of
+	// the process, e.g. "200000 100000" or "max 100000" when unlimited.
+	cgroupV2CPUMaxPath = "/sys/fs/cgroup/cpu.max"
+	// The cgroup v1 CPU quota (-1 when unlimited) and period.
+	cgroupV1CPUQuotaPath  = "/sys/fs/cgroup/cpu/cpu.cfs_quota_us"
+	cgroupV1CPUPeriodPath = "/sys/fs/cgroup/cpu/cpu.cfs_period_us"
+)
+
+// cpuQuota converts a cgroup CPU quota and period into a number of CPUs,
+// rounded up. It returns false if the quota is unlimited or malformed.
+func cpuQuota(quota string, period string) (int, bool) {
+	q, err := strconv.ParseInt(strings.TrimSpace(quota), 10, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseInt(strings.TrimSpace(period), 10, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return int(math.Ceil(float64(q) / float64(p))), true
+}
+
+// cgroupCPUQuota returns the number of CPUs the cgroup of the process (e.g.
+// its container) is limited to, if any.
+func cgroupCPUQuota() (int, bool) {
+	if content, err := os.ReadFile(cgroupV2CPUMaxPath); err == nil {
+		fields := strings.Fields(string(content))
+		if len(fields) != 2 {
+			return 0, false
+		}
+		return cpuQuota(fields[0], fields[1])
+	}
+	quota, err := os.ReadFile(cgroupV1CPUQuotaPath)
+	if err != nil {
+		return 0, false
+	}
+	period, err := os.ReadFile(cgroupV1CPUPeriodPath)
+	if err != nil {
+		return 0, false
+	}
+	return cpuQuota(string(quota), string(period))
+}
+
+// defaultCPUCount is the number of CPUs available to the process: the CPU
+// quota of its cgroup, if lower than the number of CPUs of the machine (which
+// runtime.NumCPU returns even in containers with CPU limits).
+func defaultCPUCount() uint {
+	count := runtime.NumCPU()
+	if quota, ok := cgroupCPUQuota(); ok {
+		count = min(count, quota)
+	}
+	return uint(max(count, 1))
+}
+
+// ResolveCPUCount derives, from CPUCount, the worker counts which weren't set
+// explicitly, and sets GOMAXPROCS to it, so that an explicit cpu-count is
+// honored consistently.
+func (cfg *Config) ResolveCPUCount() {
+	for _, option := range cfg.options() {
+		if option.explicit {
+			continue
+		}
+		switch option.ConfigKey {
+		case &cfg.PreflightWorkerCount:
+			cfg.PreflightWorkerCount = cfg.CPUCount
+		case &cfg.PreflightWorkerQueueSize:
+			cfg.PreflightWorkerQueueSize = cfg.CPUCount
+		case &cfg.CaptiveCoreHTTPQueryThreadPoolSize:
+			cfg.CaptiveCoreHTTPQueryThreadPoolSize = uint16(min(cfg.CPUCount, math.MaxUint16)) //nolint:gosec
+		}
+	}
+	runtime.GOMAXPROCS(int(min(cfg.CPUCount, math.MaxInt32))) //nolint:gosec
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPUQuota(t *testing.T) {
+	for _, tc := range []struct {
+		quota, period string
+		cpus          int
+		ok            bool
+	}{
+		{"200000", "100000", 2, true},
+		{"150000", "100000\n", 2, true},
+		{"50000", "100000", 1, true},
+		{"max", "100000", 0, false},
+		{"-1", "100000", 0, false},
+		{"100000", "0", 0, false},
+	} {
+		cpus, ok := cpuQuota(tc.quota, tc.period)
+		assert.Equal(t, tc.ok, ok, "%s/%s", tc.quota, tc.period)
+		assert.Equal(t, tc.cpus, cpus, "%s/%s", tc.quota, tc.period)
+	}
+}
+
+func TestResolveCPUCount(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	var cfg Config
+	require.NoError(t, cfg.SetValues(func(key string) (string, bool) {
+		switch key {
+		case "CPU_COUNT":
+			return "3", true
+		case "PREFLIGHT_WORKER_QUEUE_SIZE":
+			return "10", true
+		default:
+			return "", false
+		}
+	}))
+	cfg.ResolveCPUCount()
+	assert.Equal(t, 3, runtime.GOMAXPROCS(0))
+	assert.Equal(t, uint(3), cfg.PreflightWorkerCount)
+	assert.Equal(t, uint16(3), cfg.CaptiveCoreHTTPQueryThreadPoolSize)
+	// the explicit worker counts take precedence
+	assert.Equal(t, uint(10), cfg.PreflightWorkerQueueSize)
+}
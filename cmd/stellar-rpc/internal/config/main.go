@@ -1,13 +1,21 @@
 package config
 
 import (
+	"fmt"
+	"math"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/stellar/go/ingest/ledgerbackend"
 	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/network"
 )
 
 // Config represents the configuration of a stellar-rpc server
@@ -17,6 +25,7 @@ type Config struct {
 	Strict bool
 
 	StellarCoreURL                      string
+	StellarCores                        []StellarCore
 	CaptiveCoreStoragePath              string
 	StellarCoreBinaryPath               string
 	CaptiveCoreConfigPath               string
@@ -27,39 +36,106 @@ type Config struct {
 
 	Endpoint                                       string
 	AdminEndpoint                                  string
+	MetricsEndpoint                                string
 	CheckpointFrequency                            uint32
 	CoreRequestTimeout                             time.Duration
+	CoreQueryCircuitBreakerThreshold               uint
+	CoreQueryCircuitBreakerCooldown                time.Duration
+	CoreQueryMaxIdleConns                          uint
+	CoreQueryMaxConnsPerHost                       uint
+	CoreQueryIdleConnTimeout                       time.Duration
+	SendTransactionIdempotencyWindow               time.Duration
+	SendTransactionCoreRetries                     uint
+	SendTransactionCoreRetryBackoff                time.Duration
+	SendTransactionMaxExpiredAge                   time.Duration
+	SubmittedTransactionPendingWindow              time.Duration
 	DefaultEventsLimit                             uint
 	DefaultTransactionsLimit                       uint
 	DefaultLedgersLimit                            uint
 	FriendbotURL                                   string
 	HistoryArchiveURLs                             []string
+	HistoryArchives                                []HistoryArchive
 	HistoryArchiveUserAgent                        string
 	IngestionTimeout                               time.Duration
+	IngestionBatchSize                             uint
+	CheckpointDeferralLatencyThreshold             time.Duration
+	CheckpointDeferralMaxCheckpoints               uint
+	CheckpointDeferralMaxWALSizeBytes              uint64
+	IngestionStartLedgerFloor                      uint32
+	IngestionStartWithinRetentionWindow            bool
+	IngestionAbortOnLedgerGap                      bool
+	IngestOperations                               bool
+	IndexContractData                              bool
 	LogFormat                                      LogFormat
 	LogLevel                                       logrus.Level
 	MaxEventsLimit                                 uint
+	MaxEventSize                                   uint
+	OversizedEventPolicy                           string
+	EventStorageFormat                             string
 	MaxTransactionsLimit                           uint
+	MaxTransactionsByHashLimit                     uint
 	MaxLedgersLimit                                uint
+	LedgerEntriesHotKeys                           uint
+	MaxLedgerEntriesKeys                           uint
+	DefaultLedgerEntriesKeys                       uint
+	InfoResponseCacheTTL                           time.Duration
 	MaxHealthyLedgerLatency                        time.Duration
+	WarmupOnStartup                                bool
+	WarmupLedgerKeys                               []string
 	NetworkPassphrase                              string
+	SimulateTransactionStrictValidation            bool
+	SimulateTransactionCoreFallback                bool
 	PreflightWorkerCount                           uint
+	CPUCount                                       uint
 	PreflightWorkerQueueSize                       uint
 	PreflightEnableDebug                           bool
+	PreflightTimeout                               time.Duration
+	MaxSimulationInstructionLimit                  uint
+	MaxSimulationMemoryLimit                       uint
 	SQLiteDBPath                                   string
+	SQLiteJournalMode                              string
+	SQLiteSynchronous                              string
+	SQLiteAllowNewerSchema                         bool
+	SQLiteVacuumInterval                           time.Duration
+	SQLiteVacuumWindow                             string
+	MaintenanceWindow                              string
+	MaintenanceWindowTimezone                      string
 	HistoryRetentionWindow                         uint32
+	HistoryRetentionDuration                       time.Duration
+	MaxDBSizeBytes                                 uint64
 	SorobanFeeStatsLedgerRetentionWindow           uint32
 	ClassicFeeStatsLedgerRetentionWindow           uint32
+	MaxConcurrentRequestsPerClient                 uint
+	ClientIPHeader                                 string
+	TrustedProxies                                 []string
+	CORSMethods                                    []string
+	FinalizedResponseMaxAge                        time.Duration
+	AuditLogPath                                   string
+	AuditLogMaxSizeMB                              uint
+	AuditLogMaxBackups                             uint
+	AuditLogBodies                                 bool
+	PrettyJSON                                     bool
 	RequestBacklogGlobalQueueLimit                 uint
+	RequestBacklogMethodPriorities                 []string
+	MethodMaxRequestSizes                          []string
+	RequestLogSampleRates                          []string
+	MaxRequestSize                                 uint
+	RequestSizeWarningThreshold                    uint
 	RequestBacklogGetHealthQueueLimit              uint
 	RequestBacklogGetEventsQueueLimit              uint
 	RequestBacklogGetNetworkQueueLimit             uint
 	RequestBacklogGetVersionInfoQueueLimit         uint
 	RequestBacklogGetLatestLedgerQueueLimit        uint
 	RequestBacklogGetLedgerEntriesQueueLimit       uint
+	RequestBacklogGetContractInterfaceQueueLimit   uint
+	RequestBacklogGetAccountSequenceQueueLimit     uint
+	RequestBacklogGetResourceFeeQueueLimit         uint
+	RequestBacklogGetContractDataQueueLimit        uint
 	RequestBacklogGetTransactionQueueLimit         uint
 	RequestBacklogGetTransactionsQueueLimit        uint
+	RequestBacklogGetTransactionsByHashQueueLimit  uint
 	RequestBacklogGetLedgersQueueLimit             uint
+	RequestBacklogGetLedgerCloseMetaQueueLimit     uint
 	RequestBacklogSendTransactionQueueLimit        uint
 	RequestBacklogSimulateTransactionQueueLimit    uint
 	RequestBacklogGetFeeStatsTransactionQueueLimit uint
@@ -71,21 +147,119 @@ type Config struct {
 	MaxGetVersionInfoExecutionDuration             time.Duration
 	MaxGetLatestLedgerExecutionDuration            time.Duration
 	MaxGetLedgerEntriesExecutionDuration           time.Duration
+	MaxGetContractInterfaceExecutionDuration       time.Duration
+	MaxGetAccountSequenceExecutionDuration         time.Duration
+	MaxGetResourceFeeExecutionDuration             time.Duration
+	MaxGetContractDataExecutionDuration            time.Duration
 	MaxGetTransactionExecutionDuration             time.Duration
 	MaxGetTransactionsExecutionDuration            time.Duration
+	MaxGetTransactionsByHashExecutionDuration      time.Duration
 	MaxGetLedgersExecutionDuration                 time.Duration
+	MaxGetLedgerCloseMetaExecutionDuration         time.Duration
 	MaxSendTransactionExecutionDuration            time.Duration
 	MaxSimulateTransactionExecutionDuration        time.Duration
 	MaxGetFeeStatsExecutionDuration                time.Duration
+	MemoryShedHeapThreshold                        uint64
 	ServeLedgersFromDatastore                      bool
+	LedgerReadFallbackToDatastore                  bool
+	DatastoreReadRetries                           uint
+	CheckDatastoreOnStartup                        bool
 	BufferedStorageBackendConfig                   ledgerbackend.BufferedStorageBackendConfig
 	DataStoreConfig                                datastore.DataStoreConfig
 
+	RequestBacklogGetTransactionsByContractQueueLimit uint
+	MaxGetTransactionsByContractExecutionDuration     time.Duration
+	RequestBacklogGetTransactionProofQueueLimit       uint
+	MaxGetTransactionProofExecutionDuration           time.Duration
+	RequestBacklogGetOperationsQueueLimit             uint
+	MaxGetOperationsExecutionDuration                 time.Duration
+
 	// We memoize these, so they bind to pflags correctly
 	optionsCache *Options
 	flagset      *pflag.FlagSet
 }
 
+// HistoryArchive is the configuration of a single history archive. Only the
+// URL is passed on to captive core, the other settings only apply to the
+// requests made by RPC itself.
+type HistoryArchive struct {
+	URL string `toml:"url"`
+	// UserAgent overrides the global history archive user agent
+	UserAgent string `toml:"user_agent,omitempty"`
+	// Headers are sent along with every request to the archive (e.g. for
+	// authentication). They are only supported by HTTP archives.
+	Headers map[string]string `toml:"headers,omitempty"`
+	// Weight is the relative share of the requests sent to the archive, 0
+	// means 1.
+	Weight uint `toml:"weight,omitempty"`
+}
+
+// AllHistoryArchives returns the configured history archives, including the
+// ones given through the history-archive-urls shorthand, with their defaults
+// filled in.
+func (cfg *Config) AllHistoryArchives() []HistoryArchive {
+	archives := make([]HistoryArchive, 0, len(cfg.HistoryArchiveURLs)+len(cfg.HistoryArchives))
+	for _, url := range cfg.HistoryArchiveURLs {
+		archives = append(archives, HistoryArchive{URL: url})
+	}
+	archives = append(archives, cfg.HistoryArchives...)
+	for i := range archives {
+		if archives[i].UserAgent == "" {
+			archives[i].UserAgent = cfg.HistoryArchiveUserAgent
+		}
+		if archives[i].Weight == 0 {
+			archives[i].Weight = 1
+		}
+	}
+	return archives
+}
+
+// StellarCore is the configuration of a stellar-core instance which
+// transactions are submitted to.
+type StellarCore struct {
+	URL string `toml:"url"`
+	// Weight is the relative share of the submissions sent to the instance, 0
+	// means 1.
+	Weight uint `toml:"weight,omitempty"`
+}
+
+// AllStellarCores returns the stellar-core instances which transactions are
+// submitted to: the stellar_cores entries, if any, and the instance at
+// StellarCoreURL otherwise.
+func (cfg *Config) AllStellarCores() []StellarCore {
+	if len(cfg.StellarCores) == 0 {
+		return []StellarCore{{URL: cfg.StellarCoreURL, Weight: 1}}
+	}
+	cores := append([]StellarCore(nil), cfg.StellarCores...)
+	for i := range cores {
+		if cores[i].Weight == 0 {
+			cores[i].Weight = 1
+		}
+	}
+	return cores
+}
+
+// WarmupLedgerKeysXDR returns the decoded WarmupLedgerKeys.
+func (cfg *Config) WarmupLedgerKeysXDR() ([]xdr.LedgerKey, error) {
+	keys := make([]xdr.LedgerKey, len(cfg.WarmupLedgerKeys))
+	for i, key := range cfg.WarmupLedgerKeys {
+		if err := xdr.SafeUnmarshalBase64(key, &keys[i]); err != nil {
+			return nil, fmt.Errorf("invalid warmup ledger key %q: %w", key, err)
+		}
+	}
+	return keys, nil
+}
+
+// AllHistoryArchiveURLs returns the URLs of AllHistoryArchives.
+func (cfg *Config) AllHistoryArchiveURLs() []string {
+	archives := cfg.AllHistoryArchives()
+	urls := make([]string, 0, len(archives))
+	for _, archive := range archives {
+		urls = append(urls, archive.URL)
+	}
+	return urls
+}
+
 func (cfg *Config) ExtendedUserAgent(extension string) string {
 	if cfg.HistoryArchiveUserAgent == "" {
 		return extension
@@ -155,6 +329,7 @@ func (cfg *Config) loadEnv(lookupEnv func(string) (string, bool)) error {
 		if err := option.setValue(value); err != nil {
 			return err
 		}
+		option.explicit = true
 	}
 	return nil
 }
@@ -172,6 +347,7 @@ func (cfg *Config) loadFlags() error {
 		if err := option.setValue(val); err != nil {
 			return err
 		}
+		option.explicit = true
 	}
 	return nil
 }
@@ -190,5 +366,131 @@ func (cfg *Config) loadConfigPath() error {
 }
 
 func (cfg *Config) Validate() error {
-	return cfg.options().Validate()
+	if err := cfg.options().Validate(); err != nil {
+		return err
+	}
+	return cfg.validatePorts()
+}
+
+// validatePorts checks that the ports used by captive core don't conflict
+// with each other or with the RPC endpoint, which otherwise makes captive core
+// fail to start in a confusing way.
+func (cfg *Config) validatePorts() error {
+	ports := map[uint16]string{}
+	if _, portStr, err := net.SplitHostPort(cfg.Endpoint); err == nil {
+		if port, err := strconv.ParseUint(portStr, 10, 16); err == nil && port != 0 {
+			ports[uint16(port)] = "endpoint"
+		}
+	}
+	check := func(name string, port uint16) error {
+		if port == 0 {
+			return nil
+		}
+		if other, ok := ports[port]; ok {
+			return fmt.Errorf("%s (%d) must not be the same as %s", name, port, other)
+		}
+		ports[port] = name
+		return nil
+	}
+	if err := check("stellar-captive-core-http-port", cfg.CaptiveCoreHTTPPort); err != nil {
+		return err
+	}
+	return check("stellar-captive-core-http-query-port", cfg.CaptiveCoreHTTPQueryPort)
+}
+
+// HistoryRetentionWindowFor converts HistoryRetentionDuration into a history
+// retention window using the given average ledger close time. It returns false
+// if no duration is configured or if the retention window was set explicitly,
+// which takes precedence.
+func (cfg *Config) HistoryRetentionWindowFor(averageLedgerCloseTime time.Duration) (uint32, bool) {
+	if cfg.HistoryRetentionDuration <= 0 || averageLedgerCloseTime <= 0 {
+		return 0, false
+	}
+	for _, option := range cfg.options() {
+		if option.ConfigKey == &cfg.HistoryRetentionWindow && option.explicit {
+			return 0, false
+		}
+	}
+	// round up, so that at least the requested duration is retained
+	ledgers := (cfg.HistoryRetentionDuration + averageLedgerCloseTime - 1) / averageLedgerCloseTime
+	return uint32(min(ledgers, math.MaxUint32)), true //nolint:gosec
+}
+
+// ResolveHistoryRetentionWindow sets HistoryRetentionWindow to the window
+// converted from HistoryRetentionDuration (see HistoryRetentionWindowFor). It
+// returns whether the window changed.
+func (cfg *Config) ResolveHistoryRetentionWindow(averageLedgerCloseTime time.Duration) bool {
+	window, ok := cfg.HistoryRetentionWindowFor(averageLedgerCloseTime)
+	if !ok || window == cfg.HistoryRetentionWindow {
+		return false
+	}
+	cfg.HistoryRetentionWindow = window
+	return true
+}
+
+// SQLiteCorruptionRisk returns a description of why the configured SQLite
+// journal and synchronous modes risk corrupting the database after a crash or
+// power loss, or an empty string if they don't.
+func (cfg *Config) SQLiteCorruptionRisk() string {
+	switch {
+	case cfg.SQLiteJournalMode == "OFF" || cfg.SQLiteJournalMode == "MEMORY":
+		return "journal_mode " + cfg.SQLiteJournalMode + " cannot roll back interrupted transactions"
+	case cfg.SQLiteSynchronous == "OFF":
+		return "synchronous OFF does not wait for writes to reach the disk"
+	case cfg.SQLiteSynchronous == "NORMAL" && cfg.SQLiteJournalMode != "WAL":
+		return "synchronous NORMAL is only safe in WAL journal mode"
+	default:
+		return ""
+	}
+}
+
+// MethodMaxRequestSizeMap returns the parsed MethodMaxRequestSizes, in bytes.
+func (cfg *Config) MethodMaxRequestSizeMap() (map[string]uint, error) {
+	sizes := make(map[string]uint, len(cfg.MethodMaxRequestSizes))
+	for _, item := range cfg.MethodMaxRequestSizes {
+		method, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("method request size %q must have the method=bytes format", item)
+		}
+		size, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("invalid request size of %s: %q must be a positive number of bytes", method, value)
+		}
+		sizes[method] = uint(size)
+	}
+	return sizes, nil
+}
+
+// RequestLogSampleRateMap returns the parsed RequestLogSampleRates.
+func (cfg *Config) RequestLogSampleRateMap() (map[string]uint64, error) {
+	rates := make(map[string]uint64, len(cfg.RequestLogSampleRates))
+	for _, item := range cfg.RequestLogSampleRates {
+		method, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("method log sample rate %q must have the method=N format", item)
+		}
+		rate, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || rate == 0 {
+			return nil, fmt.Errorf("invalid log sample rate of %s: %q must be a positive number", method, value)
+		}
+		rates[method] = rate
+	}
+	return rates, nil
+}
+
+// RequestBacklogMethodPriorityMap returns the parsed RequestBacklogMethodPriorities.
+func (cfg *Config) RequestBacklogMethodPriorityMap() (map[string]network.RequestPriority, error) {
+	priorities := make(map[string]network.RequestPriority, len(cfg.RequestBacklogMethodPriorities))
+	for _, item := range cfg.RequestBacklogMethodPriorities {
+		method, name, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("method priority %q must have the method=priority format", item)
+		}
+		priority, err := network.ParseRequestPriority(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority of %s: %w", method, err)
+		}
+		priorities[method] = priority
+	}
+	return priorities, nil
 }
@@ -85,3 +85,99 @@ func TestConfigLoadFlagsDefaultValuesOverrideExisting(t *testing.T) {
 	// Check it didn't overwrite values which were not set in the flags
 	assert.Equal(t, "localhost:8000", cfg.Endpoint)
 }
+
+func TestResolveHistoryRetentionWindow(t *testing.T) {
+	var cfg Config
+	require.NoError(t, cfg.loadDefaults())
+	assert.False(t, cfg.ResolveHistoryRetentionWindow(NominalLedgerCloseTime),
+		"should not change the window if no duration is configured")
+	assert.Equal(t, uint32(SevenDayOfLedgers), cfg.HistoryRetentionWindow)
+
+	require.NoError(t, cfg.SetValues(func(key string) (string, bool) {
+		if key == "HISTORY_RETENTION_DURATION" {
+			return "24h", true
+		}
+		return "", false
+	}))
+	assert.True(t, cfg.ResolveHistoryRetentionWindow(NominalLedgerCloseTime))
+	assert.Equal(t, uint32(OneDayOfLedgers), cfg.HistoryRetentionWindow)
+
+	// partial ledgers are rounded up
+	assert.True(t, cfg.ResolveHistoryRetentionWindow(7*time.Second))
+	assert.Equal(t, uint32(12343), cfg.HistoryRetentionWindow)
+
+	// an explicit window takes precedence
+	cfg = Config{}
+	require.NoError(t, cfg.SetValues(func(key string) (string, bool) {
+		switch key {
+		case "HISTORY_RETENTION_DURATION":
+			return "24h", true
+		case "HISTORY_RETENTION_WINDOW":
+			return "100", true
+		default:
+			return "", false
+		}
+	}))
+	assert.False(t, cfg.ResolveHistoryRetentionWindow(NominalLedgerCloseTime))
+	assert.Equal(t, uint32(100), cfg.HistoryRetentionWindow)
+	_, ok := cfg.HistoryRetentionWindowFor(NominalLedgerCloseTime)
+	assert.False(t, ok)
+}
+
+func TestSQLiteModes(t *testing.T) {
+	var cfg Config
+	require.NoError(t, cfg.loadDefaults())
+	validate := func() error {
+		for _, option := range cfg.options() {
+			if option.Name == "sqlite-journal-mode" || option.Name == "sqlite-synchronous" {
+				if err := option.Validate(option); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	require.NoError(t, validate())
+	assert.Equal(t, "WAL", cfg.SQLiteJournalMode)
+	assert.Equal(t, "NORMAL", cfg.SQLiteSynchronous)
+	assert.Empty(t, cfg.SQLiteCorruptionRisk())
+
+	cfg.SQLiteSynchronous = "full"
+	require.NoError(t, validate())
+	assert.Equal(t, "FULL", cfg.SQLiteSynchronous)
+	assert.Empty(t, cfg.SQLiteCorruptionRisk())
+
+	cfg.SQLiteJournalMode = "memory"
+	require.NoError(t, validate())
+	assert.Contains(t, cfg.SQLiteCorruptionRisk(), "MEMORY")
+
+	cfg.SQLiteJournalMode = "DELETE"
+	cfg.SQLiteSynchronous = "NORMAL"
+	assert.Contains(t, cfg.SQLiteCorruptionRisk(), "only safe in WAL")
+
+	cfg.SQLiteJournalMode = "BOGUS"
+	require.ErrorContains(t, validate(), "sqlite-journal-mode must be one of")
+}
+
+func TestValidatePorts(t *testing.T) {
+	cfg := Config{
+		Endpoint:                 "localhost:8000",
+		CaptiveCoreHTTPPort:      11626,
+		CaptiveCoreHTTPQueryPort: 11628,
+	}
+	require.NoError(t, cfg.validatePorts())
+
+	cfg.CaptiveCoreHTTPQueryPort = 11626
+	require.ErrorContains(t, cfg.validatePorts(), "stellar-captive-core-http-port")
+
+	// the HTTP port is disabled
+	cfg.CaptiveCoreHTTPPort = 0
+	require.NoError(t, cfg.validatePorts())
+
+	cfg.CaptiveCoreHTTPQueryPort = 8000
+	require.ErrorContains(t, cfg.validatePorts(), "same as endpoint")
+
+	cfg.CaptiveCoreHTTPQueryPort = 11628
+	cfg.CaptiveCoreHTTPPort = 8000
+	require.ErrorContains(t, cfg.validatePorts(), "same as endpoint")
+}
@@ -66,6 +66,9 @@ type Option struct {
 	MarshalTOML func(*Option) (interface{}, error)
 
 	flag *pflag.Flag // The persistent flag that the config option is attached to
+	// explicit is true if the value was provided by the operator (through
+	// the environment, flags or config file) rather than from DefaultValue.
+	explicit bool
 }
 
 // Returns false if this option is omitted in the toml
@@ -2,11 +2,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"reflect"
-	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/pelletier/go-toml"
@@ -21,10 +24,32 @@ const (
 	// OneDayOfLedgers is (roughly) a 24 hour window of ledgers.
 	OneDayOfLedgers   = 17280
 	SevenDayOfLedgers = OneDayOfLedgers * 7
+	// NominalLedgerCloseTime is the target time between ledgers, used when
+	// the actual average close time is not known yet.
+	NominalLedgerCloseTime = 5 * time.Second
+
+	// OversizedEventPolicyTruncate and OversizedEventPolicySkip are the
+	// accepted values of the oversized-event-policy option.
+	OversizedEventPolicyTruncate = "truncate"
+	OversizedEventPolicySkip     = "skip"
+
+	// EventStorageFormatXDR and EventStorageFormatCompressedXDR are the
+	// accepted values of the event-storage-format option.
+	EventStorageFormatXDR           = "xdr"
+	EventStorageFormatCompressedXDR = "compressed-xdr"
 
 	defaultHTTPEndpoint             = "localhost:8000"
 	defaultCaptiveCoreHTTPPort      = 11626 // regular queries like /info
 	defaultCaptiveCoreHTTPQueryPort = 11628
+
+	// maxLedgerEntriesHotKeys bounds the memory used to track hot keys
+	maxLedgerEntriesHotKeys = 10000
+)
+
+//nolint:gochecknoglobals
+var (
+	sqliteJournalModes     = []string{"WAL", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "OFF"}
+	sqliteSynchronousModes = []string{"NORMAL", "FULL", "EXTRA", "OFF"}
 )
 
 // TODO: refactor and remove the linter exceptions
@@ -64,6 +89,11 @@ func (cfg *Config) options() Options {
 			Usage:     "Admin endpoint to listen and serve on. WARNING: this should not be accessible from the Internet and does not use TLS. \"\" (default) disables the admin server",
 			ConfigKey: &cfg.AdminEndpoint,
 		},
+		{
+			Name:      "metrics-endpoint",
+			Usage:     "Endpoint serving only the Prometheus metrics (under /metrics), separately from the admin endpoint (which also serves them). \"\" (default) disables the metrics server",
+			ConfigKey: &cfg.MetricsEndpoint,
+		},
 		{
 			Name:      "stellar-core-url",
 			Usage:     "URL used to query Stellar Core (local captive core by default)",
@@ -78,12 +108,108 @@ func (cfg *Config) options() Options {
 				return nil
 			},
 		},
+		{
+			TomlKey:   "stellar_cores",
+			ConfigKey: &cfg.StellarCores,
+			Usage: "stellar-core instances which transactions are submitted to, instead of stellar-core-url. Each" +
+				" entry has a url and optionally a weight, which is the relative share of the submissions sent to the" +
+				" instance (1 by default). Failed instances are avoided for a while",
+			CustomSetValue: func(option *Option, i interface{}) error {
+				return unmarshalTOMLTreeList(i, option.ConfigKey, "stellar_cores")
+			},
+			MarshalTOML: func(_ *Option) (interface{}, error) {
+				trees := make([]*toml.Tree, 0, len(cfg.StellarCores))
+				for _, core := range cfg.StellarCores {
+					tomlBytes, err := toml.Marshal(core)
+					if err != nil {
+						return nil, fmt.Errorf("failed to marshal stellar_cores: %w", err)
+					}
+					tree, err := toml.LoadBytes(tomlBytes)
+					if err != nil {
+						return nil, fmt.Errorf("failed to marshal stellar_cores: %w", err)
+					}
+					trees = append(trees, tree)
+				}
+				return trees, nil
+			},
+			Validate: func(_ *Option) error {
+				for i, core := range cfg.StellarCores {
+					if core.URL == "" {
+						return fmt.Errorf("stellar_cores entry %d has no url", i)
+					}
+				}
+				return nil
+			},
+		},
 		{
 			Name:         "stellar-core-timeout",
 			Usage:        "Timeout used when submitting requests to stellar-core",
 			ConfigKey:    &cfg.CoreRequestTimeout,
 			DefaultValue: 2 * time.Second,
 		},
+		{
+			Name: "core-query-circuit-breaker-threshold",
+			Usage: "Number of consecutive failed requests to the captive-core query server after which" +
+				" requests fail fast, for the circuit breaker cooldown period (0 disables the circuit breaker)",
+			ConfigKey:    &cfg.CoreQueryCircuitBreakerThreshold,
+			DefaultValue: uint(5),
+		},
+		{
+			Name: "core-query-circuit-breaker-cooldown",
+			Usage: "How long requests to the captive-core query server fail fast once the circuit breaker opens," +
+				" before a request is let through to probe whether the query server recovered",
+			ConfigKey:    &cfg.CoreQueryCircuitBreakerCooldown,
+			DefaultValue: 10 * time.Second,
+		},
+		{
+			Name: "core-query-max-idle-conns",
+			Usage: "Maximum number of idle (keep-alive) connections to the captive-core query server kept for reuse" +
+				" (0 disables keeping idle connections)",
+			ConfigKey:    &cfg.CoreQueryMaxIdleConns,
+			DefaultValue: uint(100),
+		},
+		{
+			Name:         "core-query-max-conns-per-host",
+			Usage:        "Maximum number of connections to the captive-core query server, including the active ones (0 means no limit)",
+			ConfigKey:    &cfg.CoreQueryMaxConnsPerHost,
+			DefaultValue: uint(0),
+		},
+		{
+			Name:         "core-query-idle-conn-timeout",
+			Usage:        "How long an idle connection to the captive-core query server is kept before being closed",
+			ConfigKey:    &cfg.CoreQueryIdleConnTimeout,
+			DefaultValue: 90 * time.Second,
+		},
+		{
+			Name:         "send-transaction-idempotency-window",
+			Usage:        "Time window during which sendTransaction requests carrying the same idempotency key return the prior result instead of being resubmitted (0 disables deduplication)",
+			ConfigKey:    &cfg.SendTransactionIdempotencyWindow,
+			DefaultValue: 30 * time.Second,
+		},
+		{
+			Name:         "send-transaction-core-retries",
+			Usage:        "Maximum number of times a sendTransaction submission is retried after a transient error (connection refused/reset or timeout) talking to stellar-core (0 disables retries)",
+			ConfigKey:    &cfg.SendTransactionCoreRetries,
+			DefaultValue: uint(2),
+		},
+		{
+			Name:         "send-transaction-core-retry-backoff",
+			Usage:        "Delay before the first sendTransaction submission retry, doubled on every subsequent retry",
+			ConfigKey:    &cfg.SendTransactionCoreRetryBackoff,
+			DefaultValue: 100 * time.Millisecond,
+		},
+		{
+			Name:         "send-transaction-max-expired-age",
+			Usage:        "Reject the sendTransaction requests whose transaction time bounds expired more than this duration before the close time of the latest ledger, without submitting them to stellar-core (0 disables the check)",
+			ConfigKey:    &cfg.SendTransactionMaxExpiredAge,
+			DefaultValue: time.Duration(0),
+		},
+		{
+			Name:         "submitted-transaction-pending-window",
+			Usage:        "Time window during which getTransaction reports the transactions accepted by sendTransaction as PENDING instead of NOT_FOUND, until they are ingested (0 disables the PENDING status)",
+			ConfigKey:    &cfg.SubmittedTransactionPendingWindow,
+			DefaultValue: time.Duration(0),
+		},
 		{
 			Name:         "stellar-captive-core-http-port",
 			Usage:        "HTTP port for Captive Core to listen on (0 disables the HTTP server)",
@@ -99,13 +225,13 @@ func (cfg *Config) options() Options {
 		},
 		{
 			Name:         "stellar-captive-core-http-query-thread-pool-size",
-			Usage:        "Number of threads to use by Captive Core's high-performance query server",
+			Usage:        "Number of threads to use by Captive Core's high-performance query server. Defaults to cpu-count.",
 			ConfigKey:    &cfg.CaptiveCoreHTTPQueryThreadPoolSize,
-			DefaultValue: uint16(runtime.NumCPU()), //nolint:gosec
+			DefaultValue: uint16(min(defaultCPUCount(), math.MaxUint16)), //nolint:gosec
 		},
 		{
 			Name:         "stellar-captive-core-http-query-snapshot-ledgers",
-			Usage:        "Size of ledger history in Captive Core's high-performance query server (don't touch unless you know what you are doing)",
+			Usage:        "Size of ledger history in Captive Core's high-performance query server, which also bounds the past ledgers simulateTransaction and getLedgerEntries can be called at (don't touch unless you know what you are doing)",
 			ConfigKey:    &cfg.CaptiveCoreHTTPQuerySnapshotLedgers,
 			DefaultValue: uint16(4),
 		},
@@ -205,10 +331,55 @@ func (cfg *Config) options() Options {
 			},
 		},
 		{
-			Name:      "history-archive-urls",
-			Usage:     "comma-separated list of stellar history archives to connect with",
+			Name: "history-archive-urls",
+			Usage: "comma-separated list of stellar history archives to connect with. Shorthand for" +
+				" history_archives entries with the default settings",
 			ConfigKey: &cfg.HistoryArchiveURLs,
-			Validate:  required,
+			Validate: func(option *Option) error {
+				if len(cfg.HistoryArchives) > 0 {
+					return nil
+				}
+				return required(option)
+			},
+		},
+		{
+			TomlKey:   "history_archives",
+			ConfigKey: &cfg.HistoryArchives,
+			Usage: "History archives to connect with (in addition to history-archive-urls). Each entry has a url" +
+				" and optionally a user_agent, HTTP headers (e.g. for authentication) and a weight, which is" +
+				" the relative share of the requests sent to the archive (1 by default). Captive core only gets the urls," +
+				" the other settings only apply to the archive requests made by RPC itself",
+			CustomSetValue: func(option *Option, i interface{}) error {
+				return unmarshalTOMLTreeList(i, option.ConfigKey, "history_archives")
+			},
+			MarshalTOML: func(_ *Option) (interface{}, error) {
+				trees := make([]*toml.Tree, 0, len(cfg.HistoryArchives))
+				for _, archive := range cfg.HistoryArchives {
+					tomlBytes, err := toml.Marshal(archive)
+					if err != nil {
+						return nil, fmt.Errorf("failed to marshal history_archives: %w", err)
+					}
+					tree, err := toml.LoadBytes(tomlBytes)
+					if err != nil {
+						return nil, fmt.Errorf("failed to marshal history_archives: %w", err)
+					}
+					trees = append(trees, tree)
+				}
+				return trees, nil
+			},
+			Validate: func(_ *Option) error {
+				for i, archive := range cfg.HistoryArchives {
+					if archive.URL == "" {
+						return fmt.Errorf("history_archives entry %d has no url", i)
+					}
+					if len(archive.Headers) > 0 &&
+						!strings.HasPrefix(archive.URL, "http://") && !strings.HasPrefix(archive.URL, "https://") {
+						return fmt.Errorf("history_archives entry %d (%s): headers are only supported by HTTP archives",
+							i, archive.URL)
+					}
+				}
+				return nil
+			},
 		},
 		{
 			Name:      "friendbot-url",
@@ -228,12 +399,152 @@ func (cfg *Config) options() Options {
 			// TODO: deprecate and rename to stellar_rpc.sqlite
 			DefaultValue: "soroban_rpc.sqlite",
 		},
+		{
+			Name: "sqlite-journal-mode",
+			Usage: "SQLite journal mode (" + strings.Join(sqliteJournalModes, ", ") + ")." +
+				" Modes other than WAL are slower and/or risk corrupting the database on crashes",
+			ConfigKey:    &cfg.SQLiteJournalMode,
+			DefaultValue: "WAL",
+			Validate:     oneOf(sqliteJournalModes),
+		},
+		{
+			Name: "sqlite-synchronous",
+			Usage: "SQLite synchronous mode (" + strings.Join(sqliteSynchronousModes, ", ") + ")." +
+				" FULL and EXTRA trade write performance for durability on power loss",
+			ConfigKey:    &cfg.SQLiteSynchronous,
+			DefaultValue: "NORMAL",
+			Validate:     oneOf(sqliteSynchronousModes),
+		},
+		{
+			Name: "sqlite-allow-newer-schema",
+			Usage: "Start even if the SQLite database schema was migrated by a newer version of stellar-rpc (e.g." +
+				" after a downgrade), which otherwise refuses to run. The older version may misbehave or corrupt" +
+				" the database, so it's safer to delete it and let stellar-rpc rebuild it",
+			ConfigKey:    &cfg.SQLiteAllowNewerSchema,
+			DefaultValue: false,
+		},
+		{
+			Name: "sqlite-vacuum-interval",
+			Usage: "How often the SQLite database is vacuumed, shrinking the database file after history is" +
+				" trimmed. Ingestion is paused while vacuuming (0 disables vacuuming)",
+			ConfigKey:    &cfg.SQLiteVacuumInterval,
+			DefaultValue: time.Duration(0),
+		},
+		{
+			Name: "sqlite-vacuum-window",
+			Usage: "Deprecated, use maintenance-window instead. Daily UTC time window (HH:MM-HH:MM, e.g." +
+				" 02:00-05:00) in which the SQLite database can be vacuumed, used when maintenance-window is empty",
+			ConfigKey:    &cfg.SQLiteVacuumWindow,
+			DefaultValue: "",
+			Validate: func(*Option) error {
+				_, err := ParseTimeWindow(cfg.SQLiteVacuumWindow)
+				return err
+			},
+		},
+		{
+			Name: "maintenance-window",
+			Usage: "Daily time window (HH:MM-HH:MM, e.g. 02:00-05:00, in the maintenance-window-timezone) in" +
+				" which the heavy background jobs (e.g. vacuuming the SQLite database) run, ideally a low" +
+				" traffic period. Jobs which must run (e.g. database migrations) ignore it. When empty, the" +
+				" jobs can run at any time",
+			ConfigKey:    &cfg.MaintenanceWindow,
+			DefaultValue: "",
+			Validate: func(*Option) error {
+				_, err := ParseTimeWindow(cfg.MaintenanceWindow)
+				return err
+			},
+		},
+		{
+			Name:         "maintenance-window-timezone",
+			Usage:        "IANA time zone (e.g. UTC, America/New_York) of the maintenance-window",
+			ConfigKey:    &cfg.MaintenanceWindowTimezone,
+			DefaultValue: "UTC",
+			Validate: func(*Option) error {
+				_, err := time.LoadLocation(cfg.MaintenanceWindowTimezone)
+				return err
+			},
+		},
 		{
 			Name:         "ingestion-timeout",
 			Usage:        "Ingestion Timeout when bootstrapping data (checkpoint and in-memory initialization) and preparing ledger reads",
 			ConfigKey:    &cfg.IngestionTimeout,
 			DefaultValue: 50 * time.Minute,
 		},
+		{
+			Name: "ingestion-batch-size",
+			Usage: "Maximum number of rows (transactions, events, operations, etc.) written per insert statement" +
+				" when ingesting a ledger, capped to fit in the SQLite limit of variables per statement. Larger batches" +
+				" need fewer statements but use more memory",
+			ConfigKey:    &cfg.IngestionBatchSize,
+			DefaultValue: uint(150),
+			Validate:     positive,
+		},
+		{
+			Name: "checkpoint-deferral-latency-threshold",
+			Usage: "Average query latency above which the database checkpoints after ingesting ledgers are" +
+				" deferred, letting the write-ahead log grow to prioritize the queries. The ingestion commits" +
+				" keep their pace (0 disables the deferral)",
+			ConfigKey:    &cfg.CheckpointDeferralLatencyThreshold,
+			DefaultValue: time.Duration(0),
+			Validate: func(option *Option) error {
+				if cfg.CheckpointDeferralLatencyThreshold < 0 {
+					return fmt.Errorf("%s must not be negative", option.Name)
+				}
+				return nil
+			},
+		},
+		{
+			Name:         "checkpoint-deferral-max-checkpoints",
+			Usage:        "Maximum number of consecutive database checkpoints deferred under slow queries",
+			ConfigKey:    &cfg.CheckpointDeferralMaxCheckpoints,
+			DefaultValue: uint(100),
+			Validate:     positive,
+		},
+		{
+			Name: "checkpoint-deferral-max-wal-size-bytes",
+			Usage: "Size (in bytes) of the write-ahead log from which the database checkpoints are no longer" +
+				" deferred under slow queries (0 only bounds the number of deferred checkpoints)",
+			ConfigKey:    &cfg.CheckpointDeferralMaxWALSizeBytes,
+			DefaultValue: uint64(128 * 1024 * 1024),
+		},
+		{
+			Name: "ingestion-start-ledger-floor",
+			Usage: "Minimum ledger to start ingesting from. If the database is further behind, the ledgers in between" +
+				" are skipped and the ledgers already ingested are deleted, so as not to leave a gap. It must not be" +
+				" beyond the latest ledger in the history archives. 0 disables it",
+			ConfigKey:    &cfg.IngestionStartLedgerFloor,
+			DefaultValue: uint32(0),
+		},
+		{
+			Name: "ingestion-start-within-retention-window",
+			Usage: "Start ingesting no earlier than history-retention-window ledgers before the latest ledger in the" +
+				" history archives, skipping the ledgers which would be trimmed right after being ingested",
+			ConfigKey:    &cfg.IngestionStartWithinRetentionWindow,
+			DefaultValue: false,
+		},
+		{
+			Name: "ingestion-abort-on-ledger-gap",
+			Usage: "Abort (and restart) ingestion when the ledger backend delivers a ledger which doesn't follow the" +
+				" previously ingested one, instead of only logging an error. The first ledger ingested after starting" +
+				" ingestion is never checked",
+			ConfigKey:    &cfg.IngestionAbortOnLedgerGap,
+			DefaultValue: false,
+		},
+		{
+			Name: "ingest-operations",
+			Usage: "Ingest the operations of the transactions (within the history retention window), which are" +
+				" served by getOperations. Only the ledgers ingested while enabled have their operations stored",
+			ConfigKey:    &cfg.IngestOperations,
+			DefaultValue: false,
+		},
+		{
+			Name: "index-contract-data",
+			Usage: "Index the keys of the contract data entries, which lets getContractData list all the entries of" +
+				" a contract. The index is only complete when enabled on an empty database, otherwise it only holds" +
+				" the entries modified since it was enabled. Disabling it drops the index",
+			ConfigKey:    &cfg.IndexContractData,
+			DefaultValue: false,
+		},
 		{
 			Name:         "checkpoint-frequency",
 			Usage:        "establishes how many ledgers exist between checkpoints, do NOT change this unless you really know what you are doing",
@@ -250,6 +561,30 @@ func (cfg *Config) options() Options {
 			DefaultValue: uint32(SevenDayOfLedgers),
 			Validate:     positive,
 		},
+		{
+			Name: "history-retention-duration",
+			Usage: "configures history retention window for transactions and events as a duration (e.g. 168h)," +
+				" converted to a number of ledgers using the observed average ledger close time at startup and" +
+				" adjusted every 720 ingested ledgers. An explicitly set history-retention-window takes precedence",
+			ConfigKey:    &cfg.HistoryRetentionDuration,
+			DefaultValue: time.Duration(0),
+			Validate: func(option *Option) error {
+				if cfg.HistoryRetentionDuration < 0 {
+					return fmt.Errorf("%s must not be negative", option.Name)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "max-db-size-bytes",
+			Usage: "Maximum size (in bytes) of the data in the database, i.e. of its used pages plus its WAL file." +
+				" When exceeded after ingesting a ledger, the WAL is checkpointed and, if still exceeded, the oldest" +
+				" ledgers are trimmed (shortening the history retention window) until the data is 10% below it. The" +
+				" freed pages are reused, but the database file only shrinks when vacuumed, so its size on disk can" +
+				" stay above the data size. 0 disables the limit",
+			ConfigKey:    &cfg.MaxDBSizeBytes,
+			DefaultValue: uint64(0),
+		},
 		{
 			Name:         "classic-fee-stats-retention-window",
 			Usage:        "configures classic fee stats retention window expressed in number of ledgers",
@@ -286,12 +621,58 @@ func (cfg *Config) options() Options {
 				return nil
 			},
 		},
+		{
+			Name: "max-event-size",
+			Usage: "Maximum size in bytes of a single (serialized) event stored during ingestion." +
+				" Larger events are handled according to oversized-event-policy. 0 means no limit",
+			ConfigKey:    &cfg.MaxEventSize,
+			DefaultValue: uint(0),
+		},
+		{
+			Name: "oversized-event-policy",
+			Usage: fmt.Sprintf("What to do with events exceeding max-event-size: %q replaces the event data"+
+				" with a marker (keeping the topics), %q drops the event", OversizedEventPolicyTruncate, OversizedEventPolicySkip),
+			ConfigKey:    &cfg.OversizedEventPolicy,
+			DefaultValue: OversizedEventPolicyTruncate,
+			Validate: func(option *Option) error {
+				switch cfg.OversizedEventPolicy {
+				case OversizedEventPolicyTruncate, OversizedEventPolicySkip:
+					return nil
+				default:
+					return fmt.Errorf("invalid %s: %q", option.Name, cfg.OversizedEventPolicy)
+				}
+			},
+		},
+		{
+			Name: "event-storage-format",
+			Usage: fmt.Sprintf("How the events are stored during ingestion: %q stores their raw XDR, %q their"+
+				" compressed XDR, which uses less disk space but more CPU time to ingest and read the events."+
+				" The events already stored are still read after a change",
+				EventStorageFormatXDR, EventStorageFormatCompressedXDR),
+			ConfigKey:    &cfg.EventStorageFormat,
+			DefaultValue: EventStorageFormatXDR,
+			Validate: func(option *Option) error {
+				switch cfg.EventStorageFormat {
+				case EventStorageFormatXDR, EventStorageFormatCompressedXDR:
+					return nil
+				default:
+					return fmt.Errorf("invalid %s: %q", option.Name, cfg.EventStorageFormat)
+				}
+			},
+		},
 		{
 			Name:         "max-transactions-limit",
 			Usage:        "Maximum amount of transactions allowed in a single getTransactions response",
 			ConfigKey:    &cfg.MaxTransactionsLimit,
 			DefaultValue: uint(200),
 		},
+		{
+			Name:         "max-transactions-by-hash-limit",
+			Usage:        "Maximum amount of hashes allowed in a single getTransactionsByHash request",
+			ConfigKey:    &cfg.MaxTransactionsByHashLimit,
+			DefaultValue: uint(100),
+			Validate:     positive,
+		},
 		{
 			Name:         "default-transactions-limit",
 			Usage:        "Default cap on the amount of transactions included in a single getTransactions response",
@@ -330,6 +711,53 @@ func (cfg *Config) options() Options {
 				return nil
 			},
 		},
+		{
+			Name: "ledger-entries-hot-keys",
+			Usage: "Number of most requested getLedgerEntries keys to report in the admin endpoint (under" +
+				" /ledger-entries/hot-keys). Tracking has a small per-request cost, 0 disables it",
+			ConfigKey:    &cfg.LedgerEntriesHotKeys,
+			DefaultValue: uint(0),
+			Validate: func(option *Option) error {
+				if cfg.LedgerEntriesHotKeys > maxLedgerEntriesHotKeys {
+					return fmt.Errorf("%s cannot exceed %d", option.Name, maxLedgerEntriesHotKeys)
+				}
+				return nil
+			},
+		},
+		{
+			Name:         "max-ledger-entries-keys",
+			Usage:        "Maximum amount of keys allowed in a single getLedgerEntries request",
+			ConfigKey:    &cfg.MaxLedgerEntriesKeys,
+			DefaultValue: uint(200),
+			Validate:     positive,
+		},
+		{
+			Name: "default-ledger-entries-keys",
+			Usage: "Default cap on the amount of keys sent to Captive Core in a single ledger entry query. Larger" +
+				" getLedgerEntries requests (up to max-ledger-entries-keys) are split into several queries at the same ledger",
+			ConfigKey:    &cfg.DefaultLedgerEntriesKeys,
+			DefaultValue: uint(200),
+			Validate: func(option *Option) error {
+				if err := positive(option); err != nil {
+					return err
+				}
+				if cfg.DefaultLedgerEntriesKeys > cfg.MaxLedgerEntriesKeys {
+					return fmt.Errorf(
+						"default-ledger-entries-keys (%v) cannot exceed max-ledger-entries-keys (%v)",
+						cfg.DefaultLedgerEntriesKeys,
+						cfg.MaxLedgerEntriesKeys,
+					)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "info-response-cache-ttl",
+			Usage: "How long the getNetwork and getVersionInfo responses are cached for. They are always" +
+				" recomputed once a new ledger is ingested. 0 disables caching",
+			ConfigKey:    &cfg.InfoResponseCacheTTL,
+			DefaultValue: time.Second,
+		},
 		{
 			Name: "max-healthy-ledger-latency",
 			Usage: "maximum ledger latency (i.e. time elapsed since the last known ledger closing time) considered to be healthy" +
@@ -337,26 +765,163 @@ func (cfg *Config) options() Options {
 			ConfigKey:    &cfg.MaxHealthyLedgerLatency,
 			DefaultValue: 30 * time.Second,
 		},
+		{
+			Name: "warmup-on-startup",
+			Usage: "Once the initial sync completes, run representative queries (ledger range, latest ledger and" +
+				" events, warmup-ledger-keys) to warm the caches up before reporting healthy",
+			ConfigKey:    &cfg.WarmupOnStartup,
+			DefaultValue: false,
+		},
+		{
+			Name: "warmup-ledger-keys",
+			Usage: "comma-separated list of base64-encoded ledger keys fetched from captive core during the startup" +
+				" warmup (see warmup-on-startup), e.g. entries of popular contracts",
+			ConfigKey: &cfg.WarmupLedgerKeys,
+			Validate: func(_ *Option) error {
+				_, err := cfg.WarmupLedgerKeysXDR()
+				return err
+			},
+		},
+		{
+			Name: "simulate-transaction-strict-validation",
+			Usage: "Reject the malformed simulateTransaction transactions before simulating them, with an invalid" +
+				" params error telling whether the base64 encoding, the XDR or the operation is invalid (rather" +
+				" than an error in the simulation response). The whitespace of the transactions is ignored",
+			ConfigKey:    &cfg.SimulateTransactionStrictValidation,
+			DefaultValue: false,
+		},
+		{
+			Name: "simulate-transaction-core-fallback",
+			Usage: "Query the ledger entries of the simulations through the stellar-core instances transactions are" +
+				" submitted to when the Captive Core query server is unavailable, so that simulateTransaction is" +
+				" slower instead of failing. The stellar-core instances must serve ledger entry queries (i.e. their" +
+				" URL must point at their HTTP query server)",
+			ConfigKey:    &cfg.SimulateTransactionCoreFallback,
+			DefaultValue: false,
+		},
+		{
+			Name: "cpu-count",
+			Usage: "Number of CPUs available to the daemon, which sets GOMAXPROCS and the default number of preflight" +
+				" workers and Captive Core query threads. Defaults to the CPU quota of the container (cgroup), if any," +
+				" or else to the number of CPUs",
+			ConfigKey:    &cfg.CPUCount,
+			DefaultValue: defaultCPUCount(),
+			Validate:     positive,
+		},
 		{
 			Name:         "preflight-worker-count",
-			Usage:        "Number of workers (read goroutines) used to compute preflights for the simulateTransaction endpoint. Defaults to the number of CPUs.",
+			Usage:        "Number of workers (read goroutines) used to compute preflights for the simulateTransaction endpoint. Defaults to cpu-count.",
 			ConfigKey:    &cfg.PreflightWorkerCount,
-			DefaultValue: uint(runtime.NumCPU()),
+			DefaultValue: defaultCPUCount(),
 			Validate:     positive,
 		},
 		{
 			Name:         "preflight-worker-queue-size",
-			Usage:        "Maximum number of outstanding preflight requests for the simulateTransaction endpoint. Defaults to the number of CPUs.",
+			Usage:        "Maximum number of outstanding preflight requests for the simulateTransaction endpoint. Defaults to cpu-count.",
 			ConfigKey:    &cfg.PreflightWorkerQueueSize,
-			DefaultValue: uint(runtime.NumCPU()),
+			DefaultValue: defaultCPUCount(),
 			Validate:     positive,
 		},
+		{
+			Name: "preflight-timeout",
+			Usage: "Maximum duration of a single preflight (simulation) in the simulateTransaction endpoint," +
+				" independently of max-simulate-transaction-execution-duration, so that a slow simulation frees its" +
+				" preflight worker sooner (0 disables it)",
+			ConfigKey:    &cfg.PreflightTimeout,
+			DefaultValue: time.Duration(0),
+		},
 		{
 			Name:         "preflight-enable-debug",
 			Usage:        "Enable debug information in preflighting (provides more detailed errors). It should not be enabled in production deployments.",
 			ConfigKey:    &cfg.PreflightEnableDebug,
 			DefaultValue: true,
 		},
+		{
+			Name: "max-simulation-instruction-limit",
+			Usage: "Maximum CPU instruction limit simulateTransaction requests can set (through" +
+				" resourceConfig.instructionLimit) to simulate with a budget above the network limits. 0 disables the override",
+			ConfigKey:    &cfg.MaxSimulationInstructionLimit,
+			DefaultValue: uint(1_000_000_000),
+		},
+		{
+			Name: "max-simulation-memory-limit",
+			Usage: "Maximum memory limit (in bytes) simulateTransaction requests can set (through" +
+				" resourceConfig.memoryLimit) to simulate with a budget above the network limits. 0 disables the override",
+			ConfigKey:    &cfg.MaxSimulationMemoryLimit,
+			DefaultValue: uint(512 * 1024 * 1024),
+			Validate: func(option *Option) error {
+				if cfg.MaxSimulationMemoryLimit > math.MaxUint32 {
+					return fmt.Errorf("%s cannot exceed %d", option.Name, uint64(math.MaxUint32))
+				}
+				return nil
+			},
+		},
+		{
+			Name: "max-concurrent-requests-per-client",
+			Usage: "Maximum number of concurrent http requests from a single client IP, further requests are" +
+				" rejected with a 429 status (0 disables the limit)",
+			ConfigKey:    &cfg.MaxConcurrentRequestsPerClient,
+			DefaultValue: uint(0),
+		},
+		{
+			Name: "client-ip-header",
+			Usage: "Header set by the trusted reverse proxies with the client IP (X-Forwarded-For by default), used" +
+				" to enforce max-concurrent-requests-per-client. It requires trusted-proxies, see below",
+			ConfigKey:    &cfg.ClientIPHeader,
+			DefaultValue: "",
+		},
+		{
+			Name: "trusted-proxies",
+			Usage: "comma-separated list of CIDRs (or IPs) of the reverse proxies in front of the server. When set," +
+				" the client IP is only taken from client-ip-header (X-Forwarded-For by default) for connections" +
+				" from these proxies, skipping the proxies listed in the header",
+			ConfigKey: &cfg.TrustedProxies,
+			Validate: func(_ *Option) error {
+				if _, err := parseTrustedProxies(cfg.TrustedProxies); err != nil {
+					return fmt.Errorf("invalid trusted-proxies: %w", err)
+				}
+				if cfg.ClientIPHeader != "" && len(cfg.TrustedProxies) == 0 {
+					// the header could be sent by any client to spoof its IP
+					return errors.New("client-ip-header requires trusted-proxies")
+				}
+				return nil
+			},
+		},
+		{
+			Name: "audit-log-path",
+			Usage: "File to which an audit record (time, client IP, JSON-RPC methods and ids) of every http request" +
+				" and an audit record (time, request id, method, status) of every JSON-RPC call are written," +
+				" regardless of the log level. The file is rotated when it reaches audit-log-max-size-mb" +
+				" (empty disables the audit log)",
+			ConfigKey:    &cfg.AuditLogPath,
+			DefaultValue: "",
+		},
+		{
+			Name:         "audit-log-max-size-mb",
+			Usage:        "Size (in megabytes) at which the audit log file is rotated",
+			ConfigKey:    &cfg.AuditLogMaxSizeMB,
+			DefaultValue: uint(100),
+			Validate:     positive,
+		},
+		{
+			Name:         "audit-log-max-backups",
+			Usage:        "Number of rotated audit log files to keep",
+			ConfigKey:    &cfg.AuditLogMaxBackups,
+			DefaultValue: uint(10),
+		},
+		{
+			Name:         "audit-log-bodies",
+			Usage:        "Include the full request and response bodies in the audit log records",
+			ConfigKey:    &cfg.AuditLogBodies,
+			DefaultValue: false,
+		},
+		{
+			Name: "pretty-json",
+			Usage: "Indent the JSON-RPC responses, to make them easier to read during development. Keep it" +
+				" disabled in production, since it makes the responses larger",
+			ConfigKey:    &cfg.PrettyJSON,
+			DefaultValue: false,
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("request-backlog-global-queue-limit"),
 			Usage:        "Maximum number of outstanding requests",
@@ -364,6 +929,85 @@ func (cfg *Config) options() Options {
 			DefaultValue: uint(5000),
 			Validate:     positive,
 		},
+		{
+			Name: "cors-methods",
+			Usage: "comma-separated list of the JSON-RPC methods which can be called from cross-origin browser" +
+				" requests, e.g. simulateTransaction,getLedgerEntries. The cross-origin requests calling other" +
+				" methods are rejected. All the methods can be called cross-origin when empty",
+			ConfigKey: &cfg.CORSMethods,
+		},
+		{
+			Name: "finalized-response-max-age",
+			Usage: "How long proxies can cache the getEvents, getTransactions and getLedgers responses which only" +
+				" cover ledgers before the latest one (with Cache-Control and ETag headers). The responses reaching" +
+				" the latest ledger must be revalidated. 0 disables the caching headers",
+			ConfigKey:    &cfg.FinalizedResponseMaxAge,
+			DefaultValue: time.Hour,
+			Validate: func(option *Option) error {
+				if cfg.FinalizedResponseMaxAge < 0 {
+					return fmt.Errorf("%s must not be negative", option.Name)
+				}
+				return nil
+			},
+		},
+		{
+			Name: "request-backlog-method-priorities",
+			Usage: "comma-separated list of method=priority pairs (with a low, normal or high priority), e.g." +
+				" simulateTransaction=low. When the global queue nears its limit, low priority requests are" +
+				" rejected past 80% of the limit and normal priority ones (the default) past 90%, keeping room" +
+				" for the high priority ones",
+			ConfigKey:    &cfg.RequestBacklogMethodPriorities,
+			DefaultValue: []string{"getHealth=high", "getLatestLedger=high"},
+			Validate: func(_ *Option) error {
+				_, err := cfg.RequestBacklogMethodPriorityMap()
+				return err
+			},
+		},
+		{
+			Name: "method-max-request-sizes",
+			Usage: "comma-separated list of method=bytes pairs limiting the size of the parameters of the requests" +
+				" to a method, e.g. getHealth=1024,simulateTransaction=2097152. The methods without a limit" +
+				" accept up to max-request-size, and the limits above it raise the size of the HTTP requests accepted",
+			ConfigKey: &cfg.MethodMaxRequestSizes,
+			Validate: func(_ *Option) error {
+				_, err := cfg.MethodMaxRequestSizeMap()
+				return err
+			},
+		},
+		{
+			Name: "request-log-sample-rates",
+			Usage: "comma-separated list of method=N pairs, e.g. getHealth=100,getLatestLedger=10, only logging" +
+				" (at info level) one in every N requests to the method. The failed requests and the ones slower" +
+				" than request-execution-warning-threshold are always logged",
+			ConfigKey: &cfg.RequestLogSampleRates,
+			Validate: func(_ *Option) error {
+				_, err := cfg.RequestLogSampleRateMap()
+				return err
+			},
+		},
+		{
+			Name: "max-request-size",
+			Usage: "Maximum size (in bytes) of the parameters of the requests to the methods without a limit in" +
+				" method-max-request-sizes. The largest of the limits is the maximum size of the HTTP requests," +
+				" above which they are rejected",
+			ConfigKey:    &cfg.MaxRequestSize,
+			DefaultValue: uint(512 * 1024),
+			Validate:     positive,
+		},
+		{
+			Name: "request-size-warning-threshold",
+			Usage: "Size (in bytes) above which the HTTP requests are still served, but logged as a warning and" +
+				" counted, to spot the clients getting close to the request size limits. It must be below" +
+				" max-request-size. 0 disables the warnings",
+			ConfigKey:    &cfg.RequestSizeWarningThreshold,
+			DefaultValue: uint(0),
+			Validate: func(option *Option) error {
+				if cfg.RequestSizeWarningThreshold != 0 && cfg.RequestSizeWarningThreshold >= cfg.MaxRequestSize {
+					return fmt.Errorf("%s must be below max-request-size (%d)", option.Name, cfg.MaxRequestSize)
+				}
+				return nil
+			},
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-health-queue-limit"),
 			Usage:        "Maximum number of outstanding GetHealth requests",
@@ -406,6 +1050,34 @@ func (cfg *Config) options() Options {
 			DefaultValue: uint(1000),
 			Validate:     positive,
 		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-contract-interface-queue-limit"),
+			Usage:        "Maximum number of outstanding GetContractInterface requests",
+			ConfigKey:    &cfg.RequestBacklogGetContractInterfaceQueueLimit,
+			DefaultValue: uint(100),
+			Validate:     positive,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-account-sequence-queue-limit"),
+			Usage:        "Maximum number of outstanding GetAccountSequence requests",
+			ConfigKey:    &cfg.RequestBacklogGetAccountSequenceQueueLimit,
+			DefaultValue: uint(1000),
+			Validate:     positive,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-resource-fee-queue-limit"),
+			Usage:        "Maximum number of outstanding GetResourceFee requests",
+			ConfigKey:    &cfg.RequestBacklogGetResourceFeeQueueLimit,
+			DefaultValue: uint(1000),
+			Validate:     positive,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-contract-data-queue-limit"),
+			Usage:        "Maximum number of outstanding GetContractData requests",
+			ConfigKey:    &cfg.RequestBacklogGetContractDataQueueLimit,
+			DefaultValue: uint(1000),
+			Validate:     positive,
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-transaction-queue-limit"),
 			Usage:        "Maximum number of outstanding GetTransaction requests",
@@ -420,6 +1092,34 @@ func (cfg *Config) options() Options {
 			DefaultValue: uint(1000),
 			Validate:     positive,
 		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-transactions-by-hash-queue-limit"),
+			Usage:        "Maximum number of outstanding GetTransactionsByHash requests",
+			ConfigKey:    &cfg.RequestBacklogGetTransactionsByHashQueueLimit,
+			DefaultValue: uint(1000),
+			Validate:     positive,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-transactions-by-contract-queue-limit"),
+			Usage:        "Maximum number of outstanding GetTransactionsByContract requests",
+			ConfigKey:    &cfg.RequestBacklogGetTransactionsByContractQueueLimit,
+			DefaultValue: uint(1000),
+			Validate:     positive,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-transaction-proof-queue-limit"),
+			Usage:        "Maximum number of outstanding GetTransactionProof requests",
+			ConfigKey:    &cfg.RequestBacklogGetTransactionProofQueueLimit,
+			DefaultValue: uint(1000),
+			Validate:     positive,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-operations-queue-limit"),
+			Usage:        "Maximum number of outstanding GetOperations requests",
+			ConfigKey:    &cfg.RequestBacklogGetOperationsQueueLimit,
+			DefaultValue: uint(1000),
+			Validate:     positive,
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-ledgers-queue-limit"),
 			Usage:        "Maximum number of outstanding getLedgers requests",
@@ -427,6 +1127,13 @@ func (cfg *Config) options() Options {
 			DefaultValue: uint(1000),
 			Validate:     positive,
 		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("request-backlog-get-ledger-close-meta-queue-limit"),
+			Usage:        "Maximum number of outstanding getLedgerCloseMeta requests",
+			ConfigKey:    &cfg.RequestBacklogGetLedgerCloseMetaQueueLimit,
+			DefaultValue: uint(100),
+			Validate:     positive,
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("request-backlog-send-transaction-queue-limit"),
 			Usage:        "Maximum number of outstanding SendTransaction requests",
@@ -496,6 +1203,30 @@ func (cfg *Config) options() Options {
 			ConfigKey:    &cfg.MaxGetLedgerEntriesExecutionDuration,
 			DefaultValue: 5 * time.Second,
 		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-contract-interface-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getContractInterface request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetContractInterfaceExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-account-sequence-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getAccountSequence request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetAccountSequenceExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-resource-fee-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getResourceFee request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetResourceFeeExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-contract-data-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getContractData request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetContractDataExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("max-get-transaction-execution-duration"),
 			Usage:        "The maximum duration of time allowed for processing a getTransaction request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
@@ -508,12 +1239,42 @@ func (cfg *Config) options() Options {
 			ConfigKey:    &cfg.MaxGetTransactionsExecutionDuration,
 			DefaultValue: 5 * time.Second,
 		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-transactions-by-hash-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getTransactionsByHash request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetTransactionsByHashExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-transactions-by-contract-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getTransactionsByContract request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetTransactionsByContractExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-transaction-proof-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getTransactionProof request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetTransactionProofExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-operations-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getOperations request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetOperationsExecutionDuration,
+			DefaultValue: 5 * time.Second,
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("max-get-ledgers-execution-duration"),
 			Usage:        "The maximum duration of time allowed for processing a getLedgers request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
 			ConfigKey:    &cfg.MaxGetLedgersExecutionDuration,
 			DefaultValue: 10 * time.Second,
 		},
+		{
+			TomlKey:      strutils.KebabToConstantCase("max-get-ledger-close-meta-execution-duration"),
+			Usage:        "The maximum duration of time allowed for processing a getLedgerCloseMeta request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
+			ConfigKey:    &cfg.MaxGetLedgerCloseMetaExecutionDuration,
+			DefaultValue: 10 * time.Second,
+		},
 		{
 			TomlKey:      strutils.KebabToConstantCase("max-send-transaction-execution-duration"),
 			Usage:        "The maximum duration of time allowed for processing a sendTransaction request. When that time elapses, the rpc server would return -32001 and abort the request's execution",
@@ -532,6 +1293,12 @@ func (cfg *Config) options() Options {
 			ConfigKey:    &cfg.MaxGetFeeStatsExecutionDuration,
 			DefaultValue: 5 * time.Second,
 		},
+		{
+			Name:         "memory-shed-heap-threshold",
+			Usage:        "Heap size (in bytes) above which expensive requests (getEvents, getTransactions and getLedgers) are rejected until memory usage drops. 0 disables load shedding",
+			ConfigKey:    &cfg.MemoryShedHeapThreshold,
+			DefaultValue: uint64(0),
+		},
 		{
 			Name:         "serve-ledgers-from-datastore",
 			TomlKey:      strutils.KebabToConstantCase("serve-ledgers-from-datastore"),
@@ -539,6 +1306,30 @@ func (cfg *Config) options() Options {
 			ConfigKey:    &cfg.ServeLedgersFromDatastore,
 			DefaultValue: false,
 		},
+		{
+			Name:    "ledger-read-fallback-to-datastore",
+			TomlKey: strutils.KebabToConstantCase("ledger-read-fallback-to-datastore"),
+			Usage: "When serving ledgers from the datastore, fetch the ledgers of getLedgers from the datastore" +
+				" when reading them from the local database fails.",
+			ConfigKey:    &cfg.LedgerReadFallbackToDatastore,
+			DefaultValue: false,
+		},
+		{
+			Name:    "datastore-read-retries",
+			TomlKey: strutils.KebabToConstantCase("datastore-read-retries"),
+			Usage: "When serving ledgers from the datastore, number of times a failed datastore read of" +
+				" getLedgers is retried before failing the request.",
+			ConfigKey:    &cfg.DatastoreReadRetries,
+			DefaultValue: uint(0),
+		},
+		{
+			Name:    "check-datastore-on-startup",
+			TomlKey: strutils.KebabToConstantCase("check-datastore-on-startup"),
+			Usage: "When serving ledgers from the datastore, probe the datastore at startup and fail " +
+				"if it can't be reached (e.g. due to wrong credentials or bucket). Disable it when starting without connectivity",
+			ConfigKey:    &cfg.CheckDatastoreOnStartup,
+			DefaultValue: false,
+		},
 		{
 			TomlKey:   "buffered_storage_backend_config",
 			ConfigKey: &cfg.BufferedStorageBackendConfig,
@@ -608,6 +1399,31 @@ func unmarshalTOMLTree(tree interface{}, out interface{}, configName string) err
 	return toml.Unmarshal(tomlBytes, out)
 }
 
+// unmarshalTOMLTreeList unmarshals a TOML array of tables into out, which
+// must be a pointer to a slice.
+func unmarshalTOMLTreeList(value interface{}, out interface{}, configName string) error {
+	var trees []interface{}
+	switch v := value.(type) {
+	case []*toml.Tree:
+		for _, tree := range v {
+			trees = append(trees, tree)
+		}
+	case []interface{}:
+		trees = v
+	default:
+		return fmt.Errorf("expected TOML array of tables for %s, got %T", configName, value)
+	}
+	slice := reflect.ValueOf(out).Elem()
+	result := reflect.MakeSlice(slice.Type(), len(trees), len(trees))
+	for i, tree := range trees {
+		if err := unmarshalTOMLTree(tree, result.Index(i).Addr().Interface(), configName); err != nil {
+			return err
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
 type missingRequiredOptionError struct {
 	strErr string
 	usage  string
@@ -666,3 +1482,19 @@ func positive(option *Option) error {
 	}
 	return nil
 }
+
+// oneOf validates that a string option is one of the allowed values,
+// normalizing it to upper case.
+func oneOf(allowed []string) func(option *Option) error {
+	return func(option *Option) error {
+		value, ok := option.ConfigKey.(*string)
+		if !ok {
+			return fmt.Errorf("%s is not a string", option.Name)
+		}
+		*value = strings.ToUpper(*value)
+		if !slices.Contains(allowed, *value) {
+			return fmt.Errorf("%s must be one of %s, got %q", option.Name, strings.Join(allowed, ", "), *value)
+		}
+		return nil
+	}
+}
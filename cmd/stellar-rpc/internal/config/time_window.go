@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const timeOfDayLayout = "15:04"
+
+// TimeWindow is a daily time range, in UTC unless a Location is set. The
+// window wraps around midnight when it ends before it starts (e.g. 22:00-04:00).
+type TimeWindow struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// ParseTimeWindow parses a "HH:MM-HH:MM" time window. An empty string is parsed
+// as a window covering the whole day.
+func ParseTimeWindow(value string) (TimeWindow, error) {
+	if value == "" {
+		return TimeWindow{}, nil
+	}
+	start, end, ok := strings.Cut(value, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("time window %q must have the HH:MM-HH:MM format", value)
+	}
+	var window TimeWindow
+	for _, item := range []struct {
+		value  string
+		target *time.Duration
+	}{
+		{start, &window.Start},
+		{end, &window.End},
+	} {
+		parsed, err := time.Parse(timeOfDayLayout, strings.TrimSpace(item.value))
+		if err != nil {
+			return TimeWindow{}, fmt.Errorf("time window %q must have the HH:MM-HH:MM format: %w", value, err)
+		}
+		*item.target = time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute
+	}
+	return window, nil
+}
+
+func (w TimeWindow) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
+
+// at returns the given time of day, days after the day of t. The time is built
+// from its wall clock, so that it stays right on daylight saving time changes.
+func (w TimeWindow) at(t time.Time, days int, timeOfDay time.Duration) time.Time {
+	hour, minute := int(timeOfDay/time.Hour), int(timeOfDay%time.Hour/time.Minute)
+	return time.Date(t.Year(), t.Month(), t.Day()+days, hour, minute, 0, 0, w.location())
+}
+
+// Contains returns true if t is within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	if w.Start == w.End {
+		return true
+	}
+	t = t.In(w.location())
+	afterStart, beforeEnd := !t.Before(w.at(t, 0, w.Start)), t.Before(w.at(t, 0, w.End))
+	if w.Start < w.End {
+		return afterStart && beforeEnd
+	}
+	return afterStart || beforeEnd
+}
+
+// Next returns the earliest time, from t on, within the window.
+func (w TimeWindow) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	t = t.In(w.location())
+	start := w.at(t, 0, w.Start)
+	if start.Before(t) {
+		start = w.at(t, 1, w.Start)
+	}
+	return start
+}
+
+// MaintenanceTimeWindow returns the window in which the heavy background jobs
+// run, falling back to the (deprecated) SQLite vacuum window.
+func (cfg *Config) MaintenanceTimeWindow() (TimeWindow, error) {
+	value := cfg.MaintenanceWindow
+	if value == "" {
+		return ParseTimeWindow(cfg.SQLiteVacuumWindow)
+	}
+	window, err := ParseTimeWindow(value)
+	if err != nil {
+		return TimeWindow{}, err
+	}
+	window.Location, err = time.LoadLocation(cfg.MaintenanceWindowTimezone)
+	return window, err
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWindow(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	window, err := ParseTimeWindow("")
+	require.NoError(t, err)
+	assert.True(t, window.Contains(at(12, 0)))
+
+	window, err = ParseTimeWindow("02:00-04:30")
+	require.NoError(t, err)
+	assert.Equal(t, TimeWindow{Start: 2 * time.Hour, End: 4*time.Hour + 30*time.Minute}, window)
+	assert.False(t, window.Contains(at(1, 59)))
+	assert.True(t, window.Contains(at(2, 0)))
+	assert.True(t, window.Contains(at(4, 29)))
+	assert.False(t, window.Contains(at(4, 30)))
+
+	// wrapping around midnight
+	window, err = ParseTimeWindow("22:00-01:00")
+	require.NoError(t, err)
+	assert.True(t, window.Contains(at(23, 0)))
+	assert.True(t, window.Contains(at(0, 30)))
+	assert.False(t, window.Contains(at(12, 0)))
+
+	for _, invalid := range []string{"02:00", "2am-4am", "02:00-25:00"} {
+		_, err = ParseTimeWindow(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestTimeWindowNext(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	window, err := ParseTimeWindow("02:00-04:30")
+	require.NoError(t, err)
+	assert.Equal(t, at(1, 3, 0), window.Next(at(1, 3, 0)))
+	assert.Equal(t, at(1, 2, 0), window.Next(at(1, 1, 0)))
+	assert.Equal(t, at(2, 2, 0), window.Next(at(1, 5, 0)))
+
+	window, err = ParseTimeWindow("22:00-01:00")
+	require.NoError(t, err)
+	assert.Equal(t, at(1, 0, 30), window.Next(at(1, 0, 30)))
+	assert.Equal(t, at(1, 22, 0), window.Next(at(1, 12, 0)))
+
+	// in another time zone (UTC-5 in January)
+	window.Location, err = time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	assert.True(t, window.Contains(at(1, 3, 0)))
+	assert.False(t, window.Contains(at(1, 23, 0)))
+	assert.True(t, window.Next(at(1, 12, 0)).Equal(at(2, 3, 0)))
+}
+
+func TestTimeWindowDaylightSavingTime(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	window, err := ParseTimeWindow("04:00-05:00")
+	require.NoError(t, err)
+	window.Location = location
+
+	// the clocks move forward at 02:00 on 2024-03-10 and back at 02:00 on 2024-11-03
+	for _, day := range []time.Time{
+		time.Date(2024, time.March, 10, 0, 0, 0, 0, location),
+		time.Date(2024, time.November, 3, 0, 0, 0, 0, location),
+	} {
+		at := func(hour, minute int) time.Time {
+			return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, location)
+		}
+		assert.False(t, window.Contains(at(3, 59)), day)
+		assert.True(t, window.Contains(at(4, 0)), day)
+		assert.True(t, window.Contains(at(4, 59)), day)
+		assert.False(t, window.Contains(at(5, 0)), day)
+		assert.True(t, window.Next(at(1, 0)).Equal(at(4, 0)), day)
+	}
+}
+
+func TestMaintenanceTimeWindow(t *testing.T) {
+	cfg := Config{SQLiteVacuumWindow: "02:00-04:00"}
+	window, err := cfg.MaintenanceTimeWindow()
+	require.NoError(t, err)
+	assert.Equal(t, TimeWindow{Start: 2 * time.Hour, End: 4 * time.Hour}, window)
+
+	cfg.MaintenanceWindow = "01:00-03:00"
+	cfg.MaintenanceWindowTimezone = "Europe/Madrid"
+	window, err = cfg.MaintenanceTimeWindow()
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, window.Start)
+	assert.Equal(t, "Europe/Madrid", window.Location.String())
+}
@@ -35,6 +35,7 @@ func parseToml(r io.Reader, strict bool, cfg *Config) error {
 		if err := option.setValue(value); err != nil {
 			return err
 		}
+		option.explicit = true
 	}
 
 	if cfg.Strict || strict {
@@ -119,6 +119,8 @@ func TestRoundTrip(t *testing.T) {
 			*v = 22
 		case *uint32:
 			*v = 32
+		case *uint64:
+			*v = 64
 		case *time.Duration:
 			*v = 5 * time.Second
 		case *[]string:
@@ -131,6 +133,10 @@ func TestRoundTrip(t *testing.T) {
 			*v = defaultBufferedStorageBackendConfig()
 		case *datastore.DataStoreConfig:
 			*v = defaultDataStoreConfig()
+		case *[]StellarCore:
+			*v = []StellarCore{{URL: "http://a", Weight: 2}}
+		case *[]HistoryArchive:
+			*v = []HistoryArchive{{URL: "http://a", Headers: map[string]string{"Authorization": "b"}, Weight: 2}}
 		default:
 			t.Fatalf("TestRoundTrip not implemented for type %s, on option %s, "+
 				"please add a test value", optType.Kind(), option.Name)
@@ -182,3 +188,50 @@ func marshalTOML(cfg *Config) ([]byte, error) {
 	}
 	return tree.Marshal()
 }
+
+func TestHistoryArchivesToml(t *testing.T) {
+	archivesToml := `
+HISTORY_ARCHIVE_URLS = [ "http://history-futurenet.stellar.org" ]
+
+[[history_archives]]
+url = "https://private-archive.example.com"
+user_agent = "my-agent"
+weight = 3
+[history_archives.headers]
+Authorization = "Bearer token"
+
+[[history_archives]]
+url = "http://other-archive.example.com"
+`
+	cfg := Config{}
+	require.NoError(t, cfg.loadDefaults())
+	require.NoError(t, parseToml(strings.NewReader(archivesToml), true, &cfg))
+	require.Equal(t, []HistoryArchive{
+		{
+			URL:       "https://private-archive.example.com",
+			UserAgent: "my-agent",
+			Headers:   map[string]string{"Authorization": "Bearer token"},
+			Weight:    3,
+		},
+		{URL: "http://other-archive.example.com"},
+	}, cfg.HistoryArchives)
+
+	defaultUserAgent := cfg.HistoryArchiveUserAgent
+	assert.Equal(t, []HistoryArchive{
+		{URL: "http://history-futurenet.stellar.org", UserAgent: defaultUserAgent, Weight: 1},
+		{
+			URL:       "https://private-archive.example.com",
+			UserAgent: "my-agent",
+			Headers:   map[string]string{"Authorization": "Bearer token"},
+			Weight:    3,
+		},
+		{URL: "http://other-archive.example.com", UserAgent: defaultUserAgent, Weight: 1},
+	}, cfg.AllHistoryArchives())
+
+	// the round trip preserves the archives
+	outBytes, err := cfg.MarshalTOML()
+	require.NoError(t, err)
+	parsed := Config{}
+	require.NoError(t, parseToml(bytes.NewReader(outBytes), false, &parsed))
+	assert.Equal(t, cfg.HistoryArchives, parsed.HistoryArchives)
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// ipv4MappedPrefixBits is the length of the ::ffff:0:0/96 prefix of the
+// IPv4-mapped IPv6 addresses.
+const ipv4MappedPrefixBits = 96
+
+// parseTrustedProxies parses a list of CIDRs (e.g. 10.0.0.0/8), where single
+// IPs are accepted as well.
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, err
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= ipv4MappedPrefixBits {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-ipv4MappedPrefixBits)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// TrustedProxyPrefixes returns the parsed TrustedProxies.
+func (cfg *Config) TrustedProxyPrefixes() []netip.Prefix {
+	// the list is validated along with the rest of the configuration
+	prefixes, _ := parseTrustedProxies(cfg.TrustedProxies)
+	return prefixes
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := parseTrustedProxies([]string{"10.1.2.3/8", " 2001:db8::1 ", "::ffff:10.0.0.0/104"})
+	require.NoError(t, err)
+	require.Len(t, prefixes, 3)
+	assert.Equal(t, "10.0.0.0/8", prefixes[0].String())
+	assert.Equal(t, "2001:db8::1/128", prefixes[1].String())
+	assert.Equal(t, "10.0.0.0/8", prefixes[2].String())
+
+	_, err = parseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+	_, err = parseTrustedProxies([]string{"not-an-ip"})
+	require.Error(t, err)
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	cfg := Config{ClientIPHeader: "X-Real-IP"}
+	validate := func() error {
+		for _, option := range cfg.options() {
+			if option.Name == "trusted-proxies" {
+				return option.Validate(option)
+			}
+		}
+		return nil
+	}
+	require.ErrorContains(t, validate(), "client-ip-header requires trusted-proxies")
+
+	cfg.TrustedProxies = []string{"10.0.0.0/8"}
+	require.NoError(t, validate())
+	cfg.ClientIPHeader = ""
+	require.NoError(t, validate())
+}
@@ -0,0 +1,189 @@
+package corepool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+// DefaultCooldown is the time during which a failed instance only receives
+// requests if all the other instances failed too.
+const DefaultCooldown = 30 * time.Second
+
+// Core is a stellar-core instance of a Pool, together with its weight.
+type Core struct {
+	interfaces.CoreClient
+	Name string
+	// Weight is the relative share of the requests sent to the instance
+	Weight uint
+}
+
+// Pool is an interfaces.CoreClient which distributes the requests among
+// stellar-core instances according to their weights. When a request to an
+// instance fails, it's sent to the next instance (again, picked according to
+// their weights) and the failed instance is set aside for a cooldown period.
+// Failed ledger entry queries only set the instance aside for queries, since
+// they are served separately from the submissions.
+type Pool struct {
+	logger   *log.Entry
+	cores    []Core
+	cooldown time.Duration
+
+	lock             sync.Mutex
+	failedUntil      []time.Time
+	queryFailedUntil []time.Time
+
+	now func() time.Time
+	// randN returns a random number in [0, n)
+	randN func(n uint) uint
+}
+
+var (
+	_ interfaces.CoreClient     = &Pool{}
+	_ interfaces.FastCoreClient = &Pool{}
+)
+
+// NewPool creates a Pool from the given instances. Instances with a weight of
+// 0 are given a weight of 1.
+func NewPool(cores []Core, cooldown time.Duration, logger *log.Entry) (*Pool, error) {
+	if len(cores) == 0 {
+		return nil, errors.New("no stellar-core instances provided")
+	}
+	cores = append([]Core(nil), cores...)
+	for i := range cores {
+		if cores[i].Weight == 0 {
+			cores[i].Weight = 1
+		}
+	}
+	return &Pool{
+		logger:           logger,
+		cores:            cores,
+		cooldown:         cooldown,
+		failedUntil:      make([]time.Time, len(cores)),
+		queryFailedUntil: make([]time.Time, len(cores)),
+		now:              time.Now,
+		randN:            rand.N[uint],
+	}, nil
+}
+
+// order returns the indexes of the instances in a random order, where
+// instances with higher weights are more likely to come first. Instances
+// within their cooldown period (according to failedUntil) come last.
+func (p *Pool) order(failedUntil []time.Time) []int {
+	p.lock.Lock()
+	now := p.now()
+	var healthy, failed []int
+	for i := range p.cores {
+		if now.Before(failedUntil[i]) {
+			failed = append(failed, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	p.lock.Unlock()
+	return append(p.shuffle(healthy), p.shuffle(failed)...)
+}
+
+// shuffle orders the instances randomly, according to their weights.
+func (p *Pool) shuffle(remaining []int) []int {
+	var totalWeight uint
+	for _, i := range remaining {
+		totalWeight += p.cores[i].Weight
+	}
+	result := make([]int, 0, len(remaining))
+	for len(remaining) > 0 {
+		target := p.randN(totalWeight)
+		j := 0
+		for ; j < len(remaining)-1; j++ {
+			if target < p.cores[remaining[j]].Weight {
+				break
+			}
+			target -= p.cores[remaining[j]].Weight
+		}
+		result = append(result, remaining[j])
+		totalWeight -= p.cores[remaining[j]].Weight
+		remaining = append(remaining[:j], remaining[j+1:]...)
+	}
+	return result
+}
+
+func (p *Pool) setFailed(failedUntil []time.Time, i int, failed bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if failed {
+		failedUntil[i] = p.now().Add(p.cooldown)
+	} else {
+		failedUntil[i] = time.Time{}
+	}
+}
+
+// run runs the action on the instances, in order, until it succeeds. The
+// failures are recorded in failedUntil.
+func (p *Pool) run(ctx context.Context, failedUntil []time.Time,
+	runner func(core interfaces.CoreClient) error,
+) error {
+	var err error
+	for _, i := range p.order(failedUntil) {
+		err = runner(p.cores[i].CoreClient)
+		if err == nil {
+			p.setFailed(failedUntil, i, false)
+			return nil
+		}
+		if ctx.Err() != nil {
+			// requests aborted by the caller don't say anything about the instance
+			return err
+		}
+		p.setFailed(failedUntil, i, true)
+		if p.logger != nil {
+			p.logger.WithError(err).Warnf("Encountered an error with stellar-core '%s'", p.cores[i].Name)
+		}
+	}
+	return err
+}
+
+func (p *Pool) Info(ctx context.Context) (*proto.InfoResponse, error) {
+	var response *proto.InfoResponse
+	err := p.run(ctx, p.failedUntil, func(core interfaces.CoreClient) error {
+		var err error
+		response, err = core.Info(ctx)
+		return err
+	})
+	return response, err
+}
+
+func (p *Pool) SubmitTransaction(ctx context.Context, txBase64 string) (*proto.TXResponse, error) {
+	var response *proto.TXResponse
+	err := p.run(ctx, p.failedUntil, func(core interfaces.CoreClient) error {
+		var err error
+		response, err = core.SubmitTransaction(ctx, txBase64)
+		return err
+	})
+	return response, err
+}
+
+// GetLedgerEntries queries the ledger entries through the instances, which
+// must serve ledger entry queries (i.e. their client must implement
+// interfaces.FastCoreClient).
+func (p *Pool) GetLedgerEntries(ctx context.Context, ledgerSeq uint32, keys ...xdr.LedgerKey,
+) (proto.GetLedgerEntryResponse, error) {
+	var response proto.GetLedgerEntryResponse
+	err := p.run(ctx, p.queryFailedUntil, func(core interfaces.CoreClient) error {
+		querier, ok := core.(interfaces.FastCoreClient)
+		if !ok {
+			return fmt.Errorf("stellar-core client %T doesn't serve ledger entry queries", core)
+		}
+		var err error
+		response, err = querier.GetLedgerEntries(ctx, ledgerSeq, keys...)
+		return err
+	})
+	return response, err
+}
@@ -0,0 +1,162 @@
+package corepool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/xdr"
+)
+
+type fakeCore struct {
+	calls int
+	err   error
+}
+
+func (c *fakeCore) Info(context.Context) (*proto.InfoResponse, error) {
+	c.calls++
+	return &proto.InfoResponse{}, c.err
+}
+
+func (c *fakeCore) SubmitTransaction(context.Context, string) (*proto.TXResponse, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &proto.TXResponse{Status: proto.TXStatusPending}, nil
+}
+
+// fakeQueryCore is a fakeCore which also serves ledger entry queries
+type fakeQueryCore struct {
+	fakeCore
+}
+
+func (c *fakeQueryCore) GetLedgerEntries(_ context.Context, ledgerSeq uint32, _ ...xdr.LedgerKey,
+) (proto.GetLedgerEntryResponse, error) {
+	c.calls++
+	return proto.GetLedgerEntryResponse{Ledger: ledgerSeq}, c.err
+}
+
+func TestPoolWeights(t *testing.T) {
+	light, heavy := &fakeCore{}, &fakeCore{}
+	pool, err := NewPool([]Core{
+		{CoreClient: light, Name: "light"},
+		{CoreClient: heavy, Name: "heavy", Weight: 3},
+	}, DefaultCooldown, nil)
+	require.NoError(t, err)
+	// iterate over all the possible random values
+	for i := range uint(4) {
+		pool.randN = func(uint) uint { return i }
+		response, err := pool.SubmitTransaction(context.Background(), "tx")
+		require.NoError(t, err)
+		assert.Equal(t, proto.TXStatusPending, response.Status)
+	}
+	assert.Equal(t, 1, light.calls)
+	assert.Equal(t, 3, heavy.calls)
+}
+
+func TestPoolFailover(t *testing.T) {
+	failing, healthy := &fakeCore{err: errors.New("boom")}, &fakeCore{}
+	pool, err := NewPool([]Core{
+		{CoreClient: failing, Name: "failing", Weight: 100},
+		{CoreClient: healthy, Name: "healthy"},
+	}, time.Minute, nil)
+	require.NoError(t, err)
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	pool.randN = func(uint) uint { return 0 }
+
+	_, err = pool.SubmitTransaction(context.Background(), "tx")
+	require.NoError(t, err)
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 1, healthy.calls)
+
+	// the failed instance is avoided during the cooldown
+	_, err = pool.SubmitTransaction(context.Background(), "tx")
+	require.NoError(t, err)
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 2, healthy.calls)
+
+	// and used again afterwards
+	failing.err = nil
+	now = now.Add(time.Minute)
+	_, err = pool.SubmitTransaction(context.Background(), "tx")
+	require.NoError(t, err)
+	assert.Equal(t, 2, failing.calls)
+	assert.Equal(t, 2, healthy.calls)
+
+	// the last error is returned when all the instances fail
+	failing.err, healthy.err = errors.New("boom"), errors.New("bang")
+	_, err = pool.SubmitTransaction(context.Background(), "tx")
+	require.ErrorContains(t, err, "bang")
+}
+
+func TestPoolCanceledRequest(t *testing.T) {
+	failing, healthy := &fakeCore{err: context.Canceled}, &fakeCore{}
+	pool, err := NewPool([]Core{
+		{CoreClient: failing, Name: "failing", Weight: 100},
+		{CoreClient: healthy, Name: "healthy"},
+	}, time.Minute, nil)
+	require.NoError(t, err)
+	pool.randN = func(uint) uint { return 0 }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = pool.SubmitTransaction(ctx, "tx")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, healthy.calls)
+	// the instance isn't blamed for the canceled request
+	assert.Equal(t, []int{0, 1}, pool.order(pool.failedUntil))
+}
+
+func TestPoolGetLedgerEntries(t *testing.T) {
+	nonQuerying, querying := &fakeCore{}, &fakeQueryCore{}
+	pool, err := NewPool([]Core{
+		{CoreClient: nonQuerying, Name: "non-querying", Weight: 100},
+		{CoreClient: querying, Name: "querying"},
+	}, time.Minute, nil)
+	require.NoError(t, err)
+	pool.randN = func(uint) uint { return 0 }
+
+	// the instances which don't serve ledger entry queries are skipped
+	response, err := pool.GetLedgerEntries(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), response.Ledger)
+	assert.Equal(t, 1, querying.calls)
+
+	// and the query fails when no instance can serve it
+	querying.err = errors.New("boom")
+	_, err = pool.GetLedgerEntries(context.Background(), 10)
+	require.Error(t, err)
+	assert.Equal(t, 2, querying.calls)
+}
+
+func TestPoolQueryFailures(t *testing.T) {
+	failing, healthy := &fakeQueryCore{}, &fakeQueryCore{}
+	pool, err := NewPool([]Core{
+		{CoreClient: failing, Name: "failing", Weight: 100},
+		{CoreClient: healthy, Name: "healthy"},
+	}, time.Minute, nil)
+	require.NoError(t, err)
+	pool.randN = func(uint) uint { return 0 }
+
+	failing.err = errors.New("boom")
+	_, err = pool.GetLedgerEntries(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 1, healthy.calls)
+
+	// the failed query sets the instance aside for queries
+	assert.Equal(t, []int{1, 0}, pool.order(pool.queryFailedUntil))
+	// but not for submissions
+	failing.err = nil
+	_, err = pool.SubmitTransaction(context.Background(), "tx")
+	require.NoError(t, err)
+	assert.Equal(t, 2, failing.calls)
+	assert.Equal(t, 1, healthy.calls)
+}
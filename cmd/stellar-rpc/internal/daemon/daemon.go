@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	runtimePprof "runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -28,20 +29,25 @@ import (
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/archivepool"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/circuitbreaker"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/config"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/corepool"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/feewindow"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/hotkeys"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ingest"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/network"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/preflight"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/rpcdatastore"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/util"
 )
 
 const (
-	maxLedgerEntryWriteBatchSize = 150
-	defaultReadTimeout           = 5 * time.Second
-	defaultShutdownGracePeriod   = 10 * time.Second
+	defaultReadTimeout         = 5 * time.Second
+	defaultShutdownGracePeriod = 10 * time.Second
+	datastoreCheckTimeout      = 30 * time.Second
 
 	// Since our default retention window will be 7 days (7*17,280 ledgers),
 	// choose a random 5-digit prime to have irregular logging intervals at each
@@ -53,6 +59,7 @@ type Daemon struct {
 	core                *ledgerbackend.CaptiveStellarCore
 	coreClient          *CoreClientWithMetrics
 	coreQueryingClient  interfaces.FastCoreClient
+	coreQueryBreaker    *circuitbreaker.Breaker
 	ingestService       *ingest.Service
 	db                  *db.DB
 	jsonRPCHandler      *internal.Handler
@@ -62,11 +69,27 @@ type Daemon struct {
 	server              *http.Server
 	adminListener       net.Listener
 	adminServer         *http.Server
+	metricsListener     net.Listener
+	metricsServer       *http.Server
 	closeOnce           sync.Once
 	closeError          error
 	done                chan struct{}
 	metricsRegistry     *prometheus.Registry
 	dataStore           datastore.DataStore
+	maintenance         *maintenanceScheduler
+	syncStatus          *syncStatus
+	stopWarmup          context.CancelFunc
+	warmupWG            sync.WaitGroup
+
+	// checkpointDeferral is nil when the checkpoints aren't deferred under
+	// slow queries
+	checkpointDeferral *db.CheckpointDeferral
+	// simulationCoreClient is the client the simulations query the ledger
+	// entries through
+	simulationCoreClient interfaces.FastCoreClient
+	// historyRetentionWindow is the history retention window in effect, which
+	// follows the observed ledger close times when configured as a duration
+	historyRetentionWindow atomic.Uint32
 }
 
 func (d *Daemon) GetDB() *db.DB {
@@ -99,6 +122,12 @@ func (d *Daemon) close() {
 			closeErrors = append(closeErrors, err)
 		}
 	}
+	if d.metricsServer != nil {
+		if err := d.metricsServer.Shutdown(shutdownCtx); err != nil {
+			d.logger.WithError(err).Error("error during metrics server Shutdown")
+			closeErrors = append(closeErrors, err)
+		}
+	}
 
 	if err := d.ingestService.Close(); err != nil {
 		d.logger.WithError(err).Error("error closing ingestion service")
@@ -109,6 +138,11 @@ func (d *Daemon) close() {
 		closeErrors = append(closeErrors, err)
 	}
 	d.jsonRPCHandler.Close()
+	if d.stopWarmup != nil {
+		d.stopWarmup()
+		d.warmupWG.Wait()
+	}
+	d.maintenance.close()
 	if err := d.db.Close(); err != nil {
 		d.logger.WithError(err).Error("Error closing db")
 		closeErrors = append(closeErrors, err)
@@ -143,7 +177,7 @@ func newCaptiveCore(cfg *config.Config, logger *supportlog.Entry) (*ledgerbacken
 	httpPort := uint(cfg.CaptiveCoreHTTPPort)
 	captiveCoreTomlParams := ledgerbackend.CaptiveCoreTomlParams{
 		HTTPPort:                           &httpPort,
-		HistoryArchiveURLs:                 cfg.HistoryArchiveURLs,
+		HistoryArchiveURLs:                 cfg.AllHistoryArchiveURLs(),
 		NetworkPassphrase:                  cfg.NetworkPassphrase,
 		Strict:                             true,
 		EnforceSorobanDiagnosticEvents:     true,
@@ -160,7 +194,7 @@ func newCaptiveCore(cfg *config.Config, logger *supportlog.Entry) (*ledgerbacken
 		BinaryPath:          cfg.StellarCoreBinaryPath,
 		StoragePath:         cfg.CaptiveCoreStoragePath,
 		NetworkPassphrase:   cfg.NetworkPassphrase,
-		HistoryArchiveURLs:  cfg.HistoryArchiveURLs,
+		HistoryArchiveURLs:  cfg.AllHistoryArchiveURLs(),
 		CheckpointFrequency: cfg.CheckpointFrequency,
 		Log:                 logger.WithField("subservice", "stellar-core"),
 		Toml:                captiveCoreToml,
@@ -171,9 +205,13 @@ func newCaptiveCore(cfg *config.Config, logger *supportlog.Entry) (*ledgerbacken
 
 func MustNew(cfg *config.Config, logger *supportlog.Entry) *Daemon {
 	logger = setupLogger(cfg, logger)
+	cfg.ResolveCPUCount()
+	logger.WithField("cpus", cfg.CPUCount).Info("using the available CPUs")
 	core := mustCreateCaptiveCore(cfg, logger)
 	historyArchive := mustCreateHistoryArchive(cfg, logger)
 	metricsRegistry := prometheus.NewRegistry()
+	coreQueryBreaker := circuitbreaker.New(cfg.CoreQueryCircuitBreakerThreshold, cfg.CoreQueryCircuitBreakerCooldown)
+	corePool := mustCreateStellarCoreClient(cfg, logger)
 
 	daemon := &Daemon{
 		logger:             logger,
@@ -181,21 +219,38 @@ func MustNew(cfg *config.Config, logger *supportlog.Entry) *Daemon {
 		db:                 mustOpenDatabase(cfg, logger, metricsRegistry),
 		done:               make(chan struct{}),
 		metricsRegistry:    metricsRegistry,
-		coreClient:         newCoreClientWithMetrics(createStellarCoreClient(cfg), metricsRegistry),
-		coreQueryingClient: createHighperfStellarCoreClient(cfg),
+		coreClient:         newCoreClientWithMetrics(corePool, metricsRegistry),
+		coreQueryingClient: newFastCoreClientWithBreaker(createHighperfStellarCoreClient(cfg), coreQueryBreaker),
+		coreQueryBreaker:   coreQueryBreaker,
+	}
+	daemon.simulationCoreClient = daemon.coreQueryingClient
+	if cfg.SimulateTransactionCoreFallback {
+		daemon.simulationCoreClient = newCoreClientWithFallback(daemon.coreQueryingClient, corePool,
+			logger.WithField("subservice", "simulation-fallback"), metricsRegistry)
 	}
 
+	daemon.resolveHistoryRetentionWindow(cfg)
+	daemon.historyRetentionWindow.Store(cfg.HistoryRetentionWindow)
+	if cfg.CheckpointDeferralLatencyThreshold != 0 {
+		daemon.checkpointDeferral = db.NewCheckpointDeferral(daemon.db, daemon,
+			cfg.CheckpointDeferralLatencyThreshold, cfg.CheckpointDeferralMaxCheckpoints,
+			int64(cfg.CheckpointDeferralMaxWALSizeBytes)) //nolint:gosec
+	}
 	feewindows := daemon.mustInitializeStorage(cfg)
 
 	if cfg.ServeLedgersFromDatastore {
 		daemon.dataStore = mustCreateDataStore(cfg, logger)
 	}
 	daemon.ingestService = createIngestService(cfg, logger, daemon, feewindows, historyArchive)
+	daemon.syncStatus = &syncStatus{ingestService: daemon.ingestService}
 	daemon.preflightWorkerPool = createPreflightWorkerPool(cfg, logger, daemon)
 	daemon.jsonRPCHandler = createJSONRPCHandler(cfg, logger, daemon, feewindows)
+	daemon.maintenance = daemon.createMaintenanceScheduler(cfg)
 
 	daemon.setupHTTPServers(cfg)
 	daemon.registerMetrics()
+	daemon.maintenance.start()
+	daemon.startWarmup(cfg)
 
 	return daemon
 }
@@ -205,6 +260,14 @@ func mustCreateDataStore(cfg *config.Config, logger *supportlog.Entry) datastore
 	if err != nil {
 		logger.WithError(err).Fatal("failed to initialize datastore")
 	}
+	if cfg.CheckDatastoreOnStartup {
+		ctx, cancel := context.WithTimeout(context.Background(), datastoreCheckTimeout)
+		defer cancel()
+		if err := rpcdatastore.CheckConnectivity(ctx, dataStore); err != nil {
+			logger.WithError(err).Fatal("datastore check failed")
+		}
+		logger.Info("datastore check succeeded")
+	}
 	return dataStore
 }
 
@@ -229,57 +292,155 @@ func mustCreateCaptiveCore(cfg *config.Config, logger *supportlog.Entry) *ledger
 }
 
 func mustCreateHistoryArchive(cfg *config.Config, logger *supportlog.Entry) *historyarchive.ArchiveInterface {
-	if len(cfg.HistoryArchiveURLs) == 0 {
+	archiveConfigs := cfg.AllHistoryArchives()
+	if len(archiveConfigs) == 0 {
 		logger.Fatal("no history archives URLs were provided")
 	}
 
-	historyArchive, err := historyarchive.NewArchivePool(
-		cfg.HistoryArchiveURLs,
-		historyarchive.ArchiveOptions{
-			Logger:              logger,
-			NetworkPassphrase:   cfg.NetworkPassphrase,
-			CheckpointFrequency: cfg.CheckpointFrequency,
-			ConnectOptions: storage.ConnectOptions{
-				Context:   context.Background(),
-				UserAgent: cfg.HistoryArchiveUserAgent,
+	// Like historyarchive.NewArchivePool, skip the archives we cannot connect to
+	archives := make([]archivepool.Archive, 0, len(archiveConfigs))
+	var lastErr error
+	for _, archiveConfig := range archiveConfigs {
+		archive, err := archivepool.Connect(
+			archiveConfig.URL,
+			archiveConfig.Headers,
+			cfg.CoreRequestTimeout,
+			historyarchive.ArchiveOptions{
+				Logger:              logger,
+				NetworkPassphrase:   cfg.NetworkPassphrase,
+				CheckpointFrequency: cfg.CheckpointFrequency,
+				ConnectOptions: storage.ConnectOptions{
+					Context:   context.Background(),
+					UserAgent: archiveConfig.UserAgent,
+				},
 			},
-		},
-	)
+		)
+		if err != nil {
+			lastErr = fmt.Errorf("error connecting to history archive (%s): %w", archiveConfig.URL, err)
+			continue
+		}
+		archives = append(archives, archivepool.Archive{
+			ArchiveInterface: archive,
+			Name:             archiveConfig.URL,
+			Weight:           archiveConfig.Weight,
+		})
+	}
+	if len(archives) == 0 {
+		logger.WithError(lastErr).Fatal("could not connect to history archive")
+	}
+
+	pool, err := archivepool.NewPool(archives, logger)
 	if err != nil {
 		logger.WithError(err).Fatal("could not connect to history archive")
 	}
+	var historyArchive historyarchive.ArchiveInterface = pool
 	return &historyArchive
 }
 
 func mustOpenDatabase(cfg *config.Config, logger *supportlog.Entry, metricsRegistry *prometheus.Registry) *db.DB {
+	if risk := cfg.SQLiteCorruptionRisk(); risk != "" {
+		logger.WithFields(supportlog.F{
+			"journal_mode": cfg.SQLiteJournalMode,
+			"synchronous":  cfg.SQLiteSynchronous,
+		}).Warn("the configured SQLite modes risk corrupting the database: " + risk)
+	}
 	dbConn, err := db.OpenSQLiteDBWithPrometheusMetrics(
-		cfg.SQLiteDBPath, interfaces.PrometheusNamespace, "db", metricsRegistry)
-	if err != nil {
+		cfg.SQLiteDBPath,
+		db.SQLiteOptions{
+			JournalMode:      cfg.SQLiteJournalMode,
+			Synchronous:      cfg.SQLiteSynchronous,
+			AllowNewerSchema: cfg.SQLiteAllowNewerSchema,
+		},
+		interfaces.PrometheusNamespace, "db", metricsRegistry)
+	if errors.Is(err, db.ErrNewerSchema) {
+		logger.WithError(err).Fatal("could not open database, upgrade stellar-rpc, delete the database or set" +
+			" sqlite-allow-newer-schema")
+	} else if err != nil {
 		logger.WithError(err).Fatal("could not open database")
 	}
 	return dbConn
 }
 
-func createStellarCoreClient(cfg *config.Config) stellarcore.Client {
-	return stellarcore.Client{
-		URL:  cfg.StellarCoreURL,
-		HTTP: &http.Client{Timeout: cfg.CoreRequestTimeout},
+// mustCreateStellarCoreClient returns the client which transactions are
+// submitted through, balancing them across the configured stellar-core
+// instances.
+func mustCreateStellarCoreClient(cfg *config.Config, logger *supportlog.Entry) *corepool.Pool {
+	var cores []corepool.Core
+	for _, core := range cfg.AllStellarCores() {
+		cores = append(cores, corepool.Core{
+			CoreClient: &stellarcore.Client{
+				URL:  core.URL,
+				HTTP: &http.Client{Timeout: cfg.CoreRequestTimeout},
+			},
+			Name:   core.URL,
+			Weight: core.Weight,
+		})
+	}
+	pool, err := corepool.NewPool(cores, corepool.DefaultCooldown, logger.WithField("subservice", "corepool"))
+	if err != nil {
+		logger.WithError(err).Fatal("could not create the stellar-core pool")
 	}
+	return pool
 }
 
 func createHighperfStellarCoreClient(cfg *config.Config) interfaces.FastCoreClient {
 	return &stellarcore.Client{
-		URL:  fmt.Sprintf("http://localhost:%d", cfg.CaptiveCoreHTTPQueryPort),
-		HTTP: &http.Client{Timeout: cfg.CoreRequestTimeout},
+		URL: fmt.Sprintf("http://localhost:%d", cfg.CaptiveCoreHTTPQueryPort),
+		HTTP: &http.Client{
+			Timeout:   cfg.CoreRequestTimeout,
+			Transport: createCoreQueryTransport(cfg),
+		},
 	}
 }
 
+// createCoreQueryTransport returns the transport of the captive-core query
+// client, pooling the connections so that they are reused across the (many)
+// ledger entry queries. All the connections go to the same host, so the
+// idle pool isn't split across hosts.
+func createCoreQueryTransport(cfg *config.Config) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()   //nolint:forcetypeassert
+	transport.MaxIdleConns = int(cfg.CoreQueryMaxIdleConns)        //nolint:gosec
+	transport.MaxIdleConnsPerHost = int(cfg.CoreQueryMaxIdleConns) //nolint:gosec
+	transport.MaxConnsPerHost = int(cfg.CoreQueryMaxConnsPerHost)  //nolint:gosec
+	transport.IdleConnTimeout = cfg.CoreQueryIdleConnTimeout
+	if cfg.CoreQueryMaxIdleConns == 0 {
+		transport.DisableKeepAlives = true
+	}
+	return transport
+}
+
+// eventDataEncoding returns the database encoding of the (validated) event
+// storage format.
+func eventDataEncoding(format string) db.EventDataEncoding {
+	if format == config.EventStorageFormatCompressedXDR {
+		return db.EventDataEncodingCompressedXDR
+	}
+	return db.EventDataEncodingXDR
+}
+
 func createIngestService(cfg *config.Config, logger *supportlog.Entry, daemon *Daemon,
 	feewindows *feewindow.FeeWindows, historyArchive *historyarchive.ArchiveInterface,
 ) *ingest.Service {
 	onIngestionRetry := func(err error, _ time.Duration) {
 		logger.WithError(err).Error("could not run ingestion. Retrying")
 	}
+	var startWindow uint32
+	if cfg.IngestionStartWithinRetentionWindow {
+		startWindow = cfg.HistoryRetentionWindow
+	}
+
+	var dbSizeLimiter *db.SizeLimiter
+	if cfg.MaxDBSizeBytes != 0 {
+		dbSizeLimiter = db.NewSizeLimiter(logger, daemon.db, daemon, int64(cfg.MaxDBSizeBytes)) //nolint:gosec
+	}
+
+	var contractDataIndex *db.ContractDataIndex
+	if cfg.IndexContractData {
+		contractDataIndex = db.NewContractDataIndex(logger, daemon.db, cfg.NetworkPassphrase,
+			int(cfg.IngestionBatchSize)) //nolint:gosec
+	} else if err := db.DropContractDataIndex(context.Background(), logger, daemon.db); err != nil {
+		logger.WithError(err).Fatal("could not drop the contract data index")
+	}
 
 	return ingest.NewService(ingest.Config{
 		Logger: logger,
@@ -287,9 +448,16 @@ func createIngestService(cfg *config.Config, logger *supportlog.Entry, daemon *D
 			logger,
 			daemon.db,
 			daemon,
-			maxLedgerEntryWriteBatchSize,
+			int(cfg.IngestionBatchSize), //nolint:gosec
 			cfg.HistoryRetentionWindow,
 			cfg.NetworkPassphrase,
+			db.EventStorage{
+				MaxSize:  int(cfg.MaxEventSize), //nolint:gosec
+				Skip:     cfg.OversizedEventPolicy == config.OversizedEventPolicySkip,
+				Encoding: eventDataEncoding(cfg.EventStorageFormat),
+			},
+			cfg.IngestOperations,
+			cfg.IndexContractData,
 		),
 		NetworkPassPhrase: cfg.NetworkPassphrase,
 		Archive:           *historyArchive,
@@ -298,6 +466,15 @@ func createIngestService(cfg *config.Config, logger *supportlog.Entry, daemon *D
 		OnIngestionRetry:  onIngestionRetry,
 		Daemon:            daemon,
 		FeeWindows:        feewindows,
+		StartLedgerFloor:  cfg.IngestionStartLedgerFloor,
+		StartWindow:       startWindow,
+		AbortOnLedgerGap:  cfg.IngestionAbortOnLedgerGap,
+		// the data served is considered usable once it's healthy
+		SyncedLedgerLatency: cfg.MaxHealthyLedgerLatency,
+		DBSizeLimiter:       dbSizeLimiter,
+		ContractDataIndex:   contractDataIndex,
+		// the window follows the observed ledger close times
+		ResolveHistoryRetentionWindow: daemon.historyRetentionWindowResolver(cfg),
 	})
 }
 
@@ -310,6 +487,7 @@ func createPreflightWorkerPool(cfg *config.Config, logger *supportlog.Entry, dae
 			EnableDebug:       cfg.PreflightEnableDebug,
 			NetworkPassphrase: cfg.NetworkPassphrase,
 			Logger:            logger,
+			Timeout:           cfg.PreflightTimeout,
 		},
 	)
 }
@@ -321,16 +499,28 @@ func createJSONRPCHandler(cfg *config.Config, logger *supportlog.Entry, daemon *
 	if cfg.ServeLedgersFromDatastore {
 		dataStoreLedgerReader = rpcdatastore.NewLedgerReader(cfg.BufferedStorageBackendConfig, daemon.dataStore)
 	}
+	var contractDataKeyReader db.ContractDataKeyReader
+	if cfg.IndexContractData {
+		contractDataKeyReader = db.NewContractDataKeyReader(logger, daemon.db)
+	}
 
 	rpcHandler := internal.NewJSONRPCHandler(cfg, internal.HandlerParams{
-		Daemon:                daemon,
-		FeeStatWindows:        feewindows,
-		Logger:                logger,
-		LedgerReader:          db.NewLedgerReader(daemon.db),
-		TransactionReader:     db.NewTransactionReader(logger, daemon.db, cfg.NetworkPassphrase),
-		EventReader:           db.NewEventReader(logger, daemon.db, cfg.NetworkPassphrase),
-		PreflightGetter:       daemon.preflightWorkerPool,
-		DataStoreLedgerReader: dataStoreLedgerReader,
+		Daemon:                 daemon,
+		FeeStatWindows:         feewindows,
+		Logger:                 logger,
+		LedgerReader:           db.NewLedgerReader(daemon.db),
+		TransactionReader:      db.NewTransactionReader(logger, daemon.db, cfg.NetworkPassphrase),
+		EventReader:            db.NewEventReader(logger, daemon.db, cfg.NetworkPassphrase),
+		OperationReader:        db.NewOperationReader(logger, daemon.db),
+		ContractDataKeyReader:  contractDataKeyReader,
+		PreflightGetter:        daemon.preflightWorkerPool,
+		ResourceFeeGetter:      daemon.preflightWorkerPool,
+		DataStoreLedgerReader:  dataStoreLedgerReader,
+		CoreQueryBreaker:       daemon.coreQueryBreaker,
+		SyncStatus:             daemon.syncStatus,
+		CheckpointDeferral:     daemon.checkpointDeferral,
+		SimulationCoreClient:   daemon.simulationCoreClient,
+		HistoryRetentionWindow: daemon.historyRetentionWindow.Load,
 	})
 	return &rpcHandler
 }
@@ -349,6 +539,9 @@ func (d *Daemon) setupHTTPServers(cfg *config.Config) {
 	if cfg.AdminEndpoint != "" {
 		d.setupAdminServer(cfg)
 	}
+	if cfg.MetricsEndpoint != "" {
+		d.setupMetricsServer(cfg)
+	}
 }
 
 func createHTTPHandler(logger *supportlog.Entry, jsonRPCHandler *internal.Handler) http.Handler {
@@ -359,7 +552,8 @@ func createHTTPHandler(logger *supportlog.Entry, jsonRPCHandler *internal.Handle
 
 func (d *Daemon) setupAdminServer(cfg *config.Config) {
 	var err error
-	adminMux := createAdminMux(d.logger, d.metricsRegistry)
+	adminMux := createAdminMux(d.logger, d.metricsRegistry, d.jsonRPCHandler.Requests,
+		d.jsonRPCHandler.LedgerEntriesHotKeys, d.db, d.maintenance)
 	d.adminListener, err = net.Listen("tcp", cfg.AdminEndpoint)
 	if err != nil {
 		d.logger.WithError(err).WithField("endpoint", cfg.AdminEndpoint).Fatal("cannot listen on admin endpoint")
@@ -367,7 +561,25 @@ func (d *Daemon) setupAdminServer(cfg *config.Config) {
 	d.adminServer = &http.Server{Handler: adminMux} //nolint:gosec
 }
 
-func createAdminMux(logger *supportlog.Entry, metricsRegistry *prometheus.Registry) *chi.Mux {
+func (d *Daemon) setupMetricsServer(cfg *config.Config) {
+	var err error
+	metricsMux := supporthttp.NewMux(d.logger)
+	metricsMux.Handle("/metrics", promhttp.HandlerFor(d.metricsRegistry, promhttp.HandlerOpts{}))
+	d.metricsListener, err = net.Listen("tcp", cfg.MetricsEndpoint)
+	if err != nil {
+		d.logger.WithError(err).WithField("endpoint", cfg.MetricsEndpoint).Fatal("cannot listen on metrics endpoint")
+	}
+	d.metricsServer = &http.Server{Handler: metricsMux, ReadTimeout: defaultReadTimeout}
+}
+
+func createAdminMux(
+	logger *supportlog.Entry,
+	metricsRegistry *prometheus.Registry,
+	requests *network.RequestRegistry,
+	hotKeys *hotkeys.Tracker,
+	database *db.DB,
+	maintenance *maintenanceScheduler,
+) *chi.Mux {
 	adminMux := supporthttp.NewMux(logger)
 	adminMux.HandleFunc("/debug/pprof/", pprof.Index)
 	adminMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
@@ -378,9 +590,78 @@ func createAdminMux(logger *supportlog.Entry, metricsRegistry *prometheus.Regist
 		adminMux.Handle("/debug/pprof/"+profile.Name(), pprof.Handler(profile.Name()))
 	}
 	adminMux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	adminMux.Get("/requests", requests.ListHandler)
+	adminMux.Post("/requests/cancel", requests.CancelHandler)
+	adminMux.Get("/ingestion/status", ingest.StatusHandler(db.NewLedgerReader(database)))
+	adminMux.Get("/maintenance/schedule", maintenance.ScheduleHandler)
+	adminMux.Post("/db/snapshot", snapshotHandler(logger, database))
+	if hotKeys != nil {
+		adminMux.Get("/ledger-entries/hot-keys", hotKeys.Handler)
+	}
 	return adminMux
 }
 
+// resolveHistoryRetentionWindow converts the configured retention duration (if
+// any) into a ledger count, based on the average close time of the ledgers
+// already in the database. The window is then adjusted during ingestion (see
+// historyRetentionWindowResolver).
+func (d *Daemon) resolveHistoryRetentionWindow(cfg *config.Config) {
+	if cfg.HistoryRetentionDuration <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.IngestionTimeout)
+	defer cancel()
+
+	averageCloseTime := d.averageLedgerCloseTime(ctx)
+	if cfg.ResolveHistoryRetentionWindow(averageCloseTime) {
+		d.logger.WithFields(supportlog.F{
+			"duration":         cfg.HistoryRetentionDuration,
+			"avgLedgerClose":   averageCloseTime,
+			"retentionLedgers": cfg.HistoryRetentionWindow,
+		}).Info("derived history retention window from duration")
+	}
+}
+
+// historyRetentionWindowResolver returns the function converting the
+// configured retention duration into a ledger count again during ingestion,
+// as the ledgers accumulate in the database (e.g. when starting with an empty
+// one) and their close times change. It returns nil if no duration is
+// configured.
+func (d *Daemon) historyRetentionWindowResolver(cfg *config.Config) func(ctx context.Context) (uint32, bool) {
+	if cfg.HistoryRetentionDuration <= 0 {
+		return nil
+	}
+	return func(ctx context.Context) (uint32, bool) {
+		averageCloseTime := d.averageLedgerCloseTime(ctx)
+		window, ok := cfg.HistoryRetentionWindowFor(averageCloseTime)
+		if !ok || window == d.historyRetentionWindow.Load() {
+			return 0, false
+		}
+		d.historyRetentionWindow.Store(window)
+		d.logger.WithFields(supportlog.F{
+			"duration":         cfg.HistoryRetentionDuration,
+			"avgLedgerClose":   averageCloseTime,
+			"retentionLedgers": window,
+		}).Info("adjusted history retention window to the observed ledger close time")
+		return window, true
+	}
+}
+
+// averageLedgerCloseTime returns the average close time of the ledgers in the
+// database, or the nominal ledger close time if there are fewer than two.
+func (d *Daemon) averageLedgerCloseTime(ctx context.Context) time.Duration {
+	ledgerRange, err := db.NewLedgerReader(d.db).GetLedgerRange(ctx)
+	switch {
+	case errors.Is(err, db.ErrEmptyDB):
+	case err != nil:
+		d.logger.WithError(err).Warn("could not get ledger range, assuming nominal ledger close time")
+	case ledgerRange.LastLedger.Sequence > ledgerRange.FirstLedger.Sequence:
+		elapsed := time.Duration(ledgerRange.LastLedger.CloseTime-ledgerRange.FirstLedger.CloseTime) * time.Second
+		return elapsed / time.Duration(ledgerRange.LastLedger.Sequence-ledgerRange.FirstLedger.Sequence)
+	}
+	return config.NominalLedgerCloseTime
+}
+
 // mustInitializeStorage initializes the storage using what was on the DB
 func (d *Daemon) mustInitializeStorage(cfg *config.Config) *feewindow.FeeWindows {
 	readTxMetaCtx, cancelReadTxMeta := context.WithTimeout(context.Background(), cfg.IngestionTimeout)
@@ -514,6 +795,17 @@ func (d *Daemon) Run() {
 		})
 	}
 
+	if d.metricsServer != nil {
+		d.logger.
+			WithField("addr", d.metricsListener.Addr().String()).
+			Info("starting metrics HTTP server")
+		panicGroup.Go(func() {
+			if err := d.metricsServer.Serve(d.metricsListener); !errors.Is(err, http.ErrServerClosed) {
+				d.logger.WithError(err).Error("metrics server encountered fatal error")
+			}
+		})
+	}
+
 	// Shutdown gracefully when we receive an interrupt signal. First
 	// server.Shutdown closes all open listeners, then closes all idle
 	// connections. Finally, it waits a grace period (10s here) for connections
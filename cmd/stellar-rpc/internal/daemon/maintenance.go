@@ -0,0 +1,158 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	supportlog "github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/config"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/util"
+)
+
+// maintenanceCheckPeriod is how often the maintenance scheduler checks whether
+// a job is due.
+const maintenanceCheckPeriod = time.Minute
+
+// maintenanceJob is a heavy background job, run periodically.
+type maintenanceJob struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+	// since is when the job last ran (or when it was scheduled, if it never
+	// ran), from which the next run is computed
+	since   time.Time
+	lastRun time.Time
+}
+
+// maintenanceScheduler runs the heavy background jobs (which compete with
+// the query traffic) within the maintenance window, deferring them while
+// outside of it. Jobs which must run, like the database migrations, don't go
+// through it.
+type maintenanceScheduler struct {
+	window config.TimeWindow
+	logger *supportlog.Entry
+
+	lock sync.Mutex
+	jobs []*maintenanceJob
+
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+func newMaintenanceScheduler(window config.TimeWindow, logger *supportlog.Entry) *maintenanceScheduler {
+	return &maintenanceScheduler{window: window, logger: logger}
+}
+
+// addJob schedules run every interval (within the window), the first time
+// after an interval from now.
+func (s *maintenanceScheduler) addJob(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.jobs = append(s.jobs, &maintenanceJob{
+		name:     name,
+		interval: interval,
+		run:      run,
+		since:    time.Now(),
+	})
+}
+
+// nextRun returns when the job is scheduled to run next, given the current
+// time.
+func (s *maintenanceScheduler) nextRun(job *maintenanceJob, now time.Time) time.Time {
+	due := job.since.Add(job.interval)
+	if due.Before(now) {
+		due = now
+	}
+	return s.window.Next(due)
+}
+
+// runDueJobs runs (sequentially) the jobs scheduled at or before now.
+func (s *maintenanceScheduler) runDueJobs(ctx context.Context, now time.Time) {
+	s.lock.Lock()
+	var due []*maintenanceJob
+	for _, job := range s.jobs {
+		if !s.nextRun(job, now).After(now) {
+			job.since, job.lastRun = now, now
+			due = append(due, job)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, job := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := job.run(ctx); err != nil {
+			s.logger.WithError(err).WithField("job", job.name).Error("maintenance job failed")
+		}
+	}
+}
+
+// start runs the jobs in the background, until close is called.
+func (s *maintenanceScheduler) start() {
+	if len(s.jobs) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	s.wg.Add(1)
+	panicGroup := util.UnrecoverablePanicGroup.Log(s.logger)
+	panicGroup.Go(func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(maintenanceCheckPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.runDueJobs(ctx, now)
+			}
+		}
+	})
+}
+
+// close stops the scheduler, waiting for the running job (if any) to finish.
+func (s *maintenanceScheduler) close() {
+	if s != nil && s.stop != nil {
+		s.stop()
+		s.wg.Wait()
+	}
+}
+
+type maintenanceJobSchedule struct {
+	Name     string     `json:"name"`
+	Interval string     `json:"interval"`
+	LastRun  *time.Time `json:"lastRun,omitempty"`
+	NextRun  time.Time  `json:"nextRun"`
+}
+
+// schedule returns the schedule of the jobs, given the current time.
+func (s *maintenanceScheduler) schedule(now time.Time) []maintenanceJobSchedule {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make([]maintenanceJobSchedule, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		item := maintenanceJobSchedule{
+			Name:     job.name,
+			Interval: job.interval.String(),
+			NextRun:  s.nextRun(job, now),
+		}
+		if !job.lastRun.IsZero() {
+			lastRun := job.lastRun
+			item.LastRun = &lastRun
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// ScheduleHandler serves the next scheduled runs of the maintenance jobs.
+func (s *maintenanceScheduler) ScheduleHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.schedule(time.Now()))
+}
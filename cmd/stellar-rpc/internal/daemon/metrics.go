@@ -2,19 +2,20 @@ package daemon
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 
-	"github.com/stellar/go/clients/stellarcore"
 	"github.com/stellar/go/ingest/ledgerbackend"
 	proto "github.com/stellar/go/protocols/stellarcore"
 	supportlog "github.com/stellar/go/support/log"
 	"github.com/stellar/go/support/logmetrics"
 	"github.com/stellar/go/xdr"
 
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/circuitbreaker"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/config"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
 )
@@ -42,6 +43,15 @@ func (d *Daemon) registerMetrics() {
 	d.metricsRegistry.MustRegister(collectors.NewGoCollector())
 	d.metricsRegistry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 	d.metricsRegistry.MustRegister(buildInfoGauge)
+
+	if d.coreQueryBreaker != nil {
+		d.metricsRegistry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: interfaces.PrometheusNamespace, Subsystem: "core_query", Name: "circuit_breaker_state",
+			Help: "state of the circuit breaker around the captive-core query server: 0 closed, 1 half-open, 2 open",
+		}, func() float64 {
+			return float64(d.coreQueryBreaker.State())
+		}))
+	}
 }
 
 func (d *Daemon) MetricsRegistry() *prometheus.Registry {
@@ -53,12 +63,12 @@ func (d *Daemon) MetricsNamespace() string {
 }
 
 type CoreClientWithMetrics struct {
-	stellarcore.Client
+	interfaces.CoreClient
 	submitMetric  *prometheus.SummaryVec
 	opCountMetric *prometheus.SummaryVec
 }
 
-func newCoreClientWithMetrics(client stellarcore.Client, registry *prometheus.Registry) *CoreClientWithMetrics {
+func newCoreClientWithMetrics(client interfaces.CoreClient, registry *prometheus.Registry) *CoreClientWithMetrics {
 	submitMetric := prometheus.NewSummaryVec(prometheus.SummaryOpts{
 		Namespace: interfaces.PrometheusNamespace, Subsystem: "txsub", Name: "submission_duration_seconds",
 		Help:       "submission durations to Stellar-Core, sliding window = 10m",
@@ -72,7 +82,7 @@ func newCoreClientWithMetrics(client stellarcore.Client, registry *prometheus.Re
 	registry.MustRegister(submitMetric, opCountMetric)
 
 	return &CoreClientWithMetrics{
-		Client:        client,
+		CoreClient:    client,
 		submitMetric:  submitMetric,
 		opCountMetric: opCountMetric,
 	}
@@ -88,7 +98,7 @@ func (c *CoreClientWithMetrics) SubmitTransaction(ctx context.Context,
 	}
 
 	startTime := time.Now()
-	response, err := c.Client.SubmitTransaction(ctx, envelopeBase64)
+	response, err := c.CoreClient.SubmitTransaction(ctx, envelopeBase64)
 	duration := time.Since(startTime).Seconds()
 
 	var status string
@@ -107,6 +117,90 @@ func (c *CoreClientWithMetrics) SubmitTransaction(ctx context.Context,
 	return response, err
 }
 
+// fastCoreClientWithBreaker fails fast while the captive-core query server is
+// deemed unavailable by the circuit breaker.
+type fastCoreClientWithBreaker struct {
+	interfaces.FastCoreClient
+	breaker *circuitbreaker.Breaker
+}
+
+func newFastCoreClientWithBreaker(client interfaces.FastCoreClient,
+	breaker *circuitbreaker.Breaker,
+) *fastCoreClientWithBreaker {
+	return &fastCoreClientWithBreaker{
+		FastCoreClient: client,
+		breaker:        breaker,
+	}
+}
+
+func (c *fastCoreClientWithBreaker) GetLedgerEntries(ctx context.Context,
+	ledgerSeq uint32, keys ...xdr.LedgerKey,
+) (proto.GetLedgerEntryResponse, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return proto.GetLedgerEntryResponse{}, fmt.Errorf("core unavailable: %w", err)
+	}
+	response, err := c.FastCoreClient.GetLedgerEntries(ctx, ledgerSeq, keys...)
+	if ctx.Err() != nil {
+		// requests aborted by the caller don't say anything about core's health
+		c.breaker.Release()
+		return response, err
+	}
+	c.breaker.Done(err == nil)
+	return response, err
+}
+
+// coreClientWithFallback queries the ledger entries through the captive-core
+// query server and, when it is unavailable, through the fallback client (the
+// stellar-core pool), so that simulateTransaction degrades instead of failing.
+type coreClientWithFallback struct {
+	fast     interfaces.FastCoreClient
+	fallback interfaces.FastCoreClient
+	logger   *supportlog.Entry
+	metric   *prometheus.CounterVec
+}
+
+func newCoreClientWithFallback(fast interfaces.FastCoreClient, fallback interfaces.FastCoreClient,
+	logger *supportlog.Entry, registry *prometheus.Registry,
+) *coreClientWithFallback {
+	metric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: interfaces.PrometheusNamespace, Subsystem: "simulate_transaction",
+		Name: "ledger_entry_queries_total",
+		Help: "ledger entry queries of the simulations, by client (fast for the captive-core query server," +
+			" fallback for the stellar-core instances) and status",
+	}, []string{"client", "status"})
+	registry.MustRegister(metric)
+	return &coreClientWithFallback{
+		fast:     fast,
+		fallback: fallback,
+		logger:   logger,
+		metric:   metric,
+	}
+}
+
+func (c *coreClientWithFallback) GetLedgerEntries(ctx context.Context,
+	ledgerSeq uint32, keys ...xdr.LedgerKey,
+) (proto.GetLedgerEntryResponse, error) {
+	response, err := c.fast.GetLedgerEntries(ctx, ledgerSeq, keys...)
+	c.observe("fast", err)
+	// requests aborted by the caller don't say anything about core's health
+	if err == nil || ctx.Err() != nil {
+		return response, err
+	}
+	c.logger.WithError(err).WithField("ledger", ledgerSeq).
+		Warn("captive-core query server unavailable, falling back to stellar-core for the simulation")
+	response, err = c.fallback.GetLedgerEntries(ctx, ledgerSeq, keys...)
+	c.observe("fallback", err)
+	return response, err
+}
+
+func (c *coreClientWithFallback) observe(client string, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	c.metric.With(prometheus.Labels{"client": client, "status": status}).Inc()
+}
+
 func (d *Daemon) CoreClient() interfaces.CoreClient {
 	return d.coreClient
 }
@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	supportlog "github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+)
+
+type snapshotRequest struct {
+	// Path is the (new) file to write the snapshot to, on the host of the
+	// daemon.
+	Path string `json:"path"`
+}
+
+// snapshotHandler writes a consistent snapshot of the database to the
+// requested path, without stopping the ingestion, for live backups.
+func snapshotHandler(logger *supportlog.Entry, database *db.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request snapshotRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Path == "" {
+			http.Error(w, "expected a JSON body with the path of the snapshot", http.StatusBadRequest)
+			return
+		}
+		startTime := time.Now()
+		info, err := database.Snapshot(r.Context(), request.Path)
+		if err != nil {
+			logger.WithError(err).WithField("path", request.Path).Error("could not snapshot the database")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.
+			WithField("path", info.Path).
+			WithField("sizeBytes", info.SizeBytes).
+			WithField("latestLedger", info.LatestLedger).
+			WithField("duration", time.Since(startTime)).
+			Info("Finished snapshotting the database")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	}
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/config"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+// createMaintenanceScheduler creates the scheduler of the heavy background
+// jobs (currently, vacuuming the database), which is started separately.
+func (d *Daemon) createMaintenanceScheduler(cfg *config.Config) *maintenanceScheduler {
+	window, err := cfg.MaintenanceTimeWindow()
+	if err != nil {
+		d.logger.WithError(err).Fatal("invalid maintenance window")
+	}
+	scheduler := newMaintenanceScheduler(window, d.logger)
+	if cfg.SQLiteVacuumInterval != 0 {
+		scheduler.addJob("vacuum", cfg.SQLiteVacuumInterval, d.vacuumJob())
+	}
+	return scheduler
+}
+
+// vacuumJob returns a maintenance job vacuuming the database.
+func (d *Daemon) vacuumJob() func(ctx context.Context) error {
+	lastRunMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: interfaces.PrometheusNamespace, Subsystem: "db", Name: "vacuum_last_run_timestamp_seconds",
+		Help: "unix timestamp of the latest successful database vacuum",
+	})
+	reclaimedMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: interfaces.PrometheusNamespace, Subsystem: "db", Name: "vacuum_last_reclaimed_bytes",
+		Help: "bytes reclaimed by the latest successful database vacuum",
+	})
+	d.metricsRegistry.MustRegister(lastRunMetric, reclaimedMetric)
+
+	return func(ctx context.Context) error {
+		startTime := time.Now()
+		d.logger.Info("Vacuuming the database, ingestion is paused until it completes")
+		reclaimed, err := d.db.Vacuum(ctx)
+		if err != nil {
+			return err
+		}
+		d.logger.
+			WithField("duration", time.Since(startTime)).
+			WithField("reclaimedBytes", reclaimed).
+			Info("Finished vacuuming the database")
+		lastRunMetric.Set(float64(time.Now().Unix()))
+		reclaimedMetric.Set(float64(reclaimed))
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/config"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ingest"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/util"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+const (
+	// warmupPollPeriod is how often the warmup checks whether the initial
+	// sync completed.
+	warmupPollPeriod = time.Second
+	// warmupTimeout bounds the duration of the warmup queries.
+	warmupTimeout = time.Minute
+)
+
+// syncStatus reports the server as initializing until the initial sync and
+// the startup warmup (when enabled) complete, so that it isn't reported healthy
+// (and sent traffic) while its caches are cold.
+type syncStatus struct {
+	ingestService *ingest.Service
+	warmedUp      atomic.Bool
+}
+
+func (s *syncStatus) Initializing() bool {
+	return s.ingestService.Initializing() || !s.warmedUp.Load()
+}
+
+// startWarmup runs representative queries once the initial sync completes, to
+// warm the database, the in-memory caches and the connection to the captive
+// core query server up.
+func (d *Daemon) startWarmup(cfg *config.Config) {
+	if !cfg.WarmupOnStartup {
+		d.syncStatus.warmedUp.Store(true)
+		return
+	}
+	keys, err := cfg.WarmupLedgerKeysXDR()
+	if err != nil {
+		d.logger.WithError(err).Fatal("invalid warmup ledger keys")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.stopWarmup = cancel
+	d.warmupWG.Add(1)
+	panicGroup := util.UnrecoverablePanicGroup.Log(d.logger)
+	panicGroup.Go(func() {
+		defer d.warmupWG.Done()
+		ticker := time.NewTicker(warmupPollPeriod)
+		defer ticker.Stop()
+		for d.ingestService.Initializing() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+
+		startTime := time.Now()
+		if err := d.warmup(ctx, cfg.NetworkPassphrase, keys); err != nil {
+			// a failed warmup only makes the first requests slower
+			d.logger.WithError(err).Warn("could not complete the startup warmup")
+		} else {
+			d.logger.WithField("duration", time.Since(startTime)).Info("Finished the startup warmup")
+		}
+		d.syncStatus.warmedUp.Store(true)
+	})
+}
+
+func (d *Daemon) warmup(ctx context.Context, networkPassphrase string, keys []xdr.LedgerKey) error {
+	ctx, cancel := context.WithTimeout(ctx, warmupTimeout)
+	defer cancel()
+
+	ledgerReader := db.NewLedgerReader(d.db)
+	ledgerRange, err := ledgerReader.GetLedgerRange(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get the ledger range: %w", err)
+	}
+	latestLedger := ledgerRange.LastLedger.Sequence
+	if _, _, err := ledgerReader.GetLedger(ctx, latestLedger); err != nil {
+		return fmt.Errorf("could not get ledger %d: %w", latestLedger, err)
+	}
+
+	eventReader := db.NewEventReader(d.logger, d.db, networkPassphrase)
+	cursorRange := protocol.CursorRange{
+		Start: protocol.Cursor{Ledger: latestLedger},
+		End:   protocol.Cursor{Ledger: latestLedger + 1},
+	}
+	err = eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, nil,
+		func(xdr.DiagnosticEvent, protocol.Cursor, int64, *xdr.Hash) bool { return true })
+	if err != nil {
+		return fmt.Errorf("could not get the events of ledger %d: %w", latestLedger, err)
+	}
+
+	if len(keys) > 0 {
+		if _, err := d.coreQueryingClient.GetLedgerEntries(ctx, latestLedger, keys...); err != nil {
+			return fmt.Errorf("could not get the ledger entries from captive core: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+// CheckpointDeferral defers the WAL checkpoints run after every ingestion
+// commit when the queries are slow, so that the queries are prioritized over
+// ingestion.
+//
+// The checkpoints copy the committed pages into the database file, competing
+// with the queries for I/O. While they are deferred, the WAL grows instead.
+// Ingestion catches up once the queries are fast again, or once too many
+// checkpoints were deferred or the WAL grew too large, since a single
+// checkpoint then copies all the pending pages. The commits themselves keep
+// their pace.
+type CheckpointDeferral struct {
+	latencyThreshold       time.Duration
+	maxDeferredCheckpoints uint
+	maxWALSize             int64
+
+	lock sync.Mutex
+	// latencySum and latencyCount accumulate the latencies of the queries
+	// served since the previous commit
+	latencySum          time.Duration
+	latencyCount        int
+	deferredCheckpoints uint
+
+	activeMetric              prometheus.Gauge
+	deferredCheckpointsMetric prometheus.Gauge
+}
+
+// NewCheckpointDeferral creates a CheckpointDeferral applied to the write
+// transactions of db, which defers their checkpoints when the average latency
+// of the queries served since the previous commit exceeds latencyThreshold.
+// At most maxDeferredCheckpoints consecutive checkpoints are deferred, and
+// none while the WAL exceeds maxWALSize bytes (0 disables the size bound).
+func NewCheckpointDeferral(db *DB, daemon interfaces.Daemon, latencyThreshold time.Duration,
+	maxDeferredCheckpoints uint, maxWALSize int64,
+) *CheckpointDeferral {
+	activeMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: daemon.MetricsNamespace(), Subsystem: "ingest", Name: "checkpoint_deferral_active",
+		Help: "whether the WAL checkpoints are deferred because of slow queries (1) or not (0)",
+	})
+	deferredCheckpointsMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: daemon.MetricsNamespace(), Subsystem: "ingest", Name: "deferred_checkpoints",
+		Help: "number of consecutive WAL checkpoints deferred because of slow queries",
+	})
+	daemon.MetricsRegistry().MustRegister(activeMetric, deferredCheckpointsMetric)
+
+	deferral := &CheckpointDeferral{
+		latencyThreshold:          latencyThreshold,
+		maxDeferredCheckpoints:    maxDeferredCheckpoints,
+		maxWALSize:                maxWALSize,
+		activeMetric:              activeMetric,
+		deferredCheckpointsMetric: deferredCheckpointsMetric,
+	}
+	db.checkpointDeferral = deferral
+	return deferral
+}
+
+// ObserveQuery records the latency of a query. It is a no-op on a nil
+// CheckpointDeferral.
+func (c *CheckpointDeferral) ObserveQuery(latency time.Duration) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.latencySum += latency
+	c.latencyCount++
+}
+
+// deferCheckpoint is called after every commit, with the current size of the
+// WAL, and tells whether its checkpoint should be deferred.
+func (c *CheckpointDeferral) deferCheckpoint(walSize int64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	slow := c.latencyCount > 0 && c.latencySum/time.Duration(c.latencyCount) > c.latencyThreshold
+	c.latencySum, c.latencyCount = 0, 0
+
+	deferred := slow && c.deferredCheckpoints < c.maxDeferredCheckpoints &&
+		(c.maxWALSize == 0 || walSize < c.maxWALSize)
+	if deferred {
+		c.deferredCheckpoints++
+	} else {
+		c.deferredCheckpoints = 0
+	}
+	if slow {
+		c.activeMetric.Set(1)
+	} else {
+		c.activeMetric.Set(0)
+	}
+	c.deferredCheckpointsMetric.Set(float64(c.deferredCheckpoints))
+	return deferred
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+func TestCheckpointDeferral(t *testing.T) {
+	db := NewTestDB(t)
+	deferral := NewCheckpointDeferral(db, interfaces.MakeNoOpDeamon(), 100*time.Millisecond, 2, 1000)
+
+	// not deferred without queries
+	assert.False(t, deferral.deferCheckpoint(0))
+
+	// not deferred with fast queries
+	deferral.ObserveQuery(50 * time.Millisecond)
+	deferral.ObserveQuery(120 * time.Millisecond)
+	assert.False(t, deferral.deferCheckpoint(0))
+
+	// deferred with slow queries, up to the maximum deferred checkpoints
+	for range 3 {
+		deferral.ObserveQuery(150 * time.Millisecond)
+	}
+	assert.True(t, deferral.deferCheckpoint(0))
+	deferral.ObserveQuery(time.Second)
+	assert.True(t, deferral.deferCheckpoint(0))
+	deferral.ObserveQuery(time.Second)
+	assert.False(t, deferral.deferCheckpoint(0))
+	deferral.ObserveQuery(time.Second)
+	assert.True(t, deferral.deferCheckpoint(0))
+
+	// the latencies are reset after every commit
+	assert.False(t, deferral.deferCheckpoint(0))
+
+	// and nothing is deferred once the WAL reaches its maximum size
+	deferral.ObserveQuery(time.Second)
+	assert.True(t, deferral.deferCheckpoint(999))
+	deferral.ObserveQuery(time.Second)
+	assert.False(t, deferral.deferCheckpoint(1000))
+
+	// a nil deferral ignores the queries
+	var disabled *CheckpointDeferral
+	disabled.ObserveQuery(time.Second)
+}
+
+func TestCheckpointDeferralDefersCheckpoints(t *testing.T) {
+	dbPath := path.Join(t.TempDir(), "db.sqlite")
+	db, err := OpenSQLiteDB(dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.TODO()
+	deferral := NewCheckpointDeferral(db, interfaces.MakeNoOpDeamon(), time.Millisecond, 10, 0)
+	writer := NewReadWriter(log.DefaultLogger, db, interfaces.MakeNoOpDeamon(), 10, 100, passphrase,
+		EventStorage{}, false, false)
+
+	walPath := dbPath + "-wal"
+	commit := func(seq uint32) {
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+		lcm := txMeta(seq, true)
+		require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+		require.NoError(t, write.Commit(lcm))
+	}
+	walSize := func() int64 {
+		info, err := os.Stat(walPath)
+		require.NoError(t, err)
+		return info.Size()
+	}
+
+	commit(1)
+	assert.Zero(t, walSize())
+
+	// the WAL isn't truncated while the queries are slow
+	deferral.ObserveQuery(time.Second)
+	commit(2)
+	assert.Positive(t, walSize())
+
+	// or once it exceeds its maximum size
+	deferral.maxWALSize = walSize() + 1
+	deferral.ObserveQuery(time.Second)
+	commit(3)
+	assert.Zero(t, walSize())
+
+	// or once the queries are fast again
+	deferral.ObserveQuery(time.Second)
+	commit(4)
+	assert.Positive(t, walSize())
+	commit(5)
+	assert.Zero(t, walSize())
+}
@@ -0,0 +1,286 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	contractDataKeyTableName = "contract_data_keys"
+	// contractDataIndexCompleteMetaKey tells whether the index of the contract
+	// data keys holds the keys of all the entries (i.e. it was bootstrapped from
+	// a checkpoint) or only the ones modified since the indexing started. It's
+	// unset while the indexing is disabled.
+	contractDataIndexCompleteMetaKey = "ContractDataIndexComplete"
+)
+
+// contractDataKeyRow is the row of a contract data entry in the index.
+type contractDataKeyRow struct {
+	contractID []byte
+	ledgerKey  []byte
+	durability xdr.ContractDataDurability
+}
+
+// newContractDataKeyRow returns the index row of a ledger key, if it's the key
+// of a contract data entry.
+func newContractDataKeyRow(key xdr.LedgerKey) (contractDataKeyRow, bool, error) {
+	data, ok := key.GetContractData()
+	if !ok || data.Contract.ContractId == nil {
+		return contractDataKeyRow{}, false, nil
+	}
+	encodedKey, err := key.MarshalBinary()
+	if err != nil {
+		return contractDataKeyRow{}, false, err
+	}
+	contractID := *data.Contract.ContractId
+	return contractDataKeyRow{
+		contractID: contractID[:],
+		ledgerKey:  encodedKey,
+		durability: data.Durability,
+	}, true, nil
+}
+
+// contractDataIndexer maintains the index of the contract data keys within a
+// write transaction.
+type contractDataIndexer struct {
+	stmtCache    *sq.StmtCache
+	passphrase   string
+	maxBatchSize int
+}
+
+// indexChanges applies the contract data changes to the index: the keys of
+// the created (or restored) entries are added and the ones of the removed
+// entries are deleted.
+func (i contractDataIndexer) indexChanges(reader ingest.ChangeReader) error {
+	// Rows may already exist if the ledger is ingested several times
+	batch := newInsertBatch(i.stmtCache, sq.Insert(contractDataKeyTableName).
+		Options("OR IGNORE").
+		Columns("contract_id", "ledger_key", "durability"),
+		i.maxBatchSize)
+	for {
+		change, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if change.Type != xdr.LedgerEntryTypeContractData {
+			continue
+		}
+		key, err := change.LedgerKey()
+		if err != nil {
+			return err
+		}
+		row, ok, err := newContractDataKeyRow(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if change.Post != nil {
+			if err := batch.add(row.contractID, row.ledgerKey, int32(row.durability)); err != nil {
+				return err
+			}
+			continue
+		}
+		// the pending keys may include the removed one
+		if err := batch.flush(); err != nil {
+			return err
+		}
+		if err := i.delete(row); err != nil {
+			return err
+		}
+	}
+	return batch.flush()
+}
+
+func (i contractDataIndexer) delete(row contractDataKeyRow) error {
+	_, err := sq.StatementBuilder.
+		RunWith(i.stmtCache).
+		Delete(contractDataKeyTableName).
+		Where(sq.Eq{"contract_id": row.contractID, "ledger_key": row.ledgerKey}).
+		Exec()
+	return err
+}
+
+// indexLedger applies the contract data changes of a ledger to the index,
+// including the evictions of the expired entries.
+func (i contractDataIndexer) indexLedger(lcm xdr.LedgerCloseMeta) error {
+	reader, err := ingest.NewLedgerChangeReaderFromLedgerCloseMeta(i.passphrase, lcm)
+	if err != nil {
+		return fmt.Errorf("failed to open change reader for ledger %d: %w", lcm.LedgerSequence(), err)
+	}
+	defer reader.Close()
+	if err := i.indexChanges(reader); err != nil {
+		return fmt.Errorf("could not index the contract data of ledger %d: %w", lcm.LedgerSequence(), err)
+	}
+
+	evictedKeys, err := lcm.EvictedLedgerKeys()
+	if err != nil {
+		return err
+	}
+	for _, key := range evictedKeys {
+		row, ok, err := newContractDataKeyRow(key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := i.delete(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContractDataIndex manages the state of the index of the contract data keys,
+// which is maintained while ingesting the ledgers (see NewReadWriter).
+type ContractDataIndex struct {
+	log          *log.Entry
+	db           *DB
+	passphrase   string
+	maxBatchSize int
+}
+
+// NewContractDataIndex creates a ContractDataIndex, bootstrapping the index with
+// up to maxBatchSize rows per insert statement.
+func NewContractDataIndex(log *log.Entry, db *DB, networkPassphrase string, maxBatchSize int) *ContractDataIndex {
+	return &ContractDataIndex{log: log, db: db, passphrase: networkPassphrase, maxBatchSize: maxBatchSize}
+}
+
+// Complete tells whether the index holds the keys of all the contract data
+// entries, or only the ones modified since the indexing started. It returns
+// ErrEmptyDB until the index is initialized (see Bootstrap and MarkPartial).
+func (c *ContractDataIndex) Complete(ctx context.Context) (bool, error) {
+	return getMetaBool(ctx, c.db, contractDataIndexCompleteMetaKey)
+}
+
+// Bootstrap fills the index with the contract data entries read from the
+// ledger state (e.g. a history archive checkpoint), which makes it complete.
+func (c *ContractDataIndex) Bootstrap(ctx context.Context, reader ingest.ChangeReader) error {
+	return c.update(ctx, func(tx writeTx) error {
+		if _, err := tx.tx.Exec(ctx, sq.Delete(contractDataKeyTableName)); err != nil {
+			return err
+		}
+		indexer := contractDataIndexer{stmtCache: tx.stmtCache, passphrase: c.passphrase, maxBatchSize: c.maxBatchSize}
+		if err := indexer.indexChanges(reader); err != nil {
+			return fmt.Errorf("could not bootstrap the contract data index: %w", err)
+		}
+		return setMetaBool(ctx, tx.tx, contractDataIndexCompleteMetaKey, true)
+	})
+}
+
+// MarkPartial initializes the index without bootstrapping it, so it only holds
+// the keys of the contract data entries modified from then on.
+func (c *ContractDataIndex) MarkPartial(ctx context.Context) error {
+	return c.update(ctx, func(tx writeTx) error {
+		return setMetaBool(ctx, tx.tx, contractDataIndexCompleteMetaKey, false)
+	})
+}
+
+func (c *ContractDataIndex) update(ctx context.Context, f func(tx writeTx) error) error {
+	rw := readWriter{log: c.log, db: c.db}
+	tx, err := rw.newWriteTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			c.log.WithError(err).Warn("could not rollback the contract data index transaction")
+		}
+	}()
+	if err := f(tx); err != nil {
+		return err
+	}
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	return tx.postCommit()
+}
+
+// DropContractDataIndex empties the index of the contract data keys, if it
+// was initialized. Since the index isn't maintained while the indexing is
+// disabled, it would become stale.
+func DropContractDataIndex(ctx context.Context, log *log.Entry, db *DB) error {
+	index := NewContractDataIndex(log, db, "", 0)
+	if _, err := index.Complete(ctx); errors.Is(err, ErrEmptyDB) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return index.update(ctx, func(tx writeTx) error {
+		if _, err := tx.tx.Exec(ctx, sq.Delete(contractDataKeyTableName)); err != nil {
+			return err
+		}
+		_, err := tx.tx.Exec(ctx, sq.Delete(metaTableName).Where(sq.Eq{"key": contractDataIndexCompleteMetaKey}))
+		return err
+	})
+}
+
+// ContractDataKeyReader reads the index of the contract data keys.
+type ContractDataKeyReader interface {
+	// GetContractDataKeys returns, in the order of their XDR encoding, up to
+	// limit keys of the contract data entries of a contract which have any of
+	// the durabilities (or any durability, when empty). The keys start after
+	// the XDR-encoded key after, if any.
+	GetContractDataKeys(ctx context.Context, contractID xdr.ContractId, durabilities []xdr.ContractDataDurability,
+		after []byte, limit uint) ([]xdr.LedgerKey, error)
+}
+
+type contractDataKeyReader struct {
+	log *log.Entry
+	db  db.SessionInterface
+}
+
+func NewContractDataKeyReader(log *log.Entry, db db.SessionInterface) ContractDataKeyReader {
+	return &contractDataKeyReader{log: log, db: db}
+}
+
+func (r *contractDataKeyReader) GetContractDataKeys(ctx context.Context, contractID xdr.ContractId,
+	durabilities []xdr.ContractDataDurability, after []byte, limit uint,
+) ([]xdr.LedgerKey, error) {
+	rowQ := sq.
+		Select("ledger_key").
+		From(contractDataKeyTableName).
+		Where(sq.Eq{"contract_id": contractID[:]}).
+		OrderBy("ledger_key").
+		Limit(uint64(limit))
+	if after != nil {
+		rowQ = rowQ.Where(sq.Gt{"ledger_key": after})
+	}
+	if len(durabilities) > 0 {
+		values := make([]int32, 0, len(durabilities))
+		for _, durability := range durabilities {
+			values = append(values, int32(durability))
+		}
+		rowQ = rowQ.Where(sq.Eq{"durability": values})
+	}
+
+	var encodedKeys [][]byte
+	err := retryRead(ctx, r.log, "contract data keys", func() error {
+		encodedKeys = nil
+		return r.db.Select(ctx, &encodedKeys, rowQ)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db read failed for contract data keys: %w", err)
+	}
+	keys := make([]xdr.LedgerKey, len(encodedKeys))
+	for i, encodedKey := range encodedKeys {
+		if err := keys[i].UnmarshalBinary(encodedKey); err != nil {
+			return nil, fmt.Errorf("could not decode contract data key: %w", err)
+		}
+	}
+	return keys, nil
+}
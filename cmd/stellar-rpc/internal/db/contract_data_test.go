@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+type changeSliceReader struct {
+	changes []ingest.Change
+}
+
+func (r *changeSliceReader) Read() (ingest.Change, error) {
+	if len(r.changes) == 0 {
+		return ingest.Change{}, io.EOF
+	}
+	change := r.changes[0]
+	r.changes = r.changes[1:]
+	return change, nil
+}
+
+func (r *changeSliceReader) Close() error {
+	return nil
+}
+
+func contractDataEntry(contractID xdr.ContractId, key string, durability xdr.ContractDataDurability,
+) *xdr.LedgerEntry {
+	sym := xdr.ScSymbol(key)
+	return &xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeContractData,
+			ContractData: &xdr.ContractDataEntry{
+				Contract: xdr.ScAddress{
+					Type:       xdr.ScAddressTypeScAddressTypeContract,
+					ContractId: &contractID,
+				},
+				Key:        xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym},
+				Durability: durability,
+				Val:        xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+			},
+		},
+	}
+}
+
+func contractDataKeySymbols(keys []xdr.LedgerKey) []string {
+	symbols := make([]string, 0, len(keys))
+	for _, key := range keys {
+		symbols = append(symbols, string(key.MustContractData().Key.MustSym()))
+	}
+	return symbols
+}
+
+func TestContractDataIndex(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	logger := log.DefaultLogger
+	contractA, contractB := xdr.ContractId{1}, xdr.ContractId{2}
+	persistent, temporary := xdr.ContractDataDurabilityPersistent, xdr.ContractDataDurabilityTemporary
+
+	index := NewContractDataIndex(logger, db, passphrase, 2)
+	_, err := index.Complete(ctx)
+	require.ErrorIs(t, err, ErrEmptyDB)
+
+	require.NoError(t, index.Bootstrap(ctx, &changeSliceReader{changes: []ingest.Change{
+		{Type: xdr.LedgerEntryTypeContractData, Post: contractDataEntry(contractA, "b", persistent)},
+		{Type: xdr.LedgerEntryTypeContractData, Post: contractDataEntry(contractA, "a", temporary)},
+		{Type: xdr.LedgerEntryTypeContractData, Post: contractDataEntry(contractA, "c", persistent)},
+		{Type: xdr.LedgerEntryTypeContractData, Post: contractDataEntry(contractB, "d", persistent)},
+	}}))
+	complete, err := index.Complete(ctx)
+	require.NoError(t, err)
+	assert.True(t, complete)
+
+	reader := NewContractDataKeyReader(logger, db)
+	keys, err := reader.GetContractDataKeys(ctx, contractA, nil, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	keys, err = reader.GetContractDataKeys(ctx, contractA, []xdr.ContractDataDurability{persistent}, nil, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"b", "c"}, contractDataKeySymbols(keys))
+
+	// pages follow the encoded keys
+	firstPage, err := reader.GetContractDataKeys(ctx, contractA, nil, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, firstPage, 2)
+	after, err := firstPage[1].MarshalBinary()
+	require.NoError(t, err)
+	secondPage, err := reader.GetContractDataKeys(ctx, contractA, nil, after, 2)
+	require.NoError(t, err)
+	require.Len(t, secondPage, 1)
+	assert.NotContains(t, contractDataKeySymbols(firstPage), contractDataKeySymbols(secondPage)[0])
+
+	// the removed entries are dropped from the index while ingesting
+	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 100, passphrase, EventStorage{}, false, true)
+	tx, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	indexer := tx.(writeTx).contractDataIndexer
+	require.NotNil(t, indexer)
+	require.NoError(t, indexer.indexChanges(&changeSliceReader{changes: []ingest.Change{
+		{Type: xdr.LedgerEntryTypeContractData, Pre: contractDataEntry(contractA, "b", persistent)},
+		{Type: xdr.LedgerEntryTypeContractData, Post: contractDataEntry(contractA, "e", persistent)},
+		{Type: xdr.LedgerEntryTypeContractData, Pre: contractDataEntry(contractA, "e", persistent)},
+	}}))
+	lcm := txMeta(1, true)
+	require.NoError(t, tx.LedgerWriter().InsertLedger(lcm))
+	require.NoError(t, tx.Commit(lcm))
+	keys, err = reader.GetContractDataKeys(ctx, contractA, []xdr.ContractDataDurability{persistent}, nil, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, contractDataKeySymbols(keys))
+
+	require.NoError(t, DropContractDataIndex(ctx, logger, db))
+	_, err = index.Complete(ctx)
+	require.ErrorIs(t, err, ErrEmptyDB)
+	keys, err = reader.GetContractDataKeys(ctx, contractB, nil, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
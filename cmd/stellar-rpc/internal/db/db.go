@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	sq "github.com/Masterminds/squirrel"
 	_ "github.com/mattn/go-sqlite3"
@@ -27,13 +28,23 @@ var sqlMigrations embed.FS
 
 var ErrEmptyDB = errors.New("DB is empty")
 
+// ErrNewerSchema is returned when opening a database whose schema was migrated
+// by a newer version of stellar-rpc, i.e. after a downgrade.
+var ErrNewerSchema = errors.New("the DB schema is newer than the one supported")
+
 const (
 	metaTableName = "metadata"
+	// schemaVersionMetaKey holds the schema version the DB was written by
+	schemaVersionMetaKey = "SchemaVersion"
 )
 
 type ReadWriter interface {
 	NewTx(ctx context.Context) (WriteTx, error)
 	GetLatestLedgerSequence(ctx context.Context) (uint32, error)
+	DeleteLedgersBefore(ctx context.Context, ledgerSeq uint32) error
+	// SetHistoryRetentionWindow changes the retention window applied by the
+	// transactions created afterwards.
+	SetHistoryRetentionWindow(window uint32)
 }
 
 type WriteTx interface {
@@ -48,59 +59,90 @@ type WriteTx interface {
 type dbCache struct {
 	latestLedgerSeq       uint32
 	latestLedgerCloseTime int64
-	ledgerEntries         transactionalCache // Just like the DB: compress-encoded ledger key -> ledger entry XDR
 	sync.RWMutex
 }
 
 type DB struct {
 	db.SessionInterface
 	cache *dbCache
+	// writeLock is held by write transactions and vacuums, which can't run
+	// concurrently
+	writeLock *sync.Mutex
+	// checkpointDeferral is nil when the checkpoints aren't deferred under slow
+	// queries
+	checkpointDeferral *CheckpointDeferral
+}
+
+// SQLiteOptions configures the durability/performance tradeoff of the SQLite
+// database. Empty values fall back to the defaults (WAL journal mode and NORMAL
+// synchronous mode).
+type SQLiteOptions struct {
+	JournalMode string
+	Synchronous string
+	// AllowNewerSchema opens the DB even when its schema is newer than the one
+	// supported (see ErrNewerSchema), at the risk of misbehaving.
+	AllowNewerSchema bool
 }
 
-func openSQLiteDB(dbFilePath string) (*db.Session, error) {
+const (
+	defaultSQLiteJournalMode = "WAL"
+	defaultSQLiteSynchronous = "NORMAL"
+)
+
+func (o SQLiteOptions) dsn(dbFilePath string) string {
+	journalMode, synchronous := o.JournalMode, o.Synchronous
+	if journalMode == "" {
+		journalMode = defaultSQLiteJournalMode
+	}
+	if synchronous == "" {
+		synchronous = defaultSQLiteSynchronous
+	}
+	// Disable WAL auto-checkpointing (we will do the checkpointing ourselves with wal_checkpoint pragmas
+	// after every write transaction). This is a no-op outside of WAL mode.
+	return fmt.Sprintf("file:%s?_journal_mode=%s&_wal_autocheckpoint=0&_synchronous=%s",
+		dbFilePath, journalMode, synchronous)
+}
+
+func openSQLiteDB(dbFilePath string, options SQLiteOptions) (*db.Session, error) {
+	// By default:
 	// 1. Use Write-Ahead Logging (WAL).
-	// 2. Disable WAL auto-checkpointing (we will do the checkpointing ourselves with wal_checkpoint pragmas
-	//    after every write transaction).
-	// 3. Use synchronous=NORMAL, which is faster and still safe in WAL mode.
-	session, err := db.Open("sqlite3",
-		fmt.Sprintf("file:%s?_journal_mode=WAL&_wal_autocheckpoint=0&_synchronous=NORMAL", dbFilePath))
+	// 2. Use synchronous=NORMAL, which is faster and still safe in WAL mode.
+	session, err := db.Open("sqlite3", options.dsn(dbFilePath))
 	if err != nil {
 		return nil, fmt.Errorf("open failed: %w", err)
 	}
 
-	if err = runSQLMigrations(session.DB.DB, "sqlite3"); err != nil {
+	if err = runSQLMigrations(session.DB.DB, "sqlite3", options.AllowNewerSchema); err != nil {
 		_ = session.Close()
 		return nil, fmt.Errorf("could not run SQL migrations: %w", err)
 	}
 	return session, nil
 }
 
-func OpenSQLiteDBWithPrometheusMetrics(dbFilePath string, namespace string, sub db.Subservice,
-	registry *prometheus.Registry,
+func OpenSQLiteDBWithPrometheusMetrics(dbFilePath string, options SQLiteOptions, namespace string,
+	sub db.Subservice, registry *prometheus.Registry,
 ) (*DB, error) {
-	session, err := openSQLiteDB(dbFilePath)
+	session, err := openSQLiteDB(dbFilePath, options)
 	if err != nil {
 		return nil, err
 	}
 	result := DB{
 		SessionInterface: db.RegisterMetrics(session, namespace, sub, registry),
-		cache: &dbCache{
-			ledgerEntries: newTransactionalCache(),
-		},
+		cache:            &dbCache{},
+		writeLock:        &sync.Mutex{},
 	}
 	return &result, nil
 }
 
 func OpenSQLiteDB(dbFilePath string) (*DB, error) {
-	session, err := openSQLiteDB(dbFilePath)
+	session, err := openSQLiteDB(dbFilePath, SQLiteOptions{})
 	if err != nil {
 		return nil, err
 	}
 	result := DB{
 		SessionInterface: session,
-		cache: &dbCache{
-			ledgerEntries: newTransactionalCache(),
-		},
+		cache:            &dbCache{},
+		writeLock:        &sync.Mutex{},
 	}
 	return &result, nil
 }
@@ -168,21 +210,28 @@ func getLatestLedgerSequence(ctx context.Context, ledgerReader LedgerReader, cac
 
 type ReadWriterMetrics struct {
 	TxIngestDuration, TxCount prometheus.Observer
+	EventSize                 prometheus.Observer
+	OversizedEvents           *prometheus.CounterVec
 }
 
 type readWriter struct {
 	log                    *log.Entry
 	db                     *DB
 	maxBatchSize           int
-	historyRetentionWindow uint32
+	historyRetentionWindow atomic.Uint32
 	passphrase             string
+	eventStorage           EventStorage
+	ingestOperations       bool
+	indexContractData      bool
 
 	metrics ReadWriterMetrics
 }
 
-// NewReadWriter constructs a new readWriter instance and configures the size of
-// ledger entry batches when writing ledger entries and the retention window for
-// how many historical ledgers are recorded in the database, hooking up metrics
+// NewReadWriter constructs a new readWriter instance and configures the maximum
+// number of rows per insert statement when ingesting, the retention window for
+// how many historical ledgers are recorded in the database, the size limit
+// of ingested events, whether the operations are ingested and whether the
+// contract data keys are indexed (see ContractDataIndex), hooking up metrics
 // for various DB ops.
 func NewReadWriter(
 	log *log.Entry,
@@ -191,6 +240,9 @@ func NewReadWriter(
 	maxBatchSize int,
 	historyRetentionWindow uint32,
 	networkPassphrase string,
+	eventStorage EventStorage,
+	ingestOperations bool,
+	indexContractData bool,
 ) ReadWriter {
 	// a metric for measuring latency of transaction store operations
 	txDurationMetric := prometheus.NewSummaryVec(prometheus.SummaryOpts{
@@ -208,19 +260,43 @@ func NewReadWriter(
 		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}, //nolint:mnd
 	})
 
-	daemon.MetricsRegistry().MustRegister(txDurationMetric, txCountMetric)
+	eventSizeMetric := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: daemon.MetricsNamespace(), Subsystem: "events",
+		Name:    "ingested_size_bytes",
+		Help:    "size of the serialized events seen during ingestion, before applying the size limit",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8), //nolint:mnd
+	})
+	oversizedEventsMetric := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: daemon.MetricsNamespace(), Subsystem: "events",
+		Name: "oversized_total",
+		Help: "number of ingested events exceeding the maximum event size, by the action taken",
+	},
+		[]string{"action"},
+	)
+
+	daemon.MetricsRegistry().MustRegister(txDurationMetric, txCountMetric, eventSizeMetric, oversizedEventsMetric)
 
-	return &readWriter{
-		log:                    log,
-		db:                     db,
-		maxBatchSize:           maxBatchSize,
-		historyRetentionWindow: historyRetentionWindow,
-		passphrase:             networkPassphrase,
+	rw := &readWriter{
+		log:               log,
+		db:                db,
+		maxBatchSize:      maxBatchSize,
+		passphrase:        networkPassphrase,
+		eventStorage:      eventStorage,
+		ingestOperations:  ingestOperations,
+		indexContractData: indexContractData,
 		metrics: ReadWriterMetrics{
 			TxIngestDuration: txDurationMetric.With(prometheus.Labels{"operation": "ingest"}),
 			TxCount:          txCountMetric,
+			EventSize:        eventSizeMetric,
+			OversizedEvents:  oversizedEventsMetric,
 		},
 	}
+	rw.historyRetentionWindow.Store(historyRetentionWindow)
+	return rw
+}
+
+func (rw *readWriter) SetHistoryRetentionWindow(window uint32) {
+	rw.historyRetentionWindow.Store(window)
 }
 
 func (rw *readWriter) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
@@ -228,38 +304,99 @@ func (rw *readWriter) GetLatestLedgerSequence(ctx context.Context) (uint32, erro
 }
 
 func (rw *readWriter) NewTx(ctx context.Context) (WriteTx, error) {
+	tx, err := rw.newWriteTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// DeleteLedgersBefore removes the data of the ledgers preceding ledgerSeq.
+func (rw *readWriter) DeleteLedgersBefore(ctx context.Context, ledgerSeq uint32) error {
+	tx, err := rw.newWriteTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			rw.log.WithError(err).Warn("could not rollback the ledger deletion transaction")
+		}
+	}()
+	// ledgerSeq is the only ledger of a single-ledger retention window
+	if err := tx.trim(ledgerSeq, 1); err != nil {
+		return err
+	}
+	tx.globalCache.Lock()
+	defer tx.globalCache.Unlock()
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	if tx.globalCache.latestLedgerSeq < ledgerSeq {
+		// all the ledgers were deleted
+		tx.globalCache.latestLedgerSeq = 0
+		tx.globalCache.latestLedgerCloseTime = 0
+	}
+	return nil
+}
+
+func (rw *readWriter) newWriteTx(ctx context.Context) (writeTx, error) {
+	rw.db.writeLock.Lock()
 	txSession := rw.db.Clone()
 	if err := txSession.Begin(ctx); err != nil {
-		return nil, err
+		rw.db.writeLock.Unlock()
+		return writeTx{}, err
 	}
 	stmtCache := sq.NewStmtCache(txSession.GetTx())
 
 	db := rw.db
 	writer := writeTx{
 		globalCache: db.cache,
+		unlock:      sync.OnceFunc(db.writeLock.Unlock),
 		postCommit: func() error {
+			if db.checkpointDeferral != nil {
+				walSize, err := db.walSize(ctx)
+				if err != nil {
+					return err
+				}
+				if db.checkpointDeferral.deferCheckpoint(walSize) {
+					return nil
+				}
+			}
 			// TODO: this is sqlite-only, it shouldn't be here
 			_, err := db.ExecRaw(ctx, "PRAGMA wal_checkpoint(TRUNCATE)")
 			return err
 		},
 		tx:                     txSession,
 		stmtCache:              stmtCache,
-		historyRetentionWindow: rw.historyRetentionWindow,
+		historyRetentionWindow: rw.historyRetentionWindow.Load(),
 		ledgerWriter:           ledgerWriter{stmtCache: stmtCache},
 
 		txWriter: transactionHandler{
-			log:        rw.log,
-			db:         txSession,
-			stmtCache:  stmtCache,
-			passphrase: rw.passphrase,
+			log:              rw.log,
+			db:               txSession,
+			stmtCache:        stmtCache,
+			passphrase:       rw.passphrase,
+			maxBatchSize:     rw.maxBatchSize,
+			ingestOperations: rw.ingestOperations,
 		},
 		eventWriter: eventHandler{
-			log:        rw.log,
-			db:         txSession,
-			stmtCache:  stmtCache,
-			passphrase: rw.passphrase,
+			log:             rw.log,
+			db:              txSession,
+			stmtCache:       stmtCache,
+			passphrase:      rw.passphrase,
+			storage:         rw.eventStorage,
+			maxBatchSize:    rw.maxBatchSize,
+			sizeMetric:      rw.metrics.EventSize,
+			oversizedMetric: rw.metrics.OversizedEvents,
 		},
 	}
+	if rw.indexContractData {
+		writer.contractDataIndexer = &contractDataIndexer{
+			stmtCache:    stmtCache,
+			passphrase:   rw.passphrase,
+			maxBatchSize: rw.maxBatchSize,
+		}
+	}
 	writer.txWriter.RegisterMetrics(
 		rw.metrics.TxIngestDuration,
 		rw.metrics.TxCount)
@@ -269,6 +406,7 @@ func (rw *readWriter) NewTx(ctx context.Context) (WriteTx, error) {
 
 type writeTx struct {
 	globalCache            *dbCache
+	unlock                 func()
 	postCommit             func() error
 	tx                     db.SessionInterface
 	stmtCache              *sq.StmtCache
@@ -276,6 +414,8 @@ type writeTx struct {
 	txWriter               transactionHandler
 	eventWriter            eventHandler
 	historyRetentionWindow uint32
+	// contractDataIndexer is nil when the contract data keys aren't indexed
+	contractDataIndexer *contractDataIndexer
 }
 
 func (w writeTx) LedgerWriter() LedgerWriter {
@@ -290,18 +430,37 @@ func (w writeTx) EventWriter() EventWriter {
 	return &w.eventWriter
 }
 
+// trim removes the data of the ledgers which fall outside the retention
+// window.
+func (w writeTx) trim(latestLedgerSeq uint32, retentionWindow uint32) error {
+	if err := w.ledgerWriter.trimLedgers(latestLedgerSeq, retentionWindow); err != nil {
+		return err
+	}
+	if err := w.txWriter.trimTransactions(latestLedgerSeq, retentionWindow); err != nil {
+		return err
+	}
+	if err := w.txWriter.trimTransactionContracts(latestLedgerSeq, retentionWindow); err != nil {
+		return err
+	}
+	if err := w.txWriter.trimOperations(latestLedgerSeq, retentionWindow); err != nil {
+		return err
+	}
+	return w.eventWriter.trimEvents(latestLedgerSeq, retentionWindow)
+}
+
 func (w writeTx) Commit(ledgerCloseMeta xdr.LedgerCloseMeta) error {
 	ledgerSeq := ledgerCloseMeta.LedgerSequence()
 	ledgerCloseTime := ledgerCloseMeta.LedgerCloseTime()
 
-	if err := w.ledgerWriter.trimLedgers(ledgerSeq, w.historyRetentionWindow); err != nil {
-		return err
-	}
-	if err := w.txWriter.trimTransactions(ledgerSeq, w.historyRetentionWindow); err != nil {
-		return err
+	// the index follows all the changes of the ledger, including the ones of
+	// its upgrades and evictions, which don't belong to any transaction
+	if w.contractDataIndexer != nil {
+		if err := w.contractDataIndexer.indexLedger(ledgerCloseMeta); err != nil {
+			return err
+		}
 	}
 
-	if err := w.eventWriter.trimEvents(ledgerSeq, w.historyRetentionWindow); err != nil {
+	if err := w.trim(ledgerSeq, w.historyRetentionWindow); err != nil {
 		return err
 	}
 
@@ -321,11 +480,13 @@ func (w writeTx) Commit(ledgerCloseMeta xdr.LedgerCloseMeta) error {
 	if err := commitAndUpdateCache(); err != nil {
 		return err
 	}
+	defer w.unlock()
 
 	return w.postCommit()
 }
 
 func (w writeTx) Rollback() error {
+	defer w.unlock()
 	// errors.New("not in transaction") is returned when rolling back a transaction which has
 	// already been committed or rolled back. We can ignore those errors
 	// because we allow rolling back after commits in defer statements.
@@ -336,8 +497,8 @@ func (w writeTx) Rollback() error {
 	return err
 }
 
-func runSQLMigrations(db *sql.DB, dialect string) error {
-	m := &migrate.AssetMigrationSource{
+func sqlMigrationSource() *migrate.AssetMigrationSource {
+	return &migrate.AssetMigrationSource{
 		Asset: sqlMigrations.ReadFile,
 		AssetDir: func() func(string) ([]string, error) {
 			return func(path string) ([]string, error) {
@@ -355,6 +516,63 @@ func runSQLMigrations(db *sql.DB, dialect string) error {
 		}(),
 		Dir: "sqlmigrations",
 	}
-	_, err := migrate.ExecMax(db, dialect, m, migrate.Up, 0)
+}
+
+// schemaVersion is the version of the schema supported by the binary, which is
+// the number of SQL migrations.
+func schemaVersion() (int, error) {
+	migrations, err := sqlMigrationSource().FindMigrations()
+	if err != nil {
+		return 0, err
+	}
+	return len(migrations), nil
+}
+
+// getDBSchemaVersion returns the schema version the DB was written by, which
+// is 0 for new DBs and for DBs written before the version was recorded.
+func getDBSchemaVersion(db *sql.DB) (int, error) {
+	var tables int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+		metaTableName).Scan(&tables)
+	if err != nil || tables == 0 {
+		return 0, err
+	}
+	var value string
+	err = db.QueryRow("SELECT value FROM "+metaTableName+" WHERE key = ?", schemaVersionMetaKey).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// runSQLMigrations migrates the DB to the schema version of the binary and
+// records it. It fails with ErrNewerSchema if the DB was written by a newer
+// schema version, unless allowNewerSchema is set.
+func runSQLMigrations(db *sql.DB, dialect string, allowNewerSchema bool) error {
+	version, err := schemaVersion()
+	if err != nil {
+		return err
+	}
+	dbVersion, err := getDBSchemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("could not get the DB schema version: %w", err)
+	}
+	if dbVersion > version && !allowNewerSchema {
+		return fmt.Errorf("%w: the DB was written by schema version %d but version %d is supported,"+
+			" it was probably migrated by a newer version of stellar-rpc", ErrNewerSchema, dbVersion, version)
+	}
+
+	// the migrations of the newer versions are unknown
+	migrations := migrate.MigrationSet{IgnoreUnknown: allowNewerSchema}
+	if _, err = migrations.ExecMax(db, dialect, sqlMigrationSource(), migrate.Up, 0); err != nil {
+		return err
+	}
+	if dbVersion >= version {
+		return nil
+	}
+	_, err = db.Exec("REPLACE INTO "+metaTableName+" (key, value) VALUES (?, ?)",
+		schemaVersionMetaKey, strconv.Itoa(version))
 	return err
 }
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	ctx := context.TODO()
+	dbPath := path.Join(t.TempDir(), "db.sqlite")
+	version, err := schemaVersion()
+	require.NoError(t, err)
+
+	// the schema version is recorded when migrating
+	db, err := OpenSQLiteDB(dbPath)
+	require.NoError(t, err)
+	value, err := getMetaValue(ctx, db, schemaVersionMetaKey)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(version), value)
+
+	// simulate a migration by a newer version
+	_, err = db.ExecRaw(ctx, "REPLACE INTO metadata (key, value) VALUES (?, ?)",
+		schemaVersionMetaKey, strconv.Itoa(version+1))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = OpenSQLiteDB(dbPath)
+	require.ErrorIs(t, err, ErrNewerSchema)
+
+	// unless allowed, in which case the newer version is kept
+	session, err := openSQLiteDB(dbPath, SQLiteOptions{AllowNewerSchema: true})
+	require.NoError(t, err)
+	defer session.Close()
+	value, err = getMetaValue(ctx, session, schemaVersionMetaKey)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(version+1), value)
+}
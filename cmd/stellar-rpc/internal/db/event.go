@@ -8,6 +8,7 @@ import (
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/ingest"
 	"github.com/stellar/go/strkey"
@@ -23,6 +24,8 @@ const (
 	firstLedger    = uint32(2)
 )
 
+// NestedTopicArray holds the accepted values of every topic position. Events
+// with any of the values at the matching position are selected.
 type NestedTopicArray [][][]byte
 
 // EventWriter is used during ingestion of events from LCM to DB
@@ -31,6 +34,14 @@ type EventWriter interface {
 }
 
 // EventReader has all the public methods to fetch events from DB
+//
+// The events are indexed by contract id, by each topic position and by
+// contract id and first topic (along with their cursor), so the efficient
+// filters constrain the contract ids and/or a single topic position (e.g. the
+// event name in the first topic, or the recipient of transfers in the third
+// one). Filters constraining several topic positions without contract ids
+// combine the indexes of the positions. The events are also indexed by
+// transaction hash, which is the most selective filter.
 type EventReader interface {
 	GetEvents(
 		ctx context.Context,
@@ -38,8 +49,63 @@ type EventReader interface {
 		contractIDs [][]byte,
 		topics NestedTopicArray,
 		eventTypes []int,
+		txHashes [][]byte,
+		f ScanFunction,
+	) error
+	// GetEventsDescending is like GetEvents but scans the events in
+	// descending Cursor order.
+	GetEventsDescending(
+		ctx context.Context,
+		cursorRange protocol.CursorRange,
+		contractIDs [][]byte,
+		topics NestedTopicArray,
+		eventTypes []int,
+		txHashes [][]byte,
 		f ScanFunction,
 	) error
+	// AggregateEvents counts the events matching the filters (like GetEvents)
+	// by contract id or first topic, returning the limit largest counts.
+	AggregateEvents(
+		ctx context.Context,
+		cursorRange protocol.CursorRange,
+		contractIDs [][]byte,
+		topics NestedTopicArray,
+		eventTypes []int,
+		by EventAggregation,
+		limit uint,
+	) ([]EventCount, error)
+}
+
+// EventAggregation is the column by which AggregateEvents counts the events.
+type EventAggregation string
+
+const (
+	EventAggregationContract EventAggregation = "contract_id"
+	// EventAggregationTopic1 counts the events by their first topic, which
+	// is usually the event name.
+	EventAggregationTopic1 EventAggregation = "topic1"
+)
+
+// EventCount is the number of events sharing a value (e.g. a contract id) of
+// an aggregation.
+type EventCount struct {
+	// Key is nil for the events without the value, e.g. the system events
+	// when counting by contract id.
+	Key   []byte `db:"aggregate_key"`
+	Count uint64 `db:"event_count"`
+}
+
+// EventStorage configures how the individual events are stored during
+// ingestion.
+type EventStorage struct {
+	// MaxSize is the maximum size, in bytes, of a serialized event. Zero means
+	// there is no limit.
+	MaxSize int
+	// Skip makes oversized events be dropped altogether, instead of having
+	// their data replaced by a truncation marker.
+	Skip bool
+	// Encoding is how the data of the events is encoded in the database.
+	Encoding EventDataEncoding
 }
 
 type eventHandler struct {
@@ -47,6 +113,12 @@ type eventHandler struct {
 	db         db.SessionInterface
 	stmtCache  *sq.StmtCache
 	passphrase string
+	storage    EventStorage
+	// maxBatchSize is the maximum number of events per insert statement
+	maxBatchSize int
+
+	sizeMetric      prometheus.Observer
+	oversizedMetric *prometheus.CounterVec
 }
 
 func NewEventReader(log *log.Entry, db db.SessionInterface, passphrase string) EventReader {
@@ -79,6 +151,61 @@ func transactionEventsIntoDiagnosticEvents(allEvents ingest.TransactionEvents) [
 	return diagEvents
 }
 
+// TransactionEvents returns the events of a transaction as they are ingested,
+// in the order of their cursors. Failed transactions have no events.
+func TransactionEvents(tx ingest.LedgerTransaction) ([]xdr.DiagnosticEvent, error) {
+	if !tx.Result.Successful() {
+		return nil, nil
+	}
+	allEvents, err := tx.GetTransactionEvents()
+	if err != nil {
+		return nil, err
+	}
+	return transactionEventsIntoDiagnosticEvents(allEvents), nil
+}
+
+// ScanLedgerEvents extracts the events of a ledger, as they are ingested, and
+// calls f on them in ascending cursor order until it returns false. It returns
+// whether the whole ledger was scanned.
+func ScanLedgerEvents(passphrase string, lcm xdr.LedgerCloseMeta, f ScanFunction) (bool, error) {
+	if lcm.CountTransactions() == 0 {
+		return true, nil
+	}
+	txReader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(passphrase, lcm)
+	if err != nil {
+		return false, errors.Join(err,
+			fmt.Errorf("failed to open transaction reader for ledger %d", lcm.LedgerSequence()),
+		)
+	}
+	defer txReader.Close()
+
+	for {
+		tx, err := txReader.Read()
+		if errors.Is(err, io.EOF) {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		if !tx.Result.Successful() {
+			continue
+		}
+
+		allEvents, err := tx.GetTransactionEvents()
+		if err != nil {
+			return false, err
+		}
+
+		transactionHash := tx.Result.TransactionHash
+		for index, e := range transactionEventsIntoDiagnosticEvents(allEvents) {
+			cursor := protocol.Cursor{Ledger: lcm.LedgerSequence(), Tx: tx.Index, Op: 0, Event: uint32(index)} //nolint:gosec
+			if !f(e, cursor, lcm.LedgerCloseTime(), &transactionHash) {
+				return false, nil
+			}
+		}
+	}
+}
+
 func (eventHandler *eventHandler) InsertEvents(lcm xdr.LedgerCloseMeta) error {
 	txCount := lcm.CountTransactions()
 
@@ -99,6 +226,17 @@ func (eventHandler *eventHandler) InsertEvents(lcm xdr.LedgerCloseMeta) error {
 		err = errors.Join(err, closeErr)
 	}()
 
+	batch := newInsertBatch(eventHandler.stmtCache, sq.Insert(eventTableName).
+		Columns(
+			"id",
+			"contract_id",
+			"event_type",
+			"event_data",
+			"event_data_encoding",
+			"ledger_close_time",
+			"transaction_hash",
+			"topic1", "topic2", "topic3", "topic4",
+		), eventHandler.maxBatchSize)
 	for {
 		var tx ingest.LedgerTransaction
 		tx, err = txReader.Read()
@@ -126,17 +264,6 @@ func (eventHandler *eventHandler) InsertEvents(lcm xdr.LedgerCloseMeta) error {
 			continue
 		}
 
-		query := sq.Insert(eventTableName).
-			Columns(
-				"id",
-				"contract_id",
-				"event_type",
-				"event_data",
-				"ledger_close_time",
-				"transaction_hash",
-				"topic1", "topic2", "topic3", "topic4",
-			)
-
 		for index, e := range diagEvents {
 			var contractID []byte
 			if e.Event.ContractId != nil {
@@ -148,11 +275,28 @@ func (eventHandler *eventHandler) InsertEvents(lcm xdr.LedgerCloseMeta) error {
 			if err != nil {
 				return err
 			}
+			if eventHandler.sizeMetric != nil {
+				eventHandler.sizeMetric.Observe(float64(len(eventBlob)))
+			}
+			if eventHandler.storage.MaxSize > 0 && len(eventBlob) > eventHandler.storage.MaxSize {
+				e, eventBlob, err = eventHandler.limitEventSize(e, len(eventBlob))
+				if err != nil {
+					return err
+				}
+				if eventBlob == nil {
+					eventHandler.log.WithField("id", id).Warn("skipping oversized event")
+					continue
+				}
+			}
 
 			v0, ok := e.Event.Body.GetV0()
 			if !ok {
 				return errors.New("unknown event version")
 			}
+			eventData, err := encodeEventData(eventHandler.storage.Encoding, eventBlob)
+			if err != nil {
+				return err
+			}
 
 			// Encode the topics
 			topicList := make([][]byte, protocol.MaxTopicCount)
@@ -165,24 +309,57 @@ func (eventHandler *eventHandler) InsertEvents(lcm xdr.LedgerCloseMeta) error {
 				topicList[index] = seg
 			}
 
-			query = query.Values(
+			err = batch.add(
 				id,
 				contractID,
 				int(e.Event.Type),
-				eventBlob,
+				eventData,
+				int(eventHandler.storage.Encoding),
 				lcm.LedgerCloseTime(),
 				transactionHash,
 				topicList[0], topicList[1], topicList[2], topicList[3],
 			)
+			if err != nil {
+				return err
+			}
 		}
-		// Ignore the last inserted ID as it is not needed
-		_, err = query.RunWith(eventHandler.stmtCache).Exec()
+	}
+
+	return batch.flush()
+}
+
+// limitEventSize handles an event exceeding the configured maximum size. Unless
+// oversized events are configured to be skipped, the event data is replaced by
+// a string marker recording the original size (the topics are kept, so that the
+// event can still be filtered). A nil blob is returned if the event must be
+// skipped, which is also the case when the topics alone exceed the limit.
+func (eventHandler *eventHandler) limitEventSize(
+	e xdr.DiagnosticEvent, size int,
+) (xdr.DiagnosticEvent, []byte, error) {
+	if !eventHandler.storage.Skip && e.Event.Body.V0 != nil {
+		// copy the body, since it's shared with the ledger close meta
+		v0 := *e.Event.Body.V0
+		marker := xdr.ScString(fmt.Sprintf("[truncated: event exceeded %d bytes, original size %d bytes]",
+			eventHandler.storage.MaxSize, size))
+		v0.Data = xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &marker}
+		e.Event.Body.V0 = &v0
+		blob, err := e.MarshalBinary()
 		if err != nil {
-			return err
+			return e, nil, err
+		}
+		if len(blob) <= eventHandler.storage.MaxSize {
+			eventHandler.countOversized("truncated")
+			return e, blob, nil
 		}
 	}
+	eventHandler.countOversized("skipped")
+	return e, nil, nil
+}
 
-	return nil
+func (eventHandler *eventHandler) countOversized(action string) {
+	if eventHandler.oversizedMetric != nil {
+		eventHandler.oversizedMetric.With(prometheus.Labels{"action": action}).Inc()
+	}
 }
 
 type ScanFunction func(
@@ -209,35 +386,98 @@ func (eventHandler *eventHandler) trimEvents(latestLedgerSeq uint32, retentionWi
 }
 
 // GetEvents applies f on all the events occurring in the given range with
-// specified contract IDs if provided. The events are returned in sorted
-// ascending Cursor order.
+// specified contract IDs (and emitted by the transactions with the specified
+// hashes) if provided. The events are returned in sorted ascending Cursor
+// order.
 //
 // If f returns false, the scan terminates early (f will not be applied on
 // remaining events in the range).
-//
-//nolint:funlen,cyclop
 func (eventHandler *eventHandler) GetEvents(
 	ctx context.Context,
 	cursorRange protocol.CursorRange,
 	contractIDs [][]byte,
 	topics NestedTopicArray,
 	eventTypes []int,
+	txHashes [][]byte,
 	f ScanFunction,
 ) error {
-	start := time.Now()
+	return eventHandler.getEvents(ctx, cursorRange, contractIDs, topics, eventTypes, txHashes, "id ASC", f)
+}
+
+// GetEventsDescending is like GetEvents, but the events are returned in
+// sorted descending Cursor order.
+func (eventHandler *eventHandler) GetEventsDescending(
+	ctx context.Context,
+	cursorRange protocol.CursorRange,
+	contractIDs [][]byte,
+	topics NestedTopicArray,
+	eventTypes []int,
+	txHashes [][]byte,
+	f ScanFunction,
+) error {
+	return eventHandler.getEvents(ctx, cursorRange, contractIDs, topics, eventTypes, txHashes, "id DESC", f)
+}
+
+// eventIndex returns the index serving the lookup of the events by a single
+// topic position, if any: across contracts, or by the first topic of the given
+// contracts (e.g. the transfers of a set of tokens). The lookup by transaction
+// hash takes precedence, since a transaction only emits a few events. It is
+// forced on the query planner, which can otherwise prefer scanning the cursor
+// range or the events of the contracts.
+func eventIndex(contractIDs [][]byte, topics NestedTopicArray, txHashes [][]byte) (string, bool) {
+	if len(txHashes) > 0 {
+		return "idx_transaction_hash_id", true
+	}
+	position := -1
+	for i, topic := range topics {
+		if topic == nil {
+			continue
+		}
+		if position >= 0 {
+			// the indexes of the positions are combined
+			return "", false
+		}
+		position = i
+	}
+	switch {
+	case position < 0:
+		return "", false
+	case len(contractIDs) > 0 && position == 0:
+		return "idx_contract_id_topic1_id", true
+	case len(contractIDs) > 0:
+		// the contract ids are more selective
+		return "", false
+	default:
+		return fmt.Sprintf("idx_topic%d_id", position+1), true
+	}
+}
 
-	rowQ := sq.
-		Select(" id", "event_data", "transaction_hash", "ledger_close_time").
-		From(eventTableName).
+// filterEvents selects the events of the cursor range matching the filters.
+func filterEvents(
+	query sq.SelectBuilder,
+	cursorRange protocol.CursorRange,
+	contractIDs [][]byte,
+	topics NestedTopicArray,
+	eventTypes []int,
+	txHashes [][]byte,
+) sq.SelectBuilder {
+	from := eventTableName
+	if index, ok := eventIndex(contractIDs, topics, txHashes); ok {
+		from += " INDEXED BY " + index
+	}
+	query = query.
+		From(from).
 		Where(sq.GtOrEq{"id": cursorRange.Start.String()}).
-		Where(sq.Lt{"id": cursorRange.End.String()}).
-		OrderBy("id ASC")
+		Where(sq.Lt{"id": cursorRange.End.String()})
 
 	if len(contractIDs) > 0 {
-		rowQ = rowQ.Where(sq.Eq{"contract_id": contractIDs})
+		query = query.Where(sq.Eq{"contract_id": contractIDs})
 	}
 	if len(eventTypes) > 0 {
-		rowQ = rowQ.Where(sq.Eq{"event_type": eventTypes})
+		query = query.Where(sq.Eq{"event_type": eventTypes})
+	}
+	if len(txHashes) > 0 {
+		query = query.Where(sq.Eq{"transaction_hash": txHashes})
 	}
 
 	if len(topics) > 0 {
@@ -249,9 +489,63 @@ func (eventHandler *eventHandler) GetEvents(
 			orConditions = append(orConditions, sq.Eq{fmt.Sprintf("topic%d", i+1): topic})
 		}
 		if len(orConditions) > 0 {
-			rowQ = rowQ.Where(orConditions)
+			query = query.Where(orConditions)
 		}
 	}
+	return query
+}
+
+// AggregateEvents implements EventReader.
+func (eventHandler *eventHandler) AggregateEvents(
+	ctx context.Context,
+	cursorRange protocol.CursorRange,
+	contractIDs [][]byte,
+	topics NestedTopicArray,
+	eventTypes []int,
+	by EventAggregation,
+	limit uint,
+) ([]EventCount, error) {
+	switch by {
+	case EventAggregationContract, EventAggregationTopic1:
+	default:
+		return nil, fmt.Errorf("unknown event aggregation: %q", by)
+	}
+	query := filterEvents(
+		sq.Select(string(by)+" AS aggregate_key", "COUNT(*) AS event_count"),
+		cursorRange, contractIDs, topics, eventTypes, nil,
+	).
+		GroupBy(string(by)).
+		OrderBy("event_count DESC", "aggregate_key").
+		Limit(uint64(limit))
+
+	var counts []EventCount
+	err := retryRead(ctx, eventHandler.log, "event aggregates", func() error {
+		counts = nil
+		return eventHandler.db.Select(ctx, &counts, query)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db read failed for event aggregates: %w", err)
+	}
+	return counts, nil
+}
+
+//nolint:funlen,cyclop
+func (eventHandler *eventHandler) getEvents(
+	ctx context.Context,
+	cursorRange protocol.CursorRange,
+	contractIDs [][]byte,
+	topics NestedTopicArray,
+	eventTypes []int,
+	txHashes [][]byte,
+	orderBy string,
+	f ScanFunction,
+) error {
+	start := time.Now()
+
+	rowQ := filterEvents(
+		sq.Select(" id", "event_data", "event_data_encoding", "transaction_hash", "ledger_close_time"),
+		cursorRange, contractIDs, topics, eventTypes, txHashes,
+	).OrderBy(orderBy)
 
 	encodedContractIDs := make([]string, 0, len(contractIDs))
 	for _, contractID := range contractIDs {
@@ -262,7 +556,13 @@ func (eventHandler *eventHandler) GetEvents(
 		encodedContractIDs = append(encodedContractIDs, result)
 	}
 
-	rows, err := eventHandler.db.Query(ctx, rowQ)
+	// the query is only retried before scanning, since f can't be applied twice on the same events
+	var rows *db.Rows
+	err := retryRead(ctx, eventHandler.log, "events", func() error {
+		var err error
+		rows, err = eventHandler.db.Query(ctx, rowQ)
+		return err
+	})
 	if err != nil {
 		eventHandler.log.
 			WithField("duration", time.Since(start)).
@@ -286,13 +586,15 @@ func (eventHandler *eventHandler) GetEvents(
 		var row struct {
 			eventCursorID   string `db:"id"`
 			eventData       []byte `db:"event_data"`
+			dataEncoding    int    `db:"event_data_encoding"`
 			transactionHash []byte `db:"transaction_hash"`
 			ledgerCloseTime int64  `db:"ledger_close_time"`
 		}
 
-		err = rows.Scan(&row.eventCursorID, &row.eventData, &row.transactionHash, &row.ledgerCloseTime)
+		err = rows.Scan(&row.eventCursorID, &row.eventData, &row.dataEncoding, &row.transactionHash,
+			&row.ledgerCloseTime)
 		if err != nil {
-			return fmt.Errorf("failed to scan row: %w", err)
+			return fmt.Errorf("failed to scan row: %w", classifyReadError(eventHandler.log, "events", err))
 		}
 
 		id, eventData, ledgerCloseTime := row.eventCursorID, row.eventData, row.ledgerCloseTime
@@ -302,6 +604,10 @@ func (eventHandler *eventHandler) GetEvents(
 			return errors.Join(err, errors.New("failed to parse cursor"))
 		}
 
+		eventData, err = decodeEventData(EventDataEncoding(row.dataEncoding), eventData)
+		if err != nil {
+			return errors.Join(err, errors.New("failed to decode event data"))
+		}
 		var eventXDR xdr.DiagnosticEvent
 		err = xdr.SafeUnmarshal(eventData, &eventXDR)
 		if err != nil {
@@ -331,7 +637,7 @@ func (eventHandler *eventHandler) GetEvents(
 		WithField("duration", time.Since(start)).
 		Debugf("Fetched and decoded all the events with filters - contractIDs: %v ", encodedContractIDs)
 
-	return rows.Err()
+	return classifyReadError(eventHandler.log, "events", rows.Err())
 }
 
 type eventTableMigration struct {
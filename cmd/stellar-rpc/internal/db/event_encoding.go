@@ -0,0 +1,61 @@
+package db
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// EventDataEncoding is how the events are stored in the database. Since it
+// is recorded along with every event, it can be changed without
+// re-ingesting the stored events.
+type EventDataEncoding int
+
+const (
+	// EventDataEncodingXDR stores the raw XDR of the events, which is the
+	// cheapest to write and read.
+	EventDataEncodingXDR EventDataEncoding = iota
+	// EventDataEncodingCompressedXDR stores the DEFLATE-compressed XDR of the
+	// events, which reduces the size of large events (e.g. with maps or
+	// vectors of repeated symbols and addresses) at the cost of compressing
+	// them during ingestion and decompressing them when read.
+	EventDataEncodingCompressedXDR
+)
+
+// encodeEventData encodes the XDR of an event for storage.
+func encodeEventData(encoding EventDataEncoding, eventXDR []byte) ([]byte, error) {
+	switch encoding {
+	case EventDataEncodingXDR:
+		return eventXDR, nil
+	case EventDataEncodingCompressedXDR:
+		var buf bytes.Buffer
+		writer, err := flate.NewWriter(&buf, flate.BestSpeed)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(eventXDR); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown event data encoding: %d", encoding)
+	}
+}
+
+// decodeEventData returns the XDR of a stored event.
+func decodeEventData(encoding EventDataEncoding, data []byte) ([]byte, error) {
+	switch encoding {
+	case EventDataEncodingXDR:
+		return data, nil
+	case EventDataEncodingCompressedXDR:
+		reader := flate.NewReader(bytes.NewReader(data))
+		defer reader.Close()
+		return io.ReadAll(reader)
+	default:
+		return nil, fmt.Errorf("unknown event data encoding: %d", encoding)
+	}
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventDataEncoding(t *testing.T) {
+	eventXDR := bytes.Repeat([]byte("repeated symbol"), 100)
+	for _, encoding := range []EventDataEncoding{EventDataEncodingXDR, EventDataEncodingCompressedXDR} {
+		data, err := encodeEventData(encoding, eventXDR)
+		require.NoError(t, err)
+		decoded, err := decodeEventData(encoding, data)
+		require.NoError(t, err)
+		assert.Equal(t, eventXDR, decoded)
+	}
+
+	compressed, err := encodeEventData(EventDataEncodingCompressedXDR, eventXDR)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(eventXDR))
+
+	_, err = encodeEventData(EventDataEncoding(42), eventXDR)
+	require.Error(t, err)
+	_, err = decodeEventData(EventDataEncoding(42), eventXDR)
+	require.Error(t, err)
+}
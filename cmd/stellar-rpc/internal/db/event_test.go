@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -23,7 +25,8 @@ func transactionMetaWithEvents(events ...xdr.ContractEvent) xdr.TransactionMeta
 		Operations: &[]xdr.OperationMeta{},
 		V3: &xdr.TransactionMetaV3{
 			SorobanMeta: &xdr.SorobanTransactionMeta{
-				Events: events,
+				Events:      events,
+				ReturnValue: xdr.ScVal{Type: xdr.ScValTypeScvVoid},
 			},
 		},
 	}
@@ -81,6 +84,10 @@ func ledgerCloseMetaWithEvents(
 				Tx: xdr.Transaction{
 					SourceAccount: xdr.MustMuxedAddress(keypair.MustRandom().Address()),
 					Operations:    operations,
+					Ext: xdr.TransactionExt{
+						V:           1,
+						SorobanData: &xdr.SorobanTransactionData{},
+					},
 				},
 			},
 		}
@@ -93,6 +100,12 @@ func ledgerCloseMetaWithEvents(
 			TxApplyProcessing: item,
 			Result: xdr.TransactionResultPair{
 				TransactionHash: txHash,
+				Result: xdr.TransactionResult{
+					Result: xdr.TransactionResultResult{
+						Code:    xdr.TransactionResultCodeTxSuccess,
+						Results: &[]xdr.OperationResult{},
+					},
+				},
 			},
 		})
 		components := []xdr.TxSetComponent{
@@ -142,7 +155,7 @@ func TestInsertEvents(t *testing.T) {
 	log.SetLevel(logrus.TraceLevel)
 	now := time.Now().UTC()
 
-	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, EventStorage{}, false, false)
 	write, err := writer.NewTx(ctx)
 	require.NoError(t, err)
 	contractID := xdr.ContractId([32]byte{})
@@ -175,6 +188,442 @@ func TestInsertEvents(t *testing.T) {
 	end := protocol.Cursor{Ledger: 100}
 	cursorRange := protocol.CursorRange{Start: start, End: end}
 
-	err = eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, nil)
+	err = eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+}
+
+func TestGetEventsDescending(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+	now := time.Now().UTC()
+
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, EventStorage{}, false, false)
+	contractID := xdr.ContractId([32]byte{})
+	counter := xdr.ScSymbol("COUNTER")
+	counterVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &counter}
+	for ledger := uint32(1); ledger <= 2; ledger++ {
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+		ledgerCloseMeta := ledgerCloseMetaWithEvents(ledger, now.Unix(),
+			transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterVal}, counterVal)),
+			transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterVal}, counterVal)),
+		)
+		require.NoError(t, write.LedgerWriter().InsertLedger(ledgerCloseMeta))
+		require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+		require.NoError(t, write.Commit(ledgerCloseMeta))
+	}
+
+	eventReader := NewEventReader(log, db, passphrase)
+	cursorRange := protocol.CursorRange{
+		Start: protocol.Cursor{Ledger: 1},
+		End:   protocol.Cursor{Ledger: 3},
+	}
+
+	var ascending, descending []protocol.Cursor
+	require.NoError(t, eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, nil,
+		func(_ xdr.DiagnosticEvent, cursor protocol.Cursor, _ int64, _ *xdr.Hash) bool {
+			ascending = append(ascending, cursor)
+			return true
+		}))
+	require.NoError(t, eventReader.GetEventsDescending(ctx, cursorRange, nil, nil, nil, nil,
+		func(_ xdr.DiagnosticEvent, cursor protocol.Cursor, _ int64, _ *xdr.Hash) bool {
+			descending = append(descending, cursor)
+			return true
+		}))
+	require.Len(t, ascending, 4)
+	slices.Reverse(descending)
+	require.Equal(t, ascending, descending)
+
+	// the scan stops at the latest event when f returns false
+	var latest []protocol.Cursor
+	require.NoError(t, eventReader.GetEventsDescending(ctx, cursorRange, nil, nil, nil, nil,
+		func(_ xdr.DiagnosticEvent, cursor protocol.Cursor, _ int64, _ *xdr.Hash) bool {
+			latest = append(latest, cursor)
+			return false
+		}))
+	require.Equal(t, []protocol.Cursor{ascending[3]}, latest)
+}
+
+func TestGetEventsByTransactionHash(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	contractID := xdr.ContractId{1}
+	counter := xdr.ScSymbol("COUNTER")
+	counterVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &counter}
+	ledgerCloseMeta := ledgerCloseMetaWithEvents(1, time.Now().Unix(),
+		transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterVal}, counterVal)),
+		transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterVal}, counterVal)),
+		transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterVal}, counterVal)),
+	)
+	require.NoError(t, write.LedgerWriter().InsertLedger(ledgerCloseMeta))
+	require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+	require.NoError(t, write.Commit(ledgerCloseMeta))
+
+	eventReader := NewEventReader(log, db, passphrase)
+	cursorRange := protocol.CursorRange{
+		Start: protocol.Cursor{Ledger: 1},
+		End:   protocol.Cursor{Ledger: 2},
+	}
+	scan := func(txHashes [][]byte) []xdr.Hash {
+		var hashes []xdr.Hash
+		require.NoError(t, eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, txHashes,
+			func(_ xdr.DiagnosticEvent, _ protocol.Cursor, _ int64, txHash *xdr.Hash) bool {
+				hashes = append(hashes, *txHash)
+				return true
+			}))
+		return hashes
+	}
+	all := scan(nil)
+	require.Len(t, all, 3)
+
+	// only the events of the given transactions are returned, in order
+	require.Equal(t, []xdr.Hash{all[0], all[2]}, scan([][]byte{all[2][:], all[0][:]}))
+	require.Empty(t, scan([][]byte{make([]byte, 32)}))
+}
+
+func TestAggregateEvents(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	contractA, contractB := xdr.ContractId{1}, xdr.ContractId{2}
+	transfer, mint := xdr.ScSymbol("transfer"), xdr.ScSymbol("mint")
+	transferVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &transfer}
+	mintVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &mint}
+	ledgerCloseMeta := ledgerCloseMetaWithEvents(1, time.Now().Unix(),
+		transactionMetaWithEvents(contractEvent(contractA, xdr.ScVec{transferVal}, transferVal)),
+		transactionMetaWithEvents(contractEvent(contractA, xdr.ScVec{transferVal}, transferVal)),
+		transactionMetaWithEvents(contractEvent(contractA, xdr.ScVec{mintVal}, mintVal)),
+		transactionMetaWithEvents(contractEvent(contractB, xdr.ScVec{transferVal}, transferVal)),
+	)
+	require.NoError(t, write.LedgerWriter().InsertLedger(ledgerCloseMeta))
+	require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+	require.NoError(t, write.Commit(ledgerCloseMeta))
+
+	eventReader := NewEventReader(log, db, passphrase)
+	cursorRange := protocol.CursorRange{
+		Start: protocol.Cursor{Ledger: 1},
+		End:   protocol.Cursor{Ledger: 2},
+	}
+	transferXDR, err := transferVal.MarshalBinary()
+	require.NoError(t, err)
+	mintXDR, err := mintVal.MarshalBinary()
+	require.NoError(t, err)
+
+	counts, err := eventReader.AggregateEvents(ctx, cursorRange, nil, nil, nil, EventAggregationContract, 10)
+	require.NoError(t, err)
+	require.Equal(t, []EventCount{{Key: contractA[:], Count: 3}, {Key: contractB[:], Count: 1}}, counts)
+
+	// the counts are filtered and bounded by the limit
+	counts, err = eventReader.AggregateEvents(ctx, cursorRange, [][]byte{contractA[:]}, nil, nil,
+		EventAggregationTopic1, 10)
 	require.NoError(t, err)
+	require.Equal(t, []EventCount{{Key: transferXDR, Count: 2}, {Key: mintXDR, Count: 1}}, counts)
+	counts, err = eventReader.AggregateEvents(ctx, cursorRange, nil, NestedTopicArray{{transferXDR}}, nil,
+		EventAggregationContract, 1)
+	require.NoError(t, err)
+	require.Equal(t, []EventCount{{Key: contractA[:], Count: 2}}, counts)
+
+	_, err = eventReader.AggregateEvents(ctx, cursorRange, nil, nil, nil, "event_data", 10)
+	require.Error(t, err)
+}
+
+func TestInsertEventsSizeLimit(t *testing.T) {
+	contractID := xdr.ContractId([32]byte{})
+	counter := xdr.ScSymbol("COUNTER")
+	counterVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &counter}
+	payload := xdr.ScBytes(make([]byte, 1024))
+	payloadVal := xdr.ScVal{Type: xdr.ScValTypeScvBytes, Bytes: &payload}
+
+	for _, tc := range []struct {
+		name  string
+		skip  bool
+		check func(t *testing.T, events []xdr.DiagnosticEvent)
+	}{
+		{
+			name: "truncate",
+			check: func(t *testing.T, events []xdr.DiagnosticEvent) {
+				require.Len(t, events, 2)
+				require.Equal(t, counterVal, events[0].Event.Body.V0.Data)
+				truncated := events[1].Event.Body.V0
+				require.Equal(t, []xdr.ScVal{counterVal}, truncated.Topics)
+				require.Equal(t, xdr.ScValTypeScvString, truncated.Data.Type)
+				require.Contains(t, string(*truncated.Data.Str), "truncated")
+			},
+		},
+		{
+			name: "skip",
+			skip: true,
+			check: func(t *testing.T, events []xdr.DiagnosticEvent) {
+				require.Len(t, events, 1)
+				require.Equal(t, counterVal, events[0].Event.Body.V0.Data)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := NewTestDB(t)
+			ctx := context.TODO()
+			log := log.DefaultLogger
+
+			writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase,
+				EventStorage{MaxSize: 512, Skip: tc.skip}, false, false)
+			write, err := writer.NewTx(ctx)
+			require.NoError(t, err)
+			ledgerCloseMeta := ledgerCloseMetaWithEvents(1, time.Now().Unix(),
+				transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterVal}, counterVal)),
+				transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterVal}, payloadVal)),
+			)
+			require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+			require.NoError(t, write.Commit(ledgerCloseMeta))
+
+			eventReader := NewEventReader(log, db, passphrase)
+			cursorRange := protocol.CursorRange{
+				Start: protocol.Cursor{Ledger: 1},
+				End:   protocol.Cursor{Ledger: 2},
+			}
+			var events []xdr.DiagnosticEvent
+			require.NoError(t, eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, nil,
+				func(event xdr.DiagnosticEvent, _ protocol.Cursor, _ int64, _ *xdr.Hash) bool {
+					events = append(events, event)
+					return true
+				}))
+			tc.check(t, events)
+
+			// the ledger close meta must not be modified
+			v0 := ledgerCloseMeta.V1.TxProcessing[1].TxApplyProcessing.V3.SorobanMeta.Events[0].Body.V0
+			require.Equal(t, payloadVal, v0.Data)
+		})
+	}
+}
+
+// BenchmarkGetEventsByName looks up the events with a given name (first topic)
+// across all the contracts, which is served by the first topic index.
+func BenchmarkGetEventsByName(b *testing.B) {
+	db := NewTestDB(b)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+	now := time.Now().UTC()
+
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 100, 1_000_000, passphrase, EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(b, err)
+
+	// ingest 1000 ledgers with 20 events each, emitted by different
+	// contracts, only 1 in 100 events being a transfer
+	names := make([]xdr.ScVal, 100)
+	for i := range names {
+		name := xdr.ScSymbol(fmt.Sprintf("event%d", i))
+		names[i] = xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &name}
+	}
+	var lcm xdr.LedgerCloseMeta
+	ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
+	for ledger := uint32(1); ledger <= 1000; ledger++ {
+		txMeta := make([]xdr.TransactionMeta, 0, 20)
+		for i := range cap(txMeta) {
+			contractID := xdr.ContractId{byte(ledger), byte(i)}
+			name := names[(int(ledger)*cap(txMeta)+i)%len(names)]
+			txMeta = append(txMeta, transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{name}, name)))
+		}
+		lcm = ledgerCloseMetaWithEvents(ledger, now.Unix(), txMeta...)
+		require.NoError(b, ledgerW.InsertLedger(lcm))
+		require.NoError(b, eventW.InsertEvents(lcm))
+	}
+	require.NoError(b, write.Commit(lcm))
+
+	transfer, err := names[0].MarshalBinary()
+	require.NoError(b, err)
+	topics := NestedTopicArray{{transfer}}
+	eventReader := NewEventReader(log, db, passphrase)
+	cursorRange := protocol.CursorRange{
+		Start: protocol.Cursor{Ledger: 1},
+		End:   protocol.Cursor{Ledger: 1001},
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		count := 0
+		require.NoError(b, eventReader.GetEvents(ctx, cursorRange, nil, topics, nil, nil,
+			func(xdr.DiagnosticEvent, protocol.Cursor, int64, *xdr.Hash) bool {
+				count++
+				return true
+			}))
+		require.Equal(b, 200, count)
+	}
+}
+
+func TestEventIndex(t *testing.T) {
+	topic := [][]byte{{1}}
+	for _, tc := range []struct {
+		contractIDs [][]byte
+		topics      NestedTopicArray
+		txHashes    [][]byte
+		index       string
+	}{
+		{nil, nil, nil, ""},
+		{nil, NestedTopicArray{topic}, nil, "idx_topic1_id"},
+		{nil, NestedTopicArray{nil, nil, topic}, nil, "idx_topic3_id"},
+		{nil, NestedTopicArray{topic, nil, topic}, nil, ""},
+		{[][]byte{{2}}, NestedTopicArray{nil, topic}, nil, ""},
+		{[][]byte{{2}}, NestedTopicArray{topic}, nil, "idx_contract_id_topic1_id"},
+		{[][]byte{{2}}, NestedTopicArray{topic, topic}, nil, ""},
+		{[][]byte{{2}}, nil, nil, ""},
+		{[][]byte{{2}}, NestedTopicArray{topic}, [][]byte{{3}}, "idx_transaction_hash_id"},
+	} {
+		index, ok := eventIndex(tc.contractIDs, tc.topics, tc.txHashes)
+		require.Equal(t, tc.index, index)
+		require.Equal(t, tc.index != "", ok)
+	}
+}
+
+// BenchmarkGetEventsByTopicPosition looks up the events with a given topic
+// at each topic position across all the contracts, which is served by the
+// index of the position.
+func BenchmarkGetEventsByTopicPosition(b *testing.B) {
+	db := NewTestDB(b)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+	now := time.Now().UTC()
+
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 100, 1_000_000, passphrase, EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(b, err)
+
+	// ingest 1000 ledgers with 20 events of 4 topics each, emitted by
+	// different contracts, every topic value being in 1 in 100 events
+	values := make([]xdr.ScVal, 100)
+	for i := range values {
+		value := xdr.ScSymbol(fmt.Sprintf("value%d", i))
+		values[i] = xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &value}
+	}
+	var lcm xdr.LedgerCloseMeta
+	ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
+	for ledger := uint32(1); ledger <= 1000; ledger++ {
+		txMeta := make([]xdr.TransactionMeta, 0, 20)
+		for i := range cap(txMeta) {
+			contractID := xdr.ContractId{byte(ledger), byte(i)}
+			index := int(ledger)*cap(txMeta) + i
+			topics := xdr.ScVec{}
+			for position := range 4 {
+				topics = append(topics, values[(index+7*position)%len(values)])
+			}
+			txMeta = append(txMeta, transactionMetaWithEvents(contractEvent(contractID, topics, topics[0])))
+		}
+		lcm = ledgerCloseMetaWithEvents(ledger, now.Unix(), txMeta...)
+		require.NoError(b, ledgerW.InsertLedger(lcm))
+		require.NoError(b, eventW.InsertEvents(lcm))
+	}
+	require.NoError(b, write.Commit(lcm))
+
+	value, err := values[0].MarshalBinary()
+	require.NoError(b, err)
+	eventReader := NewEventReader(log, db, passphrase)
+	cursorRange := protocol.CursorRange{
+		Start: protocol.Cursor{Ledger: 1},
+		End:   protocol.Cursor{Ledger: 1001},
+	}
+
+	for position := range 4 {
+		topics := make(NestedTopicArray, position+1)
+		topics[position] = [][]byte{value}
+		b.Run(fmt.Sprintf("topic%d", position+1), func(b *testing.B) {
+			for range b.N {
+				count := 0
+				require.NoError(b, eventReader.GetEvents(ctx, cursorRange, nil, topics, nil, nil,
+					func(xdr.DiagnosticEvent, protocol.Cursor, int64, *xdr.Hash) bool {
+						count++
+						return true
+					}))
+				require.Equal(b, 200, count)
+			}
+		})
+	}
+}
+
+// BenchmarkGetEventsByContractAndName looks up the transfers of a few tokens
+// among the events of many, which is served by the index of the contract id
+// and first topic. The lookup of all the events of the tokens, filtered while
+// scanning, is the baseline.
+func BenchmarkGetEventsByContractAndName(b *testing.B) {
+	db := NewTestDB(b)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+	now := time.Now().UTC()
+
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 100, 1_000_000, passphrase, EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(b, err)
+
+	// ingest 1000 ledgers with 20 events each, emitted by 50 tokens, a
+	// quarter of them being transfers
+	names := make([]xdr.ScVal, 0, 4)
+	for _, name := range []string{"transfer", "mint", "burn", "approve"} {
+		symbol := xdr.ScSymbol(name)
+		names = append(names, xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &symbol})
+	}
+	tokens := make([]xdr.ContractId, 50)
+	for i := range tokens {
+		tokens[i] = xdr.ContractId{byte(i + 1)}
+	}
+	var lcm xdr.LedgerCloseMeta
+	ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
+	for ledger := uint32(1); ledger <= 1000; ledger++ {
+		txMeta := make([]xdr.TransactionMeta, 0, 20)
+		for i := range cap(txMeta) {
+			index := int(ledger)*cap(txMeta) + i
+			name := names[index%len(names)]
+			token := tokens[(index/len(names))%len(tokens)]
+			txMeta = append(txMeta, transactionMetaWithEvents(contractEvent(token, xdr.ScVec{name}, name)))
+		}
+		lcm = ledgerCloseMetaWithEvents(ledger, now.Unix(), txMeta...)
+		require.NoError(b, ledgerW.InsertLedger(lcm))
+		require.NoError(b, eventW.InsertEvents(lcm))
+	}
+	require.NoError(b, write.Commit(lcm))
+
+	transfer, err := names[0].MarshalBinary()
+	require.NoError(b, err)
+	contractIDs := make([][]byte, 0, 5)
+	for _, token := range tokens[:5] {
+		contractIDs = append(contractIDs, token[:])
+	}
+	eventReader := NewEventReader(log, db, passphrase)
+	cursorRange := protocol.CursorRange{
+		Start: protocol.Cursor{Ledger: 1},
+		End:   protocol.Cursor{Ledger: 1001},
+	}
+
+	b.Run("contract_and_topic1", func(b *testing.B) {
+		for range b.N {
+			count := 0
+			require.NoError(b, eventReader.GetEvents(ctx, cursorRange, contractIDs, NestedTopicArray{{transfer}},
+				nil, nil,
+				func(xdr.DiagnosticEvent, protocol.Cursor, int64, *xdr.Hash) bool {
+					count++
+					return true
+				}))
+			require.Equal(b, 500, count)
+		}
+	})
+	b.Run("contract_only", func(b *testing.B) {
+		for range b.N {
+			count := 0
+			require.NoError(b, eventReader.GetEvents(ctx, cursorRange, contractIDs, nil, nil, nil,
+				func(event xdr.DiagnosticEvent, _ protocol.Cursor, _ int64, _ *xdr.Hash) bool {
+					if event.Event.Body.MustV0().Topics[0].Equals(names[0]) {
+						count++
+					}
+					return true
+				}))
+			require.Equal(b, 500, count)
+		}
+	})
 }
@@ -0,0 +1,51 @@
+package db
+
+import (
+	sq "github.com/Masterminds/squirrel"
+)
+
+// maxStatementVariables is the maximum number of variables in an SQLite
+// statement (SQLITE_MAX_VARIABLE_NUMBER).
+const maxStatementVariables = 32766
+
+// insertBatch accumulates the rows of a multi-row insert, executing the insert
+// whenever maxSize rows are pending. Larger batches need fewer statements but
+// hold more rows in memory. A non-positive maxSize leaves the batch unbounded.
+// Either way, the rows of a statement are capped so that their values fit in
+// maxStatementVariables.
+type insertBatch struct {
+	stmtCache *sq.StmtCache
+	base      sq.InsertBuilder
+	query     sq.InsertBuilder
+	maxSize   int
+	rows      int
+}
+
+func newInsertBatch(stmtCache *sq.StmtCache, base sq.InsertBuilder, maxSize int) *insertBatch {
+	return &insertBatch{stmtCache: stmtCache, base: base, query: base, maxSize: maxSize}
+}
+
+// add appends a row to the batch, executing the insert if the batch is full.
+func (b *insertBatch) add(values ...any) error {
+	b.query = b.query.Values(values...)
+	b.rows++
+	maxSize := maxStatementVariables / max(len(values), 1)
+	if b.maxSize > 0 && b.maxSize < maxSize {
+		maxSize = b.maxSize
+	}
+	if b.rows >= maxSize {
+		return b.flush()
+	}
+	return nil
+}
+
+// flush executes the insert of the pending rows, if any.
+func (b *insertBatch) flush() error {
+	if b.rows == 0 {
+		return nil
+	}
+	_, err := b.query.RunWith(b.stmtCache).Exec()
+	b.query = b.base
+	b.rows = 0
+	return err
+}
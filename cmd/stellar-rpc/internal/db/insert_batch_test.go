@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+func TestInsertBatches(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	counter := xdr.ScSymbol("COUNTER")
+	value := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &counter}
+
+	// the rows are written by several insert statements
+	writer := NewReadWriter(log.DefaultLogger, db, interfaces.MakeNoOpDeamon(), 3, 10, passphrase,
+		EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	txMeta := make([]xdr.TransactionMeta, 0, 10)
+	for range 10 {
+		txMeta = append(txMeta, transactionMetaWithEvents(
+			contractEvent(xdr.ContractId{}, xdr.ScVec{value}, value),
+			contractEvent(xdr.ContractId{}, xdr.ScVec{value}, value),
+		))
+	}
+	lcm := ledgerCloseMetaWithEvents(1, time.Now().Unix(), txMeta...)
+	require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+	require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+	require.NoError(t, write.EventWriter().InsertEvents(lcm))
+	require.NoError(t, write.Commit(lcm))
+
+	var count int
+	require.NoError(t, db.GetRaw(ctx, &count, "SELECT COUNT(*) FROM "+transactionTableName))
+	require.Equal(t, 10, count)
+	require.NoError(t, db.GetRaw(ctx, &count, "SELECT COUNT(*) FROM "+eventTableName))
+	require.Equal(t, 20, count)
+}
+
+func TestInsertBatchesVariableLimit(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	counter := xdr.ScSymbol("COUNTER")
+	value := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &counter}
+
+	// more events than fit in a single insert statement
+	writer := NewReadWriter(log.DefaultLogger, db, interfaces.MakeNoOpDeamon(), 100_000, 10, passphrase,
+		EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	txMeta := make([]xdr.TransactionMeta, 0, 1500)
+	for range 1500 {
+		txMeta = append(txMeta, transactionMetaWithEvents(
+			contractEvent(xdr.ContractId{}, xdr.ScVec{value}, value),
+			contractEvent(xdr.ContractId{}, xdr.ScVec{value}, value),
+		))
+	}
+	lcm := ledgerCloseMetaWithEvents(1, time.Now().Unix(), txMeta...)
+	require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+	require.NoError(t, write.EventWriter().InsertEvents(lcm))
+	require.NoError(t, write.Commit(lcm))
+
+	var count int
+	require.NoError(t, db.GetRaw(ctx, &count, "SELECT COUNT(*) FROM "+eventTableName))
+	require.Equal(t, 3000, count)
+}
@@ -27,6 +27,14 @@ type LedgerReader interface {
 	StreamLedgerRange(ctx context.Context, startLedger uint32, endLedger uint32, f StreamLedgerFn) error
 	NewTx(ctx context.Context) (LedgerReaderTx, error)
 	GetLatestLedgerSequence(ctx context.Context) (uint32, error)
+	GetLedgerGaps(ctx context.Context, limit uint) ([]LedgerGap, error)
+}
+
+// LedgerGap is a range of ledgers, inclusive of both ends, which is missing
+// between the oldest and the latest ingested ledgers.
+type LedgerGap struct {
+	Start uint32 `json:"start" db:"gap_start"`
+	End   uint32 `json:"end" db:"gap_end"`
 }
 
 type LedgerReaderTx interface {
@@ -76,7 +84,11 @@ func (l ledgerReaderTx) BatchGetLedgers(ctx context.Context, start uint32,
 		})
 
 	results := make([]xdr.LedgerCloseMeta, 0, end-start+1)
-	if err := l.tx.Select(ctx, &results, sql); err != nil {
+	err := retryRead(ctx, nil, "ledger batch", func() error {
+		results = results[:0]
+		return l.tx.Select(ctx, &results, sql)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -183,6 +195,27 @@ func (r ledgerReader) GetLatestLedgerSequence(ctx context.Context) (uint32, erro
 	return getLatestLedgerSequence(ctx, r, r.db.cache)
 }
 
+// GetLedgerGaps returns (up to limit) ranges of missing ledgers between the
+// oldest and the latest ingested ledgers, the most recent first.
+func (r ledgerReader) GetLedgerGaps(ctx context.Context, limit uint) ([]LedgerGap, error) {
+	sequences := sq.Select("sequence", "LEAD(sequence) OVER (ORDER BY sequence) AS next_sequence").
+		From(ledgerCloseMetaTableName)
+	query := sq.Select("sequence + 1 AS gap_start", "next_sequence - 1 AS gap_end").
+		FromSelect(sequences, "s").
+		Where("next_sequence > sequence + 1").
+		OrderBy("sequence DESC").
+		Limit(uint64(limit))
+	var gaps []LedgerGap
+	err := retryRead(ctx, nil, "ledger gaps", func() error {
+		gaps = nil
+		return r.db.Select(ctx, &gaps, query)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't query ledger gaps: %w", err)
+	}
+	return gaps, nil
+}
+
 // getLedgerRangeWithCache uses the latest ledger cache to optimize the query.
 // It only needs to look up the first ledger since we have the latest cached.
 func getLedgerRangeWithCache(ctx context.Context, db readDB,
@@ -194,7 +227,11 @@ func getLedgerRangeWithCache(ctx context.Context, db readDB,
 			fmt.Sprintf("sequence = (SELECT MIN(sequence) FROM %s)", ledgerCloseMetaTableName),
 		)
 	var lcm []xdr.LedgerCloseMeta
-	if err := db.Select(ctx, &lcm, query); err != nil {
+	err := retryRead(ctx, nil, "ledger range", func() error {
+		lcm = nil
+		return db.Select(ctx, &lcm, query)
+	})
+	if err != nil {
 		return ledgerbucketwindow.LedgerRange{}, fmt.Errorf("couldn't query ledger range: %w", err)
 	}
 
@@ -224,7 +261,11 @@ func getLedgerRangeWithoutCache(ctx context.Context, db readDB) (ledgerbucketwin
 		}).OrderBy("lcm.sequence ASC")
 
 	var lcms []xdr.LedgerCloseMeta
-	if err := db.Select(ctx, &lcms, query); err != nil {
+	err := retryRead(ctx, nil, "ledger range", func() error {
+		lcms = nil
+		return db.Select(ctx, &lcms, query)
+	})
+	if err != nil {
 		return ledgerbucketwindow.LedgerRange{}, fmt.Errorf("couldn't query ledger range: %w", err)
 	}
 
@@ -267,7 +308,11 @@ func (l ledgerWriter) trimLedgers(latestLedgerSeq uint32, retentionWindow uint32
 func getLedgerFromDB(ctx context.Context, db readDB, sequence uint32) (xdr.LedgerCloseMeta, bool, error) {
 	sql := sq.Select("meta").From(ledgerCloseMetaTableName).Where(sq.Eq{"sequence": sequence})
 	var results []xdr.LedgerCloseMeta
-	if err := db.Select(ctx, &results, sql); err != nil {
+	err := retryRead(ctx, nil, "ledger", func() error {
+		results = nil
+		return db.Select(ctx, &results, sql)
+	})
+	if err != nil {
 		return xdr.LedgerCloseMeta{}, false, err
 	}
 	switch len(results) {
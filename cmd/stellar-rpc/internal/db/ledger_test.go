@@ -79,7 +79,8 @@ func TestLedgers(t *testing.T) {
 
 	for i := 1; i <= 10; i++ {
 		ledgerSequence := uint32(i)
-		tx, err := NewReadWriter(logger, db, daemon, 150, 15, passphrase).NewTx(context.Background())
+		tx, err := NewReadWriter(logger, db, daemon, 150, 15, passphrase,
+			EventStorage{}, false, false).NewTx(context.Background())
 		require.NoError(t, err)
 
 		ledgerCloseMeta := createLedger(ledgerSequence)
@@ -92,7 +93,8 @@ func TestLedgers(t *testing.T) {
 	assertLedgerRange(t, reader, 1, 10)
 
 	ledgerSequence := uint32(11)
-	tx, err := NewReadWriter(logger, db, daemon, 150, 15, passphrase).NewTx(context.Background())
+	tx, err := NewReadWriter(logger, db, daemon, 150, 15, passphrase,
+		EventStorage{}, false, false).NewTx(context.Background())
 	require.NoError(t, err)
 	ledgerCloseMeta := createLedger(ledgerSequence)
 	require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
@@ -101,7 +103,8 @@ func TestLedgers(t *testing.T) {
 	assertLedgerRange(t, reader, 1, 11)
 
 	ledgerSequence = uint32(12)
-	tx, err = NewReadWriter(logger, db, daemon, 150, 5, passphrase).NewTx(context.Background())
+	tx, err = NewReadWriter(logger, db, daemon, 150, 5, passphrase,
+		EventStorage{}, false, false).NewTx(context.Background())
 	require.NoError(t, err)
 	ledgerCloseMeta = createLedger(ledgerSequence)
 	require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
@@ -114,7 +117,7 @@ func TestGetLedgerRange_NonEmptyDB(t *testing.T) {
 	db := NewTestDB(t)
 	ctx := context.TODO()
 
-	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, EventStorage{}, false, false)
 	write, err := writer.NewTx(ctx)
 	require.NoError(t, err)
 
@@ -145,7 +148,7 @@ func TestGetLedgerRange_SingleDBRow(t *testing.T) {
 	db := NewTestDB(t)
 	ctx := context.TODO()
 
-	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, EventStorage{}, false, false)
 	write, err := writer.NewTx(ctx)
 	require.NoError(t, err)
 
@@ -182,6 +185,61 @@ func TestGetLedgerRange_EmptyDB(t *testing.T) {
 	assert.Equal(t, int64(0), ledgerRange.LastLedger.CloseTime)
 }
 
+func TestDeleteLedgersBefore(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+
+	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 100, passphrase, EventStorage{}, false, false)
+	for i := uint32(1); i <= 5; i++ {
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+		lcm := txMeta(i, true)
+		require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+		require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+		require.NoError(t, write.Commit(lcm))
+	}
+
+	reader := NewLedgerReader(db)
+	require.NoError(t, writer.DeleteLedgersBefore(ctx, 103))
+	ledgerRange, err := reader.GetLedgerRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(103), ledgerRange.FirstLedger.Sequence)
+	assert.Equal(t, uint32(105), ledgerRange.LastLedger.Sequence)
+
+	// once all the ledgers are deleted the database is empty again
+	require.NoError(t, writer.DeleteLedgersBefore(ctx, 200))
+	_, err = writer.GetLatestLedgerSequence(ctx)
+	require.ErrorIs(t, err, ErrEmptyDB)
+	var count int
+	require.NoError(t, db.GetRaw(ctx, &count, "SELECT COUNT(*) FROM "+transactionTableName))
+	assert.Zero(t, count)
+}
+
+func TestGetLedgerGaps(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+
+	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 100, passphrase, EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	ledgerW := write.LedgerWriter()
+	var lcm xdr.LedgerCloseMeta
+	for _, sequence := range []uint32{10, 11, 14, 15, 16, 20} {
+		lcm = createLedger(sequence)
+		require.NoError(t, ledgerW.InsertLedger(lcm))
+	}
+	require.NoError(t, write.Commit(lcm))
+
+	reader := NewLedgerReader(db)
+	gaps, err := reader.GetLedgerGaps(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []LedgerGap{{Start: 17, End: 19}, {Start: 12, End: 13}}, gaps)
+
+	gaps, err = reader.GetLedgerGaps(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []LedgerGap{{Start: 17, End: 19}}, gaps)
+}
+
 func BenchmarkGetLedgerRange(b *testing.B) {
 	testDB, lcms := setupBenchmarkingDB(b)
 	reader := NewLedgerReader(testDB)
@@ -228,7 +286,7 @@ func setupBenchmarkingDB(b *testing.B) (*DB, []xdr.LedgerCloseMeta) {
 	testDB := NewTestDB(b)
 	logger := log.DefaultLogger
 	writer := NewReadWriter(logger, testDB, interfaces.MakeNoOpDeamon(),
-		100, 1_000_000, passphrase)
+		100, 1_000_000, passphrase, EventStorage{}, false, false)
 	write, err := writer.NewTx(context.TODO())
 	require.NoError(b, err)
 
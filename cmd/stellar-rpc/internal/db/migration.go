@@ -10,8 +10,9 @@ import (
 )
 
 const (
-	transactionsMigrationName = "TransactionsTable"
-	eventsMigrationName       = "EventsTable"
+	transactionsMigrationName         = "TransactionsTable"
+	eventsMigrationName               = "EventsTable"
+	transactionContractsMigrationName = "TransactionContractsTable"
 )
 
 type LedgerSeqRange struct {
@@ -208,8 +209,9 @@ func BuildMigrations(
 	// Add new DB migrations here:
 	//
 	currentMigrations := map[string]migrationApplierF{
-		transactionsMigrationName: newTransactionTableMigration,
-		eventsMigrationName:       newEventTableMigration,
+		transactionsMigrationName:         newTransactionTableMigration,
+		eventsMigrationName:               newEventTableMigration,
+		transactionContractsMigrationName: newTransactionContractTableMigration,
 	}
 
 	migrations := make([]Migration, 0, len(currentMigrations))
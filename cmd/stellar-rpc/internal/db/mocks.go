@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"io"
+	"maps"
+	"slices"
 
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/toid"
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerbucketwindow"
@@ -77,6 +80,71 @@ func (txn *MockTransactionHandler) GetTransaction(_ context.Context, hash xdr.Ha
 	return itx, err
 }
 
+func (txn *MockTransactionHandler) GetTransactions(ctx context.Context, hashes []xdr.Hash) (
+	map[xdr.Hash]Transaction, error,
+) {
+	result := make(map[xdr.Hash]Transaction, len(hashes))
+	for _, hash := range hashes {
+		tx, err := txn.GetTransaction(ctx, hash)
+		if errors.Is(err, ErrNoTransaction) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		result[hash] = tx
+	}
+	return result, nil
+}
+
+func (txn *MockTransactionHandler) GetTransactionLedgers(_ context.Context, hashes []xdr.Hash) (
+	map[xdr.Hash]uint32, error,
+) {
+	result := make(map[xdr.Hash]uint32, len(hashes))
+	for _, hash := range hashes {
+		if lcm, ok := txn.txHashToMeta[hash.HexString()]; ok {
+			result[hash] = lcm.LedgerSequence()
+		}
+	}
+	return result, nil
+}
+
+func (txn *MockTransactionHandler) GetContractTransactions(_ context.Context, contractID xdr.ContractId,
+	start toid.ID, endLedger uint32, limit uint,
+) ([]Transaction, error) {
+	ledgers := slices.Sorted(maps.Keys(txn.ledgerSeqToMeta))
+	result := []Transaction{}
+	for _, ledgerSeq := range ledgers {
+		if ledgerSeq < uint32(start.LedgerSequence) || ledgerSeq > endLedger {
+			continue
+		}
+		lcm := *txn.ledgerSeqToMeta[ledgerSeq]
+		reader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(txn.passphrase, lcm)
+		if err != nil {
+			return nil, err
+		}
+		for uint(len(result)) < limit {
+			tx, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			if ledgerSeq == uint32(start.LedgerSequence) && int32(tx.Index) < start.TransactionOrder {
+				continue
+			}
+			if !slices.Contains(invokedContracts(tx), contractID) {
+				continue
+			}
+			parsed, err := ParseTransaction(lcm, tx)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, parsed)
+		}
+	}
+	return result, nil
+}
+
 func (txn *MockTransactionHandler) RegisterMetrics(_, _ prometheus.Observer) {}
 
 type MockLedgerReader struct {
@@ -113,6 +181,10 @@ func (m *MockLedgerReader) GetLatestLedgerSequence(_ context.Context) (uint32, e
 	return 0, nil
 }
 
+func (m *MockLedgerReader) GetLedgerGaps(_ context.Context, _ uint) ([]LedgerGap, error) {
+	return nil, nil
+}
+
 func (m *MockLedgerReader) NewTx(_ context.Context) (LedgerReaderTx, error) {
 	return nil, errors.New("mock NewTx error")
 }
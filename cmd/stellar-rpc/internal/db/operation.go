@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	operationTableName = "operations"
+)
+
+// Operation is an operation of an ingested transaction.
+type Operation struct {
+	LedgerSequence   uint32 `db:"ledger_sequence"`
+	LedgerCloseTime  int64  `db:"ledger_close_time"`
+	ApplicationOrder int32  `db:"application_order"`
+	// Index is the (0-based) index of the operation in its transaction.
+	Index           int32  `db:"operation_index"`
+	TransactionHash []byte `db:"transaction_hash"`
+	Type            int32  `db:"type"` // xdr.OperationType
+	// SourceAccount is the source account (G...) of the operation, which
+	// defaults to the source account of its transaction.
+	SourceAccount string `db:"source_account"`
+	// Successful tells whether the transaction of the operation succeeded.
+	Successful bool   `db:"successful"`
+	Body       []byte `db:"body"` // XDR encoded xdr.OperationBody
+}
+
+// OperationFilter selects the operations with any of the types and any of
+// the source accounts. Empty lists select all the operations.
+type OperationFilter struct {
+	Types    []xdr.OperationType
+	Accounts []string
+}
+
+// OperationReader reads the operations ingested in the operations table.
+type OperationReader interface {
+	// GetOperations returns, in application order, up to limit operations
+	// matching the filter, starting at start (inclusive) and up to endLedger
+	// (inclusive).
+	GetOperations(ctx context.Context, start toid.ID, endLedger uint32, filter OperationFilter,
+		limit uint) ([]Operation, error)
+}
+
+type operationReader struct {
+	log *log.Entry
+	db  db.SessionInterface
+}
+
+func NewOperationReader(log *log.Entry, db db.SessionInterface) OperationReader {
+	return &operationReader{log: log, db: db}
+}
+
+// operationSourceAccount returns the source account of the operation,
+// without the multiplexing id (if any).
+func operationSourceAccount(tx ingest.LedgerTransaction, op xdr.Operation) (string, error) {
+	source := tx.Envelope.SourceAccount()
+	if op.SourceAccount != nil {
+		source = *op.SourceAccount
+	}
+	accountID := source.ToAccountId()
+	return accountID.GetAddress()
+}
+
+// insertOperations ingests the operations of the transactions of a ledger.
+func (txn *transactionHandler) insertOperations(lcm xdr.LedgerCloseMeta, txs []ingest.LedgerTransaction) error {
+	// Rows may already exist if the ledger is ingested several times
+	batch := newInsertBatch(txn.stmtCache, sq.Insert(operationTableName).
+		Options("OR IGNORE").
+		Columns(
+			"ledger_sequence",
+			"application_order",
+			"operation_index",
+			"transaction_hash",
+			"ledger_close_time",
+			"type",
+			"source_account",
+			"successful",
+			"body",
+		), txn.maxBatchSize)
+	for _, tx := range txs {
+		for index, op := range tx.Envelope.Operations() {
+			source, err := operationSourceAccount(tx, op)
+			if err != nil {
+				return err
+			}
+			body, err := op.Body.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			err = batch.add(
+				lcm.LedgerSequence(),
+				tx.Index,
+				index,
+				tx.Result.TransactionHash[:],
+				lcm.LedgerCloseTime(),
+				int32(op.Body.Type),
+				source,
+				tx.Result.Successful(),
+				body,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return batch.flush()
+}
+
+// trimOperations removes the operations of the transactions which fall
+// outside the ledger retention window.
+func (txn *transactionHandler) trimOperations(latestLedgerSeq uint32, retentionWindow uint32) error {
+	if latestLedgerSeq+1 <= retentionWindow {
+		return nil
+	}
+
+	cutoff := latestLedgerSeq + 1 - retentionWindow
+	_, err := sq.StatementBuilder.
+		RunWith(txn.stmtCache).
+		Delete(operationTableName).
+		Where(sq.Lt{"ledger_sequence": cutoff}).
+		Exec()
+	return err
+}
+
+func (r *operationReader) GetOperations(ctx context.Context, start toid.ID, endLedger uint32,
+	filter OperationFilter, limit uint,
+) ([]Operation, error) {
+	rowQ := sq.
+		Select(
+			"ledger_sequence",
+			"ledger_close_time",
+			"application_order",
+			"operation_index",
+			"transaction_hash",
+			"type",
+			"source_account",
+			"successful",
+			"body",
+		).
+		From(operationTableName).
+		// the operation order of the ids is 1-based
+		Where(sq.Expr("(ledger_sequence, application_order, operation_index) >= (?, ?, ?)",
+			start.LedgerSequence, start.TransactionOrder, start.OperationOrder-1)).
+		Where(sq.LtOrEq{"ledger_sequence": endLedger}).
+		OrderBy("ledger_sequence", "application_order", "operation_index").
+		Limit(uint64(limit))
+	if len(filter.Types) > 0 {
+		types := make([]int32, 0, len(filter.Types))
+		for _, opType := range filter.Types {
+			types = append(types, int32(opType))
+		}
+		rowQ = rowQ.Where(sq.Eq{"type": types})
+	}
+	if len(filter.Accounts) > 0 {
+		rowQ = rowQ.Where(sq.Eq{"source_account": filter.Accounts})
+	}
+
+	var operations []Operation
+	err := retryRead(ctx, r.log, "operations", func() error {
+		operations = nil
+		return r.db.Select(ctx, &operations, rowQ)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db read failed for operations: %w", err)
+	}
+	return operations, nil
+}
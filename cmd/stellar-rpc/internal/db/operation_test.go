@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+// paymentMeta returns a ledger with a transaction making a payment from
+// source.
+func paymentMeta(acctSeq uint32, source string) xdr.LedgerCloseMeta {
+	envelope := txEnvelope(acctSeq)
+	sourceAccount := xdr.MustMuxedAddress(source)
+	envelope.V1.Tx.Operations = []xdr.Operation{{
+		SourceAccount: &sourceAccount,
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypePayment,
+			PaymentOp: &xdr.PaymentOp{
+				Destination: sourceAccount,
+				Asset:       xdr.MustNewNativeAsset(),
+				Amount:      10,
+			},
+		},
+	}}
+	hash, err := network.HashTransactionInEnvelope(envelope, passphrase)
+	if err != nil {
+		panic(err)
+	}
+
+	meta := txMeta(acctSeq, true)
+	meta.V1.TxProcessing[0].Result.TransactionHash = hash
+	(*meta.V1.TxSet.V1TxSet.Phases[0].V0Components)[0].TxsMaybeDiscountedFee.Txs[0] = envelope
+	return meta
+}
+
+func TestGetOperations(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+
+	payer := keypair.MustRandom().Address()
+	// the source account of the transactions, without the multiplexing id
+	txSource := txEnvelope(1).SourceAccount().ToAccountId()
+	txSourceAddress := txSource.Address()
+
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 2, passphrase, EventStorage{}, true, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	ledgers := []xdr.LedgerCloseMeta{
+		invokeContractMeta(1, xdr.ContractId{1}),
+		paymentMeta(2, payer),
+	}
+	for _, lcm := range ledgers {
+		require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+		require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+	}
+	require.NoError(t, write.Commit(ledgers[1]))
+
+	reader := NewOperationReader(log, db)
+	start := *toid.New(101, 1, 1)
+	operations, err := reader.GetOperations(ctx, start, 102, OperationFilter{}, 10)
+	require.NoError(t, err)
+	require.Len(t, operations, 2)
+	assert.Equal(t, uint32(101), operations[0].LedgerSequence)
+	assert.Equal(t, int32(xdr.OperationTypeInvokeHostFunction), operations[0].Type)
+	assert.Equal(t, txSourceAddress, operations[0].SourceAccount)
+	assert.True(t, operations[0].Successful)
+	assert.Equal(t, uint32(102), operations[1].LedgerSequence)
+	assert.Equal(t, int32(xdr.OperationTypePayment), operations[1].Type)
+	assert.Equal(t, payer, operations[1].SourceAccount)
+	var body xdr.OperationBody
+	require.NoError(t, xdr.SafeUnmarshal(operations[1].Body, &body))
+	assert.Equal(t, xdr.Int64(10), body.MustPaymentOp().Amount)
+
+	// the filters, start, end ledger and limit bound the results
+	operations, err = reader.GetOperations(ctx, start, 102,
+		OperationFilter{Types: []xdr.OperationType{xdr.OperationTypePayment}}, 10)
+	require.NoError(t, err)
+	require.Len(t, operations, 1)
+	assert.Equal(t, uint32(102), operations[0].LedgerSequence)
+	operations, err = reader.GetOperations(ctx, start, 102, OperationFilter{Accounts: []string{txSourceAddress}}, 10)
+	require.NoError(t, err)
+	require.Len(t, operations, 1)
+	assert.Equal(t, uint32(101), operations[0].LedgerSequence)
+	operations, err = reader.GetOperations(ctx, *toid.New(101, 1, 2), 102, OperationFilter{}, 10)
+	require.NoError(t, err)
+	require.Len(t, operations, 1)
+	assert.Equal(t, uint32(102), operations[0].LedgerSequence)
+	operations, err = reader.GetOperations(ctx, start, 101, OperationFilter{}, 10)
+	require.NoError(t, err)
+	require.Len(t, operations, 1)
+	operations, err = reader.GetOperations(ctx, start, 102, OperationFilter{}, 1)
+	require.NoError(t, err)
+	require.Len(t, operations, 1)
+
+	// the operations follow the retention window
+	write, err = writer.NewTx(ctx)
+	require.NoError(t, err)
+	lcm := txMeta(3, true)
+	require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+	require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+	require.NoError(t, write.Commit(lcm))
+
+	var count int
+	require.NoError(t, db.GetRaw(ctx, &count, "SELECT COUNT(*) FROM "+operationTableName))
+	assert.Equal(t, 1, count)
+}
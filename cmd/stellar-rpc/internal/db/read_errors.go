@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/stellar/go/support/log"
+)
+
+// ErrCorruptDB is wrapped by the read errors caused by a malformed database
+// file or index. Unlike locking errors, they don't go away by retrying.
+var ErrCorruptDB = errors.New("the database appears to be corrupt, " +
+	"check it with `PRAGMA integrity_check` or delete it to re-ingest the ledgers")
+
+const (
+	// maxReadAttempts bounds the attempts of a read failing because the
+	// database is busy or locked.
+	maxReadAttempts    = 4
+	readRetryBaseDelay = 25 * time.Millisecond
+)
+
+func hasSQLiteCode(err error, codes ...sqlite3.ErrNo) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return slices.Contains(codes, sqliteErr.Code)
+}
+
+// isTransientSQLiteError tells whether err is caused by a concurrent
+// connection (e.g. ingestion) holding a lock.
+func isTransientSQLiteError(err error) bool {
+	return hasSQLiteCode(err, sqlite3.ErrBusy, sqlite3.ErrLocked)
+}
+
+func isCorruptSQLiteError(err error) bool {
+	return hasSQLiteCode(err, sqlite3.ErrCorrupt, sqlite3.ErrNotADB)
+}
+
+// classifyReadError wraps err with ErrCorruptDB when it is caused by a
+// corrupt database, logging it since it needs the operator's attention.
+func classifyReadError(logger *log.Entry, description string, err error) error {
+	if err == nil || !isCorruptSQLiteError(err) {
+		return err
+	}
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+	logger.WithError(err).WithField("read", description).Error("database corruption detected")
+	return fmt.Errorf("%w: %w", ErrCorruptDB, err)
+}
+
+// retryRead runs read, retrying it with an exponential backoff (a bounded
+// number of times) when SQLite reports the database is busy or locked.
+// The read must be idempotent (e.g. reset its destination).
+func retryRead(ctx context.Context, logger *log.Entry, description string, read func() error) error {
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+	delay := readRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := read()
+		if err == nil || !isTransientSQLiteError(err) {
+			return classifyReadError(logger, description, err)
+		}
+		if attempt == maxReadAttempts {
+			logger.WithError(err).WithField("read", description).WithField("attempts", attempt).
+				Warn("giving up on database read, the database is still locked")
+			return err
+		}
+		logger.WithError(err).WithField("read", description).WithField("attempt", attempt).
+			Debug("database is locked, retrying read")
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryRead(t *testing.T) {
+	ctx := context.Background()
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+
+	attempts := 0
+	err := retryRead(ctx, nil, "test", func() error {
+		attempts++
+		if attempts < 3 {
+			return busy
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// the attempts are bounded
+	attempts = 0
+	err = retryRead(ctx, nil, "test", func() error {
+		attempts++
+		return busy
+	})
+	require.ErrorIs(t, err, busy)
+	assert.Equal(t, maxReadAttempts, attempts)
+
+	// other errors aren't retried, and corruption is flagged
+	attempts = 0
+	err = retryRead(ctx, nil, "test", func() error {
+		attempts++
+		return sqlite3.Error{Code: sqlite3.ErrCorrupt}
+	})
+	require.ErrorIs(t, err, ErrCorruptDB)
+	assert.Equal(t, 1, attempts)
+
+	otherErr := errors.New("other")
+	err = retryRead(ctx, nil, "test", func() error {
+		return otherErr
+	})
+	require.ErrorIs(t, err, otherErr)
+	assert.NotErrorIs(t, err, ErrCorruptDB)
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+const (
+	// sizeLimitLowWatermarkPercent is the size (relative to the maximum) which
+	// the database is trimmed down to when exceeding the maximum, so that it
+	// isn't trimmed again after every ledger.
+	sizeLimitLowWatermarkPercent = 90
+	// sizeLimitTrimPercent is the share of the stored ledgers removed at every
+	// trimming step.
+	sizeLimitTrimPercent = 10
+)
+
+// SizeLimiter caps the size of the database, on top of the retention window,
+// by trimming the oldest ledgers (and their transactions, operations and
+// events) when the data exceeds the maximum size.
+//
+// The size is the one of the pages used by the data plus the one of the WAL
+// file. The database file doesn't shrink when trimming (until vacuumed), but
+// its free pages are reused, so that it stops growing. The WAL is checkpointed
+// (and truncated) before trimming, since it may only be large because of
+// deferred checkpoints (see CheckpointDeferral).
+type SizeLimiter struct {
+	log          *log.Entry
+	db           *DB
+	maxSize      int64
+	lowWatermark int64
+
+	sizeMetric    prometheus.Gauge
+	trimmedMetric prometheus.Counter
+}
+
+// NewSizeLimiter creates a SizeLimiter trimming the database down to 90% of
+// maxSize (bytes) whenever it exceeds it.
+func NewSizeLimiter(log *log.Entry, db *DB, daemon interfaces.Daemon, maxSize int64) *SizeLimiter {
+	sizeMetric := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: daemon.MetricsNamespace(), Subsystem: "db",
+		Name: "used_size_bytes",
+		Help: "size of the pages used by the data of the database plus its WAL, checked after ingesting every ledger",
+	})
+	trimmedMetric := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: daemon.MetricsNamespace(), Subsystem: "db",
+		Name: "size_limit_trimmed_ledgers_total",
+		Help: "number of ledgers trimmed (before the end of the retention window) to cap the database size",
+	})
+	daemon.MetricsRegistry().MustRegister(sizeMetric, trimmedMetric)
+
+	return &SizeLimiter{
+		log:           log,
+		db:            db,
+		maxSize:       maxSize,
+		lowWatermark:  maxSize * sizeLimitLowWatermarkPercent / 100, //nolint:mnd
+		sizeMetric:    sizeMetric,
+		trimmedMetric: trimmedMetric,
+	}
+}
+
+// Enforce trims the oldest ledgers if the database exceeds the maximum size,
+// until it is below the low watermark. The latest ledger is never trimmed.
+func (l *SizeLimiter) Enforce(ctx context.Context) error {
+	size, err := l.db.usedSize(ctx)
+	if err != nil {
+		return err
+	}
+	l.sizeMetric.Set(float64(size))
+	if size <= l.maxSize {
+		return nil
+	}
+	if err := l.db.checkpoint(ctx); err != nil {
+		return err
+	}
+	if size, err = l.db.usedSize(ctx); err != nil {
+		return err
+	}
+	l.sizeMetric.Set(float64(size))
+
+	ledgerReader := NewLedgerReader(l.db)
+	for size > l.lowWatermark {
+		ledgerRange, err := ledgerReader.GetLedgerRange(ctx)
+		if err != nil {
+			return err
+		}
+		first, last := ledgerRange.FirstLedger.Sequence, ledgerRange.LastLedger.Sequence
+		if first >= last {
+			l.log.WithField("size", size).WithField("maxSize", l.maxSize).
+				Warn("the database exceeds its maximum size, but only holds the latest ledger")
+			return nil
+		}
+		trimmed := max((last-first+1)*sizeLimitTrimPercent/100, 1) //nolint:mnd
+		cutoff := min(first+trimmed, last)
+		if err := l.trim(ctx, last, last+1-cutoff); err != nil {
+			return fmt.Errorf("could not trim the database: %w", err)
+		}
+		l.trimmedMetric.Add(float64(cutoff - first))
+
+		if size, err = l.db.usedSize(ctx); err != nil {
+			return err
+		}
+		l.sizeMetric.Set(float64(size))
+		l.log.WithField("size", size).WithField("maxSize", l.maxSize).
+			WithField("firstLedger", cutoff).WithField("lastLedger", last).
+			Warn("trimmed the oldest ledgers, since the database exceeded its maximum size")
+	}
+	return nil
+}
+
+// trim removes the data of the ledgers outside the given retention window, in
+// a write transaction. The WAL is checkpointed even if checkpoints are deferred,
+// since the size includes it.
+func (l *SizeLimiter) trim(ctx context.Context, latestLedgerSeq uint32, retentionWindow uint32) error {
+	rw := readWriter{log: l.log, db: l.db}
+	tx, err := rw.newWriteTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			l.log.WithError(err).Warn("could not rollback the trimming transaction")
+		}
+	}()
+	if err := tx.trim(latestLedgerSeq, retentionWindow); err != nil {
+		return err
+	}
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	return l.db.checkpoint(ctx)
+}
+
+// usedSize returns the size of the pages used by the data, excluding the free
+// pages left by deleted rows, plus the size of the WAL file.
+func (d *DB) usedSize(ctx context.Context) (int64, error) {
+	size, err := d.size(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var freePages, pageSize int64
+	// TODO: this is sqlite-only, it shouldn't be here
+	if err := d.GetRaw(ctx, &freePages, "PRAGMA freelist_count"); err != nil {
+		return 0, fmt.Errorf("could not get the database free page count: %w", err)
+	}
+	if err := d.GetRaw(ctx, &pageSize, "PRAGMA page_size"); err != nil {
+		return 0, fmt.Errorf("could not get the database page size: %w", err)
+	}
+	walSize, err := d.walSize(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return size - freePages*pageSize + walSize, nil
+}
+
+// walSize returns the size of the WAL file of the database, 0 if it has none
+// (e.g. when not in WAL mode).
+func (d *DB) walSize(ctx context.Context) (int64, error) {
+	var path string
+	// TODO: this is sqlite-only, it shouldn't be here
+	if err := d.GetRaw(ctx, &path, "SELECT file FROM pragma_database_list WHERE name = 'main'"); err != nil {
+		return 0, fmt.Errorf("could not get the database file: %w", err)
+	}
+	if path == "" {
+		// in-memory database
+		return 0, nil
+	}
+	info, err := os.Stat(path + "-wal")
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not get the WAL size: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// checkpoint copies the pages of the WAL into the database file and truncates
+// the WAL.
+func (d *DB) checkpoint(ctx context.Context) error {
+	// TODO: this is sqlite-only, it shouldn't be here
+	if _, err := d.ExecRaw(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("could not checkpoint the WAL: %w", err)
+	}
+	return nil
+}
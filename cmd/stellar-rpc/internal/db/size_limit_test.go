@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+func TestSizeLimiter(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	logger := log.DefaultLogger
+
+	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 100, passphrase, EventStorage{}, false, false)
+	for i := uint32(1); i <= 20; i++ {
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+		lcm := txMeta(i, true)
+		require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+		require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+		require.NoError(t, write.Commit(lcm))
+	}
+	ledgerReader := NewLedgerReader(db)
+	ledgerRange, err := ledgerReader.GetLedgerRange(ctx)
+	require.NoError(t, err)
+	first, last := ledgerRange.FirstLedger.Sequence, ledgerRange.LastLedger.Sequence
+	size, err := db.usedSize(ctx)
+	require.NoError(t, err)
+
+	// nothing is trimmed below the maximum size
+	limiter := NewSizeLimiter(logger, db, interfaces.MakeNoOpDeamon(), size)
+	require.NoError(t, limiter.Enforce(ctx))
+	ledgerRange, err = ledgerReader.GetLedgerRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, ledgerRange.FirstLedger.Sequence)
+
+	// the oldest ledgers are trimmed, but never the latest one
+	limiter = NewSizeLimiter(logger, db, interfaces.MakeNoOpDeamon(), 1)
+	require.NoError(t, limiter.Enforce(ctx))
+	ledgerRange, err = ledgerReader.GetLedgerRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last, ledgerRange.FirstLedger.Sequence)
+	assert.Equal(t, last, ledgerRange.LastLedger.Sequence)
+	var count int
+	require.NoError(t, db.GetRaw(ctx, &count, "SELECT COUNT(*) FROM "+transactionTableName))
+	assert.Equal(t, 1, count)
+}
+
+func TestSizeLimiterCountsWAL(t *testing.T) {
+	db, err := OpenSQLiteDB(path.Join(t.TempDir(), "db.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.TODO()
+	logger := log.DefaultLogger
+	deferral := NewCheckpointDeferral(db, interfaces.MakeNoOpDeamon(), time.Millisecond, 10, 0)
+	writer := NewReadWriter(logger, db, interfaces.MakeNoOpDeamon(), 10, 100, passphrase, EventStorage{}, false, false)
+	for i := uint32(1); i <= 5; i++ {
+		if i == 5 {
+			// the checkpoint of the last ledger is deferred
+			deferral.ObserveQuery(time.Second)
+		}
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+		lcm := txMeta(i, true)
+		require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+		require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+		require.NoError(t, write.Commit(lcm))
+	}
+	walSize, err := db.walSize(ctx)
+	require.NoError(t, err)
+	require.Positive(t, walSize)
+	size, err := db.usedSize(ctx)
+	require.NoError(t, err)
+	ledgerReader := NewLedgerReader(db)
+	ledgerRange, err := ledgerReader.GetLedgerRange(ctx)
+	require.NoError(t, err)
+	first := ledgerRange.FirstLedger.Sequence
+
+	// exceeding the maximum size only because of the WAL checkpoints it
+	// instead of trimming
+	limiter := NewSizeLimiter(logger, db, interfaces.MakeNoOpDeamon(), size-1)
+	require.NoError(t, limiter.Enforce(ctx))
+	walSize, err = db.walSize(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, walSize)
+	ledgerRange, err = ledgerReader.GetLedgerRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first, ledgerRange.FirstLedger.Sequence)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/stellar/go/support/db"
+)
+
+// SnapshotInfo describes a snapshot of the database.
+type SnapshotInfo struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"sizeBytes"`
+	// LatestLedger is the latest ledger ingested in the snapshot (0 if the
+	// database was empty).
+	LatestLedger uint32 `json:"latestLedger"`
+}
+
+// Snapshot writes a consistent copy of the database to a new file at path,
+// which must not exist.
+//
+// Unlike Vacuum, it doesn't block the write transactions: the copy is made
+// from a read transaction, which only sees the ledgers committed before it
+// started.
+func (d *DB) Snapshot(ctx context.Context, path string) (SnapshotInfo, error) {
+	if _, err := os.Stat(path); err == nil {
+		return SnapshotInfo{}, fmt.Errorf("snapshot file %q already exists", path)
+	}
+	// TODO: this is sqlite-only, it shouldn't be here
+	if _, err := d.ExecRaw(ctx, "VACUUM INTO ?", path); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("could not snapshot the database: %w", err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+
+	// The ledgers may have been ingested since, so the latest ledger is read
+	// from the snapshot itself
+	session, err := db.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return SnapshotInfo{}, fmt.Errorf("could not open the snapshot: %w", err)
+	}
+	defer session.Close()
+	snapshot := &DB{SessionInterface: session, cache: &dbCache{}, writeLock: &sync.Mutex{}}
+	latestLedger, err := NewLedgerReader(snapshot).GetLatestLedgerSequence(ctx)
+	if err != nil && !errors.Is(err, ErrEmptyDB) {
+		return SnapshotInfo{}, fmt.Errorf("could not read the latest ledger of the snapshot: %w", err)
+	}
+	return SnapshotInfo{
+		Path:         path,
+		SizeBytes:    stat.Size(),
+		LatestLedger: latestLedger,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+func TestSnapshot(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+
+	writer := NewReadWriter(log.DefaultLogger, db, interfaces.MakeNoOpDeamon(), 10, 10_000, passphrase,
+		EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	ledgerW := write.LedgerWriter()
+	for i := uint32(1); i <= 10; i++ {
+		require.NoError(t, ledgerW.InsertLedger(createLedger(i)))
+	}
+	require.NoError(t, write.Commit(createLedger(10)))
+
+	path := filepath.Join(t.TempDir(), "snapshot.sqlite")
+	info, err := db.Snapshot(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, path, info.Path)
+	assert.Positive(t, info.SizeBytes)
+	assert.Equal(t, uint32(10), info.LatestLedger)
+
+	snapshot, err := OpenSQLiteDB(path)
+	require.NoError(t, err)
+	defer snapshot.Close()
+	ledgerRange, err := NewLedgerReader(snapshot).GetLedgerRange(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), ledgerRange.FirstLedger.Sequence)
+	assert.Equal(t, uint32(10), ledgerRange.LastLedger.Sequence)
+
+	// existing files aren't overwritten
+	_, err = db.Snapshot(ctx, path)
+	require.Error(t, err)
+}
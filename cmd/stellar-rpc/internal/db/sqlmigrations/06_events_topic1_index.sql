@@ -0,0 +1,10 @@
+-- +migrate Up
+
+-- index events by their first topic (usually the event name) and id, so that
+-- events can be looked up by name across contracts within a cursor range
+DROP INDEX idx_topic1;
+CREATE INDEX idx_topic1_id ON events (topic1, id);
+
+-- +migrate Down
+DROP INDEX idx_topic1_id;
+CREATE INDEX idx_topic1 ON events (topic1);
@@ -0,0 +1,14 @@
+-- +migrate Up
+
+-- indexing table to find the transactions which invoked a contract
+CREATE TABLE transaction_contracts (
+    contract_id BLOB NOT NULL, -- 32-byte binary
+    ledger_sequence INTEGER NOT NULL,
+    application_order INTEGER NOT NULL,
+    PRIMARY KEY (contract_id, ledger_sequence, application_order)
+);
+
+CREATE INDEX idx_transaction_contracts_ledger_sequence ON transaction_contracts(ledger_sequence);
+
+-- +migrate Down
+drop table transaction_contracts cascade;
@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- operations of the ingested transactions, only populated when the operation
+-- ingestion is enabled
+CREATE TABLE operations (
+    ledger_sequence INTEGER NOT NULL,
+    application_order INTEGER NOT NULL,
+    operation_index INTEGER NOT NULL,
+    transaction_hash BLOB NOT NULL, -- 32-byte binary
+    ledger_close_time INTEGER NOT NULL,
+    type INTEGER NOT NULL,
+    source_account TEXT NOT NULL, -- G... strkey
+    successful BOOLEAN NOT NULL,
+    body BLOB NOT NULL, -- XDR-encoded xdr.OperationBody
+    PRIMARY KEY (ledger_sequence, application_order, operation_index)
+);
+
+CREATE INDEX idx_operations_source_account ON operations (source_account, ledger_sequence);
+
+-- +migrate Down
+drop table operations cascade;
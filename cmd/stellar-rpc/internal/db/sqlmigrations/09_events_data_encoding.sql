@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- how event_data is encoded (see EventDataEncoding), which can change across
+-- restarts with the event storage format configuration
+ALTER TABLE events ADD COLUMN event_data_encoding INTEGER NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE events DROP COLUMN event_data_encoding;
@@ -0,0 +1,13 @@
+-- +migrate Up
+
+-- index events by each of their other topics and id, so that events can be
+-- looked up by any single topic position across contracts within a cursor
+-- range
+CREATE INDEX idx_topic2_id ON events (topic2, id);
+CREATE INDEX idx_topic3_id ON events (topic3, id);
+CREATE INDEX idx_topic4_id ON events (topic4, id);
+
+-- +migrate Down
+DROP INDEX idx_topic2_id;
+DROP INDEX idx_topic3_id;
+DROP INDEX idx_topic4_id;
@@ -0,0 +1,9 @@
+-- +migrate Up
+
+-- index events by contract id, first topic (usually the event name) and id, so
+-- that the events of given contracts can be looked up by name within a cursor
+-- range, e.g. the transfers of a set of tokens (see eventIndex)
+CREATE INDEX idx_contract_id_topic1_id ON events (contract_id, topic1, id);
+
+-- +migrate Down
+DROP INDEX idx_contract_id_topic1_id;
@@ -0,0 +1,13 @@
+-- +migrate Up
+
+-- indexing table of the keys of the contract data entries (of both
+-- durabilities), only populated when the contract data indexing is enabled
+CREATE TABLE contract_data_keys (
+    contract_id BLOB NOT NULL, -- 32-byte binary
+    ledger_key BLOB NOT NULL, -- XDR-encoded xdr.LedgerKey
+    durability INTEGER NOT NULL, -- xdr.ContractDataDurability
+    PRIMARY KEY (contract_id, ledger_key)
+) WITHOUT ROWID;
+
+-- +migrate Down
+drop table contract_data_keys cascade;
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- index events by transaction hash and id, so that the events of a set of
+-- transactions can be looked up within a cursor range (see eventIndex)
+CREATE INDEX idx_transaction_hash_id ON events (transaction_hash, id);
+
+-- +migrate Down
+DROP INDEX idx_transaction_hash_id;
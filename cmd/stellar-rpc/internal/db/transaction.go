@@ -13,6 +13,7 @@ import (
 	"github.com/stellar/go/ingest"
 	"github.com/stellar/go/support/db"
 	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerbucketwindow"
@@ -45,6 +46,17 @@ type TransactionWriter interface {
 // TransactionReader provides all the public ways to read from the DB.
 type TransactionReader interface {
 	GetTransaction(ctx context.Context, hash xdr.Hash) (Transaction, error)
+	// GetTransactions fetches several transactions at once. Transactions
+	// which are not found are absent from the resulting map.
+	GetTransactions(ctx context.Context, hashes []xdr.Hash) (map[xdr.Hash]Transaction, error)
+	// GetTransactionLedgers returns the ledgers of several transactions,
+	// without decoding them. Transactions which are not found are absent from
+	// the resulting map.
+	GetTransactionLedgers(ctx context.Context, hashes []xdr.Hash) (map[xdr.Hash]uint32, error)
+	// GetContractTransactions fetches the transactions which invoked a
+	// contract, see transactionHandler.GetContractTransactions.
+	GetContractTransactions(ctx context.Context, contractID xdr.ContractId, start toid.ID, endLedger uint32,
+		limit uint) ([]Transaction, error)
 }
 
 type transactionHandler struct {
@@ -52,6 +64,10 @@ type transactionHandler struct {
 	db         db.SessionInterface
 	stmtCache  *sq.StmtCache
 	passphrase string
+	// maxBatchSize is the maximum number of rows per insert statement
+	maxBatchSize int
+	// ingestOperations enables the ingestion of the operations table
+	ingestOperations bool
 
 	ingestMetric, countMetric prometheus.Observer
 }
@@ -90,11 +106,13 @@ func (txn *transactionHandler) InsertTransactions(lcm xdr.LedgerCloseMeta) error
 	}
 
 	transactions := make(map[xdr.Hash]ingest.LedgerTransaction, txCount)
+	ledgerTxs := make([]ingest.LedgerTransaction, 0, txCount)
 	for i := range txCount {
 		tx, err := reader.Read()
 		if err != nil {
 			return fmt.Errorf("failed reading tx %d: %w", i, err)
 		}
+		ledgerTxs = append(ledgerTxs, tx)
 
 		// For fee-bump transactions, we store lookup entries for both the outer
 		// and inner hashes.
@@ -104,17 +122,30 @@ func (txn *transactionHandler) InsertTransactions(lcm xdr.LedgerCloseMeta) error
 		transactions[tx.Result.TransactionHash] = tx
 	}
 
-	query := sq.Insert(transactionTableName).
-		Columns("hash", "ledger_sequence", "application_order")
+	batch := newInsertBatch(txn.stmtCache,
+		sq.Insert(transactionTableName).Columns("hash", "ledger_sequence", "application_order"),
+		txn.maxBatchSize)
 	for hash, tx := range transactions {
-		query = query.Values(hash[:], lcm.LedgerSequence(), tx.Index)
+		if err = batch.add(hash[:], lcm.LedgerSequence(), tx.Index); err != nil {
+			return err
+		}
+	}
+	if err = batch.flush(); err != nil {
+		return err
+	}
+	if err = txn.insertTransactionContracts(lcm.LedgerSequence(), ledgerTxs); err != nil {
+		return err
+	}
+	if txn.ingestOperations {
+		if err = txn.insertOperations(lcm, ledgerTxs); err != nil {
+			return err
+		}
 	}
-	_, err = query.RunWith(txn.stmtCache).Exec()
 
 	L.WithField("duration", time.Since(start)).
 		Debugf("Ingested %d transaction lookups", len(transactions))
 
-	return err
+	return nil
 }
 
 func (txn *transactionHandler) RegisterMetrics(ingest, count prometheus.Observer) {
@@ -186,7 +217,11 @@ func (txn *transactionHandler) getTransactionByHash(ctx context.Context, hash xd
 		Where(sq.Eq{"t.hash": hash[:]}).
 		Limit(1)
 
-	if err := txn.db.Select(ctx, &rows, rowQ); err != nil {
+	err := retryRead(ctx, txn.log, "transaction", func() error {
+		rows = nil
+		return txn.db.Select(ctx, &rows, rowQ)
+	})
+	if err != nil {
 		return xdr.LedgerCloseMeta{}, ingest.LedgerTransaction{},
 			fmt.Errorf("db read failed for txhash %s: %w", hex.EncodeToString(hash[:]), err)
 	} else if len(rows) < 1 {
@@ -194,20 +229,126 @@ func (txn *transactionHandler) getTransactionByHash(ctx context.Context, hash xd
 	}
 
 	txIndex, lcm := rows[0].TxIndex, rows[0].Lcm
+	ledgerTx, err := txn.readTransaction(lcm, txIndex, hash)
+	return lcm, ledgerTx, err
+}
+
+// readTransaction reads the transaction at the given application order from
+// the ledger close meta.
+func (txn *transactionHandler) readTransaction(lcm xdr.LedgerCloseMeta, txIndex int, hash xdr.Hash) (
+	ingest.LedgerTransaction, error,
+) {
 	reader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(txn.passphrase, lcm)
 	if err != nil {
-		return lcm, ingest.LedgerTransaction{},
+		return ingest.LedgerTransaction{},
 			fmt.Errorf("failed to create ledger reader: %w", err)
 	}
 	err = reader.Seek(txIndex - 1)
 	if err != nil {
-		return lcm, ingest.LedgerTransaction{},
+		return ingest.LedgerTransaction{},
 			fmt.Errorf("failed to index to tx %d in ledger %d (txhash=%s): %w",
 				txIndex, lcm.LedgerSequence(), hash, err)
 	}
 
-	ledgerTx, err := reader.Read()
-	return lcm, ledgerTx, err
+	return reader.Read()
+}
+
+// GetTransactions fetches the transactions with the given hashes using a
+// single query, see GetTransaction.
+func (txn *transactionHandler) GetTransactions(ctx context.Context, hashes []xdr.Hash) (
+	map[xdr.Hash]Transaction, error,
+) {
+	start := time.Now()
+	result := make(map[xdr.Hash]Transaction, len(hashes))
+	if len(hashes) == 0 {
+		return result, nil
+	}
+
+	keys := make([][]byte, 0, len(hashes))
+	for _, hash := range hashes {
+		keys = append(keys, hash[:])
+	}
+	var rows []struct {
+		Hash    []byte              `db:"hash"`
+		TxIndex int                 `db:"application_order"`
+		Lcm     xdr.LedgerCloseMeta `db:"meta"`
+	}
+	rowQ := sq.
+		Select("t.hash", "t.application_order", "lcm.meta").
+		From(transactionTableName + " t").
+		Join(ledgerCloseMetaTableName + " lcm ON (t.ledger_sequence = lcm.sequence)").
+		Where(sq.Eq{"t.hash": keys})
+	err := retryRead(ctx, txn.log, "transactions", func() error {
+		rows = nil
+		return txn.db.Select(ctx, &rows, rowQ)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db read failed for %d txhashes: %w", len(hashes), err)
+	}
+
+	for _, row := range rows {
+		var hash xdr.Hash
+		if len(row.Hash) != len(hash) {
+			return nil, fmt.Errorf("unexpected txhash length (%d)", len(row.Hash))
+		}
+		copy(hash[:], row.Hash)
+		ingestTx, err := txn.readTransaction(row.Lcm, row.TxIndex, hash)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := ParseTransaction(row.Lcm, ingestTx)
+		if err != nil {
+			return nil, err
+		}
+		result[hash] = tx
+	}
+
+	txn.log.
+		WithField("requested", len(hashes)).
+		WithField("found", len(result)).
+		WithField("duration", time.Since(start)).
+		Debug("Fetched and encoded transactions")
+
+	return result, nil
+}
+
+func (txn *transactionHandler) GetTransactionLedgers(ctx context.Context, hashes []xdr.Hash) (
+	map[xdr.Hash]uint32, error,
+) {
+	result := make(map[xdr.Hash]uint32, len(hashes))
+	if len(hashes) == 0 {
+		return result, nil
+	}
+
+	keys := make([][]byte, 0, len(hashes))
+	for _, hash := range hashes {
+		keys = append(keys, hash[:])
+	}
+	var rows []struct {
+		Hash   []byte `db:"hash"`
+		Ledger uint32 `db:"ledger_sequence"`
+	}
+	rowQ := sq.
+		Select("hash", "ledger_sequence").
+		From(transactionTableName).
+		Where(sq.Eq{"hash": keys})
+	err := retryRead(ctx, txn.log, "transaction ledgers", func() error {
+		rows = nil
+		return txn.db.Select(ctx, &rows, rowQ)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db read failed for %d txhashes: %w", len(hashes), err)
+	}
+
+	for _, row := range rows {
+		var hash xdr.Hash
+		if len(row.Hash) != len(hash) {
+			return nil, fmt.Errorf("unexpected txhash length (%d)", len(row.Hash))
+		}
+		copy(hash[:], row.Hash)
+		result[hash] = row.Ledger
+	}
+	return result, nil
 }
 
 func ParseTransaction(lcm xdr.LedgerCloseMeta, ingestTx ingest.LedgerTransaction) (Transaction, error) {
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	transactionContractTableName = "transaction_contracts"
+)
+
+// invokedContracts returns the contracts invoked by a transaction, either
+// directly (by an InvokeHostFunction operation) or through the authorized
+// sub-invocations of the operation.
+func invokedContracts(tx ingest.LedgerTransaction) []xdr.ContractId {
+	var contracts []xdr.ContractId
+	add := func(address xdr.ScAddress) {
+		if address.Type != xdr.ScAddressTypeScAddressTypeContract || address.ContractId == nil {
+			return
+		}
+		if !slices.Contains(contracts, *address.ContractId) {
+			contracts = append(contracts, *address.ContractId)
+		}
+	}
+	var addInvocation func(invocation xdr.SorobanAuthorizedInvocation)
+	addInvocation = func(invocation xdr.SorobanAuthorizedInvocation) {
+		function := invocation.Function
+		if function.Type == xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeContractFn &&
+			function.ContractFn != nil {
+			add(function.ContractFn.ContractAddress)
+		}
+		for _, subInvocation := range invocation.SubInvocations {
+			addInvocation(subInvocation)
+		}
+	}
+
+	for _, op := range tx.Envelope.Operations() {
+		invoke, ok := op.Body.GetInvokeHostFunctionOp()
+		if !ok {
+			continue
+		}
+		if invoke.HostFunction.Type == xdr.HostFunctionTypeHostFunctionTypeInvokeContract &&
+			invoke.HostFunction.InvokeContract != nil {
+			add(invoke.HostFunction.InvokeContract.ContractAddress)
+		}
+		for _, auth := range invoke.Auth {
+			addInvocation(auth.RootInvocation)
+		}
+	}
+	return contracts
+}
+
+// insertTransactionContracts indexes the transactions of a ledger by the
+// contracts they invoked.
+func (txn *transactionHandler) insertTransactionContracts(ledgerSeq uint32, txs []ingest.LedgerTransaction) error {
+	// Rows may already exist if the ledger is ingested by several migrations
+	batch := newInsertBatch(txn.stmtCache, sq.Insert(transactionContractTableName).
+		Options("OR IGNORE").
+		Columns("contract_id", "ledger_sequence", "application_order"),
+		txn.maxBatchSize)
+	for _, tx := range txs {
+		for _, contractID := range invokedContracts(tx) {
+			if err := batch.add(contractID[:], ledgerSeq, tx.Index); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.flush()
+}
+
+// trimTransactionContracts removes the contract index entries of the
+// transactions which fall outside the ledger retention window.
+func (txn *transactionHandler) trimTransactionContracts(latestLedgerSeq uint32, retentionWindow uint32) error {
+	if latestLedgerSeq+1 <= retentionWindow {
+		return nil
+	}
+
+	cutoff := latestLedgerSeq + 1 - retentionWindow
+	_, err := sq.StatementBuilder.
+		RunWith(txn.stmtCache).
+		Delete(transactionContractTableName).
+		Where(sq.Lt{"ledger_sequence": cutoff}).
+		Exec()
+	return err
+}
+
+// GetContractTransactions returns, in application order, up to limit
+// transactions which invoked the given contract, starting at start (inclusive)
+// and up to endLedger (inclusive).
+func (txn *transactionHandler) GetContractTransactions(ctx context.Context, contractID xdr.ContractId,
+	start toid.ID, endLedger uint32, limit uint,
+) ([]Transaction, error) {
+	var rows []struct {
+		LedgerSeq uint32 `db:"ledger_sequence"`
+		TxIndex   int    `db:"application_order"`
+	}
+	rowQ := sq.
+		Select("ledger_sequence", "application_order").
+		From(transactionContractTableName).
+		Where(sq.Eq{"contract_id": contractID[:]}).
+		Where(sq.Or{
+			sq.Gt{"ledger_sequence": start.LedgerSequence},
+			sq.And{
+				sq.Eq{"ledger_sequence": start.LedgerSequence},
+				sq.GtOrEq{"application_order": start.TransactionOrder},
+			},
+		}).
+		Where(sq.LtOrEq{"ledger_sequence": endLedger}).
+		OrderBy("ledger_sequence", "application_order").
+		Limit(uint64(limit))
+	err := retryRead(ctx, txn.log, "contract transactions", func() error {
+		rows = nil
+		return txn.db.Select(ctx, &rows, rowQ)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("db read failed for contract transactions: %w", err)
+	}
+
+	result := make([]Transaction, 0, len(rows))
+	var reader *ingest.LedgerTransactionReader
+	var lcm xdr.LedgerCloseMeta
+	for _, row := range rows {
+		if reader == nil || lcm.LedgerSequence() != row.LedgerSeq {
+			var found bool
+			var err error
+			lcm, found, err = getLedgerFromDB(ctx, txn.db, row.LedgerSeq)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				// the ledger was trimmed after reading the index
+				reader = nil
+				continue
+			}
+			reader, err = ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(txn.passphrase, lcm)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create ledger reader: %w", err)
+			}
+		}
+		if err := reader.Seek(row.TxIndex - 1); err != nil {
+			return nil, fmt.Errorf("failed to index to tx %d in ledger %d: %w", row.TxIndex, row.LedgerSeq, err)
+		}
+		ingestTx, err := reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		tx, err := ParseTransaction(lcm, ingestTx)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tx)
+	}
+	return result, nil
+}
+
+type transactionContractTableMigration struct {
+	firstLedger uint32
+	lastLedger  uint32
+	writer      *transactionHandler
+}
+
+func (t *transactionContractTableMigration) ApplicableRange() LedgerSeqRange {
+	return LedgerSeqRange{
+		First: t.firstLedger,
+		Last:  t.lastLedger,
+	}
+}
+
+func (t *transactionContractTableMigration) Apply(_ context.Context, meta xdr.LedgerCloseMeta) error {
+	reader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(t.writer.passphrase, meta)
+	if err != nil {
+		return err
+	}
+	txs := make([]ingest.LedgerTransaction, 0, meta.CountTransactions())
+	for {
+		tx, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	}
+	return t.writer.insertTransactionContracts(meta.LedgerSequence(), txs)
+}
+
+func newTransactionContractTableMigration(
+	_ context.Context,
+	logger *log.Entry,
+	passphrase string,
+	ledgerSeqRange LedgerSeqRange,
+) migrationApplierFactory {
+	return migrationApplierFactoryF(func(db *DB) (MigrationApplier, error) {
+		migration := transactionContractTableMigration{
+			firstLedger: ledgerSeqRange.First,
+			lastLedger:  ledgerSeqRange.Last,
+			writer: &transactionHandler{
+				log:        logger,
+				db:         db,
+				stmtCache:  sq.NewStmtCache(db.GetTx()),
+				passphrase: passphrase,
+			},
+		}
+		return &migration, nil
+	})
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+// invokeContractMeta returns a ledger with a transaction invoking contractID,
+// which authorizes calls to the authorized contracts.
+func invokeContractMeta(acctSeq uint32, contractID xdr.ContractId, authorized ...xdr.ContractId) xdr.LedgerCloseMeta {
+	address := func(id xdr.ContractId) xdr.ScAddress {
+		return xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &id}
+	}
+	invocation := xdr.SorobanAuthorizedInvocation{
+		Function: xdr.SorobanAuthorizedFunction{
+			Type: xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeContractFn,
+			ContractFn: &xdr.InvokeContractArgs{
+				ContractAddress: address(contractID),
+				FunctionName:    "swap",
+			},
+		},
+	}
+	for _, id := range authorized {
+		invocation.SubInvocations = append(invocation.SubInvocations, xdr.SorobanAuthorizedInvocation{
+			Function: xdr.SorobanAuthorizedFunction{
+				Type: xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeContractFn,
+				ContractFn: &xdr.InvokeContractArgs{
+					ContractAddress: address(id),
+					FunctionName:    "transfer",
+				},
+			},
+		})
+	}
+
+	envelope := txEnvelope(acctSeq)
+	envelope.V1.Tx.Operations = []xdr.Operation{{
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+				HostFunction: xdr.HostFunction{
+					Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+					InvokeContract: &xdr.InvokeContractArgs{
+						ContractAddress: address(contractID),
+						FunctionName:    "swap",
+					},
+				},
+				Auth: []xdr.SorobanAuthorizationEntry{{
+					Credentials:    xdr.SorobanCredentials{Type: xdr.SorobanCredentialsTypeSorobanCredentialsSourceAccount},
+					RootInvocation: invocation,
+				}},
+			},
+		},
+	}}
+	hash, err := network.HashTransactionInEnvelope(envelope, passphrase)
+	if err != nil {
+		panic(err)
+	}
+
+	meta := txMeta(acctSeq, true)
+	meta.V1.TxProcessing[0].Result.TransactionHash = hash
+	(*meta.V1.TxSet.V1TxSet.Phases[0].V0Components)[0].TxsMaybeDiscountedFee.Txs[0] = envelope
+	return meta
+}
+
+func TestGetContractTransactions(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+	log := log.DefaultLogger
+
+	contractA, contractB := xdr.ContractId{1}, xdr.ContractId{2}
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 2, passphrase, EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	// ledger 101 invokes A, which calls B; ledger 102 invokes B
+	ledgers := []xdr.LedgerCloseMeta{
+		invokeContractMeta(1, contractA, contractB),
+		invokeContractMeta(2, contractB),
+	}
+	for _, lcm := range ledgers {
+		require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+		require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+	}
+	require.NoError(t, write.Commit(ledgers[1]))
+
+	reader := NewTransactionReader(log, db, passphrase)
+	start := *toid.New(101, 1, 1)
+	txs, err := reader.GetContractTransactions(ctx, contractA, start, 102, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, uint32(101), txs[0].Ledger.Sequence)
+
+	txs, err = reader.GetContractTransactions(ctx, contractB, start, 102, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, uint32(101), txs[0].Ledger.Sequence)
+	assert.Equal(t, uint32(102), txs[1].Ledger.Sequence)
+
+	// the limit, start and end ledger bound the results
+	txs, err = reader.GetContractTransactions(ctx, contractB, start, 102, 1)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, uint32(101), txs[0].Ledger.Sequence)
+	txs, err = reader.GetContractTransactions(ctx, contractB, *toid.New(101, 2, 1), 102, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, uint32(102), txs[0].Ledger.Sequence)
+	txs, err = reader.GetContractTransactions(ctx, contractB, start, 101, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, uint32(101), txs[0].Ledger.Sequence)
+
+	// classic transactions aren't indexed and the index follows the retention window
+	write, err = writer.NewTx(ctx)
+	require.NoError(t, err)
+	lcm := txMeta(3, true)
+	require.NoError(t, write.LedgerWriter().InsertLedger(lcm))
+	require.NoError(t, write.TransactionWriter().InsertTransactions(lcm))
+	require.NoError(t, write.Commit(lcm))
+
+	txs, err = reader.GetContractTransactions(ctx, contractA, start, 103, 10)
+	require.NoError(t, err)
+	assert.Empty(t, txs)
+	txs, err = reader.GetContractTransactions(ctx, contractB, start, 103, 10)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, uint32(102), txs[0].Ledger.Sequence)
+
+	var count int
+	require.NoError(t, db.GetRaw(ctx, &count, "SELECT COUNT(*) FROM "+transactionContractTableName))
+	assert.Equal(t, 1, count)
+}
@@ -71,7 +71,7 @@ func TestTransactionFound(t *testing.T) {
 	log := log.DefaultLogger
 	log.SetLevel(logrus.TraceLevel)
 
-	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, EventStorage{}, false, false)
 	write, err := writer.NewTx(ctx)
 	require.NoError(t, err)
 
@@ -100,7 +100,7 @@ func TestTransactionFound(t *testing.T) {
 	end := protocol.Cursor{Ledger: 1000}
 	cursorRange := protocol.CursorRange{Start: start, End: end}
 
-	err = eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, nil)
+	err = eventReader.GetEvents(ctx, cursorRange, nil, nil, nil, nil, nil)
 	require.NoError(t, err)
 
 	// check all 200 cases
@@ -114,6 +114,28 @@ func TestTransactionFound(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, expectedEnvelope, tx.Envelope)
 	}
+
+	// fetch them all at once, including a missing one
+	hashes := []xdr.Hash{{}}
+	for _, lcm := range lcms {
+		hashes = append(hashes, lcm.TransactionHash(0))
+	}
+	txs, err := reader.GetTransactions(ctx, hashes)
+	require.NoError(t, err)
+	require.Len(t, txs, len(lcms))
+	for _, lcm := range lcms {
+		h := lcm.TransactionHash(0)
+		expected, err := reader.GetTransaction(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, expected, txs[h])
+	}
+
+	ledgers, err := reader.GetTransactionLedgers(ctx, hashes)
+	require.NoError(t, err)
+	require.Len(t, ledgers, len(lcms))
+	for _, lcm := range lcms {
+		assert.Equal(t, lcm.LedgerSequence(), ledgers[lcm.TransactionHash(0)])
+	}
 }
 
 func BenchmarkTransactionFetch(b *testing.B) {
@@ -121,7 +143,7 @@ func BenchmarkTransactionFetch(b *testing.B) {
 	ctx := context.TODO()
 	log := log.DefaultLogger
 
-	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 100, 1_000_000, passphrase)
+	writer := NewReadWriter(log, db, interfaces.MakeNoOpDeamon(), 100, 1_000_000, passphrase, EventStorage{}, false, false)
 	write, err := writer.NewTx(ctx)
 	require.NoError(b, err)
 
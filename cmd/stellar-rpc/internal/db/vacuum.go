@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// Vacuum rebuilds the database file, releasing the space left by deleted rows
+// (e.g. after trimming the history), and returns the number of bytes reclaimed.
+//
+// Write transactions are blocked while the database is rebuilt, which can
+// take a while for large databases.
+func (d *DB) Vacuum(ctx context.Context) (int64, error) {
+	d.writeLock.Lock()
+	defer d.writeLock.Unlock()
+
+	before, err := d.size(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// TODO: this is sqlite-only, it shouldn't be here
+	if _, err := d.ExecRaw(ctx, "VACUUM"); err != nil {
+		return 0, fmt.Errorf("could not vacuum the database: %w", err)
+	}
+	// In WAL mode, the rebuilt database is written to the WAL file, which must
+	// be checkpointed for the database file to shrink
+	if _, err := d.ExecRaw(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return 0, fmt.Errorf("could not checkpoint the database: %w", err)
+	}
+	after, err := d.size(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return before - after, nil
+}
+
+// size returns the size of the database in bytes.
+func (d *DB) size(ctx context.Context) (int64, error) {
+	var pageCount, pageSize int64
+	if err := d.GetRaw(ctx, &pageCount, "PRAGMA page_count"); err != nil {
+		return 0, fmt.Errorf("could not get the database page count: %w", err)
+	}
+	if err := d.GetRaw(ctx, &pageSize, "PRAGMA page_size"); err != nil {
+		return 0, fmt.Errorf("could not get the database page size: %w", err)
+	}
+	return pageCount * pageSize, nil
+}
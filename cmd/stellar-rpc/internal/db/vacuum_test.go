@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+)
+
+func TestVacuum(t *testing.T) {
+	db := NewTestDB(t)
+	ctx := context.TODO()
+
+	writer := NewReadWriter(log.DefaultLogger, db, interfaces.MakeNoOpDeamon(), 10, 10_000, passphrase,
+		EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+	ledgerW := write.LedgerWriter()
+	for i := uint32(1); i <= 1000; i++ {
+		require.NoError(t, ledgerW.InsertLedger(createLedger(i)))
+	}
+	require.NoError(t, write.Commit(createLedger(1000)))
+
+	_, err = db.ExecRaw(ctx, "DELETE FROM "+ledgerCloseMetaTableName)
+	require.NoError(t, err)
+
+	reclaimed, err := db.Vacuum(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, reclaimed)
+
+	// writes aren't blocked once the vacuum completes
+	write, err = writer.NewTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, write.Rollback())
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"math"
 	"slices"
 	"sync"
 
@@ -121,6 +122,45 @@ func computeFeeDistribution(fees []uint64, ledgerCount uint32) FeeDistribution {
 	}
 }
 
+// minBaseFee is the minimum fee (in stroops) accepted by the network.
+const minBaseFee = 100
+
+// RecommendedFee returns the fee which would have given a transaction (about)
+// the given probability (between 0 and 1) of being included, according to the
+// distribution.
+//
+// The model assumes that the demand stays as in the window of the distribution
+// and that, under surge pricing, transactions are included in fee order. A fee
+// at the p-th percentile of the fees of the included transactions then outbids
+// a fraction p of the competing transactions. The fee is interpolated linearly
+// between the percentiles of the distribution and rounded up. It is the minimum
+// base fee when no transactions were included in the window, since any valid
+// fee was then enough.
+func (d FeeDistribution) RecommendedFee(inclusionProbability float64) uint64 {
+	if d.FeeCount == 0 {
+		return minBaseFee
+	}
+	points := []struct {
+		probability float64
+		fee         uint64
+	}{
+		{0, d.Min}, {0.1, d.P10}, {0.2, d.P20}, {0.3, d.P30}, {0.4, d.P40}, {0.5, d.P50}, {0.6, d.P60},
+		{0.7, d.P70}, {0.8, d.P80}, {0.9, d.P90}, {0.95, d.P95}, {0.99, d.P99}, {1, d.Max},
+	}
+	if inclusionProbability <= 0 {
+		return d.Min
+	}
+	for i := 1; i < len(points); i++ {
+		lower, upper := points[i-1], points[i]
+		if inclusionProbability > upper.probability {
+			continue
+		}
+		ratio := (inclusionProbability - lower.probability) / (upper.probability - lower.probability)
+		return lower.fee + uint64(math.Ceil(ratio*float64(upper.fee-lower.fee)))
+	}
+	return d.Max
+}
+
 func (fw *FeeWindow) GetFeeDistribution() FeeDistribution {
 	fw.lock.RLock()
 	defer fw.lock.RUnlock()
@@ -130,17 +170,60 @@ func (fw *FeeWindow) GetFeeDistribution() FeeDistribution {
 type FeeWindows struct {
 	SorobanInclusionFeeWindow *FeeWindow
 	ClassicFeeWindow          *FeeWindow
-	networkPassPhrase         string
-	db                        *db.DB
+	// SuccessfulSorobanInclusionFeeWindow and SuccessfulClassicFeeWindow
+	// only track the fees of the successful transactions, since failed
+	// transactions pay fees too.
+	SuccessfulSorobanInclusionFeeWindow *FeeWindow
+	SuccessfulClassicFeeWindow          *FeeWindow
+	networkPassPhrase                   string
+	db                                  *db.DB
 }
 
 func NewFeeWindows(classicRetention uint32, sorobanRetention uint32, networkPassPhrase string, db *db.DB) *FeeWindows {
 	return &FeeWindows{
-		SorobanInclusionFeeWindow: NewFeeWindow(sorobanRetention),
-		ClassicFeeWindow:          NewFeeWindow(classicRetention),
-		networkPassPhrase:         networkPassPhrase,
-		db:                        db,
+		SorobanInclusionFeeWindow:           NewFeeWindow(sorobanRetention),
+		ClassicFeeWindow:                    NewFeeWindow(classicRetention),
+		SuccessfulSorobanInclusionFeeWindow: NewFeeWindow(sorobanRetention),
+		SuccessfulClassicFeeWindow:          NewFeeWindow(classicRetention),
+		networkPassPhrase:                   networkPassPhrase,
+		db:                                  db,
+	}
+}
+
+// transactionFee returns the inclusion fee of a Soroban transaction or the
+// fee per operation of a classic transaction. ok is false if the fee can't be
+// computed.
+func transactionFee(tx ingest.LedgerTransaction) (fee uint64, soroban bool, ok bool) {
+	feeCharged := uint64(tx.Result.Result.FeeCharged)
+	ops := tx.Envelope.Operations()
+	if len(ops) == 0 {
+		// should not happen
+		return 0, false, false
+	}
+	if len(ops) == 1 {
+		switch ops[0].Body.Type { //nolint:exhaustive
+		case xdr.OperationTypeInvokeHostFunction, xdr.OperationTypeExtendFootprintTtl, xdr.OperationTypeRestoreFootprint:
+			var sorobanFees xdr.SorobanTransactionMetaExtV1
+			switch tx.UnsafeMeta.V {
+			case 3:
+				if tx.UnsafeMeta.V3.SorobanMeta == nil || tx.UnsafeMeta.V3.SorobanMeta.Ext.V != 1 {
+					return 0, true, false
+				}
+				sorobanFees = *tx.UnsafeMeta.V3.SorobanMeta.Ext.V1
+			case 4:
+				if tx.UnsafeMeta.V4.SorobanMeta == nil || tx.UnsafeMeta.V4.SorobanMeta.Ext.V != 1 {
+					return 0, true, false
+				}
+				sorobanFees = *tx.UnsafeMeta.V4.SorobanMeta.Ext.V1
+			default:
+				return 0, true, false
+			}
+			resourceFeeCharged := sorobanFees.TotalNonRefundableResourceFeeCharged +
+				sorobanFees.TotalRefundableResourceFeeCharged
+			return feeCharged - uint64(resourceFeeCharged), true, true
+		}
 	}
+	return feeCharged / uint64(len(ops)), false, true
 }
 
 func (fw *FeeWindows) IngestFees(meta xdr.LedgerCloseMeta) error {
@@ -148,8 +231,8 @@ func (fw *FeeWindows) IngestFees(meta xdr.LedgerCloseMeta) error {
 	if err != nil {
 		return errors.Join(err, fw.db.Rollback())
 	}
-	var sorobanInclusionFees []uint64
-	var classicFees []uint64
+	var sorobanInclusionFees, successfulSorobanInclusionFees []uint64
+	var classicFees, successfulClassicFees []uint64
 	for {
 		tx, err := reader.Read()
 		if errors.Is(err, io.EOF) {
@@ -158,51 +241,40 @@ func (fw *FeeWindows) IngestFees(meta xdr.LedgerCloseMeta) error {
 		if err != nil {
 			return errors.Join(err, fw.db.Rollback())
 		}
-		feeCharged := uint64(tx.Result.Result.FeeCharged)
-		ops := tx.Envelope.Operations()
-		if len(ops) == 0 {
-			// should not happen
+		fee, soroban, ok := transactionFee(tx)
+		if !ok {
 			continue
 		}
-		if len(ops) == 1 {
-			switch ops[0].Body.Type { //nolint:exhaustive
-			case xdr.OperationTypeInvokeHostFunction, xdr.OperationTypeExtendFootprintTtl, xdr.OperationTypeRestoreFootprint:
-				var sorobanFees xdr.SorobanTransactionMetaExtV1
-				switch tx.UnsafeMeta.V {
-				case 3:
-					if tx.UnsafeMeta.V3.SorobanMeta == nil || tx.UnsafeMeta.V3.SorobanMeta.Ext.V != 1 {
-						continue
-					}
-					sorobanFees = *tx.UnsafeMeta.V3.SorobanMeta.Ext.V1
-				case 4:
-					if tx.UnsafeMeta.V4.SorobanMeta == nil || tx.UnsafeMeta.V4.SorobanMeta.Ext.V != 1 {
-						continue
-					}
-					sorobanFees = *tx.UnsafeMeta.V4.SorobanMeta.Ext.V1
-				default:
-					continue
-				}
-				resourceFeeCharged := sorobanFees.TotalNonRefundableResourceFeeCharged +
-					sorobanFees.TotalRefundableResourceFeeCharged
-				inclusionFee := feeCharged - uint64(resourceFeeCharged)
-				sorobanInclusionFees = append(sorobanInclusionFees, inclusionFee)
-				continue
+		successful := tx.Result.Successful()
+		if soroban {
+			sorobanInclusionFees = append(sorobanInclusionFees, fee)
+			if successful {
+				successfulSorobanInclusionFees = append(successfulSorobanInclusionFees, fee)
+			}
+		} else {
+			classicFees = append(classicFees, fee)
+			if successful {
+				successfulClassicFees = append(successfulClassicFees, fee)
 			}
 		}
-		feePerOp := feeCharged / uint64(len(ops))
-		classicFees = append(classicFees, feePerOp)
-	}
-	bucket := ledgerbucketwindow.LedgerBucket[[]uint64]{
-		LedgerSeq:            meta.LedgerSequence(),
-		LedgerCloseTimestamp: meta.LedgerCloseTime(),
-		BucketContent:        classicFees,
 	}
-	if err := fw.ClassicFeeWindow.AppendLedgerFees(bucket); err != nil {
-		return errors.Join(err, fw.db.Rollback())
-	}
-	bucket.BucketContent = sorobanInclusionFees
-	if err := fw.SorobanInclusionFeeWindow.AppendLedgerFees(bucket); err != nil {
-		return errors.Join(err, fw.db.Rollback())
+	for _, ledgerFees := range []struct {
+		window *FeeWindow
+		fees   []uint64
+	}{
+		{fw.ClassicFeeWindow, classicFees},
+		{fw.SorobanInclusionFeeWindow, sorobanInclusionFees},
+		{fw.SuccessfulClassicFeeWindow, successfulClassicFees},
+		{fw.SuccessfulSorobanInclusionFeeWindow, successfulSorobanInclusionFees},
+	} {
+		bucket := ledgerbucketwindow.LedgerBucket[[]uint64]{
+			LedgerSeq:            meta.LedgerSequence(),
+			LedgerCloseTimestamp: meta.LedgerCloseTime(),
+			BucketContent:        ledgerFees.fees,
+		}
+		if err := ledgerFees.window.AppendLedgerFees(bucket); err != nil {
+			return errors.Join(err, fw.db.Rollback())
+		}
 	}
 	return nil
 }
@@ -9,6 +9,9 @@ import (
 	"github.com/montanaflynn/stats"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
 )
 
 func TestBasicComputeFeeDistribution(t *testing.T) {
@@ -89,6 +92,122 @@ func TestBasicComputeFeeDistribution(t *testing.T) {
 	}
 }
 
+func TestRecommendedFee(t *testing.T) {
+	assert.Equal(t, uint64(100), FeeDistribution{}.RecommendedFee(0.9))
+
+	distribution := FeeDistribution{
+		Max: 10000, Min: 100, Mode: 100,
+		P10: 100, P20: 100, P30: 100, P40: 200, P50: 300,
+		P60: 400, P70: 500, P80: 600, P90: 1000, P95: 2000, P99: 5000,
+		FeeCount: 100,
+	}
+	for _, testCase := range []struct {
+		probability float64
+		expected    uint64
+	}{
+		{0, 100},
+		{0.05, 100},
+		{0.5, 300},
+		{0.85, 800},
+		{0.9, 1000},
+		{0.97, 3500},
+		{0.999, 9500},
+		{1, 10000},
+	} {
+		assert.Equal(t, testCase.expected, distribution.RecommendedFee(testCase.probability), testCase.probability)
+	}
+}
+
+// classicFeeMeta returns a ledger with classic transactions (of one
+// operation) charged the given fees, which only succeed if successful.
+func classicFeeMeta(fees []int64, successful []bool) xdr.LedgerCloseMeta {
+	var envelopes []xdr.TransactionEnvelope
+	var txProcessing []xdr.TransactionResultMeta
+	for i, fee := range fees {
+		envelope, err := xdr.NewTransactionEnvelope(xdr.EnvelopeTypeEnvelopeTypeTx, xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				Fee:           xdr.Uint32(fee),
+				SeqNum:        xdr.SequenceNumber(i + 1),
+				SourceAccount: xdr.MustMuxedAddress("GA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJVSGZ"),
+				Operations: []xdr.Operation{{
+					Body: xdr.OperationBody{
+						Type:           xdr.OperationTypeBumpSequence,
+						BumpSequenceOp: &xdr.BumpSequenceOp{},
+					},
+				}},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+		hash, err := network.HashTransactionInEnvelope(envelope, network.TestNetworkPassphrase)
+		if err != nil {
+			panic(err)
+		}
+		code := xdr.TransactionResultCodeTxFailed
+		if successful[i] {
+			code = xdr.TransactionResultCodeTxSuccess
+		}
+		envelopes = append(envelopes, envelope)
+		txProcessing = append(txProcessing, xdr.TransactionResultMeta{
+			TxApplyProcessing: xdr.TransactionMeta{
+				V:          3,
+				Operations: &[]xdr.OperationMeta{},
+				V3:         &xdr.TransactionMetaV3{},
+			},
+			Result: xdr.TransactionResultPair{
+				TransactionHash: hash,
+				Result: xdr.TransactionResult{
+					FeeCharged: xdr.Int64(fee),
+					Result: xdr.TransactionResultResult{
+						Code:    code,
+						Results: &[]xdr.OperationResult{},
+					},
+				},
+			},
+		})
+	}
+	components := []xdr.TxSetComponent{{
+		Type: xdr.TxSetComponentTypeTxsetCompTxsMaybeDiscountedFee,
+		TxsMaybeDiscountedFee: &xdr.TxSetComponentTxsMaybeDiscountedFee{
+			Txs: envelopes,
+		},
+	}}
+	return xdr.LedgerCloseMeta{
+		V: 1,
+		V1: &xdr.LedgerCloseMetaV1{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{LedgerSeq: 10},
+			},
+			TxProcessing: txProcessing,
+			TxSet: xdr.GeneralizedTransactionSet{
+				V: 1,
+				V1TxSet: &xdr.TransactionSetV1{
+					Phases: []xdr.TransactionPhase{{
+						V:            0,
+						V0Components: &components,
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestIngestFeesSuccessfulOnly(t *testing.T) {
+	windows := NewFeeWindows(10, 10, network.TestNetworkPassphrase, nil)
+	meta := classicFeeMeta([]int64{100, 300, 200}, []bool{true, false, true})
+	require.NoError(t, windows.IngestFees(meta))
+
+	all := windows.ClassicFeeWindow.GetFeeDistribution()
+	assert.Equal(t, uint32(3), all.FeeCount)
+	assert.Equal(t, uint64(300), all.Max)
+	successful := windows.SuccessfulClassicFeeWindow.GetFeeDistribution()
+	assert.Equal(t, uint32(2), successful.FeeCount)
+	assert.Equal(t, uint64(200), successful.Max)
+	assert.Equal(t, uint64(100), successful.Min)
+	assert.Equal(t, uint32(0), windows.SuccessfulSorobanInclusionFeeWindow.GetFeeDistribution().FeeCount)
+}
+
 func TestComputeFeeDistributionAgainstAlternative(t *testing.T) {
 	for range 100_000 {
 		fees := generateFees(nil)
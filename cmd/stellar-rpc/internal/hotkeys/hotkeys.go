@@ -0,0 +1,159 @@
+package hotkeys
+
+import (
+	"container/heap"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const (
+	// trackedKeysFactor is the ratio between the amount of keys tracked and the
+	// amount of keys reported. Tracking more keys than reported improves the
+	// accuracy of the reported counts.
+	trackedKeysFactor = 4
+	// maxKeyLength bounds the size of the keys kept in memory. Requests for
+	// longer keys are accounted for in the "other" bucket.
+	maxKeyLength = 1024
+)
+
+// KeyCount is the amount of times a key was requested.
+type KeyCount struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+	// MaxOvercount is an upper bound of how much Count may overestimate the
+	// real amount of requests for the key.
+	MaxOvercount uint64 `json:"maxOvercount,omitempty"`
+}
+
+// Report summarizes the keys requested since the Tracker was created.
+type Report struct {
+	Total uint64     `json:"total"`
+	Keys  []KeyCount `json:"keys"`
+	// Other is the amount of requests not attributed to the reported keys.
+	Other uint64 `json:"other"`
+}
+
+type trackedKey struct {
+	key       string
+	count     uint64
+	overcount uint64
+	index     int
+}
+
+type trackedKeyHeap []*trackedKey
+
+func (h trackedKeyHeap) Len() int           { return len(h) }
+func (h trackedKeyHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+
+func (h trackedKeyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *trackedKeyHeap) Push(x any) {
+	entry := x.(*trackedKey) //nolint:forcetypeassert
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *trackedKeyHeap) Pop() any {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	*h = old[:n-1]
+	return entry
+}
+
+// Tracker approximates the most requested keys using a bounded amount of
+// memory (the Space-Saving algorithm). Once all the slots are taken, a new key
+// replaces the least requested one, inheriting its count.
+type Tracker struct {
+	lock     sync.Mutex
+	topN     int
+	capacity int
+	total    uint64
+	keys     map[string]*trackedKey
+	byCount  trackedKeyHeap
+}
+
+// NewTracker creates a Tracker reporting the topN most requested keys. A nil
+// Tracker is valid and doesn't track anything.
+func NewTracker(topN int) *Tracker {
+	capacity := topN * trackedKeysFactor
+	return &Tracker{
+		topN:     topN,
+		capacity: capacity,
+		keys:     make(map[string]*trackedKey, capacity),
+		byCount:  make(trackedKeyHeap, 0, capacity),
+	}
+}
+
+// Record accounts for a request of each of the given keys.
+func (t *Tracker) Record(keys ...string) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, key := range keys {
+		t.total++
+		if len(key) > maxKeyLength {
+			continue
+		}
+		if entry, ok := t.keys[key]; ok {
+			entry.count++
+			heap.Fix(&t.byCount, entry.index)
+			continue
+		}
+		if len(t.byCount) < t.capacity {
+			entry := &trackedKey{key: key, count: 1}
+			heap.Push(&t.byCount, entry)
+			t.keys[key] = entry
+			continue
+		}
+		// Replace the least requested key
+		entry := t.byCount[0]
+		delete(t.keys, entry.key)
+		entry.key = key
+		entry.overcount = entry.count
+		entry.count++
+		heap.Fix(&t.byCount, 0)
+		t.keys[key] = entry
+	}
+}
+
+// Report returns the most requested keys, most requested first.
+func (t *Tracker) Report() Report {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	keys := make([]KeyCount, 0, len(t.byCount))
+	for _, entry := range t.byCount {
+		keys = append(keys, KeyCount{Key: entry.key, Count: entry.count, MaxOvercount: entry.overcount})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if len(keys) > t.topN {
+		keys = keys[:t.topN]
+	}
+	report := Report{Total: t.total, Keys: keys}
+	var reported uint64
+	for _, key := range keys {
+		// only the requests we are sure about
+		reported += key.Count - key.MaxOvercount
+	}
+	report.Other = t.total - reported
+	return report
+}
+
+// Handler serves the report as JSON.
+func (t *Tracker) Handler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(t.Report())
+}
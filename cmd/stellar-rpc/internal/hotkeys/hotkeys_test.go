@@ -0,0 +1,58 @@
+package hotkeys
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerReport(t *testing.T) {
+	tracker := NewTracker(2)
+	tracker.Record("a", "b", "a", "c", "a", "b")
+
+	report := tracker.Report()
+	assert.Equal(t, uint64(6), report.Total)
+	assert.Equal(t, []KeyCount{{Key: "a", Count: 3}, {Key: "b", Count: 2}}, report.Keys)
+	assert.Equal(t, uint64(1), report.Other)
+}
+
+func TestTrackerBoundedMemory(t *testing.T) {
+	tracker := NewTracker(2)
+	for i := 0; i < 1000; i++ {
+		tracker.Record("hot", "cold-"+strconv.Itoa(i))
+	}
+	tracker.Record(strings.Repeat("x", maxKeyLength+1))
+
+	assert.Len(t, tracker.keys, 2*trackedKeysFactor)
+	assert.Len(t, tracker.byCount, 2*trackedKeysFactor)
+
+	report := tracker.Report()
+	assert.Equal(t, uint64(2001), report.Total)
+	require.Len(t, report.Keys, 2)
+	assert.Equal(t, "hot", report.Keys[0].Key)
+	assert.Equal(t, uint64(1000), report.Keys[0].Count)
+	assert.Equal(t, report.Total-(1000+report.Keys[1].Count-report.Keys[1].MaxOvercount), report.Other)
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+	tracker.Record("a")
+}
+
+func TestTrackerHandler(t *testing.T) {
+	tracker := NewTracker(1)
+	tracker.Record("a")
+
+	w := httptest.NewRecorder()
+	tracker.Handler(w, httptest.NewRequest(http.MethodGet, "/ledger-entries/hot-keys", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, Report{Total: 1, Keys: []KeyCount{{Key: "a", Count: 1}}}, report)
+}
@@ -31,6 +31,15 @@ func (m *MockDB) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
 	return args.Get(0).(uint32), args.Error(1) //nolint:forcetypeassert
 }
 
+func (m *MockDB) DeleteLedgersBefore(ctx context.Context, ledgerSeq uint32) error {
+	args := m.Called(ctx, ledgerSeq)
+	return args.Error(0)
+}
+
+func (m *MockDB) SetHistoryRetentionWindow(window uint32) {
+	m.Called(window)
+}
+
 type MockTx struct {
 	mock.Mock
 }
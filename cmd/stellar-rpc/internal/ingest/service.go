@@ -3,13 +3,16 @@ package ingest
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/historyarchive"
+	goingest "github.com/stellar/go/ingest"
 	backends "github.com/stellar/go/ingest/ledgerbackend"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/xdr"
@@ -22,6 +25,10 @@ import (
 
 const (
 	maxRetries = 5
+	// historyRetentionWindowResolvePeriod is the number of ledgers (about an
+	// hour) between the conversions of the history retention duration into a
+	// window, following the observed ledger close times.
+	historyRetentionWindowResolvePeriod = 720
 )
 
 var errEmptyArchives = errors.New("cannot start ingestion without history archives, " +
@@ -37,6 +44,30 @@ type Config struct {
 	Timeout           time.Duration
 	OnIngestionRetry  backoff.Notify
 	Daemon            interfaces.Daemon
+	// StartLedgerFloor is the minimum ledger to start ingesting from, 0 means
+	// no minimum.
+	StartLedgerFloor uint32
+	// StartWindow, when non-zero, prevents starting ingestion more than
+	// StartWindow ledgers before the latest ledger in the history archives.
+	StartWindow uint32
+	// AbortOnLedgerGap makes ingestion fail (and restart from the latest
+	// ledger in the database) when a ledger doesn't follow the previously
+	// ingested one. Otherwise, the gap is only logged.
+	AbortOnLedgerGap bool
+	// SyncedLedgerLatency is the maximum age of the latest ingested ledger
+	// for the initial sync to be considered complete.
+	SyncedLedgerLatency time.Duration
+	// DBSizeLimiter, when set, caps the size of the database after ingesting
+	// every ledger.
+	DBSizeLimiter *db.SizeLimiter
+	// ContractDataIndex, when set, is the index of the contract data keys
+	// maintained by DB, which is bootstrapped from the history archives when
+	// ingestion starts with an empty database.
+	ContractDataIndex *db.ContractDataIndex
+	// ResolveHistoryRetentionWindow, when set, is called after ingesting every
+	// ledger multiple of historyRetentionWindowResolvePeriod and returns the
+	// history retention window to trim DB with from then on (if it changed).
+	ResolveHistoryRetentionWindow func(ctx context.Context) (uint32, bool)
 }
 
 func NewService(cfg Config) *Service {
@@ -81,13 +112,38 @@ func newService(cfg Config) *Service {
 		ledgerBackend:     cfg.LedgerBackend,
 		networkPassPhrase: cfg.NetworkPassPhrase,
 		timeout:           cfg.Timeout,
+		startLedgerFloor:  cfg.StartLedgerFloor,
+		startWindow:       cfg.StartWindow,
+		abortOnLedgerGap:  cfg.AbortOnLedgerGap,
+		syncedLatency:     cfg.SyncedLedgerLatency,
+		dbSizeLimiter:     cfg.DBSizeLimiter,
+		contractDataIndex: cfg.ContractDataIndex,
+		resolveWindow:     cfg.ResolveHistoryRetentionWindow,
 		metrics: Metrics{
 			ingestionDurationMetric: ingestionDurationMetric,
 			latestLedgerMetric:      latestLedgerMetric,
 			ledgerStatsMetric:       ledgerStatsMetric,
 		},
+		now: time.Now,
 	}
 
+	// ledgerLagMetric is computed when scraped, so that it keeps growing
+	// while no ledgers are ingested
+	ledgerLagMetric := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: cfg.Daemon.MetricsNamespace(), Subsystem: "ingest", Name: "latest_ledger_lag_seconds",
+		Help: "seconds elapsed since the close time of the latest ingested ledger (0 until a ledger is ingested)",
+	}, service.latestLedgerLag)
+	initializingMetric := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: cfg.Daemon.MetricsNamespace(), Subsystem: "ingest", Name: "initializing",
+		Help: "1 until the initial sync with the network completes, 0 afterwards",
+	}, func() float64 {
+		if service.Initializing() {
+			return 1
+		}
+		return 0
+	})
+	cfg.Daemon.MetricsRegistry().MustRegister(ledgerLagMetric, initializingMetric)
+
 	return service
 }
 
@@ -132,9 +188,52 @@ type Service struct {
 	ledgerBackend     backends.LedgerBackend
 	timeout           time.Duration
 	networkPassPhrase string
+	startLedgerFloor  uint32
+	startWindow       uint32
+	abortOnLedgerGap  bool
 	done              context.CancelFunc
 	wg                sync.WaitGroup
 	metrics           Metrics
+	// lastIngestedLedger is the latest ledger ingested since ingestion
+	// (re)started, 0 until then
+	lastIngestedLedger uint32
+	// latestLedgerCloseTime is the close time (unix timestamp) of the latest
+	// ingested ledger
+	latestLedgerCloseTime atomic.Int64
+	now                   func() time.Time
+	// synced is set once an ingested ledger closed less than syncedLatency
+	// ago, which completes the initial sync
+	synced        atomic.Bool
+	syncedLatency time.Duration
+	dbSizeLimiter *db.SizeLimiter
+	// contractDataIndex is nil when the contract data keys aren't indexed
+	contractDataIndex *db.ContractDataIndex
+	// resolveWindow is nil when the history retention window is fixed
+	resolveWindow func(ctx context.Context) (uint32, bool)
+}
+
+// Initializing returns true until the initial sync with the network completes,
+// before which the ingested data lags behind the network.
+func (s *Service) Initializing() bool {
+	return !s.synced.Load()
+}
+
+func (s *Service) updateSynced(sequence uint32, closeTime int64) {
+	if s.synced.Load() || s.now().Sub(time.Unix(closeTime, 0)) > s.syncedLatency {
+		return
+	}
+	s.synced.Store(true)
+	s.logger.Infof("Initial sync completed at ledger %d", sequence)
+}
+
+// latestLedgerLag returns the seconds elapsed since the latest ingested ledger
+// closed.
+func (s *Service) latestLedgerLag() float64 {
+	closeTime := s.latestLedgerCloseTime.Load()
+	if closeTime == 0 {
+		return 0
+	}
+	return s.now().Sub(time.Unix(closeTime, 0)).Seconds()
 }
 
 func (s *Service) Close() error {
@@ -148,6 +247,7 @@ func (s *Service) run(ctx context.Context, archive historyarchive.ArchiveInterfa
 	if err != nil {
 		return err
 	}
+	s.lastIngestedLedger = 0
 
 	for ; ; nextLedgerSeq++ {
 		if err := s.ingest(ctx, nextLedgerSeq); err != nil {
@@ -161,36 +261,151 @@ func (s *Service) getNextLedgerSequence(ctx context.Context,
 ) (uint32, error) {
 	var nextLedgerSeq uint32
 	curLedgerSeq, err := s.db.GetLatestLedgerSequence(ctx)
+	emptyDB := errors.Is(err, db.ErrEmptyDB)
 	switch {
 	case err == nil:
 		nextLedgerSeq = curLedgerSeq + 1
 
-	case errors.Is(err, db.ErrEmptyDB):
-		root, rootErr := archive.GetRootHAS()
+	case emptyDB:
 		// DB is empty, check latest available ledger in History Archives
-		if rootErr != nil {
-			return 0, rootErr
-		}
-		if root.CurrentLedger == 0 {
-			return 0, errEmptyArchives
+		nextLedgerSeq, err = getLatestArchiveLedger(archive)
+		if err != nil {
+			return 0, err
 		}
-		nextLedgerSeq = root.CurrentLedger
 
 	default:
 		return 0, err
 	}
+	nextLedgerSeq, err = s.applyStartLedgerFloor(ctx, nextLedgerSeq, emptyDB, archive)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.initContractDataIndex(ctx, archive, nextLedgerSeq, emptyDB); err != nil {
+		return 0, fmt.Errorf("could not initialize the contract data index: %w", err)
+	}
 	prepareRangeCtx, cancelPrepareRange := context.WithTimeout(ctx, s.timeout)
 	defer cancelPrepareRange()
 	return nextLedgerSeq,
 		s.ledgerBackend.PrepareRange(prepareRangeCtx, backends.UnboundedRange(nextLedgerSeq))
 }
 
+func getLatestArchiveLedger(archive historyarchive.ArchiveInterface) (uint32, error) {
+	root, err := archive.GetRootHAS()
+	if err != nil {
+		return 0, err
+	}
+	if root.CurrentLedger == 0 {
+		return 0, errEmptyArchives
+	}
+	return root.CurrentLedger, nil
+}
+
+// initContractDataIndex initializes the index of the contract data keys, if
+// it's enabled and not initialized yet. The index must be consistent with the
+// ingested ledgers, so it's only bootstrapped (from the ledger state of the
+// checkpoint ingestion starts at) along with an empty database. Otherwise, it
+// only holds the entries modified from then on.
+func (s *Service) initContractDataIndex(ctx context.Context, archive historyarchive.ArchiveInterface,
+	nextLedgerSeq uint32, emptyDB bool,
+) error {
+	if s.contractDataIndex == nil {
+		return nil
+	}
+	complete, err := s.contractDataIndex.Complete(ctx)
+	switch {
+	case err == nil:
+		if !complete {
+			s.logger.Warn("the contract data index only holds the entries modified since the indexing was enabled")
+		}
+		return nil
+	case !errors.Is(err, db.ErrEmptyDB):
+		return err
+	}
+
+	if !emptyDB || !archive.GetCheckpointManager().IsCheckpoint(nextLedgerSeq) {
+		s.logger.WithField("next_ledger", nextLedgerSeq).
+			Warn("the contract data index can't be bootstrapped, it will only hold the entries modified from now on")
+		return s.contractDataIndex.MarkPartial(ctx)
+	}
+	s.logger.Infof("Bootstrapping the contract data index from checkpoint ledger %d", nextLedgerSeq)
+	reader, err := goingest.NewCheckpointChangeReader(ctx, archive, nextLedgerSeq)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return s.contractDataIndex.Bootstrap(ctx, reader)
+}
+
+// applyStartLedgerFloor moves the next ledger to ingest forward if it is
+// below the configured start ledger floor or start window, so that a
+// database which is far behind doesn't catch up (and temporarily retain)
+// more history than needed. The ledgers already in the database are then
+// below the floor, and they are deleted so as not to leave a gap in the data.
+func (s *Service) applyStartLedgerFloor(ctx context.Context,
+	nextLedgerSeq uint32, emptyDB bool, archive historyarchive.ArchiveInterface,
+) (uint32, error) {
+	if s.startLedgerFloor == 0 && s.startWindow == 0 {
+		return nextLedgerSeq, nil
+	}
+	latest, err := getLatestArchiveLedger(archive)
+	if err != nil {
+		return 0, err
+	}
+	if s.startLedgerFloor > latest {
+		return 0, fmt.Errorf(
+			"ingestion start ledger floor (%d) is beyond the latest ledger in the history archives (%d)",
+			s.startLedgerFloor, latest)
+	}
+	floor := s.startLedgerFloor
+	if s.startWindow != 0 && latest > s.startWindow && latest-s.startWindow+1 > floor {
+		floor = latest - s.startWindow + 1
+	}
+	if nextLedgerSeq >= floor {
+		return nextLedgerSeq, nil
+	}
+	s.logger.WithFields(log.F{
+		"next_ledger": nextLedgerSeq,
+		"floor":       floor,
+	}).Warn("skipping ledgers below the ingestion start ledger floor")
+	if !emptyDB {
+		s.logger.WithField("floor", floor).
+			Warn("deleting the ingested ledgers below the ingestion start ledger floor")
+		if err := s.db.DeleteLedgersBefore(ctx, floor); err != nil {
+			return 0, fmt.Errorf("could not delete the ledgers below the ingestion start ledger floor: %w", err)
+		}
+	}
+	return floor, nil
+}
+
+// checkLedgerContiguity verifies that a ledger about to be committed follows
+// the previously ingested one, so that a ledger backend delivering ledgers out
+// of order (or skipping some) doesn't silently leave a gap in the data. The
+// first ledger ingested after (re)starting ingestion isn't checked, since it
+// can legitimately skip ledgers (e.g. with a start ledger floor).
+func (s *Service) checkLedgerContiguity(sequence uint32) error {
+	if s.lastIngestedLedger == 0 || sequence == s.lastIngestedLedger+1 {
+		return nil
+	}
+	s.logger.WithFields(log.F{
+		"previous_ledger": s.lastIngestedLedger,
+		"ledger":          sequence,
+	}).Error("ingested ledgers are not contiguous, the data is missing ledgers or out of order")
+	if s.abortOnLedgerGap {
+		return fmt.Errorf("ledger %d doesn't follow the previously ingested ledger %d",
+			sequence, s.lastIngestedLedger)
+	}
+	return nil
+}
+
 func (s *Service) ingest(ctx context.Context, sequence uint32) error {
 	s.logger.Infof("Ingesting ledger %d", sequence)
 	ledgerCloseMeta, err := s.ledgerBackend.GetLedger(ctx, sequence)
 	if err != nil {
 		return err
 	}
+	if err := s.checkLedgerContiguity(ledgerCloseMeta.LedgerSequence()); err != nil {
+		return err
+	}
 
 	startTime := time.Now()
 	tx, err := s.db.NewTx(ctx)
@@ -210,6 +425,18 @@ func (s *Service) ingest(ctx context.Context, sequence uint32) error {
 	if err := tx.Commit(ledgerCloseMeta); err != nil {
 		return err
 	}
+	s.lastIngestedLedger = ledgerCloseMeta.LedgerSequence()
+	if s.dbSizeLimiter != nil {
+		// the ledger is already committed, so ingestion carries on regardless
+		if err := s.dbSizeLimiter.Enforce(ctx); err != nil {
+			s.logger.WithError(err).Error("could not enforce the maximum database size")
+		}
+	}
+	if s.resolveWindow != nil && sequence%historyRetentionWindowResolvePeriod == 0 {
+		if window, changed := s.resolveWindow(ctx); changed {
+			s.db.SetHistoryRetentionWindow(window)
+		}
+	}
 	s.logger.
 		WithField("duration", time.Since(startTime).Seconds()).
 		Debugf("Ingested ledger %d", sequence)
@@ -218,6 +445,8 @@ func (s *Service) ingest(ctx context.Context, sequence uint32) error {
 		With(prometheus.Labels{"type": "total"}).
 		Observe(time.Since(startTime).Seconds())
 	s.metrics.latestLedgerMetric.Set(float64(sequence))
+	s.latestLedgerCloseTime.Store(ledgerCloseMeta.LedgerCloseTime())
+	s.updateSynced(sequence, ledgerCloseMeta.LedgerCloseTime())
 	return nil
 }
 
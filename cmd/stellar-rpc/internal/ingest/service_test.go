@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/stellar/go/historyarchive"
 	"github.com/stellar/go/ingest/ledgerbackend"
 	"github.com/stellar/go/network"
 	supportlog "github.com/stellar/go/support/log"
@@ -31,6 +32,12 @@ func (rw *ErrorReadWriter) NewTx(_ context.Context) (db.WriteTx, error) {
 	return nil, errors.New("could not create new tx")
 }
 
+func (rw *ErrorReadWriter) DeleteLedgersBefore(_ context.Context, _ uint32) error {
+	return errors.New("could not delete ledgers")
+}
+
+func (rw *ErrorReadWriter) SetHistoryRetentionWindow(_ uint32) {}
+
 func TestRetryRunningIngestion(t *testing.T) {
 	var retryWg sync.WaitGroup
 	retryWg.Add(1)
@@ -69,9 +76,126 @@ func TestIngestion(t *testing.T) {
 	ledger := createTestLedger(t)
 	setupMockExpectations(ctx, t, mockDB, mockLedgerBackend, mockTx, ledger, sequence)
 
+	assert.Zero(t, service.latestLedgerLag())
 	require.NoError(t, service.ingest(ctx, sequence))
 
 	assertMockExpectations(t, mockDB, mockTx, mockLedgerBackend)
+
+	service.now = func() time.Time { return time.Unix(ledger.LedgerCloseTime()+5, 0) }
+	assert.InDelta(t, 5.0, service.latestLedgerLag(), 0)
+}
+
+func TestResolveHistoryRetentionWindow(t *testing.T) {
+	ctx := context.Background()
+	// the window is only resolved every historyRetentionWindowResolvePeriod ledgers
+	for sequence, resolve := range map[uint32]bool{
+		historyRetentionWindowResolvePeriod - 1: false,
+		historyRetentionWindowResolvePeriod:     true,
+	} {
+		mockDB, mockLedgerBackend, mockTx := setupMocks()
+		service := setupService(mockDB, mockLedgerBackend)
+		resolved := false
+		service.resolveWindow = func(context.Context) (uint32, bool) {
+			resolved = true
+			return 100, true
+		}
+		ledger := createTestLedger(t)
+		setupMockExpectations(ctx, t, mockDB, mockLedgerBackend, mockTx, ledger, sequence)
+		if resolve {
+			mockDB.On("SetHistoryRetentionWindow", uint32(100)).Once()
+		}
+
+		require.NoError(t, service.ingest(ctx, sequence))
+		assert.Equal(t, resolve, resolved)
+		assertMockExpectations(t, mockDB, mockTx, mockLedgerBackend)
+	}
+}
+
+func TestInitialSync(t *testing.T) {
+	now := time.Unix(1000, 0)
+	service := &Service{
+		logger:        supportlog.New(),
+		now:           func() time.Time { return now },
+		syncedLatency: 30 * time.Second,
+	}
+	assert.True(t, service.Initializing())
+
+	// catching up
+	service.updateSynced(10, now.Add(-time.Minute).Unix())
+	assert.True(t, service.Initializing())
+
+	service.updateSynced(11, now.Add(-10*time.Second).Unix())
+	assert.False(t, service.Initializing())
+
+	// falling behind later on doesn't go back to initializing
+	now = now.Add(time.Hour)
+	service.updateSynced(12, now.Add(-time.Minute).Unix())
+	assert.False(t, service.Initializing())
+}
+
+func TestCheckLedgerContiguity(t *testing.T) {
+	service := &Service{logger: supportlog.New()}
+
+	// the first ledger after starting isn't checked
+	require.NoError(t, service.checkLedgerContiguity(10))
+	service.lastIngestedLedger = 10
+	require.NoError(t, service.checkLedgerContiguity(11))
+
+	// gaps are only logged by default
+	require.NoError(t, service.checkLedgerContiguity(13))
+	require.NoError(t, service.checkLedgerContiguity(10))
+
+	service.abortOnLedgerGap = true
+	require.EqualError(t, service.checkLedgerContiguity(13),
+		"ledger 13 doesn't follow the previously ingested ledger 10")
+	require.Error(t, service.checkLedgerContiguity(9))
+	require.NoError(t, service.checkLedgerContiguity(11))
+}
+
+type fakeArchive struct {
+	historyarchive.ArchiveInterface
+	latest uint32
+}
+
+func (a fakeArchive) GetRootHAS() (historyarchive.HistoryArchiveState, error) {
+	return historyarchive.HistoryArchiveState{CurrentLedger: a.latest}, nil
+}
+
+func TestApplyStartLedgerFloor(t *testing.T) {
+	ctx := context.Background()
+	archive := fakeArchive{latest: 1000}
+	mockDB := &MockDB{}
+	service := &Service{logger: supportlog.New(), db: mockDB}
+
+	// disabled
+	next, err := service.applyStartLedgerFloor(ctx, 10, true, archive)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), next)
+
+	service.startLedgerFloor = 500
+	next, err = service.applyStartLedgerFloor(ctx, 10, true, archive)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(500), next)
+	next, err = service.applyStartLedgerFloor(ctx, 600, false, archive)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(600), next)
+
+	// the ingested ledgers below the floor are deleted, so as not to leave a gap
+	mockDB.On("DeleteLedgersBefore", ctx, uint32(500)).Return(nil).Once()
+	next, err = service.applyStartLedgerFloor(ctx, 10, false, archive)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(500), next)
+	mockDB.AssertExpectations(t)
+
+	// the start window wins when it is more restrictive
+	service.startWindow = 100
+	next, err = service.applyStartLedgerFloor(ctx, 10, true, archive)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(901), next)
+
+	service.startLedgerFloor = 1001
+	_, err = service.applyStartLedgerFloor(ctx, 10, true, archive)
+	require.ErrorContains(t, err, "beyond the latest ledger in the history archives")
 }
 
 func setupMocks() (*MockDB, *ledgerbackend.MockDatabaseBackend, *MockTx) {
@@ -108,7 +232,10 @@ func createTestLedger(t *testing.T) xdr.LedgerCloseMeta {
 }
 
 func createLedgerHeader() xdr.LedgerHeaderHistoryEntry {
-	return xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerVersion: 10}}
+	return xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{
+		LedgerVersion: 10,
+		ScpValue:      xdr.StellarValue{CloseTime: 1000},
+	}}
 }
 
 func createTransactionSet() xdr.GeneralizedTransactionSet {
@@ -0,0 +1,62 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+)
+
+// maxReportedLedgerGaps bounds the number of gaps reported by StatusHandler.
+const maxReportedLedgerGaps = 100
+
+// Status describes the ledgers ingested in the database.
+type Status struct {
+	OldestLedger uint32 `json:"oldestLedger"`
+	LatestLedger uint32 `json:"latestLedger"`
+	// ContiguousOldestLedger is the oldest ledger from which all the ledgers
+	// up to LatestLedger are available.
+	ContiguousOldestLedger uint32 `json:"contiguousOldestLedger"`
+	// Gaps are the ranges of missing ledgers, the most recent first.
+	Gaps []db.LedgerGap `json:"gaps"`
+}
+
+// GetStatus returns the ingestion status, detecting the gaps in the ingested
+// ledgers.
+func GetStatus(ctx context.Context, reader db.LedgerReader) (Status, error) {
+	ledgerRange, err := reader.GetLedgerRange(ctx)
+	if errors.Is(err, db.ErrEmptyDB) {
+		return Status{Gaps: []db.LedgerGap{}}, nil
+	} else if err != nil {
+		return Status{}, err
+	}
+	gaps, err := reader.GetLedgerGaps(ctx, maxReportedLedgerGaps)
+	if err != nil {
+		return Status{}, err
+	}
+	status := Status{
+		OldestLedger:           ledgerRange.FirstLedger.Sequence,
+		LatestLedger:           ledgerRange.LastLedger.Sequence,
+		ContiguousOldestLedger: ledgerRange.FirstLedger.Sequence,
+		Gaps:                   append([]db.LedgerGap{}, gaps...),
+	}
+	if len(gaps) > 0 {
+		status.ContiguousOldestLedger = gaps[0].End + 1
+	}
+	return status, nil
+}
+
+// StatusHandler serves the ingestion status as JSON.
+func StatusHandler(reader db.LedgerReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, err := GetStatus(r.Context(), reader)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(status)
+	}
+}
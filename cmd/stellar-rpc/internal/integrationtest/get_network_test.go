@@ -19,4 +19,8 @@ func TestGetNetworkSucceeds(t *testing.T) {
 	assert.Equal(t, infrastructure.FriendbotURL, result.FriendbotURL)
 	assert.Equal(t, infrastructure.StandaloneNetworkPassphrase, result.Passphrase)
 	assert.GreaterOrEqual(t, result.ProtocolVersion, 20)
+	assert.Positive(t, result.LatestLedger)
+	assert.Positive(t, result.BaseFee)
+	assert.Positive(t, result.BaseReserve)
+	assert.Positive(t, result.MaxTxSetSize)
 }
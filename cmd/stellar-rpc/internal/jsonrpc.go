@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -18,10 +20,12 @@ import (
 	"github.com/rs/cors"
 	"github.com/stellar/go/support/log"
 
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/circuitbreaker"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/config"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/feewindow"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/hotkeys"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/methods"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/network"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/rpcdatastore"
@@ -29,10 +33,6 @@ import (
 )
 
 const (
-	// maxHTTPRequestSize defines the largest request size that the http handler
-	// would be willing to accept before dropping the request. The implementation
-	// uses the default MaxBytesHandler to limit the request size.
-	maxHTTPRequestSize          = 512 * 1024 // half a megabyte
 	warningThresholdDenominator = 3
 )
 
@@ -40,6 +40,13 @@ const (
 type Handler struct {
 	bridge jhttp.Bridge
 	logger *log.Entry
+	// auditLogger is nil when the audit log is disabled
+	auditLogger *network.AuditLogger
+	// Requests tracks the in-flight JSON RPC requests
+	Requests *network.RequestRegistry
+	// LedgerEntriesHotKeys tracks the most requested getLedgerEntries keys,
+	// it is nil when disabled
+	LedgerEntriesHotKeys *hotkeys.Tracker
 	http.Handler
 }
 
@@ -49,20 +56,103 @@ func (h Handler) Close() {
 	if err := h.bridge.Close(); err != nil {
 		h.logger.WithError(err).Warn("could not close bridge")
 	}
+	if h.auditLogger != nil {
+		if err := h.auditLogger.Close(); err != nil {
+			h.logger.WithError(err).Warn("could not close the audit log")
+		}
+	}
+}
+
+func newAuditLogger(cfg *config.Config, params HandlerParams, clientIPResolver network.ClientIPResolver,
+) *network.AuditLogger {
+	out, err := network.OpenRotatingFile(
+		cfg.AuditLogPath, int64(cfg.AuditLogMaxSizeMB)*1024*1024, cfg.AuditLogMaxBackups) //nolint:mnd
+	if err != nil {
+		params.Logger.WithError(err).Fatal("could not open the audit log")
+	}
+	droppedCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: params.Daemon.MetricsNamespace(),
+		Subsystem: "network",
+		Name:      "audit_log_dropped_records",
+		Help:      "The metric measures the count of audit log records dropped because the audit log couldn't keep up",
+	})
+	params.Daemon.MetricsRegistry().MustRegister(droppedCounter)
+	return network.NewAuditLogger(out, clientIPResolver, cfg.AuditLogBodies, droppedCounter, params.Logger)
 }
 
 type HandlerParams struct {
 	FeeStatWindows        *feewindow.FeeWindows
 	TransactionReader     db.TransactionReader
 	EventReader           db.EventReader
+	OperationReader       db.OperationReader
+	ContractDataKeyReader db.ContractDataKeyReader
 	LedgerReader          db.LedgerReader
 	Logger                *log.Entry
 	PreflightGetter       methods.PreflightGetter
+	ResourceFeeGetter     methods.ResourceFeeGetter
 	Daemon                interfaces.Daemon
 	DataStoreLedgerReader rpcdatastore.LedgerReader
+	CoreQueryBreaker      *circuitbreaker.Breaker
+	SyncStatus            methods.SyncStatus
+	// CheckpointDeferral is nil when the checkpoints aren't deferred under slow
+	// queries
+	CheckpointDeferral *db.CheckpointDeferral
+	// SimulationCoreClient is the client the simulations query the ledger
+	// entries through, which may fall back to the stellar-core instances
+	SimulationCoreClient interfaces.FastCoreClient
+	// HistoryRetentionWindow returns the history retention window in effect
+	HistoryRetentionWindow func() uint32
+}
+
+// checkpointDeferralExemptMethods don't query the database, so their latency
+// doesn't defer the checkpoints.
+var checkpointDeferralExemptMethods = map[string]bool{
+	protocol.SimulateTransactionMethodName: true,
+	protocol.SendTransactionMethodName:     true,
+}
+
+// requestLogSampler only logs one in every N requests to the methods with a
+// sample rate of N, to keep the logs of busy nodes from being flooded by the
+// liveness probes. The failed and the slow requests are always logged.
+type requestLogSampler struct {
+	rates map[string]uint64
+	// counts is only written on creation, the counters are atomic
+	counts        map[string]*atomic.Uint64
+	slowThreshold time.Duration
 }
 
-func decorateHandlers(daemon interfaces.Daemon, logger *log.Entry, m handler.Map) handler.Map {
+func newRequestLogSampler(rates map[string]uint64, slowThreshold time.Duration) *requestLogSampler {
+	counts := make(map[string]*atomic.Uint64, len(rates))
+	for method := range rates {
+		counts[method] = &atomic.Uint64{}
+	}
+	return &requestLogSampler{rates: rates, counts: counts, slowThreshold: slowThreshold}
+}
+
+// sample tells whether a new request to method is logged.
+func (s *requestLogSampler) sample(method string) bool {
+	count, ok := s.counts[method]
+	if !ok {
+		return true
+	}
+	return (count.Add(1)-1)%s.rates[method] == 0
+}
+
+// alwaysLogged tells whether a finished request is logged even if it wasn't
+// sampled.
+func (s *requestLogSampler) alwaysLogged(status string, duration time.Duration) bool {
+	return status != "ok" || duration >= s.slowThreshold
+}
+
+func decorateHandlers(
+	daemon interfaces.Daemon,
+	logger *log.Entry,
+	requests *network.RequestRegistry,
+	checkpointDeferral *db.CheckpointDeferral,
+	logSampler *requestLogSampler,
+	auditLogger *network.AuditLogger,
+	m handler.Map,
+) handler.Map {
 	requestMetric := prometheus.NewSummaryVec(prometheus.SummaryOpts{
 		Namespace:  daemon.MetricsNamespace(),
 		Subsystem:  "json_rpc",
@@ -76,7 +166,12 @@ func decorateHandlers(daemon interfaces.Daemon, logger *log.Entry, m handler.Map
 		h := h
 		decorated[endpoint] = handler.New(func(ctx context.Context, r *jrpc2.Request) (interface{}, error) {
 			reqID := strconv.FormatUint(middleware.NextRequestID(), 10)
-			logRequest(logger, reqID, r)
+			sampled := logSampler.sample(r.Method())
+			if sampled {
+				logRequest(logger, reqID, r)
+			}
+			ctx, done := requests.Register(ctx, reqID, r.Method(), r.ParamString())
+			defer done()
 			startTime := time.Now()
 			result, err := h(ctx, r)
 			duration := time.Since(startTime)
@@ -93,7 +188,13 @@ func decorateHandlers(daemon interfaces.Daemon, logger *log.Entry, m handler.Map
 				}
 			}
 			requestMetric.With(label).Observe(duration.Seconds())
-			logResponse(logger, reqID, duration, label["status"], result)
+			auditLogger.RecordCall(reqID, r.Method(), label["status"], startTime, duration)
+			if !checkpointDeferralExemptMethods[r.Method()] {
+				checkpointDeferral.ObserveQuery(duration)
+			}
+			if sampled || logSampler.alwaysLogged(label["status"], duration) {
+				logResponse(logger, reqID, r.Method(), duration, label["status"], result)
+			}
 			return result, err
 		})
 	}
@@ -101,6 +202,33 @@ func decorateHandlers(daemon interfaces.Daemon, logger *log.Entry, m handler.Map
 	return decorated
 }
 
+// ignoreNotifications drops the JSON-RPC notifications (requests without an id)
+// sent to the handler, since nobody would get their result.
+func ignoreNotifications(logger *log.Entry, h jrpc2.Handler) jrpc2.Handler {
+	return func(ctx context.Context, r *jrpc2.Request) (any, error) {
+		if r.IsNotification() {
+			logger.WithField("method", r.Method()).Debug("ignoring JSONRPC notification")
+			return nil, nil
+		}
+		return h(ctx, r)
+	}
+}
+
+// limitRequestSize rejects the requests to the handler whose parameters exceed
+// maxSize bytes.
+func limitRequestSize(maxSize uint, h jrpc2.Handler) jrpc2.Handler {
+	return func(ctx context.Context, r *jrpc2.Request) (any, error) {
+		if size := uint(len(r.ParamString())); size > maxSize {
+			return nil, &jrpc2.Error{
+				Code: jrpc2.InvalidRequest,
+				Message: fmt.Sprintf("%s request parameters too large (%d bytes, the maximum is %d bytes)",
+					r.Method(), size, maxSize),
+			}
+		}
+		return h(ctx, r)
+	}
+}
+
 func logRequest(logger *log.Entry, reqID string, req *jrpc2.Request) {
 	logger = logger.WithFields(log.F{
 		"subsys":   "jsonrpc",
@@ -115,10 +243,13 @@ func logRequest(logger *log.Entry, reqID string, req *jrpc2.Request) {
 	logger.Debug("starting JSONRPC request params")
 }
 
-func logResponse(logger *log.Entry, reqID string, duration time.Duration, status string, response any) {
+func logResponse(logger *log.Entry, reqID string, method string, duration time.Duration, status string,
+	response any,
+) {
 	logger = logger.WithFields(log.F{
 		"subsys":   "jsonrpc",
 		"req":      reqID,
+		"method":   method,
 		"duration": duration.String(),
 		"json_req": reqID,
 		"status":   status,
@@ -154,7 +285,35 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 		},
 	}
 
-	retentionWindow := cfg.HistoryRetentionWindow
+	var hotKeys *hotkeys.Tracker
+	if cfg.LedgerEntriesHotKeys > 0 {
+		hotKeys = hotkeys.NewTracker(int(cfg.LedgerEntriesHotKeys))
+	}
+
+	var submitted *methods.SubmittedTransactions
+	if cfg.SubmittedTransactionPendingWindow > 0 {
+		submitted = methods.NewSubmittedTransactions(cfg.SubmittedTransactionPendingWindow, params.Logger)
+	}
+
+	queryAgeMetric := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: params.Daemon.MetricsNamespace(), Subsystem: "json_rpc", Name: "query_start_ledger_age",
+		Help: "Age (in ledgers before the latest ledger) of the start ledger of the getEvents and getTransactions" +
+			" requests, to size the history retention window",
+		// from a minute to a month of ledgers
+		Buckets: []float64{12, 60, 180, 720, 4320, 17280, 120960, 518400},
+	}, []string{"endpoint"})
+	params.Daemon.MetricsRegistry().MustRegister(queryAgeMetric)
+
+	ledgerReadFallbackCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: params.Daemon.MetricsNamespace(), Subsystem: "network", Name: "ledger_read_fallbacks",
+		Help: "The metric measures the count of getLedgers datastore fallbacks and retries after failed ledger reads",
+	}, []string{"action"})
+	params.Daemon.MetricsRegistry().MustRegister(ledgerReadFallbackCounter)
+	ledgerReadFallback := methods.LedgerReadFallback{
+		ToDatastore:      cfg.LedgerReadFallbackToDatastore,
+		DatastoreRetries: cfg.DatastoreReadRetries,
+		Counter:          ledgerReadFallbackCounter,
+	}
 
 	handlers := []struct {
 		methodName           string
@@ -162,28 +321,55 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 		queueLimit           uint
 		longName             string
 		requestDurationLimit time.Duration
+		// sheddable marks expensive methods, rejected under memory pressure
+		sheddable bool
+		// notifiable marks the methods which can be invoked as JSON-RPC
+		// notifications (without an id), the other notifications are ignored
+		notifiable bool
+		// availableWhileInitializing marks the methods which don't serve
+		// ingested data, the others are rejected until the initial sync completes
+		availableWhileInitializing bool
 	}{
 		{
 			methodName: protocol.GetHealthMethodName,
 			underlyingHandler: methods.NewHealthCheck(
-				retentionWindow, params.LedgerReader, cfg.MaxHealthyLedgerLatency),
-			longName:             toSnakeCase(protocol.GetHealthMethodName),
-			queueLimit:           cfg.RequestBacklogGetHealthQueueLimit,
-			requestDurationLimit: cfg.MaxGetHealthExecutionDuration,
+				params.HistoryRetentionWindow, params.LedgerReader, cfg.MaxHealthyLedgerLatency, params.CoreQueryBreaker,
+				params.SyncStatus),
+			longName:                   toSnakeCase(protocol.GetHealthMethodName),
+			queueLimit:                 cfg.RequestBacklogGetHealthQueueLimit,
+			requestDurationLimit:       cfg.MaxGetHealthExecutionDuration,
+			availableWhileInitializing: true,
 		},
 		{
 			methodName: protocol.GetEventsMethodName,
 			underlyingHandler: methods.NewGetEventsHandler(
 				params.Logger,
 				params.EventReader,
+				params.TransactionReader,
 				cfg.MaxEventsLimit,
 				cfg.DefaultEventsLimit,
 				params.LedgerReader,
+				params.DataStoreLedgerReader,
+				cfg.NetworkPassphrase,
+				queryAgeMetric.With(prometheus.Labels{"endpoint": protocol.GetEventsMethodName}),
 			),
 
 			longName:             toSnakeCase(protocol.GetEventsMethodName),
 			queueLimit:           cfg.RequestBacklogGetEventsQueueLimit,
 			requestDurationLimit: cfg.MaxGetEventsExecutionDuration,
+			sheddable:            true,
+		},
+		{
+			methodName: protocol.GetEventsTipMethodName,
+			underlyingHandler: methods.NewGetEventsTipHandler(
+				params.EventReader,
+				params.LedgerReader,
+			),
+			// getEventsTip shares the getEvents limits since it runs the same queries
+			longName:             toSnakeCase(protocol.GetEventsTipMethodName),
+			queueLimit:           cfg.RequestBacklogGetEventsQueueLimit,
+			requestDurationLimit: cfg.MaxGetEventsExecutionDuration,
+			sheddable:            true,
 		},
 		{
 			methodName: protocol.GetNetworkMethodName,
@@ -191,18 +377,21 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 				cfg.NetworkPassphrase,
 				cfg.FriendbotURL,
 				params.LedgerReader,
+				cfg.InfoResponseCacheTTL,
 			),
-			longName:             toSnakeCase(protocol.GetNetworkMethodName),
-			queueLimit:           cfg.RequestBacklogGetNetworkQueueLimit,
-			requestDurationLimit: cfg.MaxGetNetworkExecutionDuration,
+			longName:                   toSnakeCase(protocol.GetNetworkMethodName),
+			queueLimit:                 cfg.RequestBacklogGetNetworkQueueLimit,
+			requestDurationLimit:       cfg.MaxGetNetworkExecutionDuration,
+			availableWhileInitializing: true,
 		},
 		{
 			methodName: protocol.GetVersionInfoMethodName,
 			underlyingHandler: methods.NewGetVersionInfoHandler(params.Logger,
-				params.LedgerReader, params.Daemon),
-			longName:             toSnakeCase(protocol.GetVersionInfoMethodName),
-			queueLimit:           cfg.RequestBacklogGetVersionInfoQueueLimit,
-			requestDurationLimit: cfg.MaxGetVersionInfoExecutionDuration,
+				params.LedgerReader, params.Daemon, cfg.InfoResponseCacheTTL),
+			longName:                   toSnakeCase(protocol.GetVersionInfoMethodName),
+			queueLimit:                 cfg.RequestBacklogGetVersionInfoQueueLimit,
+			requestDurationLimit:       cfg.MaxGetVersionInfoExecutionDuration,
+			availableWhileInitializing: true,
 		},
 		{
 			methodName:           protocol.GetLatestLedgerMethodName,
@@ -214,52 +403,149 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 		{
 			methodName: protocol.GetLedgersMethodName,
 			underlyingHandler: methods.NewGetLedgersHandler(params.LedgerReader,
-				cfg.MaxLedgersLimit, cfg.DefaultLedgersLimit, params.DataStoreLedgerReader, params.Logger),
+				cfg.MaxLedgersLimit, cfg.DefaultLedgersLimit, params.DataStoreLedgerReader, ledgerReadFallback,
+				params.Logger),
 			longName:             toSnakeCase(protocol.GetLedgersMethodName),
 			queueLimit:           cfg.RequestBacklogGetLedgersQueueLimit,
 			requestDurationLimit: cfg.MaxGetLedgersExecutionDuration,
+			sheddable:            true,
+		},
+		{
+			methodName: protocol.GetLedgerCloseMetaMethodName,
+			underlyingHandler: methods.NewGetLedgerCloseMetaHandler(params.LedgerReader,
+				params.DataStoreLedgerReader, params.Logger),
+			longName:             toSnakeCase(protocol.GetLedgerCloseMetaMethodName),
+			queueLimit:           cfg.RequestBacklogGetLedgerCloseMetaQueueLimit,
+			requestDurationLimit: cfg.MaxGetLedgerCloseMetaExecutionDuration,
+			sheddable:            true,
 		},
 		{
 			methodName: protocol.GetLedgerEntriesMethodName,
 			underlyingHandler: methods.NewGetLedgerEntriesHandler(params.Logger,
-				params.Daemon.FastCoreClient(), params.LedgerReader),
+				params.Daemon.FastCoreClient(), params.LedgerReader, hotKeys, cfg.MaxLedgerEntriesKeys,
+				cfg.DefaultLedgerEntriesKeys, uint32(cfg.CaptiveCoreHTTPQuerySnapshotLedgers)),
 			longName:             toSnakeCase(protocol.GetLedgerEntriesMethodName),
 			queueLimit:           cfg.RequestBacklogGetLedgerEntriesQueueLimit,
 			requestDurationLimit: cfg.MaxGetLedgerEntriesExecutionDuration,
 		},
 		{
-			methodName:           protocol.GetTransactionMethodName,
-			underlyingHandler:    methods.NewGetTransactionHandler(params.Logger, params.TransactionReader, params.LedgerReader),
+			methodName: protocol.GetContractInterfaceMethodName,
+			underlyingHandler: methods.NewGetContractInterfaceHandler(params.Logger,
+				params.Daemon.FastCoreClient(), params.LedgerReader),
+			longName:             toSnakeCase(protocol.GetContractInterfaceMethodName),
+			queueLimit:           cfg.RequestBacklogGetContractInterfaceQueueLimit,
+			requestDurationLimit: cfg.MaxGetContractInterfaceExecutionDuration,
+		},
+		{
+			methodName: protocol.GetAccountSequenceMethodName,
+			underlyingHandler: methods.NewGetAccountSequenceHandler(
+				params.Daemon.FastCoreClient(), params.LedgerReader),
+			longName:             toSnakeCase(protocol.GetAccountSequenceMethodName),
+			queueLimit:           cfg.RequestBacklogGetAccountSequenceQueueLimit,
+			requestDurationLimit: cfg.MaxGetAccountSequenceExecutionDuration,
+		},
+		{
+			methodName: protocol.GetContractDataMethodName,
+			underlyingHandler: methods.NewGetContractDataHandler(
+				params.Daemon.FastCoreClient(), params.LedgerReader, params.ContractDataKeyReader,
+				cfg.MaxLedgerEntriesKeys),
+			longName:             toSnakeCase(protocol.GetContractDataMethodName),
+			queueLimit:           cfg.RequestBacklogGetContractDataQueueLimit,
+			requestDurationLimit: cfg.MaxGetContractDataExecutionDuration,
+		},
+		{
+			methodName: protocol.GetTransactionMethodName,
+			underlyingHandler: methods.NewGetTransactionHandler(params.Logger, params.TransactionReader,
+				params.LedgerReader, submitted),
 			longName:             toSnakeCase(protocol.GetTransactionMethodName),
 			queueLimit:           cfg.RequestBacklogGetTransactionQueueLimit,
 			requestDurationLimit: cfg.MaxGetTransactionExecutionDuration,
 		},
+		{
+			methodName: protocol.GetTransactionProofMethodName,
+			underlyingHandler: methods.NewGetTransactionProofHandler(params.Logger, params.TransactionReader,
+				params.LedgerReader),
+			longName:             toSnakeCase(protocol.GetTransactionProofMethodName),
+			queueLimit:           cfg.RequestBacklogGetTransactionProofQueueLimit,
+			requestDurationLimit: cfg.MaxGetTransactionProofExecutionDuration,
+		},
+		{
+			methodName: protocol.GetTransactionsByHashMethodName,
+			underlyingHandler: methods.NewGetTransactionsByHashHandler(params.Logger, params.TransactionReader,
+				params.LedgerReader, cfg.MaxTransactionsByHashLimit),
+			longName:             toSnakeCase(protocol.GetTransactionsByHashMethodName),
+			queueLimit:           cfg.RequestBacklogGetTransactionsByHashQueueLimit,
+			requestDurationLimit: cfg.MaxGetTransactionsByHashExecutionDuration,
+		},
+		{
+			methodName: protocol.GetTransactionsByContractMethodName,
+			underlyingHandler: methods.NewGetTransactionsByContractHandler(params.Logger, params.TransactionReader,
+				params.LedgerReader, cfg.MaxTransactionsLimit, cfg.DefaultTransactionsLimit),
+			longName:             toSnakeCase(protocol.GetTransactionsByContractMethodName),
+			queueLimit:           cfg.RequestBacklogGetTransactionsByContractQueueLimit,
+			requestDurationLimit: cfg.MaxGetTransactionsByContractExecutionDuration,
+		},
+		{
+			methodName: protocol.GetOperationsMethodName,
+			underlyingHandler: methods.NewGetOperationsHandler(params.Logger, params.OperationReader,
+				params.LedgerReader, cfg.IngestOperations, cfg.MaxTransactionsLimit, cfg.DefaultTransactionsLimit),
+			longName:             toSnakeCase(protocol.GetOperationsMethodName),
+			queueLimit:           cfg.RequestBacklogGetOperationsQueueLimit,
+			requestDurationLimit: cfg.MaxGetOperationsExecutionDuration,
+		},
 		{
 			methodName: protocol.GetTransactionsMethodName,
 			underlyingHandler: methods.NewGetTransactionsHandler(params.Logger, params.LedgerReader,
-				cfg.MaxTransactionsLimit, cfg.DefaultTransactionsLimit, cfg.NetworkPassphrase),
+				cfg.MaxTransactionsLimit, cfg.DefaultTransactionsLimit, cfg.NetworkPassphrase,
+				params.DataStoreLedgerReader,
+				queryAgeMetric.With(prometheus.Labels{"endpoint": protocol.GetTransactionsMethodName})),
 			longName:             toSnakeCase(protocol.GetTransactionsMethodName),
 			queueLimit:           cfg.RequestBacklogGetTransactionsQueueLimit,
 			requestDurationLimit: cfg.MaxGetTransactionsExecutionDuration,
+			sheddable:            true,
 		},
 		{
 			methodName: protocol.SendTransactionMethodName,
 			underlyingHandler: methods.NewSendTransactionHandler(
-				params.Daemon, params.Logger, params.LedgerReader, cfg.NetworkPassphrase),
-			longName:             toSnakeCase(protocol.SendTransactionMethodName),
-			queueLimit:           cfg.RequestBacklogSendTransactionQueueLimit,
-			requestDurationLimit: cfg.MaxSendTransactionExecutionDuration,
+				params.Daemon, params.Logger, params.LedgerReader, cfg.NetworkPassphrase,
+				cfg.SendTransactionIdempotencyWindow,
+				methods.SubmitRetryPolicy{
+					MaxRetries: cfg.SendTransactionCoreRetries,
+					Backoff:    cfg.SendTransactionCoreRetryBackoff,
+					Budget:     cfg.MaxSendTransactionExecutionDuration,
+				},
+				cfg.SendTransactionMaxExpiredAge,
+				submitted),
+			longName:                   toSnakeCase(protocol.SendTransactionMethodName),
+			queueLimit:                 cfg.RequestBacklogSendTransactionQueueLimit,
+			requestDurationLimit:       cfg.MaxSendTransactionExecutionDuration,
+			notifiable:                 true,
+			availableWhileInitializing: true,
 		},
 		{
 			methodName: protocol.SimulateTransactionMethodName,
 			underlyingHandler: methods.NewSimulateTransactionHandler(
 				params.Logger, params.LedgerReader,
-				params.Daemon.FastCoreClient(), params.PreflightGetter),
+				params.SimulationCoreClient, params.PreflightGetter,
+				methods.SimulationBudgetLimits{
+					MaxInstructionLimit: uint64(cfg.MaxSimulationInstructionLimit),
+					MaxMemoryLimit:      uint64(cfg.MaxSimulationMemoryLimit),
+				},
+				uint32(cfg.CaptiveCoreHTTPQuerySnapshotLedgers),
+				cfg.SimulateTransactionStrictValidation),
 
 			longName:             toSnakeCase(protocol.SimulateTransactionMethodName),
 			queueLimit:           cfg.RequestBacklogSimulateTransactionQueueLimit,
 			requestDurationLimit: cfg.MaxSimulateTransactionExecutionDuration,
 		},
+		{
+			methodName: protocol.GetResourceFeeMethodName,
+			underlyingHandler: methods.NewGetResourceFeeHandler(
+				params.LedgerReader, params.Daemon.FastCoreClient(), params.ResourceFeeGetter),
+			longName:             toSnakeCase(protocol.GetResourceFeeMethodName),
+			queueLimit:           cfg.RequestBacklogGetResourceFeeQueueLimit,
+			requestDurationLimit: cfg.MaxGetResourceFeeExecutionDuration,
+		},
 		{
 			methodName:           protocol.GetFeeStatsMethodName,
 			underlyingHandler:    methods.NewGetFeeStatsHandler(params.FeeStatWindows, params.LedgerReader, params.Logger),
@@ -268,6 +554,29 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 			requestDurationLimit: cfg.MaxGetFeeStatsExecutionDuration,
 		},
 	}
+	var memoryShedder *network.MemoryLoadShedder
+	if cfg.MemoryShedHeapThreshold > 0 {
+		shedCounter := prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: params.Daemon.MetricsNamespace(), Subsystem: "network",
+			Name: "memory_pressure_shed_requests",
+			Help: "The metric measures the count of requests rejected because the heap exceeded the configured threshold",
+		})
+		params.Daemon.MetricsRegistry().MustRegister(shedCounter)
+		memoryShedder = network.MakeMemoryLoadShedder(cfg.MemoryShedHeapThreshold, shedCounter, params.Logger)
+	}
+	requestSizes, err := cfg.MethodMaxRequestSizeMap()
+	if err != nil {
+		// the request sizes are validated with the config
+		params.Logger.WithError(err).Fatal("invalid method request sizes")
+	}
+	// The largest request size that the http handler is willing to accept
+	// before dropping the request (through MaxBytesHandler) is the one of the
+	// methods without a limit (see max-request-size), raised by the method
+	// request size limits (see method-max-request-sizes) above it.
+	maxRequestSize := cfg.MaxRequestSize
+	for _, size := range requestSizes {
+		maxRequestSize = max(maxRequestSize, size)
+	}
 	handlersMap := handler.Map{}
 	for _, handler := range handlers {
 		queueLimiterGaugeName := handler.longName + "_inflight_requests"
@@ -311,10 +620,40 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 			requestDurationLimitCounter,
 			params.Logger)
 		handlersMap[handler.methodName] = durationLimiter.Handle
+		if memoryShedder != nil && handler.sheddable {
+			handlersMap[handler.methodName] = memoryShedder.WrapJrpcHandler(durationLimiter.Handle)
+		}
+		if !handler.availableWhileInitializing {
+			handlersMap[handler.methodName] = methods.RejectWhileInitializing(
+				params.SyncStatus, handlersMap[handler.methodName])
+		}
+		if !handler.notifiable {
+			handlersMap[handler.methodName] = ignoreNotifications(params.Logger, handlersMap[handler.methodName])
+		}
+		requestSize, ok := requestSizes[handler.methodName]
+		if !ok {
+			requestSize = cfg.MaxRequestSize
+		}
+		handlersMap[handler.methodName] = limitRequestSize(requestSize, handlersMap[handler.methodName])
+	}
+	logSampleRates, err := cfg.RequestLogSampleRateMap()
+	if err != nil {
+		// the sample rates are validated with the config
+		params.Logger.WithError(err).Fatal("invalid request log sample rates")
+	}
+	requests := network.MakeRequestRegistry()
+	clientIPResolver := network.NewClientIPResolver(cfg.ClientIPHeader, cfg.TrustedProxyPrefixes())
+	var auditLogger *network.AuditLogger
+	if cfg.AuditLogPath != "" {
+		auditLogger = newAuditLogger(cfg, params, clientIPResolver)
 	}
 	bridge := jhttp.NewBridge(decorateHandlers(
 		params.Daemon,
 		params.Logger,
+		requests,
+		params.CheckpointDeferral,
+		newRequestLogSampler(logSampleRates, cfg.RequestExecutionWarningThreshold),
+		auditLogger,
 		handlersMap),
 		&bridgeOptions)
 
@@ -324,10 +663,22 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 		Help: "Number of concurrenty in-flight http requests",
 	})
 
-	queueLimitedBridge := network.MakeHTTPBacklogQueueLimiter(
-		bridge,
+	methodPriorities, err := cfg.RequestBacklogMethodPriorityMap()
+	if err != nil {
+		// the priorities are validated with the config
+		params.Logger.WithError(err).Fatal("invalid request backlog method priorities")
+	}
+	cacheableMethods := []string{
+		protocol.GetEventsMethodName, protocol.GetTransactionsMethodName, protocol.GetLedgersMethodName,
+	}
+	queueLimitedBridge := network.MakeHTTPPriorityBacklogQueueLimiter(
+		network.MakeHTTPCacheControl(
+			network.MakeHTTPJSONResponseFormatter(bridge, cfg.PrettyJSON),
+			cacheableMethods,
+			cfg.FinalizedResponseMaxAge),
 		globalQueueRequestBacklogLimiter,
 		uint64(cfg.RequestBacklogGlobalQueueLimit),
+		methodPriorities,
 		params.Logger)
 
 	globalQueueRequestExecutionDurationWarningCounter := prometheus.NewCounter(prometheus.CounterOpts{
@@ -350,7 +701,36 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 		globalQueueRequestExecutionDurationLimitCounter,
 		params.Logger)
 
-	handler = http.MaxBytesHandler(handler, maxHTTPRequestSize)
+	// the body of the cross-origin requests is read within the size limit
+	handler = network.MakeHTTPCORSMethodFilter(handler, cfg.CORSMethods, params.Logger)
+	requestSizeWarningCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: params.Daemon.MetricsNamespace(),
+		Subsystem: "network",
+		Name:      "request_size_threshold_warning",
+		Help:      "The metric measures the count of requests that surpassed the warning threshold for their size",
+	})
+	params.Daemon.MetricsRegistry().MustRegister(requestSizeWarningCounter)
+	handler = network.MakeHTTPRequestSizeMonitor(
+		handler,
+		int64(cfg.RequestSizeWarningThreshold),
+		clientIPResolver,
+		requestSizeWarningCounter,
+		params.Logger)
+	handler = http.MaxBytesHandler(handler, int64(maxRequestSize))
+
+	clientConcurrencyLimitCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: params.Daemon.MetricsNamespace(),
+		Subsystem: "network",
+		Name:      "client_concurrency_limit_rejections",
+		Help:      "The metric measures the count of requests rejected by the per-client concurrency limit",
+	})
+	params.Daemon.MetricsRegistry().MustRegister(clientConcurrencyLimitCounter)
+	handler = network.MakeHTTPClientConcurrencyLimiter(
+		handler,
+		cfg.MaxConcurrentRequestsPerClient,
+		clientIPResolver,
+		clientConcurrencyLimitCounter,
+		params.Logger)
 
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins:         []string{},
@@ -359,9 +739,19 @@ func NewJSONRPCHandler(cfg *config.Config, params HandlerParams) Handler {
 		AllowedMethods:         []string{"GET", "PUT", "POST", "PATCH", "DELETE", "HEAD", "OPTIONS"},
 	})
 
+	handler = corsMiddleware.Handler(handler)
+
+	// the audit log records all the requests, including the rejected ones
+	if auditLogger != nil {
+		handler = auditLogger.Handler(handler)
+	}
+
 	return Handler{
-		bridge:  bridge,
-		logger:  params.Logger,
-		Handler: corsMiddleware.Handler(handler),
+		bridge:               bridge,
+		logger:               params.Logger,
+		auditLogger:          auditLogger,
+		Requests:             requests,
+		LedgerEntriesHotKeys: hotKeys,
+		Handler:              handler,
 	}
 }
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/creachadair/jrpc2/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/network"
+)
+
+func parseRequest(t *testing.T, msg string) *jrpc2.Request {
+	requests, err := jrpc2.ParseRequests([]byte(msg))
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	return requests[0].ToRequest()
+}
+
+func TestIgnoreNotifications(t *testing.T) {
+	var calls atomic.Int32
+	local := server.NewLocal(handler.Map{
+		"getHealth": ignoreNotifications(log.DefaultLogger, func(context.Context, *jrpc2.Request) (any, error) {
+			calls.Add(1)
+			return "result", nil
+		}),
+	}, nil)
+
+	var result string
+	require.NoError(t, local.Client.CallResult(context.Background(), "getHealth", nil, &result))
+	require.Equal(t, "result", result)
+	require.Equal(t, int32(1), calls.Load())
+
+	require.NoError(t, local.Client.Notify(context.Background(), "getHealth", nil))
+	// closing the server waits for the notification to be handled
+	require.NoError(t, local.Close())
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestLimitRequestSize(t *testing.T) {
+	h := limitRequestSize(16, func(context.Context, *jrpc2.Request) (any, error) {
+		return "result", nil
+	})
+
+	result, err := h(context.Background(), parseRequest(t, `{"jsonrpc": "2.0", "id": 1, "method": "getHealth"}`))
+	require.NoError(t, err)
+	require.Equal(t, "result", result)
+
+	result, err = h(context.Background(),
+		parseRequest(t, `{"jsonrpc": "2.0", "id": 1, "method": "getHealth", "params": {"a": "0123456789"}}`))
+	require.Nil(t, result)
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	require.Equal(t, jrpc2.InvalidRequest, jrpcErr.Code)
+	require.Contains(t, jrpcErr.Message, "getHealth request parameters too large")
+}
+
+func TestRequestLogSampler(t *testing.T) {
+	sampler := newRequestLogSampler(map[string]uint64{"getHealth": 3}, time.Second)
+
+	var sampled []bool
+	for range 5 {
+		sampled = append(sampled, sampler.sample("getHealth"))
+	}
+	require.Equal(t, []bool{true, false, false, true, false}, sampled)
+	// the methods without a sample rate are always logged
+	require.True(t, sampler.sample("getEvents"))
+	require.True(t, sampler.sample("getEvents"))
+
+	require.False(t, sampler.alwaysLogged("ok", time.Millisecond))
+	require.True(t, sampler.alwaysLogged("ok", 2*time.Second))
+	require.True(t, sampler.alwaysLogged("invalid_params", time.Millisecond))
+}
+
+func TestDecorateHandlersAuditsCalls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	out, err := network.OpenRotatingFile(path, 1024*1024, 1)
+	require.NoError(t, err)
+	auditLogger := network.NewAuditLogger(out, network.NewClientIPResolver("", nil), false,
+		prometheus.NewCounter(prometheus.CounterOpts{Name: "dropped"}), log.DefaultLogger)
+
+	local := server.NewLocal(decorateHandlers(
+		interfaces.MakeNoOpDeamon(),
+		log.DefaultLogger,
+		network.MakeRequestRegistry(),
+		nil,
+		newRequestLogSampler(nil, time.Second),
+		auditLogger,
+		handler.Map{
+			"getHealth": func(context.Context, *jrpc2.Request) (any, error) {
+				return "healthy", nil
+			},
+			"getEvents": func(context.Context, *jrpc2.Request) (any, error) {
+				return nil, &jrpc2.Error{Code: jrpc2.InvalidParams, Message: "invalid"}
+			},
+		}), nil)
+	var result string
+	require.NoError(t, local.Client.CallResult(context.Background(), "getHealth", nil, &result))
+	_, err = local.Client.Call(context.Background(), "getEvents", nil)
+	require.Error(t, err)
+	require.NoError(t, local.Close())
+	require.NoError(t, auditLogger.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	var requestIDs []string
+	for i, expected := range []struct{ method, status string }{
+		{"getHealth", "ok"},
+		{"getEvents", "invalid_parameters"},
+	} {
+		var entry struct {
+			Type      string `json:"type"`
+			RequestID string `json:"requestId"`
+			Method    string `json:"method"`
+			Status    string `json:"status"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(lines[i]), &entry))
+		require.Equal(t, "call", entry.Type)
+		require.Equal(t, expected.method, entry.Method)
+		require.Equal(t, expected.status, entry.Status)
+		require.NotEmpty(t, entry.RequestID)
+		requestIDs = append(requestIDs, entry.RequestID)
+	}
+	// the calls are recorded with the ids they are logged with
+	require.NotEqual(t, requestIDs[0], requestIDs[1])
+}
@@ -0,0 +1,61 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+// NewGetAccountSequenceHandler returns a JSON RPC handler which retrieves the
+// sequence number of an account from Stellar Core.
+func NewGetAccountSequenceHandler(
+	coreClient interfaces.FastCoreClient,
+	latestLedgerReader db.LedgerReader,
+) jrpc2.Handler {
+	return NewHandler(accountSequenceHandler{
+		getter: ledgerentries.NewLedgerEntryGetter(coreClient, latestLedgerReader),
+	}.getAccountSequence)
+}
+
+type accountSequenceHandler struct {
+	getter ledgerentries.LedgerEntryGetter
+}
+
+func (h accountSequenceHandler) getAccountSequence(ctx context.Context,
+	request protocol.GetAccountSequenceRequest,
+) (protocol.GetAccountSequenceResponse, error) {
+	accountID, err := xdr.AddressToAccountId(request.AccountID)
+	if err != nil {
+		return protocol.GetAccountSequenceResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: fmt.Sprintf("invalid account ID %q: %v", request.AccountID, err),
+		}
+	}
+
+	key := xdr.LedgerKey{
+		Type:    xdr.LedgerEntryTypeAccount,
+		Account: &xdr.LedgerKeyAccount{AccountId: accountID},
+	}
+	entries, latestLedger, err := h.getter.GetLedgerEntries(ctx, []xdr.LedgerKey{key})
+	if err != nil {
+		return protocol.GetAccountSequenceResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	response := protocol.GetAccountSequenceResponse{LatestLedger: latestLedger}
+	if len(entries) > 0 {
+		response.Exists = true
+		response.Sequence = int64(entries[0].Entry.Data.MustAccount().SeqNum)
+	}
+	return response, nil
+}
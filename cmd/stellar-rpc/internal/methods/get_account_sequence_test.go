@@ -0,0 +1,68 @@
+package methods
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type accountGetter struct {
+	accounts map[string]xdr.SequenceNumber
+}
+
+func (g accountGetter) GetLedgerEntries(_ context.Context, keys []xdr.LedgerKey,
+) ([]ledgerentries.LedgerKeyAndEntry, uint32, error) {
+	accountID := keys[0].MustAccount().AccountId
+	seqNum, ok := g.accounts[accountID.Address()]
+	if !ok {
+		return nil, 100, nil
+	}
+	return []ledgerentries.LedgerKeyAndEntry{{
+		Key: keys[0],
+		Entry: xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{
+					AccountId: accountID,
+					SeqNum:    seqNum,
+				},
+			},
+		},
+	}}, 100, nil
+}
+
+func TestGetAccountSequence(t *testing.T) {
+	existing := keypair.MustRandom().Address()
+	handler := accountSequenceHandler{
+		getter: accountGetter{accounts: map[string]xdr.SequenceNumber{existing: 1234}},
+	}
+
+	response, err := handler.getAccountSequence(context.Background(),
+		protocol.GetAccountSequenceRequest{AccountID: existing})
+	require.NoError(t, err)
+	assert.Equal(t, protocol.GetAccountSequenceResponse{
+		Exists:       true,
+		Sequence:     1234,
+		LatestLedger: 100,
+	}, response)
+
+	response, err = handler.getAccountSequence(context.Background(),
+		protocol.GetAccountSequenceRequest{AccountID: keypair.MustRandom().Address()})
+	require.NoError(t, err)
+	assert.Equal(t, protocol.GetAccountSequenceResponse{LatestLedger: 100}, response)
+
+	_, err = handler.getAccountSequence(context.Background(),
+		protocol.GetAccountSequenceRequest{AccountID: "CAAAA"})
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	assert.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+}
@@ -0,0 +1,306 @@
+package methods
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+// maxSymbolLength is the maximum length of an ScSymbol.
+const maxSymbolLength = 32
+
+// NewGetContractDataHandler returns a JSON RPC handler which reads the contract
+// data entries stored under symbol (or string) keys from Stellar Core. The
+// entries of a contract can also be listed through the contract data index,
+// unless keyReader is nil.
+func NewGetContractDataHandler(
+	coreClient interfaces.FastCoreClient,
+	latestLedgerReader db.LedgerReader,
+	keyReader db.ContractDataKeyReader,
+	maxKeys uint,
+) jrpc2.Handler {
+	return NewHandler(contractDataHandler{
+		getter:             ledgerentries.NewLedgerEntryGetter(coreClient, latestLedgerReader),
+		latestLedgerReader: latestLedgerReader,
+		keyReader:          keyReader,
+		maxKeys:            maxKeys,
+	}.getContractData)
+}
+
+type contractDataHandler struct {
+	getter             ledgerentries.LedgerEntryGetter
+	latestLedgerReader db.LedgerReader
+	// keyReader is nil when the contract data keys aren't indexed
+	keyReader db.ContractDataKeyReader
+	// maxKeys is the maximum number of keys read at once, and the default
+	// limit when listing
+	maxKeys uint
+}
+
+func isValidSymbol(symbol string) bool {
+	if len(symbol) > maxSymbolLength {
+		return false
+	}
+	for _, c := range symbol {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// contractDataKey builds the ledger key of the contract data entry stored under
+// key.
+func contractDataKey(contractID xdr.ContractId, key string, request protocol.GetContractDataRequest,
+) (xdr.LedgerKey, error) {
+	var scKey xdr.ScVal
+	if request.KeyType == protocol.ContractDataKeyTypeString {
+		str := xdr.ScString(key)
+		scKey = xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &str}
+	} else {
+		if !isValidSymbol(key) {
+			return xdr.LedgerKey{}, fmt.Errorf(
+				"invalid symbol %q: symbols have at most %d characters among a-z, A-Z, 0-9 and _", key, maxSymbolLength)
+		}
+		sym := xdr.ScSymbol(key)
+		scKey = xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}
+	}
+	durability := xdr.ContractDataDurabilityPersistent
+	if request.Durability == protocol.ContractDataDurabilityTemporary {
+		durability = xdr.ContractDataDurabilityTemporary
+	}
+	return xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.LedgerKeyContractData{
+			Contract: xdr.ScAddress{
+				Type:       xdr.ScAddressTypeScAddressTypeContract,
+				ContractId: &contractID,
+			},
+			Key:        scKey,
+			Durability: durability,
+		},
+	}, nil
+}
+
+func (h contractDataHandler) getContractData(ctx context.Context,
+	request protocol.GetContractDataRequest,
+) (protocol.GetContractDataResponse, error) {
+	if err := request.IsValid(h.maxKeys); err != nil {
+		return protocol.GetContractDataResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+	rawContractID := strkey.MustDecode(strkey.VersionByteContract, request.ContractID)
+	var contractID xdr.ContractId
+	copy(contractID[:], rawContractID)
+	if request.Listing() {
+		return h.listContractData(ctx, contractID, request)
+	}
+
+	// the entries are matched to the requested keys through their encoded
+	// ledger keys, duplicates are only fetched once
+	ledgerKeys := make([]xdr.LedgerKey, 0, len(request.Keys))
+	requestKeys := make(map[string]string, len(request.Keys))
+	for _, key := range request.Keys {
+		ledgerKey, err := contractDataKey(contractID, key, request)
+		if err != nil {
+			return protocol.GetContractDataResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InvalidParams,
+				Message: err.Error(),
+			}
+		}
+		encodedKey, err := ledgerKey.MarshalBinaryBase64()
+		if err != nil {
+			return protocol.GetContractDataResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		if _, ok := requestKeys[encodedKey]; ok {
+			continue
+		}
+		requestKeys[encodedKey] = key
+		ledgerKeys = append(ledgerKeys, ledgerKey)
+	}
+
+	entries, latestLedger, err := h.getter.GetLedgerEntries(ctx, ledgerKeys)
+	if err != nil {
+		return protocol.GetContractDataResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	response := protocol.GetContractDataResponse{
+		Entries:      make([]protocol.ContractDataResult, 0, len(entries)),
+		LatestLedger: latestLedger,
+	}
+	for _, entry := range entries {
+		result, err := contractDataResult(entry, requestKeys, request.Format)
+		if err != nil {
+			return protocol.GetContractDataResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		response.Entries = append(response.Entries, result)
+	}
+	return response, nil
+}
+
+// listContractData lists a page of the live entries of a contract, whose keys
+// are read from the contract data index and values from Stellar Core.
+func (h contractDataHandler) listContractData(ctx context.Context, contractID xdr.ContractId,
+	request protocol.GetContractDataRequest,
+) (protocol.GetContractDataResponse, error) {
+	if h.keyReader == nil {
+		return protocol.GetContractDataResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidRequest,
+			Message: "listing the contract data entries needs their index (see the index-contract-data option)",
+		}
+	}
+	var durabilities []xdr.ContractDataDurability
+	switch request.Durability {
+	case protocol.ContractDataDurabilityPersistent:
+		durabilities = []xdr.ContractDataDurability{xdr.ContractDataDurabilityPersistent}
+	case protocol.ContractDataDurabilityTemporary:
+		durabilities = []xdr.ContractDataDurability{xdr.ContractDataDurabilityTemporary}
+	}
+	limit := h.maxKeys
+	var after []byte
+	if request.Pagination != nil {
+		if request.Pagination.Cursor != "" {
+			var err error
+			if after, err = base64.StdEncoding.DecodeString(request.Pagination.Cursor); err != nil {
+				return protocol.GetContractDataResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InvalidParams,
+					Message: fmt.Sprintf("invalid cursor: %v", err),
+				}
+			}
+		}
+		if request.Pagination.Limit > 0 {
+			limit = request.Pagination.Limit
+		}
+	}
+
+	keys, err := h.keyReader.GetContractDataKeys(ctx, contractID, durabilities, after, limit)
+	if err != nil {
+		return protocol.GetContractDataResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	var entries []ledgerentries.LedgerKeyAndEntry
+	var latestLedger uint32
+	if len(keys) > 0 {
+		entries, latestLedger, err = h.getter.GetLedgerEntries(ctx, keys)
+	} else {
+		latestLedger, err = h.latestLedgerReader.GetLatestLedgerSequence(ctx)
+	}
+	if err != nil {
+		return protocol.GetContractDataResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	response := protocol.GetContractDataResponse{
+		Entries:      make([]protocol.ContractDataResult, 0, len(entries)),
+		LatestLedger: latestLedger,
+	}
+	for _, entry := range entries {
+		// the index may still hold the keys of the expired (but not yet
+		// evicted) entries, and the archived ones aren't live either
+		if entry.LiveUntilLedgerSeq != nil && *entry.LiveUntilLedgerSeq < latestLedger {
+			continue
+		}
+		result, err := listedContractDataResult(entry, request.Format)
+		if err != nil {
+			return protocol.GetContractDataResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		response.Entries = append(response.Entries, result)
+	}
+	if uint(len(keys)) >= limit {
+		lastKey, err := keys[len(keys)-1].MarshalBinary()
+		if err != nil {
+			return protocol.GetContractDataResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		response.Cursor = base64.StdEncoding.EncodeToString(lastKey)
+	}
+	return response, nil
+}
+
+// encodeScVal serializes a value in the requested format.
+func encodeScVal(value xdr.ScVal, format string) (string, json.RawMessage, error) {
+	if format == protocol.FormatJSON {
+		valueJSON, err := xdr2json.ConvertInterface(value)
+		return "", valueJSON, err
+	}
+	valueXDR, err := xdr.MarshalBase64(value)
+	return valueXDR, nil, err
+}
+
+func contractDataResult(entry ledgerentries.LedgerKeyAndEntry, requestKeys map[string]string, format string,
+) (protocol.ContractDataResult, error) {
+	encodedKey, err := entry.Key.MarshalBinaryBase64()
+	if err != nil {
+		return protocol.ContractDataResult{}, err
+	}
+	result := protocol.ContractDataResult{
+		Key:                requestKeys[encodedKey],
+		LastModifiedLedger: uint32(entry.Entry.LastModifiedLedgerSeq),
+		LiveUntilLedgerSeq: entry.LiveUntilLedgerSeq,
+	}
+	value := entry.Entry.Data.MustContractData().Val
+	if result.ValueXDR, result.ValueJSON, err = encodeScVal(value, format); err != nil {
+		return protocol.ContractDataResult{}, fmt.Errorf("could not serialize the value of %q: %w", result.Key, err)
+	}
+	return result, nil
+}
+
+// listedContractDataResult returns the result of a listed entry, which holds
+// its key and durability.
+func listedContractDataResult(entry ledgerentries.LedgerKeyAndEntry, format string,
+) (protocol.ContractDataResult, error) {
+	data := entry.Entry.Data.MustContractData()
+	result := protocol.ContractDataResult{
+		Durability:         protocol.ContractDataDurabilityPersistent,
+		LastModifiedLedger: uint32(entry.Entry.LastModifiedLedgerSeq),
+		LiveUntilLedgerSeq: entry.LiveUntilLedgerSeq,
+	}
+	if data.Durability == xdr.ContractDataDurabilityTemporary {
+		result.Durability = protocol.ContractDataDurabilityTemporary
+	}
+	if sym, ok := data.Key.GetSym(); ok {
+		result.Key = string(sym)
+	} else if str, ok := data.Key.GetStr(); ok {
+		result.Key = string(str)
+	}
+	var err error
+	if result.KeyXDR, result.KeyJSON, err = encodeScVal(data.Key, format); err != nil {
+		return protocol.ContractDataResult{}, fmt.Errorf("could not serialize a key: %w", err)
+	}
+	if result.ValueXDR, result.ValueJSON, err = encodeScVal(data.Val, format); err != nil {
+		return protocol.ContractDataResult{}, fmt.Errorf("could not serialize a value: %w", err)
+	}
+	return result, nil
+}
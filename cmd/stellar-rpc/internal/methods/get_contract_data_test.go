@@ -0,0 +1,165 @@
+package methods
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type contractDataGetter struct {
+	// values holds the stored u32 values by symbol
+	values      map[string]uint32
+	requestKeys []xdr.LedgerKey
+}
+
+func (g *contractDataGetter) GetLedgerEntries(_ context.Context, keys []xdr.LedgerKey,
+) ([]ledgerentries.LedgerKeyAndEntry, uint32, error) {
+	g.requestKeys = keys
+	var result []ledgerentries.LedgerKeyAndEntry
+	for _, key := range keys {
+		contractData := key.MustContractData()
+		value, ok := g.values[string(contractData.Key.MustSym())]
+		if !ok {
+			continue
+		}
+		u32 := xdr.Uint32(value)
+		result = append(result, ledgerentries.LedgerKeyAndEntry{
+			Key: key,
+			Entry: xdr.LedgerEntry{
+				LastModifiedLedgerSeq: 90,
+				Data: xdr.LedgerEntryData{
+					Type: xdr.LedgerEntryTypeContractData,
+					ContractData: &xdr.ContractDataEntry{
+						Contract:   contractData.Contract,
+						Key:        contractData.Key,
+						Durability: contractData.Durability,
+						Val:        xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &u32},
+					},
+				},
+			},
+		})
+	}
+	return result, 100, nil
+}
+
+func TestGetContractData(t *testing.T) {
+	contractID := strkey.MustEncode(strkey.VersionByteContract, make([]byte, 32))
+	getter := &contractDataGetter{values: map[string]uint32{"Admin": 1, "Counter": 2}}
+	handler := contractDataHandler{getter: getter, maxKeys: 3}
+
+	_, err := handler.getContractData(context.Background(), protocol.GetContractDataRequest{
+		ContractID: contractID,
+		Keys:       []string{"Counter", "Missing", "Counter", "Admin"},
+	})
+	require.Error(t, err, "more keys than allowed")
+
+	response, err := handler.getContractData(context.Background(), protocol.GetContractDataRequest{
+		ContractID: contractID,
+		Keys:       []string{"Counter", "Missing", "Counter"},
+	})
+	require.NoError(t, err)
+	// duplicates are only fetched once
+	require.Len(t, getter.requestKeys, 2)
+	assert.Equal(t, xdr.ContractDataDurabilityPersistent, getter.requestKeys[0].MustContractData().Durability)
+	assert.Equal(t, uint32(100), response.LatestLedger)
+	require.Len(t, response.Entries, 1)
+	assert.Equal(t, "Counter", response.Entries[0].Key)
+	assert.Equal(t, uint32(90), response.Entries[0].LastModifiedLedger)
+	var value xdr.ScVal
+	require.NoError(t, xdr.SafeUnmarshalBase64(response.Entries[0].ValueXDR, &value))
+	assert.Equal(t, uint32(2), uint32(value.MustU32()))
+
+	response, err = handler.getContractData(context.Background(), protocol.GetContractDataRequest{
+		ContractID: contractID,
+		Keys:       []string{"Admin"},
+		Durability: protocol.ContractDataDurabilityTemporary,
+		Format:     protocol.FormatJSON,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, xdr.ContractDataDurabilityTemporary, getter.requestKeys[0].MustContractData().Durability)
+	require.Len(t, response.Entries, 1)
+	assert.Empty(t, response.Entries[0].ValueXDR)
+	assert.JSONEq(t, `{"u32":1}`, string(response.Entries[0].ValueJSON))
+
+	for _, request := range []protocol.GetContractDataRequest{
+		{ContractID: "CAAAA", Keys: []string{"Admin"}},
+		{ContractID: contractID, Keys: []string{"not a symbol"}},
+		{ContractID: contractID, Keys: []string{"Admin"}, KeyType: "bytes"},
+	} {
+		_, err = handler.getContractData(context.Background(), request)
+		var jrpcErr *jrpc2.Error
+		require.ErrorAs(t, err, &jrpcErr)
+		assert.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+	}
+}
+
+type contractDataKeyReader struct {
+	symbols []string
+}
+
+func (r contractDataKeyReader) GetContractDataKeys(_ context.Context, contractID xdr.ContractId,
+	_ []xdr.ContractDataDurability, after []byte, limit uint,
+) ([]xdr.LedgerKey, error) {
+	var keys []xdr.LedgerKey
+	for _, symbol := range r.symbols {
+		key, err := contractDataKey(contractID, symbol, protocol.GetContractDataRequest{})
+		if err != nil {
+			return nil, err
+		}
+		encodedKey, err := key.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		if string(encodedKey) > string(after) && uint(len(keys)) < limit {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func TestListContractData(t *testing.T) {
+	contractID := strkey.MustEncode(strkey.VersionByteContract, make([]byte, 32))
+	getter := &contractDataGetter{values: map[string]uint32{"Admin": 1, "Counter": 2}}
+	handler := contractDataHandler{getter: getter, maxKeys: 2}
+
+	_, err := handler.getContractData(context.Background(), protocol.GetContractDataRequest{ContractID: contractID})
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	assert.Equal(t, jrpc2.InvalidRequest, jrpcErr.Code, "listing needs the index")
+
+	// the keys of the removed entries may linger in the index
+	handler.keyReader = contractDataKeyReader{symbols: []string{"Admin", "Counter", "Removed"}}
+	response, err := handler.getContractData(context.Background(), protocol.GetContractDataRequest{
+		ContractID: contractID,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Entries, 2)
+	assert.Equal(t, "Admin", response.Entries[0].Key)
+	assert.Equal(t, protocol.ContractDataDurabilityPersistent, response.Entries[0].Durability)
+	assert.NotEmpty(t, response.Entries[0].KeyXDR)
+	require.NotEmpty(t, response.Cursor)
+
+	response, err = handler.getContractData(context.Background(), protocol.GetContractDataRequest{
+		ContractID: contractID,
+		Pagination: &protocol.LedgerPaginationOptions{Cursor: response.Cursor},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, response.Entries)
+	assert.Empty(t, response.Cursor)
+
+	_, err = handler.getContractData(context.Background(), protocol.GetContractDataRequest{
+		ContractID: contractID,
+		Pagination: &protocol.LedgerPaginationOptions{Limit: 3},
+	})
+	require.ErrorAs(t, err, &jrpcErr)
+	assert.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+}
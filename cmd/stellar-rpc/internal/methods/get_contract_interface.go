@@ -0,0 +1,310 @@
+package methods
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+// Names of the Wasm custom sections embedded by the Soroban SDK.
+const (
+	wasmSectionContractSpec    = "contractspecv0"
+	wasmSectionContractMeta    = "contractmetav0"
+	wasmSectionContractEnvMeta = "contractenvmetav0"
+)
+
+// NewGetContractInterfaceHandler returns a JSON RPC handler which decodes the
+// interface and metadata embedded in a contract's Wasm.
+func NewGetContractInterfaceHandler(
+	logger *log.Entry,
+	coreClient interfaces.FastCoreClient,
+	latestLedgerReader db.LedgerReader,
+) jrpc2.Handler {
+	return NewHandler((&contractInterfaceHandler{
+		logger: logger,
+		getter: ledgerentries.NewLedgerEntryGetter(coreClient, latestLedgerReader),
+	}).getContractInterface)
+}
+
+type contractInterfaceHandler struct {
+	logger *log.Entry
+	getter ledgerentries.LedgerEntryGetter
+}
+
+func (h contractInterfaceHandler) getContractInterface(ctx context.Context,
+	request protocol.GetContractInterfaceRequest,
+) (protocol.GetContractInterfaceResponse, error) {
+	if err := request.Validate(); err != nil {
+		return protocol.GetContractInterfaceResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+
+	var (
+		wasmHash xdr.Hash
+		archived bool
+	)
+	if request.ContractID != "" {
+		instance, instanceArchived, err := getContractInstance(ctx, h.getter, request.ContractID)
+		if err != nil {
+			return protocol.GetContractInterfaceResponse{}, err
+		}
+		if instance.Executable.Type != xdr.ContractExecutableTypeContractExecutableWasm {
+			return protocol.GetContractInterfaceResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InvalidParams,
+				Message: "contract is a built-in Stellar Asset Contract and has no Wasm interface",
+			}
+		}
+		wasmHash = *instance.Executable.WasmHash
+		archived = instanceArchived
+	} else {
+		hashBytes, err := hex.DecodeString(request.WasmHash)
+		if err != nil || len(hashBytes) != len(wasmHash) {
+			return protocol.GetContractInterfaceResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InvalidParams,
+				Message: "wasmHash must be a hex-encoded 32 byte hash",
+			}
+		}
+		copy(wasmHash[:], hashBytes)
+	}
+
+	codeKey := xdr.LedgerKey{
+		Type:         xdr.LedgerEntryTypeContractCode,
+		ContractCode: &xdr.LedgerKeyContractCode{Hash: wasmHash},
+	}
+	entries, latestLedger, err := h.getter.GetLedgerEntries(ctx, []xdr.LedgerKey{codeKey})
+	if err != nil {
+		h.logger.WithError(err).WithField("request", request).
+			Info("could not obtain contract code")
+		return protocol.GetContractInterfaceResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	if len(entries) == 0 {
+		return protocol.GetContractInterfaceResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: fmt.Sprintf("contract code %s not found", wasmHash.HexString()),
+		}
+	}
+	archived = archived || isArchived(entries[0], latestLedger)
+
+	response, err := contractInterfaceFromWasm(entries[0].Entry.Data.MustContractCode().Code, request.Format)
+	if err != nil {
+		return protocol.GetContractInterfaceResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	response.WasmHash = wasmHash.HexString()
+	response.Archived = archived
+	response.LatestLedger = latestLedger
+	return response, nil
+}
+
+// getContractInstance fetches the instance entry of the given contract,
+// reporting whether it is archived.
+func getContractInstance(ctx context.Context, getter ledgerentries.LedgerEntryGetter, contractID string,
+) (xdr.ScContractInstance, bool, error) {
+	rawID, err := strkey.Decode(strkey.VersionByteContract, contractID)
+	if err != nil {
+		return xdr.ScContractInstance{}, false, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: fmt.Sprintf("invalid contract ID %q: %v", contractID, err),
+		}
+	}
+	var id xdr.ContractId
+	copy(id[:], rawID)
+
+	instanceKey := xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.LedgerKeyContractData{
+			Contract: xdr.ScAddress{
+				Type:       xdr.ScAddressTypeScAddressTypeContract,
+				ContractId: &id,
+			},
+			Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+			Durability: xdr.ContractDataDurabilityPersistent,
+		},
+	}
+	entries, latestLedger, err := getter.GetLedgerEntries(ctx, []xdr.LedgerKey{instanceKey})
+	if err != nil {
+		return xdr.ScContractInstance{}, false, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	if len(entries) == 0 {
+		return xdr.ScContractInstance{}, false, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: fmt.Sprintf("contract %s not found", contractID),
+		}
+	}
+	instance, ok := entries[0].Entry.Data.MustContractData().Val.GetInstance()
+	if !ok {
+		return xdr.ScContractInstance{}, false, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: fmt.Sprintf("unexpected instance entry for contract %s", contractID),
+		}
+	}
+	return instance, isArchived(entries[0], latestLedger), nil
+}
+
+func isArchived(entry ledgerentries.LedgerKeyAndEntry, latestLedger uint32) bool {
+	// archived entries are reported with a live-until ledger of 0
+	return entry.LiveUntilLedgerSeq != nil && *entry.LiveUntilLedgerSeq < latestLedger
+}
+
+func contractInterfaceFromWasm(code []byte, format string) (protocol.GetContractInterfaceResponse, error) {
+	sections, err := parseWasmCustomSections(code)
+	if err != nil {
+		return protocol.GetContractInterfaceResponse{}, fmt.Errorf("could not parse contract wasm: %w", err)
+	}
+
+	var response protocol.GetContractInterfaceResponse
+	specs, err := decodeXDRStream[xdr.ScSpecEntry](sections[wasmSectionContractSpec])
+	if err != nil {
+		return response, fmt.Errorf("could not decode %s section: %w", wasmSectionContractSpec, err)
+	}
+	metas, err := decodeXDRStream[xdr.ScMetaEntry](sections[wasmSectionContractMeta])
+	if err != nil {
+		return response, fmt.Errorf("could not decode %s section: %w", wasmSectionContractMeta, err)
+	}
+	envMetas, err := decodeXDRStream[xdr.ScEnvMetaEntry](sections[wasmSectionContractEnvMeta])
+	if err != nil {
+		return response, fmt.Errorf("could not decode %s section: %w", wasmSectionContractEnvMeta, err)
+	}
+
+	switch format {
+	case protocol.FormatJSON:
+		if response.SpecJSON, err = convertToJSONList(specs); err != nil {
+			return response, err
+		}
+		if response.MetaJSON, err = convertToJSONList(metas); err != nil {
+			return response, err
+		}
+		if response.EnvMetaJSON, err = convertToJSONList(envMetas); err != nil {
+			return response, err
+		}
+	default:
+		if response.SpecXDR, err = marshalBase64List(specs); err != nil {
+			return response, err
+		}
+		if response.MetaXDR, err = marshalBase64List(metas); err != nil {
+			return response, err
+		}
+		if response.EnvMetaXDR, err = marshalBase64List(envMetas); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+// decodeXDRStream decodes the back-to-back XDR values which make up the
+// contents of a contract custom section.
+func decodeXDRStream[T any](data []byte) ([]T, error) {
+	var result []T
+	reader := bytes.NewReader(data)
+	for reader.Len() > 0 {
+		var entry T
+		if _, err := xdr.Unmarshal(reader, &entry); err != nil {
+			return nil, err
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func marshalBase64List[T any](entries []T) ([]string, error) {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		b64, err := xdr.MarshalBase64(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, b64)
+	}
+	return result, nil
+}
+
+func convertToJSONList[T encoding.BinaryMarshaler](entries []T) ([]json.RawMessage, error) {
+	result := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		js, err := xdr2json.ConvertInterface(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, js)
+	}
+	return result, nil
+}
+
+var wasmMagic = []byte{0x00, 'a', 's', 'm'} //nolint:gochecknoglobals
+
+// parseWasmCustomSections returns the contents of the custom sections of a
+// Wasm module, keyed by section name. Sections with the same name are
+// concatenated in the order they appear.
+func parseWasmCustomSections(code []byte) (map[string][]byte, error) {
+	const (
+		headerSize      = 8 // magic + version
+		customSectionID = 0
+	)
+	if len(code) < headerSize || !bytes.Equal(code[:len(wasmMagic)], wasmMagic) {
+		return nil, errors.New("not a wasm module")
+	}
+
+	sections := make(map[string][]byte)
+	reader := bytes.NewReader(code[headerSize:])
+	for reader.Len() > 0 {
+		sectionID, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid section size: %w", err)
+		}
+		if size > uint64(reader.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		contents := make([]byte, size)
+		if _, err := io.ReadFull(reader, contents); err != nil {
+			return nil, err
+		}
+		if sectionID != customSectionID {
+			continue
+		}
+
+		contentsReader := bytes.NewReader(contents)
+		nameLen, err := binary.ReadUvarint(contentsReader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid custom section name: %w", err)
+		}
+		if nameLen > uint64(contentsReader.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		nameStart := len(contents) - contentsReader.Len()
+		name := string(contents[nameStart : nameStart+int(nameLen)])
+		sections[name] = append(sections[name], contents[nameStart+int(nameLen):]...)
+	}
+	return sections, nil
+}
@@ -0,0 +1,154 @@
+package methods
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type contractCodeGetter struct {
+	code         []byte
+	liveUntil    *uint32
+	latestLedger uint32
+}
+
+func (g contractCodeGetter) GetLedgerEntries(_ context.Context, keys []xdr.LedgerKey,
+) ([]ledgerentries.LedgerKeyAndEntry, uint32, error) {
+	if g.code == nil || keys[0].Type != xdr.LedgerEntryTypeContractCode {
+		return nil, g.latestLedger, nil
+	}
+	return []ledgerentries.LedgerKeyAndEntry{{
+		Key: keys[0],
+		Entry: xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeContractCode,
+				ContractCode: &xdr.ContractCodeEntry{
+					Hash: keys[0].ContractCode.Hash,
+					Code: g.code,
+				},
+			},
+		},
+		LiveUntilLedgerSeq: g.liveUntil,
+	}}, g.latestLedger, nil
+}
+
+func readTestWasm(t *testing.T) []byte {
+	code, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "wasms", "test_no_arg_constructor.wasm"))
+	require.NoError(t, err)
+	return code
+}
+
+func TestParseWasmCustomSections(t *testing.T) {
+	sections, err := parseWasmCustomSections(readTestWasm(t))
+	require.NoError(t, err)
+	assert.Contains(t, sections, wasmSectionContractSpec)
+	assert.Contains(t, sections, wasmSectionContractMeta)
+	assert.Contains(t, sections, wasmSectionContractEnvMeta)
+
+	// a module with only the header has no sections
+	sections, err = parseWasmCustomSections([]byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Empty(t, sections)
+
+	_, err = parseWasmCustomSections([]byte("not wasm"))
+	require.Error(t, err)
+
+	// custom section claiming more bytes than available
+	_, err = parseWasmCustomSections([]byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00, 0x00, 0x10, 0x01})
+	require.Error(t, err)
+}
+
+func TestGetContractInterface(t *testing.T) {
+	code := readTestWasm(t)
+	hash := sha256.Sum256(code)
+	liveUntil := uint32(200)
+	handler := contractInterfaceHandler{
+		logger: log.DefaultLogger,
+		getter: contractCodeGetter{code: code, liveUntil: &liveUntil, latestLedger: 100},
+	}
+
+	request := protocol.GetContractInterfaceRequest{WasmHash: hex.EncodeToString(hash[:])}
+	response, err := handler.getContractInterface(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(hash[:]), response.WasmHash)
+	assert.False(t, response.Archived)
+	assert.Equal(t, uint32(100), response.LatestLedger)
+	assert.NotEmpty(t, response.MetaXDR)
+	assert.NotEmpty(t, response.EnvMetaXDR)
+
+	var functions []string
+	for _, b64 := range response.SpecXDR {
+		var entry xdr.ScSpecEntry
+		require.NoError(t, xdr.SafeUnmarshalBase64(b64, &entry))
+		if fn, ok := entry.GetFunctionV0(); ok {
+			functions = append(functions, string(fn.Name))
+		}
+	}
+	assert.Contains(t, functions, "__constructor")
+
+	// archived code is still inspectable
+	archivedLiveUntil := uint32(0)
+	handler.getter = contractCodeGetter{code: code, liveUntil: &archivedLiveUntil, latestLedger: 100}
+	response, err = handler.getContractInterface(context.TODO(), request)
+	require.NoError(t, err)
+	assert.True(t, response.Archived)
+	assert.NotEmpty(t, response.SpecXDR)
+}
+
+func TestGetContractInterfaceErrors(t *testing.T) {
+	for _, testCase := range []struct {
+		name    string
+		getter  contractCodeGetter
+		request protocol.GetContractInterfaceRequest
+		code    jrpc2.Code
+	}{
+		{
+			name:    "neither contract nor hash",
+			request: protocol.GetContractInterfaceRequest{},
+			code:    jrpc2.InvalidParams,
+		},
+		{
+			name:    "invalid hash",
+			request: protocol.GetContractInterfaceRequest{WasmHash: "abcd"},
+			code:    jrpc2.InvalidParams,
+		},
+		{
+			name:    "invalid contract id",
+			request: protocol.GetContractInterfaceRequest{ContractID: "GABC"},
+			code:    jrpc2.InvalidParams,
+		},
+		{
+			name:    "code not found",
+			getter:  contractCodeGetter{latestLedger: 100},
+			request: protocol.GetContractInterfaceRequest{WasmHash: hex.EncodeToString(make([]byte, 32))},
+			code:    jrpc2.InvalidParams,
+		},
+		{
+			name:    "invalid code",
+			getter:  contractCodeGetter{code: []byte("garbage"), latestLedger: 100},
+			request: protocol.GetContractInterfaceRequest{WasmHash: hex.EncodeToString(make([]byte, 32))},
+			code:    jrpc2.InternalError,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			handler := contractInterfaceHandler{logger: log.DefaultLogger, getter: testCase.getter}
+			_, err := handler.getContractInterface(context.TODO(), testCase.request)
+			var rpcErr *jrpc2.Error
+			require.ErrorAs(t, err, &rpcErr)
+			assert.Equal(t, testCase.code, rpcErr.Code)
+		})
+	}
+}
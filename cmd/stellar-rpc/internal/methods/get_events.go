@@ -2,19 +2,25 @@ package methods
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/stellar/go/ingest"
 	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/collections/set"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerbucketwindow"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/preflight"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/rpcdatastore"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
 	"github.com/stellar/stellar-rpc/protocol"
 )
@@ -25,11 +31,15 @@ const (
 )
 
 type eventsRPCHandler struct {
-	dbReader     db.EventReader
-	maxLimit     uint
-	defaultLimit uint
-	logger       *log.Entry
-	ledgerReader db.LedgerReader
+	dbReader              db.EventReader
+	transactionReader     db.TransactionReader
+	maxLimit              uint
+	defaultLimit          uint
+	logger                *log.Entry
+	ledgerReader          db.LedgerReader
+	datastoreLedgerReader rpcdatastore.LedgerReader
+	networkPassphrase     string
+	queryAge              prometheus.Observer
 }
 
 func combineContractIDs(filters []protocol.EventFilter) ([][]byte, error) {
@@ -68,8 +78,17 @@ func combineEventTypes(filters []protocol.EventFilter) []int {
 	return uniqueEventTypes
 }
 
+// combineTopics returns, for every topic position, the values accepted at that
+// position by any of the filters. The database query only keeps events with
+// at least one topic among them, which is a superset of the matching events
+// (they are matched exactly afterwards). No values are returned when a filter
+// or topic filter accepts any topics, since the query can't exclude events then.
+//
+// When all the topic filters constrain the first topic, only the first topic
+// values are returned, which lets the query use the first topic index.
 func combineTopics(filters []protocol.EventFilter) ([][][]byte, error) {
 	encodedTopicsList := make([][][]byte, protocol.MaxTopicCount)
+	firstTopicConstrained := true
 
 	for _, filter := range filters {
 		if len(filter.Topics) == 0 {
@@ -77,18 +96,34 @@ func combineTopics(filters []protocol.EventFilter) ([][][]byte, error) {
 		}
 
 		for _, topicFilter := range filter.Topics {
+			wildcardsOnly := true
 			for i, segmentFilter := range topicFilter {
-				if segmentFilter.Wildcard == nil && segmentFilter.ScVal != nil {
-					encodedTopic, err := segmentFilter.ScVal.MarshalBinary()
+				if segmentFilter.Wildcard != nil {
+					continue
+				}
+				for _, value := range segmentFilter.Values() {
+					encodedTopic, err := value.MarshalBinary()
 					if err != nil {
 						return [][][]byte{}, fmt.Errorf("failed to marshal segment: %w", err)
 					}
 					encodedTopicsList[i] = append(encodedTopicsList[i], encodedTopic)
+					wildcardsOnly = false
 				}
 			}
+			if wildcardsOnly {
+				return [][][]byte{}, nil
+			}
+			if topicFilter[0].Wildcard != nil {
+				firstTopicConstrained = false
+			}
 		}
 	}
 
+	if firstTopicConstrained {
+		for i := 1; i < len(encodedTopicsList); i++ {
+			encodedTopicsList[i] = nil
+		}
+	}
 	return encodedTopicsList, nil
 }
 
@@ -117,9 +152,21 @@ func (h eventsRPCHandler) getEvents(ctx context.Context, request protocol.GetEve
 		}
 	}
 
+	if request.Ledger != 0 {
+		request.StartLedger = request.Ledger
+		request.EndLedger = request.Ledger + 1
+	}
 	start := protocol.Cursor{Ledger: request.StartLedger}
 	limit := h.defaultLimit
+	// snapshotLedger, when set, caps the pages to a point-in-time view
+	var snapshotLedger uint32
+	if request.Snapshot {
+		snapshotLedger = ledgerRange.LastLedger.Sequence
+	}
 	if request.Pagination != nil {
+		if request.Pagination.SnapshotLedger != 0 {
+			snapshotLedger = request.Pagination.SnapshotLedger
+		}
 		if request.Pagination.Cursor != nil {
 			start = *request.Pagination.Cursor
 			// increment event index because, when paginating, we start with the
@@ -130,26 +177,68 @@ func (h eventsRPCHandler) getEvents(ctx context.Context, request protocol.GetEve
 			limit = request.Pagination.Limit
 		}
 	}
+	if request.IncludeStateChanges {
+		limit = min(limit, protocol.MaxStateChangesEventsLimit)
+	}
 	endLedger := start.Ledger + LedgerScanLimit
 	// endLedger should not exceed ledger retention window
 	endLedger = min(ledgerRange.LastLedger.Sequence+1, endLedger)
 	if request.EndLedger != 0 {
 		endLedger = min(request.EndLedger, endLedger)
 	}
+	if snapshotLedger != 0 {
+		endLedger = min(snapshotLedger+1, endLedger)
+	}
 
 	end := protocol.Cursor{Ledger: endLedger}
 	cursorRange := protocol.CursorRange{Start: start, End: end}
 
-	if start.Ledger < ledgerRange.FirstLedger.Sequence || start.Ledger > ledgerRange.LastLedger.Sequence {
+	firstLedger := ledgerRange.FirstLedger.Sequence
+	if h.datastoreLedgerReader != nil {
+		dsRange, err := h.datastoreLedgerReader.GetAvailableLedgerRange(ctx)
+		if err != nil {
+			// log error but continue using local ledger range
+			h.logger.WithError(err).Error("failed to get available ledger range from datastore")
+		} else {
+			// extend available range to include datastore
+			firstLedger = min(dsRange.FirstLedger, firstLedger)
+		}
+	}
+
+	if start.Ledger < firstLedger || start.Ledger > ledgerRange.LastLedger.Sequence {
 		return protocol.GetEventsResponse{}, &jrpc2.Error{
 			Code: jrpc2.InvalidRequest,
 			Message: fmt.Sprintf(
 				"startLedger must be within the ledger range: %d - %d",
-				ledgerRange.FirstLedger.Sequence,
+				firstLedger,
 				ledgerRange.LastLedger.Sequence,
 			),
 		}
 	}
+	observeQueryAge(h.queryAge, ledgerRange.LastLedger.Sequence, start.Ledger)
+
+	var txHashes [][]byte
+	var unknownTxHashes []string
+	if len(request.TransactionHashes) > 0 {
+		// the transactions are looked up in the database, so their events
+		// can't be extracted from the datastore
+		if start.Ledger < ledgerRange.FirstLedger.Sequence {
+			return protocol.GetEventsResponse{}, &jrpc2.Error{
+				Code: jrpc2.InvalidParams,
+				Message: fmt.Sprintf(
+					"only the events of the ledger range %d - %d can be filtered by transaction hash",
+					ledgerRange.FirstLedger.Sequence,
+					ledgerRange.LastLedger.Sequence,
+				),
+			}
+		}
+		txHashes, unknownTxHashes, err = h.lookupTransactionHashes(ctx, request.TransactionHashes)
+		if err != nil {
+			return protocol.GetEventsResponse{}, &jrpc2.Error{
+				Code: jrpc2.InternalError, Message: err.Error(),
+			}
+		}
+	}
 
 	found := make([]entry, 0, limit)
 
@@ -169,20 +258,65 @@ func (h eventsRPCHandler) getEvents(ctx context.Context, request protocol.GetEve
 
 	eventTypes := combineEventTypes(request.Filters)
 
+	if request.Aggregate != "" {
+		return h.aggregateEvents(ctx, request, cursorRange, contractIDs, topics, eventTypes, limit, ledgerRange)
+	}
+
+	groupByTransaction := request.GroupBy == protocol.EventsGroupByTransaction
+	// when grouping by transaction, the limit applies to the number of transactions
+	transactionCount := uint(0)
+	limitReached := false
+
 	// Scan function to apply filters
 	eventScanFunction := func(
 		event xdr.DiagnosticEvent, cursor protocol.Cursor, ledgerCloseTimestamp int64, txHash *xdr.Hash,
 	) bool {
-		if request.Matches(event) {
+		if !request.Matches(event) {
+			return true
+		}
+		if groupByTransaction {
+			if len(found) == 0 || !sameTransaction(found[len(found)-1].cursor, cursor) {
+				if transactionCount == limit {
+					// stop before the first event of the transaction beyond
+					// the limit, so that all the returned groups are complete
+					limitReached = true
+					return false
+				}
+				transactionCount++
+			}
 			found = append(found, entry{cursor, ledgerCloseTimestamp, event, txHash})
+			return true
 		}
-		return uint(len(found)) < limit
+		found = append(found, entry{cursor, ledgerCloseTimestamp, event, txHash})
+		limitReached = uint(len(found)) >= limit
+		return !limitReached
 	}
 
-	err = h.dbReader.GetEvents(ctx, cursorRange, contractIDs, topics, eventTypes, eventScanFunction)
-	if err != nil {
-		return protocol.GetEventsResponse{}, &jrpc2.Error{
-			Code: jrpc2.InvalidRequest, Message: err.Error(),
+	// The events which predate the retention window are extracted from the
+	// datastore ledgers, the rest are read from the database.
+	fromDatastore := start.Ledger < ledgerRange.FirstLedger.Sequence
+	// none of the transactions the events are filtered by is known
+	scanDB := len(request.TransactionHashes) == 0 || len(txHashes) > 0
+	if fromDatastore {
+		dsCursorRange := protocol.CursorRange{
+			Start: start,
+			End:   protocol.Cursor{Ledger: min(endLedger, ledgerRange.FirstLedger.Sequence)},
+		}
+		if err := h.scanDatastoreEvents(ctx, dsCursorRange, eventScanFunction); err != nil {
+			return protocol.GetEventsResponse{}, &jrpc2.Error{
+				Code: jrpc2.InternalError, Message: err.Error(),
+			}
+		}
+		cursorRange.Start = dsCursorRange.End
+		scanDB = !limitReached && endLedger > ledgerRange.FirstLedger.Sequence
+	}
+
+	if scanDB {
+		err = h.dbReader.GetEvents(ctx, cursorRange, contractIDs, topics, eventTypes, txHashes, eventScanFunction)
+		if err != nil {
+			return protocol.GetEventsResponse{}, &jrpc2.Error{
+				Code: jrpc2.InvalidRequest, Message: err.Error(),
+			}
 		}
 	}
 
@@ -200,11 +334,28 @@ func (h eventsRPCHandler) getEvents(ctx context.Context, request protocol.GetEve
 		}
 		results = append(results, info)
 	}
+	if request.IncludeStateChanges || request.IncludeContractCreation {
+		if err := h.addOperationContext(ctx, found, results, request); err != nil {
+			return protocol.GetEventsResponse{}, &jrpc2.Error{
+				Code: jrpc2.InternalError, Message: err.Error(),
+			}
+		}
+	}
 
 	var cursor string
-	if uint(len(results)) == limit {
+	// the scan covers the ledgers up to the last event found when the limit is
+	// reached, and up to the end of the search window otherwise
+	ledgersScanned := uint32(0)
+	if endLedger > start.Ledger {
+		ledgersScanned = endLedger - start.Ledger
+	}
+	// the last ledger covered by the page
+	lastLedger := endLedger - 1
+	if limitReached {
 		lastEvent := results[len(results)-1]
 		cursor = lastEvent.ID
+		lastLedger = found[len(found)-1].cursor.Ledger
+		ledgersScanned = lastLedger - start.Ledger + 1
 	} else {
 		// cursor represents end of the search window if events does not reach limit
 		// here endLedger is always exclusive when fetching events
@@ -213,18 +364,340 @@ func (h eventsRPCHandler) getEvents(ctx context.Context, request protocol.GetEve
 		maxCursor.Ledger = endLedger - 1
 		cursor = maxCursor.String()
 	}
+	cursor = protocol.EncodeSnapshotCursor(cursor, snapshotLedger)
+
+	var transactions []protocol.TransactionEvents
+	if groupByTransaction {
+		transactions = groupEventsByTransaction(results)
+		results = []protocol.EventInfo{}
+	}
+
+	return protocol.GetEventsResponse{
+		Events:       results,
+		Transactions: transactions,
+		Cursor:       cursor,
+
+		UnknownTransactionHashes: unknownTxHashes,
+
+		LatestLedger:          ledgerRange.LastLedger.Sequence,
+		OldestLedger:          ledgerRange.FirstLedger.Sequence,
+		LatestLedgerCloseTime: ledgerRange.LastLedger.CloseTime,
+		OldestLedgerCloseTime: ledgerRange.FirstLedger.CloseTime,
+		FromDatastore:         fromDatastore,
+		LedgersScanned:        ledgersScanned,
+		Page: protocol.PageInfo{
+			Cursor:       cursor,
+			Limit:        limit,
+			HasMore:      limitReached,
+			Finalized:    lastLedger < ledgerRange.LastLedger.Sequence,
+			OldestLedger: ledgerRange.FirstLedger.Sequence,
+			LatestLedger: ledgerRange.LastLedger.Sequence,
+		},
+	}, nil
+}
+
+// lookupTransactionHashes returns the (decoded) hashes of the transactions
+// stored in the database, and the ones which aren't, which fall outside of
+// the retention window (or don't exist).
+func (h eventsRPCHandler) lookupTransactionHashes(ctx context.Context, hexHashes []string,
+) ([][]byte, []string, error) {
+	hashes := make([]xdr.Hash, 0, len(hexHashes))
+	for _, hexHash := range hexHashes {
+		// the hashes were validated with the request
+		hash, err := parseTransactionHash(hexHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	ledgers, err := h.transactionReader.GetTransactionLedgers(ctx, hashes)
+	if err != nil {
+		return nil, nil, err
+	}
+	var known [][]byte
+	var unknown []string
+	for i, hash := range hashes {
+		if _, ok := ledgers[hash]; ok {
+			known = append(known, hash[:])
+		} else {
+			unknown = append(unknown, hexHashes[i])
+		}
+	}
+	return known, unknown, nil
+}
+
+// aggregateEvents counts the events of the cursor range matching the filters,
+// which were validated to be exactly expressible in the database query.
+func (h eventsRPCHandler) aggregateEvents(ctx context.Context, request protocol.GetEventsRequest,
+	cursorRange protocol.CursorRange, contractIDs [][]byte, topics [][][]byte, eventTypes []int,
+	limit uint, ledgerRange ledgerbucketwindow.LedgerRange,
+) (protocol.GetEventsResponse, error) {
+	if cursorRange.Start.Ledger < ledgerRange.FirstLedger.Sequence {
+		return protocol.GetEventsResponse{}, &jrpc2.Error{
+			Code: jrpc2.InvalidParams,
+			Message: fmt.Sprintf("only the events of the ledger range %d - %d can be aggregated",
+				ledgerRange.FirstLedger.Sequence, ledgerRange.LastLedger.Sequence),
+		}
+	}
+	limit = min(limit, protocol.MaxEventAggregates)
+	by := db.EventAggregationContract
+	if request.Aggregate == protocol.EventsAggregateByName {
+		by = db.EventAggregationTopic1
+	}
+	counts, err := h.dbReader.AggregateEvents(ctx, cursorRange, contractIDs, topics, eventTypes, by, limit)
+	if err != nil {
+		return protocol.GetEventsResponse{}, &jrpc2.Error{
+			Code: jrpc2.InternalError, Message: err.Error(),
+		}
+	}
+
+	aggregates := make([]protocol.EventAggregate, 0, len(counts))
+	for _, count := range counts {
+		aggregate := protocol.EventAggregate{Count: count.Count}
+		switch {
+		case count.Key == nil:
+		case by == db.EventAggregationContract:
+			aggregate.ContractID, err = strkey.Encode(strkey.VersionByteContract, count.Key)
+		default:
+			aggregate.Topic = base64.StdEncoding.EncodeToString(count.Key)
+			aggregate.Name, err = eventName(count.Key)
+		}
+		if err != nil {
+			return protocol.GetEventsResponse{}, &jrpc2.Error{
+				Code: jrpc2.InternalError, Message: err.Error(),
+			}
+		}
+		aggregates = append(aggregates, aggregate)
+	}
 
+	// the whole range is aggregated, so the cursor points to its end
+	maxCursor := protocol.MaxCursor
+	maxCursor.Ledger = cursorRange.End.Ledger - 1
+	cursor := maxCursor.String()
+	ledgersScanned := uint32(0)
+	if cursorRange.End.Ledger > cursorRange.Start.Ledger {
+		ledgersScanned = cursorRange.End.Ledger - cursorRange.Start.Ledger
+	}
 	return protocol.GetEventsResponse{
-		Events: results,
-		Cursor: cursor,
+		Events:     []protocol.EventInfo{},
+		Aggregates: aggregates,
+		Cursor:     cursor,
 
 		LatestLedger:          ledgerRange.LastLedger.Sequence,
 		OldestLedger:          ledgerRange.FirstLedger.Sequence,
 		LatestLedgerCloseTime: ledgerRange.LastLedger.CloseTime,
 		OldestLedgerCloseTime: ledgerRange.FirstLedger.CloseTime,
+		LedgersScanned:        ledgersScanned,
+		Page: protocol.PageInfo{
+			Cursor:       cursor,
+			Limit:        limit,
+			Finalized:    maxCursor.Ledger < ledgerRange.LastLedger.Sequence,
+			OldestLedger: ledgerRange.FirstLedger.Sequence,
+			LatestLedger: ledgerRange.LastLedger.Sequence,
+		},
 	}, nil
 }
 
+// eventName returns the symbol or string of an encoded ScVal, if it is one.
+func eventName(topic []byte) (string, error) {
+	var value xdr.ScVal
+	if err := xdr.SafeUnmarshal(topic, &value); err != nil {
+		return "", fmt.Errorf("failed to decode event topic: %w", err)
+	}
+	switch value.Type {
+	case xdr.ScValTypeScvSymbol:
+		return string(value.MustSym()), nil
+	case xdr.ScValTypeScvString:
+		return string(value.MustStr()), nil
+	default:
+		return "", nil
+	}
+}
+
+// scanDatastoreEvents fetches the ledgers of the cursor range from the datastore
+// (in batches) and calls f on their events, until it returns false.
+func (h eventsRPCHandler) scanDatastoreEvents(ctx context.Context, cursorRange protocol.CursorRange,
+	f db.ScanFunction,
+) error {
+	if h.datastoreLedgerReader == nil {
+		return errors.New("datastore ledger reader not configured")
+	}
+	for ledgerSeq := cursorRange.Start.Ledger; ledgerSeq < cursorRange.End.Ledger; {
+		end := min(ledgerSeq+datastoreLedgersBatchSize, cursorRange.End.Ledger) - 1
+		ledgers, err := h.datastoreLedgerReader.GetLedgers(ctx, ledgerSeq, end)
+		if err != nil {
+			return fmt.Errorf("error fetching ledgers from datastore: %w", err)
+		}
+		if len(ledgers) == 0 {
+			return fmt.Errorf("datastore does not contain ledger %d", ledgerSeq)
+		}
+		for _, ledger := range ledgers {
+			scanned, err := db.ScanLedgerEvents(h.networkPassphrase, ledger, func(
+				event xdr.DiagnosticEvent, cursor protocol.Cursor, ledgerCloseTimestamp int64, txHash *xdr.Hash,
+			) bool {
+				// skip the events preceding the pagination cursor
+				if cursor.Cmp(cursorRange.Start) < 0 {
+					return true
+				}
+				return f(event, cursor, ledgerCloseTimestamp, txHash)
+			})
+			if err != nil {
+				return err
+			}
+			if !scanned {
+				return nil
+			}
+		}
+		ledgerSeq += uint32(len(ledgers)) //nolint:gosec
+	}
+	return nil
+}
+
+// addOperationContext sets the requested details of the operation which
+// emitted every event: its state changes (the ledger entry changes of the
+// operation) and whether it created a contract.
+//
+//nolint:cyclop
+func (h eventsRPCHandler) addOperationContext(ctx context.Context, found []entry, results []protocol.EventInfo,
+	request protocol.GetEventsRequest,
+) error {
+	var txReader *ingest.LedgerTransactionReader
+	defer func() {
+		if txReader != nil {
+			_ = txReader.Close()
+		}
+	}()
+	for i, entry := range found {
+		if txReader == nil || txReader.GetSequence() != entry.cursor.Ledger {
+			if txReader != nil {
+				_ = txReader.Close()
+				txReader = nil
+			}
+			lcm, err := h.getLedger(ctx, entry.cursor.Ledger)
+			if err != nil {
+				return err
+			}
+			txReader, err = ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(h.networkPassphrase, lcm)
+			if err != nil {
+				return fmt.Errorf("could not read the transactions of ledger %d: %w", entry.cursor.Ledger, err)
+			}
+		}
+		if err := txReader.Seek(int(entry.cursor.Tx) - 1); err != nil {
+			return fmt.Errorf("could not find transaction %d of ledger %d: %w",
+				entry.cursor.Tx, entry.cursor.Ledger, err)
+		}
+		tx, err := txReader.Read()
+		if err != nil {
+			return fmt.Errorf("could not read transaction %d of ledger %d: %w",
+				entry.cursor.Tx, entry.cursor.Ledger, err)
+		}
+		if request.IncludeContractCreation {
+			results[i].InContractCreation = isContractCreation(tx.Envelope, entry.cursor.Op)
+		}
+		if !request.IncludeStateChanges {
+			continue
+		}
+		changes, err := tx.GetOperationChanges(entry.cursor.Op)
+		if err != nil {
+			return err
+		}
+		results[i].StateChanges = make([]protocol.LedgerEntryChange, 0, len(changes))
+		for _, change := range changes {
+			stateChange, err := ledgerEntryChangeFromChange(change, request.Format)
+			if err != nil {
+				return err
+			}
+			results[i].StateChanges = append(results[i].StateChanges, stateChange)
+		}
+	}
+	return nil
+}
+
+// isContractCreation tells whether the operation creates a contract. Only the
+// contract creations of protocol 22 onwards run contract code (the
+// constructor, with no arguments in the case of CreateContract) and can emit
+// events.
+func isContractCreation(envelope xdr.TransactionEnvelope, opIndex uint32) bool {
+	operations := envelope.Operations()
+	if int(opIndex) >= len(operations) {
+		return false
+	}
+	invocation, ok := operations[opIndex].Body.GetInvokeHostFunctionOp()
+	if !ok {
+		return false
+	}
+	switch invocation.HostFunction.Type {
+	case xdr.HostFunctionTypeHostFunctionTypeCreateContract, xdr.HostFunctionTypeHostFunctionTypeCreateContractV2:
+		return true
+	default:
+		return false
+	}
+}
+
+// getLedger returns a ledger from the database or, for the ledgers preceding
+// the retention window, from the datastore.
+func (h eventsRPCHandler) getLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	lcm, found, err := h.ledgerReader.GetLedger(ctx, sequence)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+	if found {
+		return lcm, nil
+	}
+	if h.datastoreLedgerReader != nil {
+		ledgers, err := h.datastoreLedgerReader.GetLedgers(ctx, sequence, sequence)
+		if err != nil {
+			return xdr.LedgerCloseMeta{}, fmt.Errorf("error fetching ledger from datastore: %w", err)
+		}
+		if len(ledgers) > 0 {
+			return ledgers[0], nil
+		}
+	}
+	return xdr.LedgerCloseMeta{}, fmt.Errorf("ledger %d not found", sequence)
+}
+
+func ledgerEntryChangeFromChange(change ingest.Change, format string) (protocol.LedgerEntryChange, error) {
+	var (
+		diff preflight.XDRDiff
+		err  error
+	)
+	if change.Pre != nil {
+		if diff.Before, err = change.Pre.MarshalBinary(); err != nil {
+			return protocol.LedgerEntryChange{}, err
+		}
+	}
+	if change.Post != nil {
+		if diff.After, err = change.Post.MarshalBinary(); err != nil {
+			return protocol.LedgerEntryChange{}, err
+		}
+	}
+	return LedgerEntryChangeFromXDRDiff(diff, format)
+}
+
+func sameTransaction(a, b protocol.Cursor) bool {
+	return a.Ledger == b.Ledger && a.Tx == b.Tx
+}
+
+// groupEventsByTransaction groups consecutive events of the same transaction,
+// preserving their order.
+func groupEventsByTransaction(events []protocol.EventInfo) []protocol.TransactionEvents {
+	transactions := []protocol.TransactionEvents{}
+	for _, event := range events {
+		last := len(transactions) - 1
+		if last < 0 || transactions[last].Ledger != event.Ledger || transactions[last].TxIndex != event.TxIndex {
+			transactions = append(transactions, protocol.TransactionEvents{
+				TransactionHash: event.TransactionHash,
+				Ledger:          event.Ledger,
+				LedgerClosedAt:  event.LedgerClosedAt,
+				TxIndex:         event.TxIndex,
+			})
+			last++
+		}
+		transactions[last].Events = append(transactions[last].Events, event)
+	}
+	return transactions
+}
+
 func eventInfoForEvent(
 	event xdr.DiagnosticEvent,
 	cursor protocol.Cursor,
@@ -263,6 +736,22 @@ func eventInfoForEvent(
 			info.TopicJSON = append(info.TopicJSON, topic)
 		}
 
+		// also decode the topic and value into native values, for the
+		// consumers unaware of the ScVal encoding
+		info.TopicTyped = make([]protocol.TypedScVal, 0, protocol.MaxTopicCount)
+		for _, topic := range v0.Topics {
+			typed, err := decodeTypedScVal(topic)
+			if err != nil {
+				return protocol.EventInfo{}, err
+			}
+			info.TopicTyped = append(info.TopicTyped, typed)
+		}
+		valueTyped, err := decodeTypedScVal(v0.Data)
+		if err != nil {
+			return protocol.EventInfo{}, err
+		}
+		info.ValueTyped = &valueTyped
+
 		var convErr error
 		info.ValueJSON, convErr = xdr2json.ConvertInterface(v0.Data)
 		if convErr != nil {
@@ -298,20 +787,29 @@ func eventInfoForEvent(
 	return info, nil
 }
 
-// NewGetEventsHandler returns a json rpc handler to fetch and filter events
+// NewGetEventsHandler returns a json rpc handler to fetch and filter events. Events which
+// predate the retention window are extracted from the datastore ledgers, when configured.
 func NewGetEventsHandler(
 	logger *log.Entry,
 	dbReader db.EventReader,
+	transactionReader db.TransactionReader,
 	maxLimit uint,
 	defaultLimit uint,
 	ledgerReader db.LedgerReader,
+	datastoreLedgerReader rpcdatastore.LedgerReader,
+	networkPassphrase string,
+	queryAge prometheus.Observer,
 ) jrpc2.Handler {
 	eventsHandler := eventsRPCHandler{
-		dbReader:     dbReader,
-		maxLimit:     maxLimit,
-		defaultLimit: defaultLimit,
-		logger:       logger,
-		ledgerReader: ledgerReader,
+		dbReader:              dbReader,
+		transactionReader:     transactionReader,
+		maxLimit:              maxLimit,
+		defaultLimit:          defaultLimit,
+		logger:                logger,
+		ledgerReader:          ledgerReader,
+		datastoreLedgerReader: datastoreLedgerReader,
+		networkPassphrase:     networkPassphrase,
+		queryAge:              queryAge,
 	}
 	return NewHandler(eventsHandler.getEvents)
 }
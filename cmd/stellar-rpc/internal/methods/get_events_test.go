@@ -40,7 +40,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 		ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
@@ -89,7 +89,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 
@@ -161,14 +161,15 @@ func TestGetEvents(t *testing.T) {
 		cursor.Ledger = 1
 		cursorStr := cursor.String()
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 	})
@@ -179,7 +180,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 
@@ -239,13 +240,72 @@ func TestGetEvents(t *testing.T) {
 		assert.Equal(t, expectedIDs, eventIDs)
 	})
 
+	t.Run("filtering by transaction hash", func(t *testing.T) {
+		dbx := newTestDB(t)
+		ctx := context.TODO()
+		log := log.DefaultLogger
+		log.SetLevel(logrus.TraceLevel)
+
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+
+		var txMeta []xdr.TransactionMeta
+		for range 5 {
+			txMeta = append(txMeta, transactionMetaWithEvents(
+				contractEvent(xdr.ContractId{1}, xdr.ScVec{counterScVal}, counterScVal),
+			))
+		}
+		ledgerCloseMeta := ledgerCloseMetaWithEvents(1, now.Unix(), txMeta...)
+		require.NoError(t, write.LedgerWriter().InsertLedger(ledgerCloseMeta))
+		require.NoError(t, write.TransactionWriter().InsertTransactions(ledgerCloseMeta))
+		require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+		require.NoError(t, write.Commit(ledgerCloseMeta))
+
+		handler := eventsRPCHandler{
+			dbReader:          db.NewEventReader(log, dbx, passphrase),
+			transactionReader: db.NewTransactionReader(log, dbx, passphrase),
+			maxLimit:          10000,
+			defaultLimit:      100,
+			ledgerReader:      db.NewLedgerReader(dbx),
+		}
+		unknownHash := xdr.Hash{1}.HexString()
+		results, err := handler.getEvents(ctx, protocol.GetEventsRequest{
+			StartLedger: 1,
+			TransactionHashes: []string{
+				ledgerCloseMeta.TransactionHash(3).HexString(),
+				unknownHash,
+				ledgerCloseMeta.TransactionHash(1).HexString(),
+			},
+		})
+		require.NoError(t, err)
+		eventIDs := []string{}
+		for _, event := range results.Events {
+			eventIDs = append(eventIDs, event.ID)
+		}
+		assert.Equal(t, []string{
+			protocol.Cursor{Ledger: 1, Tx: 2, Op: 0, Event: 0}.String(),
+			protocol.Cursor{Ledger: 1, Tx: 4, Op: 0, Event: 0}.String(),
+		}, eventIDs)
+		assert.Equal(t, []string{unknownHash}, results.UnknownTransactionHashes)
+
+		// the events of unknown transactions aren't looked up
+		results, err = handler.getEvents(ctx, protocol.GetEventsRequest{
+			StartLedger:       1,
+			TransactionHashes: []string{unknownHash},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, results.Events)
+		assert.Equal(t, []string{unknownHash}, results.UnknownTransactionHashes)
+	})
+
 	t.Run("filtering by topic", func(t *testing.T) {
 		dbx := newTestDB(t)
 		ctx := context.TODO()
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 
@@ -322,14 +382,15 @@ func TestGetEvents(t *testing.T) {
 		cursor.Ledger = 1
 		cursorStr := cursor.String()
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 
@@ -365,15 +426,21 @@ func TestGetEvents(t *testing.T) {
 
 		expected[0].ValueJSON = valueJs
 		expected[0].TopicJSON = topicsJs
+		expected[0].TopicTyped = []protocol.TypedScVal{
+			{Type: "symbol", Value: "COUNTER"},
+			{Type: "u64", Value: "4"},
+		}
+		expected[0].ValueTyped = &protocol.TypedScVal{Type: "u64", Value: "4"}
 		require.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 	})
@@ -385,7 +452,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 
@@ -455,14 +522,15 @@ func TestGetEvents(t *testing.T) {
 		})
 		require.NoError(t, err)
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                []protocol.EventInfo{},
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 
@@ -482,14 +550,15 @@ func TestGetEvents(t *testing.T) {
 		})
 		require.NoError(t, err)
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                []protocol.EventInfo{},
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 
@@ -562,15 +631,23 @@ func TestGetEvents(t *testing.T) {
 
 		expected[0].ValueJSON = valueJs
 		expected[0].TopicJSON = topicsJs
+		expected[0].TopicTyped = []protocol.TypedScVal{
+			{Type: "symbol", Value: "COUNTER"},
+			{Type: "u64", Value: "4"},
+			{Type: "u64", Value: "4"},
+			{Type: "symbol", Value: "COUNTER"},
+		}
+		expected[0].ValueTyped = &protocol.TypedScVal{Type: "u64", Value: "4"}
 		require.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 	})
@@ -581,7 +658,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 
@@ -688,14 +765,15 @@ func TestGetEvents(t *testing.T) {
 		cursor.Ledger = 1
 		cursorStr := cursor.String()
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 	})
@@ -706,7 +784,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 		ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
@@ -777,14 +855,15 @@ func TestGetEvents(t *testing.T) {
 		cursor.Ledger = 1
 		cursorStr := cursor.String()
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursorStr,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 100, false),
 			results,
 		)
 	})
@@ -795,7 +874,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 
@@ -861,14 +940,15 @@ func TestGetEvents(t *testing.T) {
 		cursor := expected[len(expected)-1].ID
 
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursor,
 				LatestLedger:          1,
 				OldestLedger:          1,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 10, true),
 			results,
 		)
 	})
@@ -879,7 +959,7 @@ func TestGetEvents(t *testing.T) {
 		log := log.DefaultLogger
 		log.SetLevel(logrus.TraceLevel)
 
-		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 		write, err := writer.NewTx(ctx)
 		require.NoError(t, err)
 
@@ -973,14 +1053,15 @@ func TestGetEvents(t *testing.T) {
 		}
 		cursor := expected[len(expected)-1].ID
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                expected,
 				Cursor:                cursor,
 				LatestLedger:          5,
 				OldestLedger:          5,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 2, true),
 			results,
 		)
 
@@ -1001,17 +1082,268 @@ func TestGetEvents(t *testing.T) {
 		rawCursor.Ledger = uint32(endLedger - 1)
 		cursor = rawCursor.String()
 		assert.Equal(t,
-			protocol.GetEventsResponse{
+			withPage(protocol.GetEventsResponse{
 				Events:                []protocol.EventInfo{},
 				Cursor:                cursor,
 				LatestLedger:          5,
 				OldestLedger:          5,
 				LatestLedgerCloseTime: now.Unix(),
 				OldestLedgerCloseTime: now.Unix(),
-			},
+				LedgersScanned:        1,
+			}, 2, false),
 			results,
 		)
 	})
+
+	t.Run("group by transaction", func(t *testing.T) {
+		dbx := newTestDB(t)
+		ctx := context.TODO()
+		log := log.DefaultLogger
+		log.SetLevel(logrus.TraceLevel)
+
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+
+		ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
+		store := db.NewEventReader(log, dbx, passphrase)
+
+		contractID := xdr.ContractId([32]byte{})
+		var txMeta []xdr.TransactionMeta
+		for range 3 {
+			txMeta = append(txMeta, transactionMetaWithEvents(
+				contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal),
+				contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal),
+			))
+		}
+		ledgerCloseMeta := ledgerCloseMetaWithEvents(5, now.Unix(), txMeta...)
+		require.NoError(t, ledgerW.InsertLedger(ledgerCloseMeta), "ingestion failed for ledger ")
+		require.NoError(t, eventW.InsertEvents(ledgerCloseMeta), "ingestion failed for events ")
+		require.NoError(t, write.Commit(ledgerCloseMeta))
+
+		handler := eventsRPCHandler{
+			dbReader:     store,
+			maxLimit:     10000,
+			defaultLimit: 100,
+			ledgerReader: db.NewLedgerReader(dbx),
+		}
+		results, err := handler.getEvents(ctx, protocol.GetEventsRequest{
+			StartLedger: 5,
+			GroupBy:     protocol.EventsGroupByTransaction,
+			Pagination:  &protocol.PaginationOptions{Limit: 2},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, results.Events)
+		require.Len(t, results.Transactions, 2)
+		for i, transaction := range results.Transactions {
+			assert.Equal(t, ledgerCloseMeta.TransactionHash(i).HexString(), transaction.TransactionHash)
+			assert.Equal(t, int32(5), transaction.Ledger)
+			assert.Equal(t, uint32(i+1), transaction.TxIndex)
+			require.Len(t, transaction.Events, 2)
+			for j, event := range transaction.Events {
+				assert.Equal(t, protocol.Cursor{Ledger: 5, Tx: uint32(i + 1), Event: uint32(j)}.String(), event.ID)
+			}
+		}
+		// pages end on a complete transaction
+		assert.Equal(t, protocol.Cursor{Ledger: 5, Tx: 2, Event: 1}.String(), results.Cursor)
+
+		cursor, err := protocol.ParseCursor(results.Cursor)
+		require.NoError(t, err)
+		results, err = handler.getEvents(ctx, protocol.GetEventsRequest{
+			GroupBy:    protocol.EventsGroupByTransaction,
+			Pagination: &protocol.PaginationOptions{Cursor: &cursor, Limit: 2},
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Transactions, 1)
+		assert.Equal(t, uint32(3), results.Transactions[0].TxIndex)
+		assert.Len(t, results.Transactions[0].Events, 2)
+		maxCursor := protocol.MaxCursor
+		maxCursor.Ledger = 5
+		assert.Equal(t, maxCursor.String(), results.Cursor)
+	})
+
+	t.Run("single ledger", func(t *testing.T) {
+		dbx := newTestDB(t)
+		ctx := context.TODO()
+		log := log.DefaultLogger
+		log.SetLevel(logrus.TraceLevel)
+
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+
+		ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
+		store := db.NewEventReader(log, dbx, passphrase)
+
+		contractID := xdr.ContractId([32]byte{})
+		var ledgerCloseMeta xdr.LedgerCloseMeta
+		for ledger := uint32(5); ledger <= 7; ledger++ {
+			ledgerCloseMeta = ledgerCloseMetaWithEvents(ledger, now.Unix(), transactionMetaWithEvents(
+				contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal),
+				contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal),
+			))
+			require.NoError(t, ledgerW.InsertLedger(ledgerCloseMeta), "ingestion failed for ledger ")
+			require.NoError(t, eventW.InsertEvents(ledgerCloseMeta), "ingestion failed for events ")
+		}
+		require.NoError(t, write.Commit(ledgerCloseMeta))
+
+		handler := eventsRPCHandler{
+			dbReader:     store,
+			maxLimit:     10000,
+			defaultLimit: 100,
+			ledgerReader: db.NewLedgerReader(dbx),
+		}
+		results, err := handler.getEvents(ctx, protocol.GetEventsRequest{
+			Ledger:     6,
+			Pagination: &protocol.PaginationOptions{Limit: 1},
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Events, 1)
+		assert.Equal(t, protocol.Cursor{Ledger: 6, Tx: 1, Event: 0}.String(), results.Events[0].ID)
+
+		cursor, err := protocol.ParseCursor(results.Cursor)
+		require.NoError(t, err)
+		results, err = handler.getEvents(ctx, protocol.GetEventsRequest{
+			Ledger:     6,
+			Pagination: &protocol.PaginationOptions{Cursor: &cursor},
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Events, 1)
+		assert.Equal(t, protocol.Cursor{Ledger: 6, Tx: 1, Event: 1}.String(), results.Events[0].ID)
+		// the events of the next ledger are not included
+		maxCursor := protocol.MaxCursor
+		maxCursor.Ledger = 6
+		assert.Equal(t, maxCursor.String(), results.Cursor)
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		dbx := newTestDB(t)
+		ctx := context.TODO()
+		log := log.DefaultLogger
+		log.SetLevel(logrus.TraceLevel)
+
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
+		store := db.NewEventReader(log, dbx, passphrase)
+		contractID := xdr.ContractId([32]byte{})
+		ingestLedgers := func(first, last uint32) {
+			write, err := writer.NewTx(ctx)
+			require.NoError(t, err)
+			var ledgerCloseMeta xdr.LedgerCloseMeta
+			for ledger := first; ledger <= last; ledger++ {
+				ledgerCloseMeta = ledgerCloseMetaWithEvents(ledger, now.Unix(), transactionMetaWithEvents(
+					contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal),
+				))
+				require.NoError(t, write.LedgerWriter().InsertLedger(ledgerCloseMeta))
+				require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+			}
+			require.NoError(t, write.Commit(ledgerCloseMeta))
+		}
+		ingestLedgers(5, 6)
+
+		handler := eventsRPCHandler{
+			dbReader:     store,
+			maxLimit:     10000,
+			defaultLimit: 100,
+			ledgerReader: db.NewLedgerReader(dbx),
+		}
+		results, err := handler.getEvents(ctx, protocol.GetEventsRequest{
+			StartLedger: 5,
+			Snapshot:    true,
+			Pagination:  &protocol.PaginationOptions{Limit: 1},
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Events, 1)
+		assert.Equal(t, protocol.Cursor{Ledger: 5, Tx: 1}.String()+"@6", results.Cursor)
+
+		// ledgers ingested after the first page are left out
+		ingestLedgers(7, 7)
+		var pagination protocol.PaginationOptions
+		require.NoError(t, json.Unmarshal([]byte(`{"cursor": "`+results.Cursor+`"}`), &pagination))
+		assert.Equal(t, uint32(6), pagination.SnapshotLedger)
+		results, err = handler.getEvents(ctx, protocol.GetEventsRequest{Pagination: &pagination})
+		require.NoError(t, err)
+		require.Len(t, results.Events, 1)
+		assert.Equal(t, protocol.Cursor{Ledger: 6, Tx: 1}.String(), results.Events[0].ID)
+		maxCursor := protocol.MaxCursor
+		maxCursor.Ledger = 6
+		assert.Equal(t, maxCursor.String()+"@6", results.Cursor)
+		assert.Equal(t, uint32(7), results.LatestLedger)
+	})
+
+	t.Run("datastore", func(t *testing.T) {
+		dbx := newTestDB(t)
+		ctx := context.TODO()
+		log := log.DefaultLogger
+		log.SetLevel(logrus.TraceLevel)
+
+		writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+
+		ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
+		store := db.NewEventReader(log, dbx, passphrase)
+
+		contractID := xdr.ContractId([32]byte{})
+		newLedger := func(ledger uint32) xdr.LedgerCloseMeta {
+			return ledgerCloseMetaWithEvents(ledger, now.Unix(), transactionMetaWithEvents(
+				contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal),
+				contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal),
+			))
+		}
+		// the database retains ledgers 5 to 7 while the datastore has them all
+		var ledgerCloseMeta xdr.LedgerCloseMeta
+		for ledger := uint32(5); ledger <= 7; ledger++ {
+			ledgerCloseMeta = newLedger(ledger)
+			require.NoError(t, ledgerW.InsertLedger(ledgerCloseMeta), "ingestion failed for ledger ")
+			require.NoError(t, eventW.InsertEvents(ledgerCloseMeta), "ingestion failed for events ")
+		}
+		require.NoError(t, write.Commit(ledgerCloseMeta))
+
+		mockStore := new(MockDatastoreReader)
+		mockStore.On("GetAvailableLedgerRange", ctx).
+			Return(protocol.LedgerSeqRange{FirstLedger: 2, LastLedger: 7}, nil)
+		mockStore.On("GetLedgers", ctx, uint32(3), uint32(4)).
+			Return([]xdr.LedgerCloseMeta{newLedger(3), newLedger(4)}, nil)
+
+		handler := eventsRPCHandler{
+			dbReader:              store,
+			maxLimit:              10000,
+			defaultLimit:          100,
+			ledgerReader:          db.NewLedgerReader(dbx),
+			datastoreLedgerReader: mockStore,
+			networkPassphrase:     passphrase,
+		}
+		results, err := handler.getEvents(ctx, protocol.GetEventsRequest{
+			StartLedger: 3,
+			Pagination:  &protocol.PaginationOptions{Limit: 3},
+		})
+		require.NoError(t, err)
+		assert.True(t, results.FromDatastore)
+		require.Len(t, results.Events, 3)
+		assert.Equal(t, protocol.Cursor{Ledger: 3, Tx: 1, Event: 0}.String(), results.Events[0].ID)
+		assert.Equal(t, protocol.Cursor{Ledger: 4, Tx: 1, Event: 0}.String(), results.Events[2].ID)
+		assert.Equal(t, results.Events[2].ID, results.Cursor)
+		assert.Equal(t, uint32(5), results.OldestLedger)
+		assert.Equal(t, uint32(2), results.LedgersScanned)
+
+		// the events are read from the datastore and then from the database
+		results, err = handler.getEvents(ctx, protocol.GetEventsRequest{StartLedger: 3})
+		require.NoError(t, err)
+		assert.True(t, results.FromDatastore)
+		require.Len(t, results.Events, 10)
+		assert.Equal(t, protocol.Cursor{Ledger: 7, Tx: 1, Event: 1}.String(), results.Events[9].ID)
+		assert.Equal(t, uint32(5), results.LedgersScanned)
+
+		// the events within the retention window don't involve the datastore
+		results, err = handler.getEvents(ctx, protocol.GetEventsRequest{StartLedger: 5})
+		require.NoError(t, err)
+		assert.False(t, results.FromDatastore)
+		require.Len(t, results.Events, 6)
+		mockStore.AssertExpectations(t)
+
+		_, err = handler.getEvents(ctx, protocol.GetEventsRequest{StartLedger: 1})
+		require.ErrorContains(t, err, "startLedger must be within the ledger range: 2 - 7")
+	})
 }
 
 func BenchmarkGetEvents(b *testing.B) {
@@ -1029,7 +1361,7 @@ func BenchmarkGetEvents(b *testing.B) {
 	contractID := xdr.ContractId([32]byte{})
 	now := time.Now().UTC()
 
-	writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase)
+	writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
 	write, err := writer.NewTx(ctx)
 	require.NoError(b, err)
 	ledgerW, eventW := write.LedgerWriter(), write.EventWriter()
@@ -1193,6 +1525,150 @@ func transactionMetaWithEvents(events ...xdr.ContractEvent) xdr.TransactionMeta
 	}
 }
 
+func TestGetEventsStateChanges(t *testing.T) {
+	ctx := context.TODO()
+	dbx := newTestDB(t)
+	log := log.DefaultLogger
+	writer := db.NewReadWriter(log, dbx, interfaces.MakeNoOpDeamon(), 10, 10, passphrase, db.EventStorage{}, false, false)
+	write, err := writer.NewTx(ctx)
+	require.NoError(t, err)
+
+	contractID := xdr.ContractId([32]byte{})
+	counter := xdr.ScSymbol("COUNTER")
+	counterScVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &counter}
+	dataEntry := func(value uint32) xdr.LedgerEntry {
+		return xdr.LedgerEntry{
+			LastModifiedLedgerSeq: 1,
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeContractData,
+				ContractData: &xdr.ContractDataEntry{
+					Contract: xdr.ScAddress{
+						Type:       xdr.ScAddressTypeScAddressTypeContract,
+						ContractId: &contractID,
+					},
+					Key:        counterScVal,
+					Durability: xdr.ContractDataDurabilityPersistent,
+					Val:        xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: (*xdr.Uint32)(&value)},
+				},
+			},
+		}
+	}
+	before, after := dataEntry(1), dataEntry(2)
+	txMeta := transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{counterScVal}, counterScVal))
+	txMeta.V3.Operations = []xdr.OperationMeta{{
+		Changes: xdr.LedgerEntryChanges{
+			{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &before},
+			{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &after},
+		},
+	}}
+	ledgerCloseMeta := ledgerCloseMetaWithEvents(1, time.Now().Unix(), txMeta)
+	require.NoError(t, write.LedgerWriter().InsertLedger(ledgerCloseMeta))
+	require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+	require.NoError(t, write.Commit(ledgerCloseMeta))
+
+	handler := eventsRPCHandler{
+		dbReader:          db.NewEventReader(log, dbx, passphrase),
+		maxLimit:          10000,
+		defaultLimit:      100,
+		ledgerReader:      db.NewLedgerReader(dbx),
+		networkPassphrase: passphrase,
+	}
+
+	// the state changes are only included on demand
+	results, err := handler.getEvents(ctx, protocol.GetEventsRequest{StartLedger: 1})
+	require.NoError(t, err)
+	require.Len(t, results.Events, 1)
+	assert.Nil(t, results.Events[0].StateChanges)
+
+	results, err = handler.getEvents(ctx, protocol.GetEventsRequest{StartLedger: 1, IncludeStateChanges: true})
+	require.NoError(t, err)
+	require.Len(t, results.Events, 1)
+	require.Len(t, results.Events[0].StateChanges, 1)
+	change := results.Events[0].StateChanges[0]
+	assert.Equal(t, protocol.LedgerEntryChangeTypeUpdated, change.Type)
+	expectedBefore, err := xdr.MarshalBase64(before)
+	require.NoError(t, err)
+	expectedAfter, err := xdr.MarshalBase64(after)
+	require.NoError(t, err)
+	require.NotNil(t, change.BeforeXDR)
+	require.NotNil(t, change.AfterXDR)
+	assert.Equal(t, expectedBefore, *change.BeforeXDR)
+	assert.Equal(t, expectedAfter, *change.AfterXDR)
+
+	// the number of events is bounded when including the state changes
+	_, err = handler.getEvents(ctx, protocol.GetEventsRequest{
+		StartLedger:         1,
+		IncludeStateChanges: true,
+		Pagination:          &protocol.PaginationOptions{Limit: protocol.MaxStateChangesEventsLimit + 1},
+	})
+	require.ErrorContains(t, err, "when including state changes")
+}
+
+func TestIsContractCreation(t *testing.T) {
+	envelope := func(hostFunctionType xdr.HostFunctionType) xdr.TransactionEnvelope {
+		tx := txEnvelope(1)
+		tx.V1.Tx.Operations = []xdr.Operation{{
+			Body: xdr.OperationBody{
+				Type: xdr.OperationTypeInvokeHostFunction,
+				InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+					HostFunction: xdr.HostFunction{Type: hostFunctionType},
+				},
+			},
+		}}
+		return tx
+	}
+
+	assert.True(t, isContractCreation(envelope(xdr.HostFunctionTypeHostFunctionTypeCreateContract), 0))
+	assert.True(t, isContractCreation(envelope(xdr.HostFunctionTypeHostFunctionTypeCreateContractV2), 0))
+	assert.False(t, isContractCreation(envelope(xdr.HostFunctionTypeHostFunctionTypeInvokeContract), 0))
+	assert.False(t, isContractCreation(envelope(xdr.HostFunctionTypeHostFunctionTypeCreateContractV2), 1))
+	assert.False(t, isContractCreation(txEnvelope(1), 0))
+}
+
+func TestCombineTopics(t *testing.T) {
+	transferSym := xdr.ScSymbol("transfer")
+	transfer := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &transferSym}
+	mintSym := xdr.ScSymbol("mint")
+	mint := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &mintSym}
+	encodedTransfer, err := transfer.MarshalBinary()
+	require.NoError(t, err)
+	encodedMint, err := mint.MarshalBinary()
+	require.NoError(t, err)
+	wildcard := protocol.WildCardExactOne
+
+	topics, err := combineTopics([]protocol.EventFilter{
+		{Topics: []protocol.TopicFilter{
+			{{ScVals: []xdr.ScVal{transfer, mint}}, {Wildcard: &wildcard}},
+		}},
+		{Topics: []protocol.TopicFilter{
+			{{Wildcard: &wildcard}, {ScVal: &mint}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][][]byte{{encodedTransfer, encodedMint}, {encodedMint}, nil, nil}, topics)
+
+	// when every topic filter constrains the first topic, it's enough to
+	// select the events
+	topics, err = combineTopics([]protocol.EventFilter{
+		{Topics: []protocol.TopicFilter{
+			{{ScVal: &transfer}, {ScVal: &mint}},
+			{{ScVal: &mint}, {Wildcard: &wildcard}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][][]byte{{encodedTransfer, encodedMint}, nil, nil, nil}, topics)
+
+	// a topic filter made of wildcards accepts any topics
+	topics, err = combineTopics([]protocol.EventFilter{
+		{Topics: []protocol.TopicFilter{
+			{{ScVal: &transfer}},
+			{{Wildcard: &wildcard}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, topics)
+}
+
 func contractEvent(contractID xdr.ContractId, topic []xdr.ScVal, body xdr.ScVal) xdr.ContractEvent {
 	return xdr.ContractEvent{
 		ContractId: &contractID,
@@ -1245,3 +1721,20 @@ func newTestDB(tb testing.TB) *db.DB {
 	})
 	return db
 }
+
+// withPage sets the pagination metadata of an expected response, from its
+// other fields.
+func withPage(response protocol.GetEventsResponse, limit uint, hasMore bool) protocol.GetEventsResponse {
+	// the cursor points to the last ledger covered by the page
+	position, _, err := protocol.DecodeSnapshotCursor(response.Cursor)
+	cursor, parseErr := protocol.ParseCursor(position)
+	response.Page = protocol.PageInfo{
+		Cursor:       response.Cursor,
+		Limit:        limit,
+		HasMore:      hasMore,
+		Finalized:    err == nil && parseErr == nil && cursor.Ledger < response.LatestLedger,
+		OldestLedger: response.OldestLedger,
+		LatestLedger: response.LatestLedger,
+	}
+	return response
+}
@@ -0,0 +1,98 @@
+package methods
+
+import (
+	"context"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type eventsTipRPCHandler struct {
+	dbReader     db.EventReader
+	ledgerReader db.LedgerReader
+}
+
+// getEventsTip scans the events in the retention window backwards and stops
+// at the first one matching the request filters.
+func (h eventsTipRPCHandler) getEventsTip(ctx context.Context, request protocol.GetEventsTipRequest,
+) (protocol.GetEventsTipResponse, error) {
+	if err := request.Valid(); err != nil {
+		return protocol.GetEventsTipResponse{}, &jrpc2.Error{
+			Code: jrpc2.InvalidParams, Message: err.Error(),
+		}
+	}
+
+	ledgerRange, err := h.ledgerReader.GetLedgerRange(ctx)
+	if err != nil {
+		return protocol.GetEventsTipResponse{}, &jrpc2.Error{
+			Code: jrpc2.InternalError, Message: err.Error(),
+		}
+	}
+	response := protocol.GetEventsTipResponse{
+		LatestLedger:          ledgerRange.LastLedger.Sequence,
+		OldestLedger:          ledgerRange.FirstLedger.Sequence,
+		LatestLedgerCloseTime: ledgerRange.LastLedger.CloseTime,
+		OldestLedgerCloseTime: ledgerRange.FirstLedger.CloseTime,
+	}
+
+	start := protocol.Cursor{Ledger: ledgerRange.FirstLedger.Sequence}
+	if request.Cursor != nil && start.Cmp(*request.Cursor) <= 0 {
+		start = *request.Cursor
+		// the event at the cursor itself has already been seen
+		start.Event++
+	}
+	cursorRange := protocol.CursorRange{
+		Start: start,
+		End:   protocol.Cursor{Ledger: ledgerRange.LastLedger.Sequence + 1},
+	}
+
+	contractIDs, err := combineContractIDs(request.Filters)
+	if err != nil {
+		return protocol.GetEventsTipResponse{}, &jrpc2.Error{
+			Code: jrpc2.InvalidParams, Message: err.Error(),
+		}
+	}
+	topics, err := combineTopics(request.Filters)
+	if err != nil {
+		return protocol.GetEventsTipResponse{}, &jrpc2.Error{
+			Code: jrpc2.InvalidParams, Message: err.Error(),
+		}
+	}
+	eventTypes := combineEventTypes(request.Filters)
+
+	eventScanFunction := func(
+		event xdr.DiagnosticEvent, cursor protocol.Cursor, ledgerCloseTimestamp int64, _ *xdr.Hash,
+	) bool {
+		if !request.Matches(event) {
+			return true
+		}
+		response.Tip = &protocol.EventTip{
+			ID:             cursor.String(),
+			Ledger:         cursor.Ledger,
+			LedgerClosedAt: time.Unix(ledgerCloseTimestamp, 0).UTC().Format(time.RFC3339),
+		}
+		return false
+	}
+
+	err = h.dbReader.GetEventsDescending(ctx, cursorRange, contractIDs, topics, eventTypes, nil, eventScanFunction)
+	if err != nil {
+		return protocol.GetEventsTipResponse{}, &jrpc2.Error{
+			Code: jrpc2.InvalidRequest, Message: err.Error(),
+		}
+	}
+	return response, nil
+}
+
+// NewGetEventsTipHandler returns a json rpc handler to find the most recent
+// event matching a set of filters
+func NewGetEventsTipHandler(dbReader db.EventReader, ledgerReader db.LedgerReader) jrpc2.Handler {
+	return NewHandler(eventsTipRPCHandler{
+		dbReader:     dbReader,
+		ledgerReader: ledgerReader,
+	}.getEventsTip)
+}
@@ -0,0 +1,81 @@
+package methods
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func TestGetEventsTip(t *testing.T) {
+	now := time.Now().UTC()
+	dbx := newTestDB(t)
+	ctx := context.TODO()
+	writer := db.NewReadWriter(log.DefaultLogger, dbx, interfaces.MakeNoOpDeamon(),
+		10, 10, passphrase, db.EventStorage{}, false, false)
+
+	counter := xdr.ScSymbol("COUNTER")
+	other := xdr.ScSymbol("OTHER")
+	counterVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &counter}
+	otherVal := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &other}
+	contractID := xdr.ContractId([32]byte{})
+
+	// ledger 1 emits a COUNTER event, ledger 2 an OTHER event
+	for i, topic := range []xdr.ScVal{counterVal, otherVal} {
+		write, err := writer.NewTx(ctx)
+		require.NoError(t, err)
+		ledgerCloseMeta := ledgerCloseMetaWithEvents(uint32(i+1), now.Unix(),
+			transactionMetaWithEvents(contractEvent(contractID, xdr.ScVec{topic}, topic)))
+		require.NoError(t, write.LedgerWriter().InsertLedger(ledgerCloseMeta))
+		require.NoError(t, write.EventWriter().InsertEvents(ledgerCloseMeta))
+		require.NoError(t, write.Commit(ledgerCloseMeta))
+	}
+
+	handler := eventsTipRPCHandler{
+		dbReader:     db.NewEventReader(log.DefaultLogger, dbx, passphrase),
+		ledgerReader: db.NewLedgerReader(dbx),
+	}
+	counterFilter := []protocol.EventFilter{{
+		Topics: []protocol.TopicFilter{{{ScVal: &counterVal}}},
+	}}
+	latestCounter := protocol.Cursor{Ledger: 1, Tx: 1}
+	latestAny := protocol.Cursor{Ledger: 2, Tx: 1}
+
+	response, err := handler.getEventsTip(ctx, protocol.GetEventsTipRequest{})
+	require.NoError(t, err)
+	require.NotNil(t, response.Tip)
+	assert.Equal(t, protocol.EventTip{
+		ID:             latestAny.String(),
+		Ledger:         2,
+		LedgerClosedAt: now.Format(time.RFC3339),
+	}, *response.Tip)
+	assert.Equal(t, uint32(2), response.LatestLedger)
+	assert.Equal(t, uint32(1), response.OldestLedger)
+
+	response, err = handler.getEventsTip(ctx, protocol.GetEventsTipRequest{Filters: counterFilter})
+	require.NoError(t, err)
+	require.NotNil(t, response.Tip)
+	assert.Equal(t, latestCounter.String(), response.Tip.ID)
+
+	// nothing happened since the latest COUNTER event
+	response, err = handler.getEventsTip(ctx, protocol.GetEventsTipRequest{
+		Filters: counterFilter,
+		Cursor:  &latestCounter,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, response.Tip)
+
+	_, err = handler.getEventsTip(ctx, protocol.GetEventsTipRequest{
+		Filters: make([]protocol.EventFilter, protocol.MaxFiltersLimit+1),
+	})
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package methods
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/creachadair/jrpc2"
 
@@ -33,22 +34,69 @@ func convertFeeDistribution(distribution feewindow.FeeDistribution) protocol.Fee
 	}
 }
 
+// getLedgerFees reads the fee parameters from the header of a ledger.
+func getLedgerFees(ctx context.Context, ledgerReader db.LedgerReader, sequence uint32) (*protocol.LedgerFees, error) {
+	ledger, found, err := ledgerReader.GetLedger(ctx, sequence)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("ledger %d not found", sequence)
+	}
+	header := ledger.LedgerHeaderHistoryEntry().Header
+	return &protocol.LedgerFees{
+		BaseFee:         uint32(header.BaseFee),
+		BaseReserve:     uint32(header.BaseReserve),
+		ProtocolVersion: uint32(header.LedgerVersion),
+	}, nil
+}
+
 // NewGetFeeStatsHandler returns a handler obtaining fee statistics
 func NewGetFeeStatsHandler(windows *feewindow.FeeWindows, ledgerReader db.LedgerReader,
 	logger *log.Entry,
 ) jrpc2.Handler {
-	return NewHandler(func(ctx context.Context) (protocol.GetFeeStatsResponse, error) {
+	return NewHandler(func(ctx context.Context, request protocol.GetFeeStatsRequest,
+	) (protocol.GetFeeStatsResponse, error) {
+		if err := request.IsValid(); err != nil {
+			return protocol.GetFeeStatsResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InvalidParams,
+				Message: err.Error(),
+			}
+		}
 		ledgerRange, err := ledgerReader.GetLedgerRange(ctx)
 		if err != nil { // still not fatal
 			logger.WithError(err).
 				Error("could not fetch ledger range")
 		}
 
+		sorobanInclusionFeeWindow, inclusionFeeWindow := windows.SorobanInclusionFeeWindow, windows.ClassicFeeWindow
+		if request.SuccessfulOnly {
+			sorobanInclusionFeeWindow = windows.SuccessfulSorobanInclusionFeeWindow
+			inclusionFeeWindow = windows.SuccessfulClassicFeeWindow
+		}
+		sorobanInclusionFees := sorobanInclusionFeeWindow.GetFeeDistribution()
+		inclusionFees := inclusionFeeWindow.GetFeeDistribution()
 		result := protocol.GetFeeStatsResponse{
-			SorobanInclusionFee: convertFeeDistribution(windows.SorobanInclusionFeeWindow.GetFeeDistribution()),
-			InclusionFee:        convertFeeDistribution(windows.ClassicFeeWindow.GetFeeDistribution()),
+			SorobanInclusionFee: convertFeeDistribution(sorobanInclusionFees),
+			InclusionFee:        convertFeeDistribution(inclusionFees),
 			LatestLedger:        ledgerRange.LastLedger.Sequence,
 		}
+		if request.InclusionProbability > 0 {
+			result.RecommendedFees = &protocol.RecommendedFees{
+				InclusionProbability: request.InclusionProbability,
+				SorobanInclusionFee:  sorobanInclusionFees.RecommendedFee(request.InclusionProbability),
+				InclusionFee:         inclusionFees.RecommendedFee(request.InclusionProbability),
+			}
+		}
+		if request.IncludeLedgerFees {
+			result.LatestLedgerFees, err = getLedgerFees(ctx, ledgerReader, ledgerRange.LastLedger.Sequence)
+			if err != nil {
+				return protocol.GetFeeStatsResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InternalError,
+					Message: fmt.Sprintf("could not read the fees of the latest ledger: %v", err),
+				}
+			}
+		}
 		return result, nil
 	})
 }
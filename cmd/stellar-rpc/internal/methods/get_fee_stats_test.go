@@ -0,0 +1,31 @@
+package methods
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func TestGetLedgerFees(t *testing.T) {
+	ctx := context.TODO()
+	store := db.NewMockTransactionStore("passphrase")
+	ledgerReader := db.NewMockLedgerReader(store)
+
+	meta := txMeta(1, true)
+	meta.V1.LedgerHeader.Header.BaseFee = 100
+	meta.V1.LedgerHeader.Header.BaseReserve = 5000000
+	meta.V1.LedgerHeader.Header.LedgerVersion = 23
+	require.NoError(t, store.InsertTransactions(meta))
+
+	fees, err := getLedgerFees(ctx, ledgerReader, 101)
+	require.NoError(t, err)
+	assert.Equal(t, &protocol.LedgerFees{BaseFee: 100, BaseReserve: 5000000, ProtocolVersion: 23}, fees)
+
+	_, err = getLedgerFees(ctx, ledgerReader, 102)
+	require.EqualError(t, err, "ledger 102 not found")
+}
@@ -7,17 +7,52 @@ import (
 
 	"github.com/creachadair/jrpc2"
 
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/circuitbreaker"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
+// SyncStatus reports whether the server is still catching up with the network.
+type SyncStatus interface {
+	Initializing() bool
+}
+
+func isInitializing(syncStatus SyncStatus) bool {
+	return syncStatus != nil && syncStatus.Initializing()
+}
+
+func initializingError() *jrpc2.Error {
+	return &jrpc2.Error{
+		Code:    protocol.InitializingErrorCode,
+		Message: "rpc is initializing: the initial sync with the network hasn't completed yet",
+	}
+}
+
+// RejectWhileInitializing wraps a data method handler so that it returns an
+// initializing error, instead of possibly incomplete results, until the
+// initial sync completes.
+func RejectWhileInitializing(syncStatus SyncStatus, h jrpc2.Handler) jrpc2.Handler {
+	return func(ctx context.Context, r *jrpc2.Request) (any, error) {
+		if isInitializing(syncStatus) {
+			return nil, initializingError()
+		}
+		return h(ctx, r)
+	}
+}
+
 // NewHealthCheck returns a health check json rpc handler
 func NewHealthCheck(
-	retentionWindow uint32,
+	retentionWindow func() uint32,
 	ledgerReader db.LedgerReader,
 	maxHealthyLedgerLatency time.Duration,
+	coreQueryBreaker *circuitbreaker.Breaker,
+	syncStatus SyncStatus,
 ) jrpc2.Handler {
 	return NewHandler(func(ctx context.Context) (protocol.GetHealthResponse, error) {
+		if isInitializing(syncStatus) {
+			return protocol.GetHealthResponse{}, initializingError()
+		}
+
 		ledgerRange, err := ledgerReader.GetLedgerRange(ctx)
 		if err != nil || ledgerRange.LastLedger.Sequence < 1 {
 			extra := ""
@@ -45,7 +80,10 @@ func NewHealthCheck(
 			Status:                "healthy",
 			LatestLedger:          ledgerRange.LastLedger.Sequence,
 			OldestLedger:          ledgerRange.FirstLedger.Sequence,
-			LedgerRetentionWindow: retentionWindow,
+			LedgerRetentionWindow: retentionWindow(),
+		}
+		if coreQueryBreaker != nil {
+			result.CoreCircuitBreaker = coreQueryBreaker.State().String()
 		}
 		return result, nil
 	})
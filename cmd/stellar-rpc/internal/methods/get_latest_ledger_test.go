@@ -32,6 +32,10 @@ func (ledgerReader *ConstantLedgerReader) GetLedgerRange(_ context.Context) (led
 	return ledgerbucketwindow.LedgerRange{}, nil
 }
 
+func (ledgerReader *ConstantLedgerReader) GetLedgerGaps(_ context.Context, _ uint) ([]db.LedgerGap, error) {
+	return nil, nil
+}
+
 func (ledgerReader *ConstantLedgerReader) NewTx(_ context.Context) (db.LedgerReaderTx, error) {
 	return nil, errors.New("mock NewTx error")
 }
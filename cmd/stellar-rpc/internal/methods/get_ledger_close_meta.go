@@ -0,0 +1,110 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/rpcdatastore"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type ledgerCloseMetaHandler struct {
+	ledgerReader          db.LedgerReader
+	datastoreLedgerReader rpcdatastore.LedgerReader
+	logger                *log.Entry
+}
+
+// NewGetLedgerCloseMetaHandler returns a jrpc2.Handler for the
+// getLedgerCloseMeta method, which reads the ledger from the DB and falls back
+// to the datastore (when configured) for older ledgers.
+func NewGetLedgerCloseMetaHandler(ledgerReader db.LedgerReader,
+	datastoreLedgerReader rpcdatastore.LedgerReader, logger *log.Entry,
+) jrpc2.Handler {
+	return NewHandler(ledgerCloseMetaHandler{
+		ledgerReader:          ledgerReader,
+		datastoreLedgerReader: datastoreLedgerReader,
+		logger:                logger,
+	}.getLedgerCloseMeta)
+}
+
+func (h ledgerCloseMetaHandler) getLedger(ctx context.Context, sequence uint32,
+	localRange protocol.LedgerSeqRange,
+) (xdr.LedgerCloseMeta, error) {
+	if sequence >= localRange.FirstLedger {
+		ledger, found, err := h.ledgerReader.GetLedger(ctx, sequence)
+		if err != nil {
+			return xdr.LedgerCloseMeta{}, fmt.Errorf("error fetching ledger from db: %w", err)
+		}
+		if !found {
+			return xdr.LedgerCloseMeta{}, fmt.Errorf("ledger %d not found in db", sequence)
+		}
+		return ledger, nil
+	}
+	ledgers, err := h.datastoreLedgerReader.GetLedgers(ctx, sequence, sequence)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, fmt.Errorf("error fetching ledger from datastore: %w", err)
+	}
+	if len(ledgers) != 1 {
+		return xdr.LedgerCloseMeta{}, fmt.Errorf("ledger %d not found in datastore", sequence)
+	}
+	return ledgers[0], nil
+}
+
+func (h ledgerCloseMetaHandler) getLedgerCloseMeta(ctx context.Context,
+	request protocol.GetLedgerCloseMetaRequest,
+) (protocol.GetLedgerCloseMetaResponse, error) {
+	ledgerRange, err := h.ledgerReader.GetLedgerRange(ctx)
+	if err != nil {
+		return protocol.GetLedgerCloseMetaResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	localRange := ledgerRange.ToLedgerSeqRange()
+	availableRange := localRange
+	if h.datastoreLedgerReader != nil {
+		dsRange, err := h.datastoreLedgerReader.GetAvailableLedgerRange(ctx)
+		if err != nil {
+			// log error but continue using local ledger range
+			h.logger.WithError(err).Error("failed to get available ledger range from datastore")
+		} else {
+			availableRange.FirstLedger = min(dsRange.FirstLedger, availableRange.FirstLedger)
+		}
+	}
+
+	if !protocol.IsLedgerWithinRange(request.Sequence, availableRange) {
+		return protocol.GetLedgerCloseMetaResponse{}, &jrpc2.Error{
+			Code: jrpc2.InvalidParams,
+			Message: fmt.Sprintf(
+				"sequence (%d) must be between the oldest ledger: %d and the latest ledger: %d for this rpc instance",
+				request.Sequence, availableRange.FirstLedger, availableRange.LastLedger),
+		}
+	}
+
+	ledger, err := h.getLedger(ctx, request.Sequence, localRange)
+	if err != nil {
+		return protocol.GetLedgerCloseMetaResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	encoded, err := xdr.MarshalBase64(ledger)
+	if err != nil {
+		return protocol.GetLedgerCloseMetaResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: fmt.Sprintf("error marshaling ledger close meta: %v", err),
+		}
+	}
+	return protocol.GetLedgerCloseMetaResponse{
+		Sequence:        request.Sequence,
+		LedgerCloseMeta: encoded,
+		LatestLedger:    availableRange.LastLedger,
+		OldestLedger:    availableRange.FirstLedger,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package methods
+
+import (
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerbucketwindow"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func TestGetLedgerCloseMeta(t *testing.T) {
+	ctx := t.Context()
+	mockReader := new(MockLedgerReader)
+	mockStore := new(MockDatastoreReader)
+	handler := ledgerCloseMetaHandler{
+		ledgerReader:          mockReader,
+		datastoreLedgerReader: mockStore,
+	}
+
+	mockReader.On("GetLedgerRange", ctx).Return(ledgerbucketwindow.LedgerRange{
+		FirstLedger: ledgerbucketwindow.LedgerInfo{Sequence: 100},
+		LastLedger:  ledgerbucketwindow.LedgerInfo{Sequence: 200},
+	}, nil)
+	mockStore.On("GetAvailableLedgerRange", ctx).Return(protocol.LedgerSeqRange{FirstLedger: 50}, nil)
+	mockReader.On("GetLedger", ctx, uint32(150)).Return(createLedgerCloseMeta(150), true, nil)
+	mockStore.On("GetLedgers", ctx, uint32(60), uint32(60)).
+		Return([]xdr.LedgerCloseMeta{createLedgerCloseMeta(60)}, nil)
+
+	for _, sequence := range []uint32{150, 60} {
+		response, err := handler.getLedgerCloseMeta(ctx, protocol.GetLedgerCloseMetaRequest{Sequence: sequence})
+		require.NoError(t, err)
+		assert.Equal(t, sequence, response.Sequence)
+		assert.Equal(t, uint32(50), response.OldestLedger)
+		assert.Equal(t, uint32(200), response.LatestLedger)
+		expected, err := xdr.MarshalBase64(createLedgerCloseMeta(sequence))
+		require.NoError(t, err)
+		assert.Equal(t, expected, response.LedgerCloseMeta)
+	}
+
+	for _, sequence := range []uint32{49, 201} {
+		_, err := handler.getLedgerCloseMeta(ctx, protocol.GetLedgerCloseMetaRequest{Sequence: sequence})
+		var jrpcErr *jrpc2.Error
+		require.ErrorAs(t, err, &jrpcErr)
+		assert.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+	}
+	mockReader.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
@@ -3,15 +3,18 @@ package methods
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/creachadair/jrpc2"
 
+	proto "github.com/stellar/go/protocols/stellarcore"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/hotkeys"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
 	"github.com/stellar/stellar-rpc/protocol"
@@ -20,19 +23,90 @@ import (
 //nolint:gochecknoglobals
 var ErrLedgerTTLEntriesCannotBeQueriedDirectly = "ledger ttl entries cannot be queried directly"
 
-const getLedgerEntriesMaxKeys = 200
-
 // NewGetLedgerEntriesHandler returns a JSON RPC handler which retrieves ledger entries from Stellar Core.
+// The requested keys are recorded in hotKeys, which can be nil. Requests with more than maxKeys keys are rejected,
+// and Stellar Core is queried with at most batchKeys keys at once.
+// The entries can be retrieved at any of the snapshotLedgers latest ledgers.
 func NewGetLedgerEntriesHandler(
 	logger *log.Entry,
 	coreClient interfaces.FastCoreClient,
 	latestLedgerReader db.LedgerReader,
+	hotKeys *hotkeys.Tracker,
+	maxKeys uint,
+	batchKeys uint,
+	snapshotLedgers uint32,
 ) jrpc2.Handler {
+	coreClient = batchingCoreClient{FastCoreClient: coreClient, batchKeys: batchKeys}
 	getter := ledgerentries.NewLedgerEntryGetter(coreClient, latestLedgerReader)
-	return newGetLedgerEntriesHandlerFromGetter(logger, getter)
+	snapshots := ledgerEntrySnapshots{
+		latestLedgerReader: latestLedgerReader,
+		snapshotLedgers:    snapshotLedgers,
+		getterAt: func(atLedger uint32) ledgerentries.LedgerEntryGetter {
+			return ledgerentries.NewLedgerEntryAtGetter(coreClient, atLedger)
+		},
+	}
+	return newGetLedgerEntriesHandlerFromGetter(logger, getter, snapshots, hotKeys, maxKeys)
+}
+
+// batchingCoreClient splits the ledger entry queries into several queries of
+// at most batchKeys keys, all of them at the same ledger.
+type batchingCoreClient struct {
+	interfaces.FastCoreClient
+	batchKeys uint
 }
 
-func newGetLedgerEntriesHandlerFromGetter(logger *log.Entry, getter ledgerentries.LedgerEntryGetter) jrpc2.Handler {
+func (c batchingCoreClient) GetLedgerEntries(ctx context.Context, ledgerSeq uint32, keys ...xdr.LedgerKey,
+) (proto.GetLedgerEntryResponse, error) {
+	if c.batchKeys == 0 || uint(len(keys)) <= c.batchKeys {
+		return c.FastCoreClient.GetLedgerEntries(ctx, ledgerSeq, keys...)
+	}
+	var response proto.GetLedgerEntryResponse
+	for batch := range slices.Chunk(keys, int(c.batchKeys)) {
+		batchResponse, err := c.FastCoreClient.GetLedgerEntries(ctx, ledgerSeq, batch...)
+		if err != nil {
+			return proto.GetLedgerEntryResponse{}, err
+		}
+		response.Ledger = batchResponse.Ledger
+		response.Entries = append(response.Entries, batchResponse.Entries...)
+	}
+	return response, nil
+}
+
+// ledgerEntrySnapshots gets the ledger entries at one of the recent ledgers
+// whose state is retained by captive core.
+type ledgerEntrySnapshots struct {
+	latestLedgerReader db.LedgerReader
+	snapshotLedgers    uint32
+	getterAt           func(atLedger uint32) ledgerentries.LedgerEntryGetter
+}
+
+// getter returns a getter of the ledger entries at atLedger, along with the
+// latest ledger.
+func (s ledgerEntrySnapshots) getter(ctx context.Context, atLedger uint32,
+) (ledgerentries.LedgerEntryGetter, uint32, error) {
+	latestLedger, err := s.latestLedgerReader.GetLatestLedgerSequence(ctx)
+	if err != nil {
+		return nil, 0, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	if _, err := snapshotLedger(latestLedger, atLedger, s.snapshotLedgers); err != nil {
+		return nil, 0, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+	return s.getterAt(atLedger), latestLedger, nil
+}
+
+func newGetLedgerEntriesHandlerFromGetter(
+	logger *log.Entry,
+	getter ledgerentries.LedgerEntryGetter,
+	snapshots ledgerEntrySnapshots,
+	hotKeys *hotkeys.Tracker,
+	maxKeys uint,
+) jrpc2.Handler {
 	return NewHandler(func(ctx context.Context, request protocol.GetLedgerEntriesRequest,
 	) (protocol.GetLedgerEntriesResponse, error) {
 		if err := protocol.IsValidFormat(request.Format); err != nil {
@@ -42,10 +116,10 @@ func newGetLedgerEntriesHandlerFromGetter(logger *log.Entry, getter ledgerentrie
 			}
 		}
 
-		if len(request.Keys) > getLedgerEntriesMaxKeys {
+		if uint(len(request.Keys)) > maxKeys {
 			return protocol.GetLedgerEntriesResponse{}, &jrpc2.Error{
 				Code:    jrpc2.InvalidParams,
-				Message: fmt.Sprintf("key count (%d) exceeds maximum supported (%d)", len(request.Keys), getLedgerEntriesMaxKeys),
+				Message: fmt.Sprintf("key count (%d) exceeds maximum supported (%d)", len(request.Keys), maxKeys),
 			}
 		}
 		var ledgerKeys []xdr.LedgerKey
@@ -69,8 +143,17 @@ func newGetLedgerEntriesHandlerFromGetter(logger *log.Entry, getter ledgerentrie
 			}
 			ledgerKeys = append(ledgerKeys, ledgerKey)
 		}
+		hotKeys.Record(request.Keys...)
 
-		ledgerKeysAndEntries, latestLedger, err := getter.GetLedgerEntries(ctx, ledgerKeys)
+		entryGetter := getter
+		var latestLedger uint32
+		if request.AtLedger != 0 {
+			var err error
+			if entryGetter, latestLedger, err = snapshots.getter(ctx, request.AtLedger); err != nil {
+				return protocol.GetLedgerEntriesResponse{}, err
+			}
+		}
+		ledgerKeysAndEntries, ledger, err := entryGetter.GetLedgerEntries(ctx, ledgerKeys)
 		if err != nil {
 			logger.WithError(err).WithField("request", request).
 				Info("could not obtain ledger entries")
@@ -101,7 +184,15 @@ func newGetLedgerEntriesHandlerFromGetter(logger *log.Entry, getter ledgerentrie
 
 		response := protocol.GetLedgerEntriesResponse{
 			Entries:      ledgerEntryResults,
-			LatestLedger: latestLedger,
+			LatestLedger: ledger,
+		}
+		if request.AtLedger != 0 {
+			response.LatestLedger = latestLedger
+			response.Ledger = ledger
+		}
+		if request.Keyed {
+			response.EntriesByKey = keyLedgerEntryResults(request.Keys, ledgerKeysAndEntries, ledgerEntryResults)
+			response.Entries = []protocol.LedgerEntryResult{}
 		}
 		return response, nil
 	})
@@ -152,6 +243,23 @@ func sortKeysAndEntriesAccordingToRequest(b64RequestKeys []string, keyEntries []
 	return nil
 }
 
+// keyLedgerEntryResults maps the request keys to their results (which match
+// keyEntries), the keys which weren't found being mapped to nil.
+func keyLedgerEntryResults(b64RequestKeys []string, keyEntries []ledgerentries.LedgerKeyAndEntry,
+	results []protocol.LedgerEntryResult,
+) map[string]*protocol.LedgerEntryResult {
+	byKey := make(map[string]*protocol.LedgerEntryResult, len(b64RequestKeys))
+	for _, key := range b64RequestKeys {
+		byKey[key] = nil
+	}
+	for i, keyEntry := range keyEntries {
+		// the keys were already marshaled (and matched) when sorting the entries
+		b64Key, _ := xdr.MarshalBase64(keyEntry.Key)
+		byKey[b64Key] = &results[i]
+	}
+	return byKey
+}
+
 func ledgerKeyEntryToResult(keyEntry ledgerentries.LedgerKeyAndEntry,
 	format string,
 ) (protocol.LedgerEntryResult, error) {
@@ -0,0 +1,211 @@
+package methods
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+// recordingCoreClient records the amount of keys of the ledger entry queries
+type recordingCoreClient struct {
+	interfaces.FastCoreClient
+	ledgers  []uint32
+	keyCount []int
+}
+
+func (c *recordingCoreClient) GetLedgerEntries(_ context.Context, ledgerSeq uint32, keys ...xdr.LedgerKey,
+) (proto.GetLedgerEntryResponse, error) {
+	c.ledgers = append(c.ledgers, ledgerSeq)
+	c.keyCount = append(c.keyCount, len(keys))
+	entries := make([]proto.LedgerEntryResponse, len(keys))
+	for i := range entries {
+		entries[i].State = proto.LedgerEntryStateNotFound
+	}
+	return proto.GetLedgerEntryResponse{Ledger: ledgerSeq, Entries: entries}, nil
+}
+
+type staticLedgerEntryGetter struct {
+	ledger  uint32
+	entries []ledgerentries.LedgerKeyAndEntry
+}
+
+func (g staticLedgerEntryGetter) GetLedgerEntries(
+	_ context.Context, _ []xdr.LedgerKey,
+) ([]ledgerentries.LedgerKeyAndEntry, uint32, error) {
+	return g.entries, g.ledger, nil
+}
+
+func TestGetLedgerEntriesMaxKeys(t *testing.T) {
+	handler := newGetLedgerEntriesHandlerFromGetter(log.DefaultLogger, staticLedgerEntryGetter{ledger: 10},
+		ledgerEntrySnapshots{}, nil, 2)
+
+	call := func(keyCount int) (any, error) {
+		request := protocol.GetLedgerEntriesRequest{}
+		for range keyCount {
+			key, err := xdr.LedgerKey{
+				Type: xdr.LedgerEntryTypeAccount,
+				Account: &xdr.LedgerKeyAccount{
+					AccountId: xdr.MustAddress(keypair.MustRandom().Address()),
+				},
+			}.MarshalBinaryBase64()
+			require.NoError(t, err)
+			request.Keys = append(request.Keys, key)
+		}
+		params, err := json.Marshal(request)
+		require.NoError(t, err)
+		requests, err := jrpc2.ParseRequests([]byte(
+			`{"jsonrpc": "2.0", "id": 1, "method": "getLedgerEntries", "params": ` + string(params) + `}`))
+		require.NoError(t, err)
+		return handler(context.Background(), requests[0].ToRequest())
+	}
+
+	_, err := call(2)
+	require.NoError(t, err)
+
+	_, err = call(3)
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	require.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+	require.Contains(t, jrpcErr.Message, "key count (3) exceeds maximum supported (2)")
+}
+
+func TestGetLedgerEntriesKeyed(t *testing.T) {
+	accountKey := func() (xdr.LedgerKey, string) {
+		key := xdr.LedgerKey{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.LedgerKeyAccount{
+				AccountId: xdr.MustAddress(keypair.MustRandom().Address()),
+			},
+		}
+		b64Key, err := key.MarshalBinaryBase64()
+		require.NoError(t, err)
+		return key, b64Key
+	}
+	foundKey, b64FoundKey := accountKey()
+	_, b64MissingKey := accountKey()
+	entry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 5,
+		Data: xdr.LedgerEntryData{
+			Type:    xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{AccountId: foundKey.Account.AccountId},
+		},
+	}
+	getter := staticLedgerEntryGetter{
+		ledger:  10,
+		entries: []ledgerentries.LedgerKeyAndEntry{{Key: foundKey, Entry: entry}},
+	}
+	handler := newGetLedgerEntriesHandlerFromGetter(log.DefaultLogger, getter, ledgerEntrySnapshots{}, nil, 10)
+
+	params, err := json.Marshal(protocol.GetLedgerEntriesRequest{
+		Keys:  []string{b64MissingKey, b64FoundKey},
+		Keyed: true,
+	})
+	require.NoError(t, err)
+	requests, err := jrpc2.ParseRequests([]byte(
+		`{"jsonrpc": "2.0", "id": 1, "method": "getLedgerEntries", "params": ` + string(params) + `}`))
+	require.NoError(t, err)
+	result, err := handler(context.Background(), requests[0].ToRequest())
+	require.NoError(t, err)
+
+	response, ok := result.(protocol.GetLedgerEntriesResponse)
+	require.True(t, ok)
+	require.Empty(t, response.Entries)
+	require.Len(t, response.EntriesByKey, 2)
+	require.Contains(t, response.EntriesByKey, b64MissingKey)
+	require.Nil(t, response.EntriesByKey[b64MissingKey])
+	require.NotNil(t, response.EntriesByKey[b64FoundKey])
+	require.Equal(t, b64FoundKey, response.EntriesByKey[b64FoundKey].KeyXDR)
+	require.Equal(t, uint32(5), response.EntriesByKey[b64FoundKey].LastModifiedLedger)
+}
+
+func TestGetLedgerEntriesAtLedger(t *testing.T) {
+	mockReader := new(MockLedgerReader)
+	mockReader.On("GetLatestLedgerSequence", mock.Anything).Return(uint32(100), nil)
+	snapshots := ledgerEntrySnapshots{
+		latestLedgerReader: mockReader,
+		snapshotLedgers:    4,
+		getterAt: func(atLedger uint32) ledgerentries.LedgerEntryGetter {
+			return staticLedgerEntryGetter{ledger: atLedger}
+		},
+	}
+	handler := newGetLedgerEntriesHandlerFromGetter(log.DefaultLogger, staticLedgerEntryGetter{ledger: 100},
+		snapshots, nil, 10)
+
+	call := func(atLedger uint32) (any, error) {
+		params, err := json.Marshal(protocol.GetLedgerEntriesRequest{Keys: []string{}, AtLedger: atLedger})
+		require.NoError(t, err)
+		requests, err := jrpc2.ParseRequests([]byte(
+			`{"jsonrpc": "2.0", "id": 1, "method": "getLedgerEntries", "params": ` + string(params) + `}`))
+		require.NoError(t, err)
+		return handler(context.Background(), requests[0].ToRequest())
+	}
+
+	result, err := call(98)
+	require.NoError(t, err)
+	response, ok := result.(protocol.GetLedgerEntriesResponse)
+	require.True(t, ok)
+	require.Equal(t, uint32(100), response.LatestLedger)
+	require.Equal(t, uint32(98), response.Ledger)
+
+	// the latest ledger is used by default
+	result, err = call(0)
+	require.NoError(t, err)
+	response, ok = result.(protocol.GetLedgerEntriesResponse)
+	require.True(t, ok)
+	require.Equal(t, uint32(100), response.LatestLedger)
+	require.Zero(t, response.Ledger)
+
+	// the state of older ledgers isn't retained
+	_, err = call(96)
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	require.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+	require.Contains(t, jrpcErr.Message, "atLedger must be between 97 and 100")
+}
+
+func TestGetLedgerEntriesBatches(t *testing.T) {
+	mockReader := new(MockLedgerReader)
+	mockReader.On("GetLatestLedgerSequence", mock.Anything).Return(uint32(100), nil)
+	coreClient := &recordingCoreClient{}
+	handler := NewGetLedgerEntriesHandler(log.DefaultLogger, coreClient, mockReader, nil, 10, 2, 0)
+
+	request := protocol.GetLedgerEntriesRequest{}
+	for range 5 {
+		key, err := xdr.LedgerKey{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.LedgerKeyAccount{
+				AccountId: xdr.MustAddress(keypair.MustRandom().Address()),
+			},
+		}.MarshalBinaryBase64()
+		require.NoError(t, err)
+		request.Keys = append(request.Keys, key)
+	}
+	params, err := json.Marshal(request)
+	require.NoError(t, err)
+	requests, err := jrpc2.ParseRequests([]byte(
+		`{"jsonrpc": "2.0", "id": 1, "method": "getLedgerEntries", "params": ` + string(params) + `}`))
+	require.NoError(t, err)
+	result, err := handler(context.Background(), requests[0].ToRequest())
+	require.NoError(t, err)
+
+	// the keys are queried in batches, all of them at the same ledger
+	require.Equal(t, []int{2, 2, 1}, coreClient.keyCount)
+	require.Equal(t, []uint32{100, 100, 100}, coreClient.ledgers)
+	response, ok := result.(protocol.GetLedgerEntriesResponse)
+	require.True(t, ok)
+	require.Equal(t, uint32(100), response.LatestLedger)
+	require.Empty(t, response.Entries)
+}
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/creachadair/jrpc2"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stellar/go/support/log"
 	"github.com/stellar/go/xdr"
 
@@ -16,27 +19,64 @@ import (
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
+// LedgerReadFallback configures how the getLedgers reads recover from the
+// failures of a ledger source.
+type LedgerReadFallback struct {
+	// ToDatastore tries the datastore (if any) when reading ledgers from the
+	// local database fails.
+	ToDatastore bool
+	// DatastoreRetries is the number of times a failed datastore read is
+	// retried. The local reads are already retried while the database is busy.
+	DatastoreRetries uint
+	// Counter counts the fallbacks and retries, by action, if set.
+	Counter *prometheus.CounterVec
+}
+
 type ledgersHandler struct {
 	ledgerReader          db.LedgerReader
 	maxLimit              uint
 	defaultLimit          uint
 	datastoreLedgerReader rpcdatastore.LedgerReader
+	fallback              LedgerReadFallback
 	logger                *log.Entry
 }
 
 // NewGetLedgersHandler returns a jrpc2.Handler for the getLedgers method.
 func NewGetLedgersHandler(ledgerReader db.LedgerReader, maxLimit, defaultLimit uint,
-	datastoreLedgerReader rpcdatastore.LedgerReader, logger *log.Entry,
+	datastoreLedgerReader rpcdatastore.LedgerReader, fallback LedgerReadFallback, logger *log.Entry,
 ) jrpc2.Handler {
 	return NewHandler((&ledgersHandler{
 		ledgerReader:          ledgerReader,
 		maxLimit:              maxLimit,
 		defaultLimit:          defaultLimit,
 		datastoreLedgerReader: datastoreLedgerReader,
+		fallback:              fallback,
 		logger:                logger,
 	}).getLedgers)
 }
 
+// countFallback meters a fallback or retry of a ledger read.
+func (h ledgersHandler) countFallback(action string) {
+	if h.fallback.Counter != nil {
+		h.fallback.Counter.With(prometheus.Labels{"action": action}).Inc()
+	}
+}
+
+// getDatastoreLedgers reads ledgers from the datastore, retrying the failed
+// reads according to the fallback configuration.
+func (h ledgersHandler) getDatastoreLedgers(ctx context.Context, start uint32, end uint32,
+) ([]xdr.LedgerCloseMeta, error) {
+	for attempt := uint(0); ; attempt++ {
+		ledgers, err := h.datastoreLedgerReader.GetLedgers(ctx, start, end)
+		if err == nil || attempt == h.fallback.DatastoreRetries || ctx.Err() != nil {
+			return ledgers, err
+		}
+		h.logger.WithError(err).WithField("attempt", attempt+1).
+			Warnf("failed to fetch ledgers %d-%d from datastore, retrying", start, end)
+		h.countFallback("datastore_retry")
+	}
+}
+
 // getLedgers fetch ledgers and relevant metadata from DB and falling back to the remote rpcdatastore if necessary.
 func (h ledgersHandler) getLedgers(ctx context.Context, request protocol.GetLedgersRequest,
 ) (protocol.GetLedgersResponse, error) {
@@ -91,11 +131,12 @@ func (h ledgersHandler) getLedgers(ctx context.Context, request protocol.GetLedg
 	}
 
 	end := start + uint32(limit) - 1 //nolint:gosec
-	ledgers, err := h.fetchLedgers(ctx, start, end, request.Format, readTx, ledgerRange.ToLedgerSeqRange())
+	ledgers, err := h.fetchLedgers(ctx, start, end, request, readTx, ledgerRange.ToLedgerSeqRange())
 	if err != nil {
 		return protocol.GetLedgersResponse{}, err
 	}
-	cursor := strconv.Itoa(int(ledgers[len(ledgers)-1].Sequence))
+	lastSequence := ledgers[len(ledgers)-1].Sequence
+	cursor := strconv.Itoa(int(lastSequence))
 
 	return protocol.GetLedgersResponse{
 		Ledgers: ledgers,
@@ -105,6 +146,14 @@ func (h ledgersHandler) getLedgers(ctx context.Context, request protocol.GetLedg
 		OldestLedger:          ledgerRange.FirstLedger.Sequence,
 		OldestLedgerCloseTime: ledgerRange.FirstLedger.CloseTime,
 		Cursor:                cursor,
+		Page: protocol.PageInfo{
+			Cursor:       cursor,
+			Limit:        limit,
+			HasMore:      lastSequence < ledgerRange.LastLedger.Sequence,
+			Finalized:    lastSequence < ledgerRange.LastLedger.Sequence,
+			OldestLedger: ledgerRange.FirstLedger.Sequence,
+			LatestLedger: ledgerRange.LastLedger.Sequence,
+		},
 	}, nil
 }
 
@@ -152,17 +201,27 @@ func (h ledgersHandler) parseCursor(cursor string, ledgerRange protocol.LedgerSe
 
 // fetchLedgers retrieves a batch of ledgers in the range [start, start+limit-1]
 // using the local DB when available, and falling back to the remote datastore
-// for any portion of the range that lies outside the locally available range.
+// for any portion of the range that lies outside the locally available range
+// (or, if configured, for the local reads which fail).
 //
 // It handles three cases:
 //  1. Entire range is available in local db.
 //  2. Entire range is unavailable in local db so fetch fully from datastore.
 //  3. Range partially available in the local db with the rest fetched from the datastore.
 func (h ledgersHandler) fetchLedgers(ctx context.Context, start uint32,
-	end uint32, format string, readTx db.LedgerReaderTx, localLedgerRange protocol.LedgerSeqRange,
+	end uint32, request protocol.GetLedgersRequest, readTx db.LedgerReaderTx, localLedgerRange protocol.LedgerSeqRange,
 ) ([]protocol.LedgerInfo, error) {
 	fetchFromLocalDB := func(start uint32, end uint32) ([]xdr.LedgerCloseMeta, error) {
 		ledgers, err := readTx.BatchGetLedgers(ctx, start, end)
+		if err != nil && h.fallback.ToDatastore && h.datastoreLedgerReader != nil {
+			h.logger.WithError(err).Warnf("failed to fetch ledgers %d-%d from db, falling back to datastore", start, end)
+			h.countFallback("datastore_fallback")
+			var dsErr error
+			if ledgers, dsErr = h.getDatastoreLedgers(ctx, start, end); dsErr == nil {
+				return ledgers, nil
+			}
+			err = errors.Join(err, dsErr)
+		}
 		if err != nil {
 			return nil, &jrpc2.Error{
 				Code:    jrpc2.InternalError,
@@ -179,7 +238,7 @@ func (h ledgersHandler) fetchLedgers(ctx context.Context, start uint32,
 				Message: "datastore ledger reader not configured",
 			}
 		}
-		ledgers, err := h.datastoreLedgerReader.GetLedgers(ctx, start, end)
+		ledgers, err := h.getDatastoreLedgers(ctx, start, end)
 		if err != nil {
 			return nil, &jrpc2.Error{
 				Code:    jrpc2.InternalError,
@@ -232,7 +291,7 @@ func (h ledgersHandler) fetchLedgers(ctx context.Context, start uint32,
 			break
 		}
 
-		ledgerInfo, err := h.parseLedgerInfo(ledger, format)
+		ledgerInfo, err := h.parseLedgerInfo(ledger, request)
 		if err != nil {
 			return nil, &jrpc2.Error{
 				Code:    jrpc2.InternalError,
@@ -246,15 +305,37 @@ func (h ledgersHandler) fetchLedgers(ctx context.Context, start uint32,
 }
 
 // parseLedgerInfo extracts and formats the ledger metadata and header information.
-func (h ledgersHandler) parseLedgerInfo(ledger xdr.LedgerCloseMeta, format string) (protocol.LedgerInfo, error) {
+func (h ledgersHandler) parseLedgerInfo(ledger xdr.LedgerCloseMeta, request protocol.GetLedgersRequest,
+) (protocol.LedgerInfo, error) {
 	ledgerInfo := protocol.LedgerInfo{
 		Hash:            ledger.LedgerHash().HexString(),
 		Sequence:        ledger.LedgerSequence(),
 		LedgerCloseTime: ledger.LedgerCloseTime(),
 	}
 
+	if request.IncludeCounts {
+		counts := countLedgerTransactions(ledger)
+		ledgerInfo.Counts = &counts
+	}
+	if request.IncludeResultCodes {
+		ledgerInfo.ResultCodes = countLedgerResultCodes(ledger)
+	}
+
+	// The binary meta is needed for the XDR format and for hashing
+	var closeMetaB []byte
+	if request.IncludeMetaHash || request.Format != protocol.FormatJSON {
+		var err error
+		closeMetaB, err = ledger.MarshalBinary()
+		if err != nil {
+			return protocol.LedgerInfo{}, fmt.Errorf("error marshaling ledger close meta: %w", err)
+		}
+	}
+	if request.IncludeMetaHash {
+		ledgerInfo.LedgerMetadataHash = metaHash(closeMetaB)
+	}
+
 	// Format the data according to the requested format (JSON or XDR)
-	switch format {
+	switch request.Format {
 	case protocol.FormatJSON:
 		var convErr error
 		ledgerInfo.LedgerMetadataJSON, ledgerInfo.LedgerHeaderJSON, convErr = ledgerToJSON(&ledger)
@@ -262,11 +343,6 @@ func (h ledgersHandler) parseLedgerInfo(ledger xdr.LedgerCloseMeta, format strin
 			return ledgerInfo, convErr
 		}
 	default:
-		closeMetaB, err := ledger.MarshalBinary()
-		if err != nil {
-			return protocol.LedgerInfo{}, fmt.Errorf("error marshaling ledger close meta: %w", err)
-		}
-
 		headerB, err := ledger.LedgerHeaderHistoryEntry().MarshalBinary()
 		if err != nil {
 			return protocol.LedgerInfo{}, fmt.Errorf("error marshaling ledger header: %w", err)
@@ -277,3 +353,51 @@ func (h ledgersHandler) parseLedgerInfo(ledger xdr.LedgerCloseMeta, format strin
 	}
 	return ledgerInfo, nil
 }
+
+// countLedgerTransactions tallies the transactions and operations in a ledger.
+// Envelopes are in transaction set order while results are in apply order, but
+// that does not matter for the totals.
+func countLedgerTransactions(ledger xdr.LedgerCloseMeta) protocol.LedgerCounts {
+	var counts protocol.LedgerCounts
+	for _, envelope := range ledger.TransactionEnvelopes() {
+		counts.OperationCount += envelope.OperationsCount()
+	}
+
+	txCount := ledger.CountTransactions()
+	counts.TransactionCount = uint32(txCount) //nolint:gosec
+	for i := range txCount {
+		if ledger.TransactionResultPair(i).Successful() {
+			counts.SuccessfulTransactionCount++
+		} else {
+			counts.FailedTransactionCount++
+		}
+	}
+	return counts
+}
+
+// transactionResultCodeName returns the name of a transaction result code as
+// used by stellar-core (e.g. "txBAD_SEQ" for TransactionResultCodeTxBadSeq).
+func transactionResultCodeName(code xdr.TransactionResultCode) string {
+	name, ok := strings.CutPrefix(code.String(), "TransactionResultCodeTx")
+	if !ok {
+		return strconv.Itoa(int(code))
+	}
+	var builder strings.Builder
+	builder.WriteString("tx")
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			builder.WriteByte('_')
+		}
+		builder.WriteRune(unicode.ToUpper(r))
+	}
+	return builder.String()
+}
+
+// countLedgerResultCodes tallies the result codes of the transactions in a ledger.
+func countLedgerResultCodes(ledger xdr.LedgerCloseMeta) map[string]uint32 {
+	codes := map[string]uint32{}
+	for i := range ledger.CountTransactions() {
+		codes[transactionResultCodeName(ledger.TransactionResultPair(i).Result.Result.Code)]++
+	}
+	return codes
+}
@@ -2,6 +2,9 @@ package methods
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"testing"
@@ -31,7 +34,8 @@ func setupTestDB(t *testing.T, numLedgers int) *db.DB {
 	daemon := interfaces.MakeNoOpDeamon()
 	for sequence := 1; sequence <= numLedgers; sequence++ {
 		ledgerCloseMeta := txMeta(uint32(sequence)-100, true)
-		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, daemon, 150, 100, passphrase).NewTx(context.Background())
+		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, daemon, 150, 100, passphrase, db.EventStorage{}, false, false).
+			NewTx(context.Background())
 		require.NoError(t, err)
 		require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
 		require.NoError(t, tx.Commit(ledgerCloseMeta))
@@ -57,6 +61,9 @@ func TestGetLedgers_DefaultLimit(t *testing.T) {
 	assert.Equal(t, uint32(50), response.LatestLedger)
 	assert.Equal(t, ledgerCloseTime(50), response.LatestLedgerCloseTime)
 	assert.Equal(t, "5", response.Cursor)
+	assert.Equal(t, protocol.PageInfo{
+		Cursor: "5", Limit: 5, HasMore: true, Finalized: true, OldestLedger: 1, LatestLedger: 50,
+	}, response.Page)
 	assert.Len(t, response.Ledgers, 5)
 	assert.Equal(t, uint32(1), response.Ledgers[0].Sequence)
 	assert.Equal(t, uint32(5), response.Ledgers[4].Sequence)
@@ -85,6 +92,9 @@ func TestGetLedgers_CustomLimit(t *testing.T) {
 
 	assert.Equal(t, uint32(40), response.LatestLedger)
 	assert.Equal(t, "40", response.Cursor)
+	assert.False(t, response.Page.HasMore)
+	assert.False(t, response.Page.Finalized)
+	assert.Equal(t, uint(50), response.Page.Limit)
 	assert.Len(t, response.Ledgers, 40)
 	assert.Equal(t, uint32(1), response.Ledgers[0].Sequence)
 	assert.Equal(t, uint32(40), response.Ledgers[39].Sequence)
@@ -206,6 +216,89 @@ func TestGetLedgers_JSONFormat(t *testing.T) {
 	assert.NotEmpty(t, metaJSON)
 }
 
+func TestGetLedgers_IncludeCounts(t *testing.T) {
+	testDB := setupTestDB(t, 10)
+	handler := ledgersHandler{
+		ledgerReader: db.NewLedgerReader(testDB),
+		maxLimit:     100,
+		defaultLimit: 5,
+	}
+
+	request := protocol.GetLedgersRequest{StartLedger: 1}
+	response, err := handler.getLedgers(context.TODO(), request)
+	require.NoError(t, err)
+	for _, ledger := range response.Ledgers {
+		assert.Nil(t, ledger.Counts)
+	}
+
+	request.IncludeCounts = true
+	response, err = handler.getLedgers(context.TODO(), request)
+	require.NoError(t, err)
+	require.NotEmpty(t, response.Ledgers)
+	for _, ledger := range response.Ledgers {
+		require.NotNil(t, ledger.Counts)
+		assert.Equal(t, protocol.LedgerCounts{
+			TransactionCount:           1,
+			SuccessfulTransactionCount: 1,
+		}, *ledger.Counts)
+	}
+}
+
+func TestGetLedgers_IncludeMetaHash(t *testing.T) {
+	testDB := setupTestDB(t, 10)
+	handler := ledgersHandler{
+		ledgerReader: db.NewLedgerReader(testDB),
+		maxLimit:     100,
+		defaultLimit: 5,
+	}
+
+	for _, format := range []string{protocol.FormatBase64, protocol.FormatJSON} {
+		request := protocol.GetLedgersRequest{StartLedger: 1, Format: format, IncludeMetaHash: true}
+		response, err := handler.getLedgers(context.TODO(), request)
+		require.NoError(t, err)
+		require.NotEmpty(t, response.Ledgers)
+
+		metaB, err := base64.StdEncoding.DecodeString(expectedLedgerInfo.LedgerMetadata)
+		require.NoError(t, err)
+		expectedHash := sha256.Sum256(metaB)
+		assert.Equal(t, hex.EncodeToString(expectedHash[:]), response.Ledgers[0].LedgerMetadataHash)
+	}
+}
+
+func TestCountLedgerTransactions(t *testing.T) {
+	meta := txMeta(1, true)
+
+	// add a failed transaction carrying two operations to the same ledger
+	failed := txMeta(2, false)
+	meta.V1.TxProcessing = append(meta.V1.TxProcessing, failed.V1.TxProcessing...)
+	envelope := txEnvelope(2)
+	envelope.V1.Tx.Operations = []xdr.Operation{
+		{Body: xdr.OperationBody{Type: xdr.OperationTypeInflation}},
+		{Body: xdr.OperationBody{Type: xdr.OperationTypeInflation}},
+	}
+	components := *meta.V1.TxSet.V1TxSet.Phases[0].V0Components
+	components[0].TxsMaybeDiscountedFee.Txs = append(components[0].TxsMaybeDiscountedFee.Txs, envelope)
+
+	assert.Equal(t, protocol.LedgerCounts{
+		TransactionCount:           2,
+		OperationCount:             2,
+		SuccessfulTransactionCount: 1,
+		FailedTransactionCount:     1,
+	}, countLedgerTransactions(meta))
+}
+
+func TestCountLedgerResultCodes(t *testing.T) {
+	meta := txMeta(1, true)
+	for _, seq := range []uint32{2, 3} {
+		failed := txMeta(seq, false)
+		meta.V1.TxProcessing = append(meta.V1.TxProcessing, failed.V1.TxProcessing...)
+	}
+
+	assert.Equal(t, map[string]uint32{"txSUCCESS": 1, "txBAD_SEQ": 2}, countLedgerResultCodes(meta))
+	assert.Equal(t, "txFEE_BUMP_INNER_FAILED",
+		transactionResultCodeName(xdr.TransactionResultCodeTxFeeBumpInnerFailed))
+}
+
 func TestGetLedgers_NoLedgers(t *testing.T) {
 	testDB := setupTestDB(t, 0)
 	handler := ledgersHandler{
@@ -270,7 +363,7 @@ func setupBenchmarkingDB(b *testing.B) *db.DB {
 	testDB := NewTestDB(b)
 	logger := log.DefaultLogger
 	writer := db.NewReadWriter(logger, testDB, interfaces.MakeNoOpDeamon(),
-		100, 1_000_000, passphrase)
+		100, 1_000_000, passphrase, db.EventStorage{}, false, false)
 	write, err := writer.NewTx(context.TODO())
 	require.NoError(b, err)
 
@@ -409,7 +502,7 @@ func TestFetchLedgersErrors(t *testing.T) {
 			Return([]xdr.LedgerCloseMeta(nil), errors.New("db error"))
 
 		handler := ledgersHandler{}
-		_, err := handler.fetchLedgers(ctx, 150, 151, "default", mockTx, localRange)
+		_, err := handler.fetchLedgers(ctx, 150, 151, protocol.GetLedgersRequest{}, mockTx, localRange)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "db error")
 		mockTx.AssertExpectations(t)
@@ -425,7 +518,7 @@ func TestFetchLedgersErrors(t *testing.T) {
 			datastoreLedgerReader: mockStore,
 		}
 
-		_, err := handler.fetchLedgers(ctx, 50, 51, "default", mockTx, localRange)
+		_, err := handler.fetchLedgers(ctx, 50, 51, protocol.GetLedgersRequest{}, mockTx, localRange)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "datastore error")
 		mockTx.AssertExpectations(t)
@@ -436,9 +529,37 @@ func TestFetchLedgersErrors(t *testing.T) {
 		mockTx := new(MockLedgerReaderTx)
 		handler := ledgersHandler{}
 
-		_, err := handler.fetchLedgers(ctx, 50, 51, "default", mockTx, localRange)
+		_, err := handler.fetchLedgers(ctx, 50, 51, protocol.GetLedgersRequest{}, mockTx, localRange)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "datastore ledger reader not configured")
 		mockTx.AssertExpectations(t)
 	})
 }
+
+func TestFetchLedgersFallback(t *testing.T) {
+	ctx := t.Context()
+	localRange := protocol.LedgerSeqRange{FirstLedger: 100, LastLedger: 200}
+	mockTx := new(MockLedgerReaderTx)
+	mockTx.On("BatchGetLedgers", ctx, uint32(150), uint32(151)).
+		Return([]xdr.LedgerCloseMeta(nil), errors.New("db error"))
+	mockStore := new(MockDatastoreReader)
+	mockStore.On("GetLedgers", ctx, uint32(150), uint32(151)).
+		Return([]xdr.LedgerCloseMeta(nil), errors.New("datastore error")).Once()
+	mockStore.On("GetLedgers", ctx, uint32(150), uint32(151)).
+		Return(getLedgerRange([]uint32{150, 151}), nil).Once()
+
+	// the failed local read falls back to the datastore, whose failed read is
+	// retried
+	handler := ledgersHandler{
+		datastoreLedgerReader: mockStore,
+		fallback:              LedgerReadFallback{ToDatastore: true, DatastoreRetries: 1},
+		logger:                log.DefaultLogger,
+	}
+	ledgers, err := handler.fetchLedgers(ctx, 150, 151, protocol.GetLedgersRequest{}, mockTx, localRange)
+	require.NoError(t, err)
+	require.Len(t, ledgers, 2)
+	assert.Equal(t, uint32(150), ledgers[0].Sequence)
+	assert.Equal(t, uint32(151), ledgers[1].Sequence)
+	mockTx.AssertExpectations(t)
+	mockStore.AssertExpectations(t)
+}
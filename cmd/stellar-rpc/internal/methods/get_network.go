@@ -2,6 +2,7 @@ package methods
 
 import (
 	"context"
+	"time"
 
 	"github.com/creachadair/jrpc2"
 
@@ -9,25 +10,46 @@ import (
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
-// NewGetNetworkHandler returns a json rpc handler to for the getNetwork method
+// NewGetNetworkHandler returns a json rpc handler to for the getNetwork method.
+// The network parameters are read from the header of the latest ledger.
+// Responses are cached for cacheTTL (or until a new ledger is ingested).
 func NewGetNetworkHandler(
 	networkPassphrase string,
 	friendbotURL string,
 	ledgerReader db.LedgerReader,
+	cacheTTL time.Duration,
 ) jrpc2.Handler {
+	cache := newResponseCache[protocol.GetNetworkResponse](cacheTTL)
 	return NewHandler(func(ctx context.Context, _ protocol.GetNetworkRequest) (protocol.GetNetworkResponse, error) {
-		protocolVersion, err := getProtocolVersion(ctx, ledgerReader)
+		latestLedger, err := ledgerReader.GetLatestLedgerSequence(ctx)
 		if err != nil {
 			return protocol.GetNetworkResponse{}, &jrpc2.Error{
 				Code:    jrpc2.InternalError,
 				Message: err.Error(),
 			}
 		}
+		if response, ok := cache.get(latestLedger); ok {
+			return response, nil
+		}
 
-		return protocol.GetNetworkResponse{
+		header, err := getLedgerHeader(ctx, ledgerReader, latestLedger)
+		if err != nil {
+			return protocol.GetNetworkResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+
+		response := protocol.GetNetworkResponse{
 			FriendbotURL:    friendbotURL,
 			Passphrase:      networkPassphrase,
-			ProtocolVersion: int(protocolVersion),
-		}, nil
+			ProtocolVersion: int(header.LedgerVersion),
+			LatestLedger:    latestLedger,
+			BaseFee:         uint32(header.BaseFee),
+			BaseReserve:     uint32(header.BaseReserve),
+			MaxTxSetSize:    uint32(header.MaxTxSetSize),
+		}
+		cache.set(latestLedger, response)
+		return response, nil
 	})
 }
@@ -0,0 +1,179 @@
+package methods
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type operationsHandler struct {
+	logger       *log.Entry
+	reader       db.OperationReader
+	ledgerReader db.LedgerReader
+	// enabled tells whether the operations are ingested
+	enabled      bool
+	maxLimit     uint
+	defaultLimit uint
+}
+
+// initializePagination returns the position of the first operation to
+// return and the maximum number of operations to return.
+func (h operationsHandler) initializePagination(request protocol.GetOperationsRequest) (toid.ID, uint, error) {
+	start := toid.New(int32(request.StartLedger), 1, 1)
+	limit := h.defaultLimit
+	if request.Pagination != nil {
+		if request.Pagination.Cursor != "" {
+			cursorInt, err := strconv.ParseInt(request.Pagination.Cursor, 10, 64)
+			if err != nil {
+				return toid.ID{}, 0, &jrpc2.Error{
+					Code:    jrpc2.InvalidParams,
+					Message: err.Error(),
+				}
+			}
+			*start = toid.Parse(cursorInt)
+			// increment the operation index because, when paginating,
+			// we start with the item right after the cursor
+			start.OperationOrder++
+		}
+		if request.Pagination.Limit > 0 {
+			limit = request.Pagination.Limit
+		}
+	}
+	return *start, limit, nil
+}
+
+func operationInfo(operation db.Operation, format string) (protocol.OperationInfo, error) {
+	info := protocol.OperationInfo{
+		ID: toid.New(int32(operation.LedgerSequence), operation.ApplicationOrder,
+			operation.Index+1).String(),
+		Ledger:           operation.LedgerSequence,
+		LedgerCloseTime:  operation.LedgerCloseTime,
+		ApplicationOrder: operation.ApplicationOrder,
+		OperationIndex:   operation.Index,
+		TransactionHash:  hex.EncodeToString(operation.TransactionHash),
+		Type:             protocol.OperationTypeNames[xdr.OperationType(operation.Type)],
+		SourceAccount:    operation.SourceAccount,
+		Successful:       operation.Successful,
+	}
+	switch format {
+	case protocol.FormatJSON:
+		body, err := xdr2json.ConvertBytes(xdr.OperationBody{}, operation.Body)
+		if err != nil {
+			return protocol.OperationInfo{}, err
+		}
+		info.BodyJSON = body
+	default:
+		info.BodyXDR = base64.StdEncoding.EncodeToString(operation.Body)
+	}
+	return info, nil
+}
+
+//nolint:funlen
+func (h operationsHandler) getOperations(ctx context.Context, request protocol.GetOperationsRequest,
+) (protocol.GetOperationsResponse, error) {
+	if !h.enabled {
+		return protocol.GetOperationsResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidRequest,
+			Message: "the operation ingestion is disabled (see the ingest-operations option)",
+		}
+	}
+	ledgerRange, err := h.ledgerReader.GetLedgerRange(ctx)
+	if err != nil {
+		return protocol.GetOperationsResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	// The operations follow the retention window of the database, so older
+	// operations can't be served from the datastore
+	if err := request.IsValid(h.maxLimit, ledgerRange.ToLedgerSeqRange()); err != nil {
+		return protocol.GetOperationsResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+	filter := db.OperationFilter{Accounts: request.Accounts}
+	for _, name := range request.Types {
+		// the types were already validated
+		opType, _ := protocol.ParseOperationType(name)
+		filter.Types = append(filter.Types, opType)
+	}
+
+	start, limit, err := h.initializePagination(request)
+	if err != nil {
+		return protocol.GetOperationsResponse{}, err
+	}
+	endLedger := ledgerRange.LastLedger.Sequence
+	if request.EndLedger != 0 {
+		endLedger = min(request.EndLedger, endLedger)
+	}
+
+	operations, err := h.reader.GetOperations(ctx, start, endLedger, filter, limit)
+	if err != nil {
+		h.logger.WithError(err).Error("failed to fetch operations")
+		return protocol.GetOperationsResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	response := protocol.GetOperationsResponse{
+		Operations:            make([]protocol.OperationInfo, 0, len(operations)),
+		LatestLedger:          ledgerRange.LastLedger.Sequence,
+		LatestLedgerCloseTime: ledgerRange.LastLedger.CloseTime,
+		OldestLedger:          ledgerRange.FirstLedger.Sequence,
+		OldestLedgerCloseTime: ledgerRange.FirstLedger.CloseTime,
+	}
+	for _, operation := range operations {
+		info, err := operationInfo(operation, request.Format)
+		if err != nil {
+			return protocol.GetOperationsResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		response.Operations = append(response.Operations, info)
+	}
+
+	// When the page is full, the next page starts after its last operation.
+	// Otherwise, the range is exhausted and the next page starts after it.
+	response.Cursor = toid.New(int32(endLedger)+1, 0, 0).String()
+	if last := len(response.Operations) - 1; last >= 0 && uint(len(operations)) >= limit {
+		response.Cursor = response.Operations[last].ID
+		response.Page.HasMore = true
+	}
+	response.Page.Cursor = response.Cursor
+	response.Page.Limit = limit
+	response.Page.OldestLedger = response.OldestLedger
+	response.Page.LatestLedger = response.LatestLedger
+	return response, nil
+}
+
+// NewGetOperationsHandler returns a json rpc handler fetching the ingested
+// operations, within the local retention window. The operations are only
+// served when their ingestion is enabled.
+func NewGetOperationsHandler(logger *log.Entry, reader db.OperationReader, ledgerReader db.LedgerReader,
+	enabled bool, maxLimit, defaultLimit uint,
+) jrpc2.Handler {
+	handler := operationsHandler{
+		logger:       logger,
+		reader:       reader,
+		ledgerReader: ledgerReader,
+		enabled:      enabled,
+		maxLimit:     maxLimit,
+		defaultLimit: defaultLimit,
+	}
+	return NewHandler(handler.getOperations)
+}
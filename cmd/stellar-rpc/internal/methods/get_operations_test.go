@@ -0,0 +1,65 @@
+package methods
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func TestOperationInfo(t *testing.T) {
+	body := xdr.OperationBody{
+		Type:           xdr.OperationTypeBumpSequence,
+		BumpSequenceOp: &xdr.BumpSequenceOp{BumpTo: 42},
+	}
+	bodyXDR, err := body.MarshalBinary()
+	require.NoError(t, err)
+	operation := db.Operation{
+		LedgerSequence:   101,
+		LedgerCloseTime:  2625,
+		ApplicationOrder: 2,
+		Index:            0,
+		TransactionHash:  []byte{0xab, 0xcd},
+		Type:             int32(xdr.OperationTypeBumpSequence),
+		SourceAccount:    "GA7QYNF7SOWQ3GLR2BGMZEHXAVIRZA4KVWLTJJFC7MGXUA74P7UJVSGZ",
+		Successful:       true,
+		Body:             bodyXDR,
+	}
+
+	info, err := operationInfo(operation, "")
+	require.NoError(t, err)
+	assert.Equal(t, protocol.OperationInfo{
+		ID:               "433791705089",
+		Ledger:           101,
+		LedgerCloseTime:  2625,
+		ApplicationOrder: 2,
+		OperationIndex:   0,
+		TransactionHash:  "abcd",
+		Type:             "bump_sequence",
+		SourceAccount:    operation.SourceAccount,
+		Successful:       true,
+		BodyXDR:          base64.StdEncoding.EncodeToString(bodyXDR),
+	}, info)
+
+	info, err = operationInfo(operation, protocol.FormatJSON)
+	require.NoError(t, err)
+	assert.Empty(t, info.BodyXDR)
+	assert.Contains(t, string(info.BodyJSON), "bump_sequence")
+}
+
+func TestGetOperationsDisabled(t *testing.T) {
+	handler := operationsHandler{logger: log.DefaultLogger}
+	_, err := handler.getOperations(context.Background(), protocol.GetOperationsRequest{StartLedger: 1})
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	assert.Equal(t, jrpc2.InvalidRequest, jrpcErr.Code)
+}
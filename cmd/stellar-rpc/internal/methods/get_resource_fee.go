@@ -0,0 +1,90 @@
+package methods
+
+import (
+	"context"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/daemon/interfaces"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/ledgerentries"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/preflight"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type ResourceFeeGetter interface {
+	GetResourceFee(ctx context.Context, params preflight.ResourceFeeGetterParameters) (int64, error)
+}
+
+// NewGetResourceFeeHandler returns a JSON RPC handler computing the resource
+// fee of a set of transaction resources, without simulating the transaction.
+//
+// The fee parameters are read from the CONFIG_SETTING ledger entries at the
+// latest ledger and the fee is computed by the Soroban host of the protocol of
+// the latest ledger, so that the fee follows the network upgrades of the fee
+// parameters (and the protocol upgrades changing the fee model) as soon as
+// they are applied.
+func NewGetResourceFeeHandler(
+	ledgerReader db.LedgerReader,
+	coreClient interfaces.FastCoreClient,
+	getter ResourceFeeGetter,
+) jrpc2.Handler {
+	return NewHandler(resourceFeeHandler{
+		ledgerReader: ledgerReader,
+		coreClient:   coreClient,
+		getter:       getter,
+	}.getResourceFee)
+}
+
+type resourceFeeHandler struct {
+	ledgerReader db.LedgerReader
+	coreClient   interfaces.FastCoreClient
+	getter       ResourceFeeGetter
+}
+
+func (h resourceFeeHandler) getResourceFee(ctx context.Context,
+	request protocol.GetResourceFeeRequest,
+) (protocol.GetResourceFeeResponse, error) {
+	latestLedger, err := h.ledgerReader.GetLatestLedgerSequence(ctx)
+	if err != nil {
+		return protocol.GetResourceFeeResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	bucketListSize, protocolVersion, err := getBucketListSizeAndProtocolVersion(ctx, h.ledgerReader, latestLedger)
+	if err != nil {
+		return protocol.GetResourceFeeResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	fee, err := h.getter.GetResourceFee(ctx, preflight.ResourceFeeGetterParameters{
+		BucketListSize: bucketListSize,
+		Resources: preflight.TransactionResources{
+			Instructions:            request.Instructions,
+			ReadEntries:             request.ReadEntries,
+			WriteEntries:            request.WriteEntries,
+			ReadBytes:               request.ReadBytes,
+			WriteBytes:              request.WriteBytes,
+			ContractEventsSizeBytes: request.ContractEventsSizeBytes,
+			TransactionSizeBytes:    request.TransactionSizeBytes,
+		},
+		ProtocolVersion:   protocolVersion,
+		LedgerEntryGetter: ledgerentries.NewLedgerEntryAtGetter(h.coreClient, latestLedger),
+		LedgerSeq:         latestLedger,
+	})
+	if err != nil {
+		return protocol.GetResourceFeeResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	return protocol.GetResourceFeeResponse{
+		ResourceFee:     fee,
+		LatestLedger:    latestLedger,
+		ProtocolVersion: protocolVersion,
+	}, nil
+}
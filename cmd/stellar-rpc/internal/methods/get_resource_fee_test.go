@@ -0,0 +1,74 @@
+package methods
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/preflight"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type resourceFeeGetter struct {
+	params preflight.ResourceFeeGetterParameters
+}
+
+func (g *resourceFeeGetter) GetResourceFee(_ context.Context, params preflight.ResourceFeeGetterParameters,
+) (int64, error) {
+	g.params = params
+	return int64(params.Resources.Instructions) / 100, nil
+}
+
+func TestGetResourceFee(t *testing.T) {
+	ctx := t.Context()
+	mockReader := new(MockLedgerReader)
+	getter := &resourceFeeGetter{}
+	handler := resourceFeeHandler{ledgerReader: mockReader, getter: getter}
+
+	mockReader.On("GetLatestLedgerSequence", ctx).Return(uint32(150), nil)
+	mockReader.On("GetLedger", ctx, uint32(150)).Return(xdr.LedgerCloseMeta{
+		V: 1,
+		V1: &xdr.LedgerCloseMetaV1{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{LedgerSeq: 150, LedgerVersion: 23},
+			},
+			TotalByteSizeOfLiveSorobanState: 1000,
+		},
+	}, true, nil).Once()
+	mockReader.On("GetLedger", ctx, uint32(150)).Return(xdr.LedgerCloseMeta{}, false, nil)
+
+	response, err := handler.getResourceFee(ctx, protocol.GetResourceFeeRequest{
+		Instructions: 1_000_000,
+		ReadEntries:  2,
+		WriteEntries: 1,
+		ReadBytes:    300,
+		WriteBytes:   100,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, protocol.GetResourceFeeResponse{
+		ResourceFee:     10_000,
+		LatestLedger:    150,
+		ProtocolVersion: 23,
+	}, response)
+	assert.Equal(t, preflight.TransactionResources{
+		Instructions: 1_000_000,
+		ReadEntries:  2,
+		WriteEntries: 1,
+		ReadBytes:    300,
+		WriteBytes:   100,
+	}, getter.params.Resources)
+	assert.Equal(t, uint64(1000), getter.params.BucketListSize)
+	assert.Equal(t, uint32(150), getter.params.LedgerSeq)
+
+	// the fee can't be computed without the latest ledger
+	_, err = handler.getResourceFee(ctx, protocol.GetResourceFeeRequest{})
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	assert.Equal(t, jrpc2.InternalError, jrpcErr.Code)
+	mockReader.AssertExpectations(t)
+}
@@ -2,10 +2,13 @@ package methods
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/creachadair/jrpc2"
 
@@ -13,6 +16,7 @@ import (
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
@@ -29,21 +33,18 @@ func GetTransaction(
 			Message: err.Error(),
 		}
 	}
-
-	// parse hash
-	if hex.DecodedLen(len(request.Hash)) != len(xdr.Hash{}) {
+	if err := protocol.IsValidResultMetaFormat(request.ResultMetaFormat); err != nil {
 		return protocol.GetTransactionResponse{}, &jrpc2.Error{
 			Code:    jrpc2.InvalidParams,
-			Message: fmt.Sprintf("unexpected hash length (%d)", len(request.Hash)),
+			Message: err.Error(),
 		}
 	}
 
-	var txHash xdr.Hash
-	_, err := hex.Decode(txHash[:], []byte(request.Hash))
+	txHash, err := parseTransactionHash(request.Hash)
 	if err != nil {
 		return protocol.GetTransactionResponse{}, &jrpc2.Error{
 			Code:    jrpc2.InvalidParams,
-			Message: fmt.Sprintf("incorrect hash: %v", err),
+			Message: err.Error(),
 		}
 	}
 
@@ -77,54 +78,374 @@ func GetTransaction(
 		}
 	}
 
-	response.ApplicationOrder = tx.ApplicationOrder
-	response.FeeBump = tx.FeeBump
-	response.Ledger = tx.Ledger.Sequence
 	response.LedgerCloseTime = tx.Ledger.CloseTime
 
-	switch request.Format {
-	case protocol.FormatJSON:
-		result, envelope, meta, convErr := transactionToJSON(tx)
-		if convErr != nil {
+	if request.IncludeMetaHash {
+		ledger, found, err := ledgerReader.GetLedger(ctx, tx.Ledger.Sequence)
+		if err == nil && !found {
+			err = fmt.Errorf("missing meta for ledger %d", tx.Ledger.Sequence)
+		}
+		if err != nil {
 			return response, &jrpc2.Error{
 				Code:    jrpc2.InternalError,
-				Message: convErr.Error(),
+				Message: err.Error(),
 			}
 		}
-		diagEvents, convErr := jsonifySlice(xdr.DiagnosticEvent{}, tx.Events)
-		if convErr != nil {
+		closeMetaB, err := ledger.MarshalBinary()
+		if err != nil {
 			return response, &jrpc2.Error{
 				Code:    jrpc2.InternalError,
-				Message: convErr.Error(),
+				Message: fmt.Sprintf("error marshaling ledger close meta: %v", err),
 			}
 		}
+		response.LedgerMetadataHash = metaHash(closeMetaB)
+	}
 
-		response.ResultJSON = result
-		response.EnvelopeJSON = envelope
-		response.ResultMetaJSON = meta
-		response.DiagnosticEventsJSON = diagEvents
+	details, err := transactionDetails(tx, request.Format, request.ResultMetaFormat)
+	if err != nil {
+		return response, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	// keep the requested hash, which is the inner one for fee bump
+	// transactions looked up by their inner hash
+	details.TransactionHash = request.Hash
+	response.TransactionDetails = details
 
-	default:
-		response.ResultXDR = base64.StdEncoding.EncodeToString(tx.Result)
-		response.EnvelopeXDR = base64.StdEncoding.EncodeToString(tx.Envelope)
-		response.ResultMetaXDR = base64.StdEncoding.EncodeToString(tx.Meta)
-		response.DiagnosticEventsXDR = base64EncodeSlice(tx.Events)
+	if request.IncludeSignatures {
+		signatures, err := envelopeSignatures(tx)
+		if err != nil {
+			return response, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		response.Signatures = &signatures
 	}
 
-	response.Status = protocol.TransactionStatusFailed
-	if tx.Successful {
-		response.Status = protocol.TransactionStatusSuccess
+	if request.IncludeFeeBreakdown {
+		response.FeeBreakdown, err = feeBreakdown(tx)
+		if err != nil {
+			return response, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+	}
+
+	if request.IncludeInvocation {
+		response.Invocation, err = hostFunctionInvocation(tx, request.Format)
+		if err != nil {
+			return response, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+	}
+
+	if request.DiagnosticEventsIncluded() {
+		response.MetaDiagnosticEventsJSON, err = metaDiagnosticEventsJSON(tx.Meta)
+		if err != nil {
+			return response, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
 	}
 	return response, nil
 }
 
-// NewGetTransactionHandler returns a get transaction json rpc handler
+func decoratedSignatures(signatures []xdr.DecoratedSignature) []protocol.DecoratedSignature {
+	result := make([]protocol.DecoratedSignature, 0, len(signatures))
+	for _, signature := range signatures {
+		result = append(result, protocol.DecoratedSignature{
+			Hint:      hex.EncodeToString(signature.Hint[:]),
+			Signature: base64.StdEncoding.EncodeToString(signature.Signature),
+		})
+	}
+	return result
+}
+
+// envelopeSignatures decodes the signatures of a transaction's envelope,
+// separating the inner and outer signatures of fee bump transactions.
+func envelopeSignatures(tx db.Transaction) (protocol.TransactionSignatures, error) {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshal(tx.Envelope, &envelope); err != nil {
+		return protocol.TransactionSignatures{}, fmt.Errorf("error decoding transaction envelope: %w", err)
+	}
+	result := protocol.TransactionSignatures{EnvelopeHash: tx.TransactionHash}
+	if !envelope.IsFeeBump() {
+		result.Signatures = decoratedSignatures(envelope.Signatures())
+		return result, nil
+	}
+
+	var txResult xdr.TransactionResult
+	if err := xdr.SafeUnmarshal(tx.Result, &txResult); err != nil {
+		return protocol.TransactionSignatures{}, fmt.Errorf("error decoding transaction result: %w", err)
+	}
+	innerResult, ok := txResult.Result.GetInnerResultPair()
+	if !ok {
+		return protocol.TransactionSignatures{}, errors.New("missing inner result of fee bump transaction")
+	}
+	result.Signatures = decoratedSignatures(envelope.FeeBumpSignatures())
+	result.InnerHash = innerResult.TransactionHash.HexString()
+	// for fee bumps, Signatures() returns the signatures of the inner transaction
+	result.InnerSignatures = decoratedSignatures(envelope.Signatures())
+	return result, nil
+}
+
+// feeBreakdown splits the fee charged for a Soroban transaction, from the
+// resource fees of its Soroban meta (like the inclusion fees of the fee
+// stats). It returns nil for the other transactions.
+func feeBreakdown(tx db.Transaction) (*protocol.TransactionFeeBreakdown, error) {
+	var meta xdr.TransactionMeta
+	if err := xdr.SafeUnmarshal(tx.Meta, &meta); err != nil {
+		return nil, fmt.Errorf("error decoding transaction meta: %w", err)
+	}
+	var sorobanFees *xdr.SorobanTransactionMetaExtV1
+	switch {
+	case meta.V == 3 && meta.V3.SorobanMeta != nil:
+		sorobanFees = meta.V3.SorobanMeta.Ext.V1
+	case meta.V == 4 && meta.V4.SorobanMeta != nil:
+		sorobanFees = meta.V4.SorobanMeta.Ext.V1
+	}
+	if sorobanFees == nil {
+		return nil, nil
+	}
+
+	var txResult xdr.TransactionResult
+	if err := xdr.SafeUnmarshal(tx.Result, &txResult); err != nil {
+		return nil, fmt.Errorf("error decoding transaction result: %w", err)
+	}
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshal(tx.Envelope, &envelope); err != nil {
+		return nil, fmt.Errorf("error decoding transaction envelope: %w", err)
+	}
+	// the resource fee is declared by the inner transaction of fee bumps
+	innerTx := envelope.V1
+	if envelope.IsFeeBump() {
+		innerTx = envelope.FeeBump.Tx.InnerTx.V1
+	}
+
+	nonRefundable := int64(sorobanFees.TotalNonRefundableResourceFeeCharged)
+	refundable := int64(sorobanFees.TotalRefundableResourceFeeCharged)
+	breakdown := protocol.TransactionFeeBreakdown{
+		FeeCharged:               int64(txResult.FeeCharged),
+		InclusionFee:             int64(txResult.FeeCharged) - nonRefundable - refundable,
+		NonRefundableResourceFee: nonRefundable,
+		RefundableResourceFee:    refundable,
+		RentFee:                  int64(sorobanFees.RentFeeCharged),
+	}
+	if innerTx != nil && innerTx.Tx.Ext.SorobanData != nil {
+		declared := int64(innerTx.Tx.Ext.SorobanData.ResourceFee)
+		breakdown.RefundedFee = max(declared-nonRefundable-refundable, 0)
+	}
+	return &breakdown, nil
+}
+
+// hostFunctionInvocation decodes the host function invoked by a transaction,
+// with its arguments in the requested format. It returns nil for the
+// transactions without an InvokeHostFunction operation.
+func hostFunctionInvocation(tx db.Transaction, format string) (*protocol.HostFunctionInvocation, error) {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshal(tx.Envelope, &envelope); err != nil {
+		return nil, fmt.Errorf("error decoding transaction envelope: %w", err)
+	}
+	// Soroban transactions have a single operation
+	var hostFunction *xdr.HostFunction
+	for _, op := range envelope.Operations() {
+		if invoke, ok := op.Body.GetInvokeHostFunctionOp(); ok {
+			hostFunction = &invoke.HostFunction
+			break
+		}
+	}
+	if hostFunction == nil {
+		return nil, nil
+	}
 
+	var invocation protocol.HostFunctionInvocation
+	var args []xdr.ScVal
+	var createArgs *xdr.CreateContractArgs
+	switch hostFunction.Type {
+	case xdr.HostFunctionTypeHostFunctionTypeInvokeContract:
+		invokeArgs := hostFunction.MustInvokeContract()
+		contractID, err := invokeArgs.ContractAddress.String()
+		if err != nil {
+			return nil, fmt.Errorf("error encoding the invoked contract address: %w", err)
+		}
+		invocation = protocol.HostFunctionInvocation{
+			Type:         protocol.HostFunctionTypeInvokeContract,
+			ContractID:   contractID,
+			FunctionName: string(invokeArgs.FunctionName),
+		}
+		args = invokeArgs.Args
+	case xdr.HostFunctionTypeHostFunctionTypeCreateContract:
+		invocation.Type = protocol.HostFunctionTypeCreateContract
+		createArgs = hostFunction.CreateContract
+	case xdr.HostFunctionTypeHostFunctionTypeCreateContractV2:
+		invocation.Type = protocol.HostFunctionTypeCreateContractV2
+		v2 := hostFunction.MustCreateContractV2()
+		createArgs = &xdr.CreateContractArgs{ContractIdPreimage: v2.ContractIdPreimage, Executable: v2.Executable}
+		args = v2.ConstructorArgs
+	case xdr.HostFunctionTypeHostFunctionTypeUploadContractWasm:
+		wasmHash := sha256.Sum256(hostFunction.MustWasm())
+		invocation.Type = protocol.HostFunctionTypeUploadWasm
+		invocation.WasmHash = hex.EncodeToString(wasmHash[:])
+	default:
+		return nil, fmt.Errorf("unknown host function type %d", hostFunction.Type)
+	}
+	if createArgs != nil {
+		if err := describeContractCreation(&invocation, *createArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, arg := range args {
+		if format != protocol.FormatJSON {
+			argXDR, err := xdr.MarshalBase64(arg)
+			if err != nil {
+				return nil, err
+			}
+			invocation.ArgsXDR = append(invocation.ArgsXDR, argXDR)
+			continue
+		}
+		argJSON, err := xdr2json.ConvertInterface(arg)
+		if err != nil {
+			return nil, err
+		}
+		argTyped, err := decodeTypedScVal(arg)
+		if err != nil {
+			return nil, err
+		}
+		invocation.ArgsJSON = append(invocation.ArgsJSON, argJSON)
+		invocation.ArgsTyped = append(invocation.ArgsTyped, argTyped)
+	}
+	return &invocation, nil
+}
+
+// describeContractCreation sets the origin (the deployer or the asset) and
+// the code of a created contract.
+func describeContractCreation(invocation *protocol.HostFunctionInvocation, args xdr.CreateContractArgs) error {
+	switch preimage := args.ContractIdPreimage; preimage.Type {
+	case xdr.ContractIdPreimageTypeContractIdPreimageFromAddress:
+		deployer, err := preimage.MustFromAddress().Address.String()
+		if err != nil {
+			return fmt.Errorf("error encoding the deployer address: %w", err)
+		}
+		salt := preimage.MustFromAddress().Salt
+		invocation.Deployer = deployer
+		invocation.Salt = hex.EncodeToString(salt[:])
+	case xdr.ContractIdPreimageTypeContractIdPreimageFromAsset:
+		invocation.Asset = preimage.MustFromAsset().StringCanonical()
+	}
+	if wasmHash, ok := args.Executable.GetWasmHash(); ok {
+		invocation.WasmHash = wasmHash.HexString()
+	}
+	return nil
+}
+
+// metaDiagnosticEventsJSON decodes the diagnostic events of a transaction's
+// (XDR-encoded) result meta into JSON.
+func metaDiagnosticEventsJSON(metaB []byte) ([]json.RawMessage, error) {
+	var meta xdr.TransactionMeta
+	if err := xdr.SafeUnmarshal(metaB, &meta); err != nil {
+		return nil, fmt.Errorf("error decoding transaction meta: %w", err)
+	}
+	events, err := meta.GetDiagnosticEvents()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]json.RawMessage, 0, len(events))
+	for _, event := range events {
+		eventJSON, err := xdr2json.ConvertInterface(event)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, eventJSON)
+	}
+	return result, nil
+}
+
+// parseTransactionHash decodes a hex-encoded transaction hash.
+func parseTransactionHash(hash string) (xdr.Hash, error) {
+	var txHash xdr.Hash
+	if hex.DecodedLen(len(hash)) != len(txHash) {
+		return txHash, fmt.Errorf("unexpected hash length (%d)", len(hash))
+	}
+	if _, err := hex.Decode(txHash[:], []byte(hash)); err != nil {
+		return txHash, fmt.Errorf("incorrect hash: %w", err)
+	}
+	return txHash, nil
+}
+
+// transactionDetails converts a transaction read from the DB into its
+// protocol representation, in the requested format. The result meta is
+// converted to resultMetaFormat instead, unless it's empty.
+func transactionDetails(tx db.Transaction, format, resultMetaFormat string) (protocol.TransactionDetails, error) {
+	details := protocol.TransactionDetails{
+		TransactionHash:  tx.TransactionHash,
+		ApplicationOrder: tx.ApplicationOrder,
+		FeeBump:          tx.FeeBump,
+		Ledger:           tx.Ledger.Sequence,
+	}
+	if resultMetaFormat == "" {
+		resultMetaFormat = format
+	}
+
+	var err error
+	switch format {
+	case protocol.FormatJSON:
+		details.ResultJSON, err = xdr2json.ConvertBytes(xdr.TransactionResult{}, tx.Result)
+		if err != nil {
+			return details, err
+		}
+		details.EnvelopeJSON, err = xdr2json.ConvertBytes(xdr.TransactionEnvelope{}, tx.Envelope)
+		if err != nil {
+			return details, err
+		}
+		details.DiagnosticEventsJSON, err = jsonifySlice(xdr.DiagnosticEvent{}, tx.Events)
+		if err != nil {
+			return details, err
+		}
+
+	default:
+		details.ResultXDR = base64.StdEncoding.EncodeToString(tx.Result)
+		details.EnvelopeXDR = base64.StdEncoding.EncodeToString(tx.Envelope)
+		details.DiagnosticEventsXDR = base64EncodeSlice(tx.Events)
+	}
+
+	switch resultMetaFormat {
+	case protocol.FormatNone:
+	case protocol.FormatJSON:
+		details.ResultMetaJSON, err = xdr2json.ConvertBytes(xdr.TransactionMeta{}, tx.Meta)
+		if err != nil {
+			return details, err
+		}
+	default:
+		details.ResultMetaXDR = base64.StdEncoding.EncodeToString(tx.Meta)
+	}
+
+	details.Status = protocol.TransactionStatusFailed
+	if tx.Successful {
+		details.Status = protocol.TransactionStatusSuccess
+	}
+	return details, nil
+}
+
+// NewGetTransactionHandler returns a get transaction json rpc handler. The
+// transactions not found yet are reported as pending if they were recently
+// submitted.
 func NewGetTransactionHandler(logger *log.Entry, getter db.TransactionReader,
-	ledgerReader db.LedgerReader,
+	ledgerReader db.LedgerReader, submitted *SubmittedTransactions,
 ) jrpc2.Handler {
 	return NewHandler(func(ctx context.Context, request protocol.GetTransactionRequest,
 	) (protocol.GetTransactionResponse, error) {
-		return GetTransaction(ctx, logger, getter, ledgerReader, request)
+		response, err := GetTransaction(ctx, logger, getter, ledgerReader, request)
+		if err == nil && response.Status == protocol.TransactionStatusNotFound &&
+			submitted.pending(strings.ToLower(request.Hash)) {
+			response.Status = protocol.TransactionStatusPending
+		}
+		return response, err
 	})
 }
@@ -0,0 +1,131 @@
+package methods
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func GetTransactionProof(
+	ctx context.Context,
+	log *log.Entry,
+	reader db.TransactionReader,
+	ledgerReader db.LedgerReader,
+	request protocol.GetTransactionProofRequest,
+) (protocol.GetTransactionProofResponse, error) {
+	if err := protocol.IsValidFormat(request.Format); err != nil {
+		return protocol.GetTransactionProofResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+
+	txHash, err := parseTransactionHash(request.Hash)
+	if err != nil {
+		return protocol.GetTransactionProofResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+
+	storeRange, err := ledgerReader.GetLedgerRange(ctx)
+	if err != nil {
+		return protocol.GetTransactionProofResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: fmt.Sprintf("unable to get ledger range: %v", err),
+		}
+	}
+
+	response := protocol.GetTransactionProofResponse{
+		LatestLedger:    storeRange.LastLedger.Sequence,
+		OldestLedger:    storeRange.FirstLedger.Sequence,
+		TransactionHash: request.Hash,
+	}
+	tx, err := reader.GetTransaction(ctx, txHash)
+	if errors.Is(err, db.ErrNoTransaction) {
+		response.Status = protocol.TransactionStatusNotFound
+		return response, nil
+	} else if err != nil {
+		log.WithError(err).
+			WithField("hash", txHash).
+			Errorf("failed to fetch transaction")
+		return response, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	ledger, found, err := ledgerReader.GetLedger(ctx, tx.Ledger.Sequence)
+	if err == nil && !found {
+		err = fmt.Errorf("missing ledger %d", tx.Ledger.Sequence)
+	}
+	if err != nil {
+		return response, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	response.Status = protocol.TransactionStatusFailed
+	if tx.Successful {
+		response.Status = protocol.TransactionStatusSuccess
+	}
+	response.Ledger = tx.Ledger.Sequence
+	response.ApplicationOrder = tx.ApplicationOrder
+	response.LedgerHash = ledger.LedgerHash().HexString()
+	if err := setTransactionProofXDR(&response, ledger.LedgerHeaderHistoryEntry().Header, tx.Result,
+		request.Format); err != nil {
+		return response, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+	return response, nil
+}
+
+// setTransactionProofXDR sets the ledger header and the (XDR-encoded)
+// transaction result of the response, in the requested format.
+func setTransactionProofXDR(response *protocol.GetTransactionProofResponse, header xdr.LedgerHeader,
+	result []byte, format string,
+) error {
+	var err error
+	switch format {
+	case protocol.FormatJSON:
+		response.LedgerHeaderJSON, err = xdr2json.ConvertInterface(header)
+		if err != nil {
+			return err
+		}
+		response.ResultJSON, err = xdr2json.ConvertBytes(xdr.TransactionResult{}, result)
+		if err != nil {
+			return err
+		}
+	default:
+		response.LedgerHeaderXDR, err = xdr.MarshalBase64(header)
+		if err != nil {
+			return fmt.Errorf("could not serialize ledger header: %w", err)
+		}
+		response.ResultXDR = base64.StdEncoding.EncodeToString(result)
+	}
+	return nil
+}
+
+// NewGetTransactionProofHandler returns a json rpc handler locating a
+// transaction within its ledger, for independent verification.
+func NewGetTransactionProofHandler(logger *log.Entry, getter db.TransactionReader,
+	ledgerReader db.LedgerReader,
+) jrpc2.Handler {
+	return NewHandler(func(ctx context.Context, request protocol.GetTransactionProofRequest,
+	) (protocol.GetTransactionProofResponse, error) {
+		return GetTransactionProof(ctx, logger, getter, ledgerReader, request)
+	})
+}
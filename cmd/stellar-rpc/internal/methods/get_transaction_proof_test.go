@@ -0,0 +1,77 @@
+package methods
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func TestGetTransactionProof(t *testing.T) {
+	var (
+		ctx          = context.TODO()
+		store        = db.NewMockTransactionStore("passphrase")
+		ledgerReader = db.NewMockLedgerReader(store)
+	)
+
+	_, err := GetTransactionProof(ctx, log.DefaultLogger, store, ledgerReader,
+		protocol.GetTransactionProofRequest{Hash: "ab"})
+	require.EqualError(t, err, "[-32602] unexpected hash length (2)")
+
+	hash := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	proof, err := GetTransactionProof(ctx, log.DefaultLogger, store, ledgerReader,
+		protocol.GetTransactionProofRequest{Hash: hash})
+	require.NoError(t, err)
+	require.Equal(t, protocol.GetTransactionProofResponse{
+		Status:          protocol.TransactionStatusNotFound,
+		TransactionHash: hash,
+	}, proof)
+
+	meta := txMeta(1, true)
+	headerXDR, err := meta.V1.LedgerHeader.Header.MarshalBinary()
+	require.NoError(t, err)
+	meta.V1.LedgerHeader.Hash = sha256.Sum256(headerXDR)
+	require.NoError(t, store.InsertTransactions(meta))
+
+	xdrHash := txHash(1)
+	hash = hex.EncodeToString(xdrHash[:])
+	proof, err = GetTransactionProof(ctx, log.DefaultLogger, store, ledgerReader,
+		protocol.GetTransactionProofRequest{Hash: hash})
+	require.NoError(t, err)
+
+	expectedResult, err := xdr.MarshalBase64(meta.V1.TxProcessing[0].Result.Result)
+	require.NoError(t, err)
+	require.Equal(t, protocol.GetTransactionProofResponse{
+		LatestLedger:     101,
+		OldestLedger:     101,
+		Status:           protocol.TransactionStatusSuccess,
+		TransactionHash:  hash,
+		Ledger:           101,
+		ApplicationOrder: 1,
+		LedgerHash:       meta.LedgerHash().HexString(),
+		LedgerHeaderXDR:  base64.StdEncoding.EncodeToString(headerXDR),
+		ResultXDR:        expectedResult,
+	}, proof)
+
+	// the ledger hash can be verified from the header
+	returnedHeader, err := base64.StdEncoding.DecodeString(proof.LedgerHeaderXDR)
+	require.NoError(t, err)
+	headerHash := sha256.Sum256(returnedHeader)
+	require.Equal(t, proof.LedgerHash, hex.EncodeToString(headerHash[:]))
+
+	proof, err = GetTransactionProof(ctx, log.DefaultLogger, store, ledgerReader,
+		protocol.GetTransactionProofRequest{Hash: hash, Format: protocol.FormatJSON})
+	require.NoError(t, err)
+	require.Empty(t, proof.LedgerHeaderXDR)
+	require.NotEmpty(t, proof.LedgerHeaderJSON)
+	require.NotEmpty(t, proof.ResultJSON)
+}
@@ -2,6 +2,7 @@ package methods
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"testing"
@@ -385,6 +386,27 @@ func emptyTxMeta(acctSeq uint32) xdr.LedgerCloseMeta {
 	}
 }
 
+func TestGetTransaction_IncludeMetaHash(t *testing.T) {
+	store := db.NewMockTransactionStore("passphrase")
+	ledgerReader := db.NewMockLedgerReader(store)
+	meta := txMeta(1, true)
+	require.NoError(t, store.InsertTransactions(meta))
+
+	xdrHash := txHash(1)
+	request := protocol.GetTransactionRequest{Hash: hex.EncodeToString(xdrHash[:])}
+	tx, err := GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.NoError(t, err)
+	require.Empty(t, tx.LedgerMetadataHash)
+
+	request.IncludeMetaHash = true
+	tx, err = GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.NoError(t, err)
+	metaB, err := meta.MarshalBinary()
+	require.NoError(t, err)
+	expectedHash := sha256.Sum256(metaB)
+	require.Equal(t, hex.EncodeToString(expectedHash[:]), tx.LedgerMetadataHash)
+}
+
 func TestGetTransaction_JSONFormat(t *testing.T) {
 	mockDBReader := db.NewMockTransactionStore(NetworkPassphrase)
 	mockLedgerReader := db.NewMockLedgerReader(mockDBReader)
@@ -436,6 +458,233 @@ func TestGetTransaction_JSONFormat(t *testing.T) {
 	require.Equal(t, envelope, tx["envelopeJson"])
 }
 
+func TestGetTransaction_IncludeDiagnosticEvents(t *testing.T) {
+	store := db.NewMockTransactionStore("passphrase")
+	ledgerReader := db.NewMockLedgerReader(store)
+	meta := txMetaWithEvents(1, true)
+	sorobanMeta := meta.V1.TxProcessing[0].TxApplyProcessing.V3.SorobanMeta
+	diagnosticEvent := xdr.DiagnosticEvent{
+		InSuccessfulContractCall: true,
+		Event:                    sorobanMeta.Events[0],
+	}
+	diagnosticEvent.Event.Type = xdr.ContractEventTypeDiagnostic
+	sorobanMeta.DiagnosticEvents = []xdr.DiagnosticEvent{diagnosticEvent}
+	require.NoError(t, store.InsertTransactions(meta))
+
+	xdrHash := txHash(1)
+	request := protocol.GetTransactionRequest{Hash: hex.EncodeToString(xdrHash[:])}
+	tx, err := GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.NoError(t, err)
+	require.Nil(t, tx.MetaDiagnosticEventsJSON, "only included in the JSON format")
+
+	request.Format = protocol.FormatJSON
+	tx, err = GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.NoError(t, err)
+	expected, err := xdr2json.ConvertInterface(diagnosticEvent)
+	require.NoError(t, err)
+	require.Len(t, tx.MetaDiagnosticEventsJSON, 1)
+	require.JSONEq(t, string(expected), string(tx.MetaDiagnosticEventsJSON[0]))
+
+	include := false
+	request.IncludeDiagnosticEvents = &include
+	tx, err = GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.NoError(t, err)
+	require.Nil(t, tx.MetaDiagnosticEventsJSON)
+}
+
+func TestEnvelopeSignatures(t *testing.T) {
+	innerSignature := xdr.DecoratedSignature{Hint: xdr.SignatureHint{1, 2, 3, 4}, Signature: []byte{5, 6}}
+	outerSignature := xdr.DecoratedSignature{Hint: xdr.SignatureHint{7, 8, 9, 10}, Signature: []byte{11}}
+	envelope := txEnvelope(1)
+	envelope.V1.Signatures = []xdr.DecoratedSignature{innerSignature}
+	envelopeB, err := envelope.MarshalBinary()
+	require.NoError(t, err)
+
+	signatures, err := envelopeSignatures(db.Transaction{TransactionHash: "aa", Envelope: envelopeB})
+	require.NoError(t, err)
+	require.Equal(t, protocol.TransactionSignatures{
+		EnvelopeHash: "aa",
+		Signatures:   []protocol.DecoratedSignature{{Hint: "01020304", Signature: "BQY="}},
+	}, signatures)
+
+	feeBump := xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTxFeeBump,
+		FeeBump: &xdr.FeeBumpTransactionEnvelope{
+			Tx: xdr.FeeBumpTransaction{
+				FeeSource: xdr.MustMuxedAddress("GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"),
+				Fee:       200,
+				InnerTx: xdr.FeeBumpTransactionInnerTx{
+					Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+					V1:   envelope.V1,
+				},
+			},
+			Signatures: []xdr.DecoratedSignature{outerSignature},
+		},
+	}
+	feeBumpB, err := feeBump.MarshalBinary()
+	require.NoError(t, err)
+	opResults := []xdr.OperationResult{}
+	result := xdr.TransactionResult{
+		Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFeeBumpInnerSuccess,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				TransactionHash: xdr.Hash{0xbb},
+				Result: xdr.InnerTransactionResult{
+					Result: xdr.InnerTransactionResultResult{
+						Code:    xdr.TransactionResultCodeTxSuccess,
+						Results: &opResults,
+					},
+				},
+			},
+		},
+	}
+	resultB, err := result.MarshalBinary()
+	require.NoError(t, err)
+
+	signatures, err = envelopeSignatures(db.Transaction{TransactionHash: "aa", Envelope: feeBumpB, Result: resultB})
+	require.NoError(t, err)
+	require.Equal(t, protocol.TransactionSignatures{
+		EnvelopeHash:    "aa",
+		Signatures:      []protocol.DecoratedSignature{{Hint: "0708090a", Signature: "Cw=="}},
+		InnerHash:       xdr.Hash{0xbb}.HexString(),
+		InnerSignatures: []protocol.DecoratedSignature{{Hint: "01020304", Signature: "BQY="}},
+	}, signatures)
+}
+
+func TestFeeBreakdown(t *testing.T) {
+	envelope := txEnvelope(1)
+	envelope.V1.Tx.Ext = xdr.TransactionExt{V: 1, SorobanData: &xdr.SorobanTransactionData{ResourceFee: 1000}}
+	envelopeB, err := envelope.MarshalBinary()
+	require.NoError(t, err)
+	opResults := []xdr.OperationResult{}
+	result := xdr.TransactionResult{
+		FeeCharged: 1000,
+		Result:     xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxSuccess, Results: &opResults},
+	}
+	resultB, err := result.MarshalBinary()
+	require.NoError(t, err)
+	meta := xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{
+		SorobanMeta: &xdr.SorobanTransactionMeta{
+			Ext: xdr.SorobanTransactionMetaExt{V: 1, V1: &xdr.SorobanTransactionMetaExtV1{
+				TotalNonRefundableResourceFeeCharged: 600,
+				TotalRefundableResourceFeeCharged:    300,
+				RentFeeCharged:                       200,
+			}},
+			ReturnValue: xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+		},
+	}}
+	metaB, err := meta.MarshalBinary()
+	require.NoError(t, err)
+
+	breakdown, err := feeBreakdown(db.Transaction{Envelope: envelopeB, Result: resultB, Meta: metaB})
+	require.NoError(t, err)
+	require.Equal(t, &protocol.TransactionFeeBreakdown{
+		FeeCharged:               1000,
+		InclusionFee:             100,
+		NonRefundableResourceFee: 600,
+		RefundableResourceFee:    300,
+		RentFee:                  200,
+		RefundedFee:              100,
+	}, breakdown)
+
+	// the classic transactions have no breakdown
+	meta.V3.SorobanMeta = nil
+	metaB, err = meta.MarshalBinary()
+	require.NoError(t, err)
+	breakdown, err = feeBreakdown(db.Transaction{Envelope: envelopeB, Result: resultB, Meta: metaB})
+	require.NoError(t, err)
+	require.Nil(t, breakdown)
+}
+
+func TestHostFunctionInvocation(t *testing.T) {
+	invocation := func(hostFunction *xdr.HostFunction) *protocol.HostFunctionInvocation {
+		envelope := txEnvelope(1)
+		if hostFunction != nil {
+			envelope.V1.Tx.Operations = []xdr.Operation{{Body: xdr.OperationBody{
+				Type:                 xdr.OperationTypeInvokeHostFunction,
+				InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{HostFunction: *hostFunction},
+			}}}
+		}
+		envelopeB, err := envelope.MarshalBinary()
+		require.NoError(t, err)
+		result, err := hostFunctionInvocation(db.Transaction{Envelope: envelopeB}, protocol.FormatBase64)
+		require.NoError(t, err)
+		return result
+	}
+
+	contractID := xdr.ContractId{1}
+	contract := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID}
+	contractStrkey, err := contract.String()
+	require.NoError(t, err)
+	amount := xdr.Uint32(7)
+	arg := xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &amount}
+	argXDR, err := xdr.MarshalBase64(arg)
+	require.NoError(t, err)
+	require.Equal(t, &protocol.HostFunctionInvocation{
+		Type:         protocol.HostFunctionTypeInvokeContract,
+		ContractID:   contractStrkey,
+		FunctionName: "transfer",
+		ArgsXDR:      []string{argXDR},
+	}, invocation(&xdr.HostFunction{
+		Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+		InvokeContract: &xdr.InvokeContractArgs{
+			ContractAddress: contract,
+			FunctionName:    "transfer",
+			Args:            []xdr.ScVal{arg},
+		},
+	}))
+
+	wasm := []byte{0, 'a', 's', 'm'}
+	wasmHash := sha256.Sum256(wasm)
+	require.Equal(t, &protocol.HostFunctionInvocation{
+		Type:     protocol.HostFunctionTypeUploadWasm,
+		WasmHash: hex.EncodeToString(wasmHash[:]),
+	}, invocation(&xdr.HostFunction{Type: xdr.HostFunctionTypeHostFunctionTypeUploadContractWasm, Wasm: &wasm}))
+
+	executable := xdr.ContractExecutable{
+		Type:     xdr.ContractExecutableTypeContractExecutableWasm,
+		WasmHash: (*xdr.Hash)(&wasmHash),
+	}
+	require.Equal(t, &protocol.HostFunctionInvocation{
+		Type:     protocol.HostFunctionTypeCreateContractV2,
+		ArgsXDR:  []string{argXDR},
+		Deployer: contractStrkey,
+		Salt:     hex.EncodeToString(make([]byte, 31)) + "02",
+		WasmHash: hex.EncodeToString(wasmHash[:]),
+	}, invocation(&xdr.HostFunction{
+		Type: xdr.HostFunctionTypeHostFunctionTypeCreateContractV2,
+		CreateContractV2: &xdr.CreateContractArgsV2{
+			ContractIdPreimage: xdr.ContractIdPreimage{
+				Type: xdr.ContractIdPreimageTypeContractIdPreimageFromAddress,
+				FromAddress: &xdr.ContractIdPreimageFromAddress{
+					Address: contract,
+					Salt:    xdr.Uint256{31: 2},
+				},
+			},
+			Executable:      executable,
+			ConstructorArgs: []xdr.ScVal{arg},
+		},
+	}))
+
+	native := xdr.MustNewNativeAsset()
+	require.Equal(t, &protocol.HostFunctionInvocation{
+		Type:  protocol.HostFunctionTypeCreateContract,
+		Asset: "native",
+	}, invocation(&xdr.HostFunction{
+		Type: xdr.HostFunctionTypeHostFunctionTypeCreateContract,
+		CreateContract: &xdr.CreateContractArgs{
+			ContractIdPreimage: xdr.ContractIdPreimage{
+				Type:      xdr.ContractIdPreimageTypeContractIdPreimageFromAsset,
+				FromAsset: &native,
+			},
+			Executable: xdr.ContractExecutable{Type: xdr.ContractExecutableTypeContractExecutableStellarAsset},
+		},
+	}))
+
+	// the classic transactions have no invocation
+	require.Nil(t, invocation(nil))
+}
+
 func BenchmarkJSONTransactions(b *testing.B) {
 	mockDBReader := db.NewMockTransactionStore(NetworkPassphrase)
 	mockLedgerReader := db.NewMockLedgerReader(mockDBReader)
@@ -490,3 +739,33 @@ func BenchmarkJSONTransactions(b *testing.B) {
 		}
 	})
 }
+
+func TestGetTransaction_ResultMetaFormat(t *testing.T) {
+	store := db.NewMockTransactionStore("passphrase")
+	ledgerReader := db.NewMockLedgerReader(store)
+	require.NoError(t, store.InsertTransactions(txMeta(1, true)))
+
+	xdrHash := txHash(1)
+	request := protocol.GetTransactionRequest{
+		Hash:             hex.EncodeToString(xdrHash[:]),
+		ResultMetaFormat: protocol.FormatNone,
+	}
+	tx, err := GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.NoError(t, err)
+	require.Equal(t, protocol.TransactionStatusSuccess, tx.Status)
+	require.NotEmpty(t, tx.ResultXDR)
+	require.Empty(t, tx.ResultMetaXDR)
+	require.Nil(t, tx.ResultMetaJSON)
+
+	// the result meta can be requested in a different format
+	request.ResultMetaFormat = protocol.FormatJSON
+	tx, err = GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.NoError(t, err)
+	require.NotEmpty(t, tx.ResultXDR)
+	require.Empty(t, tx.ResultMetaXDR)
+	require.NotNil(t, tx.ResultMetaJSON)
+
+	request.ResultMetaFormat = "xml"
+	_, err = GetTransaction(context.TODO(), log.DefaultLogger, store, ledgerReader, request)
+	require.ErrorContains(t, err, "resultMetaFormat")
+}
@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/creachadair/jrpc2/handler"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/ingest"
 	"github.com/stellar/go/support/log"
@@ -17,40 +20,67 @@ import (
 	"github.com/stellar/go/xdr"
 
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/rpcdatastore"
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
+// datastoreLedgersBatchSize is the number of ledgers fetched at once from the
+// datastore when serving data which predates the local retention window.
+const datastoreLedgersBatchSize = 10
+
 type transactionsRPCHandler struct {
-	ledgerReader      db.LedgerReader
-	maxLimit          uint
-	defaultLimit      uint
-	logger            *log.Entry
-	networkPassphrase string
+	ledgerReader          db.LedgerReader
+	datastoreLedgerReader rpcdatastore.LedgerReader
+	maxLimit              uint
+	defaultLimit          uint
+	logger                *log.Entry
+	networkPassphrase     string
+	queryAge              prometheus.Observer
 }
 
-// initializePagination sets the pagination limit and cursor
-func (h transactionsRPCHandler) initializePagination(request protocol.GetTransactionsRequest) (toid.ID, uint, error) {
+// initializePagination sets the pagination limit and cursor, and returns the
+// snapshot ledger encoded in the cursor (if any). When going backwards, a start
+// transaction order of 0 stands for the last transaction of the ledger.
+func (h transactionsRPCHandler) initializePagination(request protocol.GetTransactionsRequest,
+) (toid.ID, uint, uint32, error) {
 	start := toid.New(int32(request.StartLedger), 1, 1)
+	if request.IsDescending() {
+		start.TransactionOrder = 0
+	}
 	limit := h.defaultLimit
+	var snapshotLedger uint32
 	if request.Pagination != nil {
 		if request.Pagination.Cursor != "" {
-			cursorInt, err := strconv.ParseInt(request.Pagination.Cursor, 10, 64)
+			position, cursorSnapshotLedger, err := protocol.DecodeSnapshotCursor(request.Pagination.Cursor)
 			if err != nil {
-				return toid.ID{}, 0, &jrpc2.Error{
+				return toid.ID{}, 0, 0, &jrpc2.Error{
+					Code:    jrpc2.InvalidParams,
+					Message: err.Error(),
+				}
+			}
+			snapshotLedger = cursorSnapshotLedger
+			cursorInt, err := strconv.ParseInt(position, 10, 64)
+			if err != nil {
+				return toid.ID{}, 0, 0, &jrpc2.Error{
 					Code:    jrpc2.InvalidParams,
 					Message: err.Error(),
 				}
 			}
 			*start = toid.Parse(cursorInt)
-			// increment tx index because, when paginating,
-			// we start with the item right after the cursor
-			start.TransactionOrder++
+			// move the tx index because, when paginating,
+			// we start with the item right after (or before) the cursor
+			if !request.IsDescending() {
+				start.TransactionOrder++
+			} else if start.TransactionOrder--; start.TransactionOrder <= 0 {
+				start.LedgerSequence--
+				start.TransactionOrder = 0
+			}
 		}
 		if request.Pagination.Limit > 0 {
 			limit = request.Pagination.Limit
 		}
 	}
-	return *start, limit, nil
+	return *start, limit, snapshotLedger, nil
 }
 
 // fetchLedgerData calls the meta table to fetch the corresponding ledger data.
@@ -72,12 +102,57 @@ func (h transactionsRPCHandler) fetchLedgerData(ctx context.Context, ledgerSeq u
 	return ledger, nil
 }
 
-// processTransactionsInLedger cycles through all the transactions in a ledger, extracts the transaction info
-// and builds the list of transactions.
+// fetchLedgers returns the next ledgers to process, starting at ledgerSeq and going
+// forward (or backwards, down to oldestLedger, when descending). Ledgers which are
+// available locally are fetched one at a time from the database, while older
+// ledgers are fetched in batches from the datastore.
+func (h transactionsRPCHandler) fetchLedgers(ctx context.Context, ledgerSeq uint32,
+	readTx db.LedgerReaderTx, localLedgerRange protocol.LedgerSeqRange, oldestLedger uint32, descending bool,
+) ([]xdr.LedgerCloseMeta, error) {
+	if ledgerSeq >= localLedgerRange.FirstLedger {
+		ledger, err := h.fetchLedgerData(ctx, ledgerSeq, readTx)
+		if err != nil {
+			return nil, err
+		}
+		return []xdr.LedgerCloseMeta{ledger}, nil
+	}
+
+	if h.datastoreLedgerReader == nil {
+		return nil, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: "datastore ledger reader not configured",
+		}
+	}
+	start, end := ledgerSeq, min(ledgerSeq+datastoreLedgersBatchSize-1, localLedgerRange.FirstLedger-1)
+	if descending {
+		start, end = max(ledgerSeq, datastoreLedgersBatchSize)-datastoreLedgersBatchSize+1, ledgerSeq
+		start = max(start, oldestLedger)
+	}
+	ledgers, err := h.datastoreLedgerReader.GetLedgers(ctx, start, end)
+	if err != nil {
+		return nil, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: fmt.Sprintf("error fetching ledgers from datastore: %v", err),
+		}
+	}
+	if descending {
+		slices.Reverse(ledgers)
+	}
+	if len(ledgers) == 0 || ledgers[0].LedgerSequence() != ledgerSeq {
+		return nil, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: fmt.Sprintf("datastore does not contain metadata for ledger: %d", ledgerSeq),
+		}
+	}
+	return ledgers, nil
+}
+
+// processTransactionsInLedger cycles through all the transactions in a ledger (in reverse
+// order when descending), extracts the transaction info and builds the list of transactions.
 func (h transactionsRPCHandler) processTransactionsInLedger(
 	ledger xdr.LedgerCloseMeta, start toid.ID,
 	txns *[]protocol.TransactionInfo, limit uint,
-	format string,
+	format string, descending bool, includeEvents bool,
 ) (*toid.ID, bool, error) {
 	reader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(h.networkPassphrase, ledger)
 	if err != nil {
@@ -87,23 +162,38 @@ func (h transactionsRPCHandler) processTransactionsInLedger(
 		}
 	}
 
-	startTxIdx := 1
+	txCount := ledger.CountTransactions()
+	firstTxIdx, lastTxIdx, step := 1, txCount, 1
 	ledgerSeq := ledger.LedgerSequence()
 	if int32(ledgerSeq) == start.LedgerSequence {
-		startTxIdx = int(start.TransactionOrder)
-		if ierr := reader.Seek(startTxIdx - 1); ierr != nil && !errors.Is(ierr, io.EOF) {
-			return nil, false, &jrpc2.Error{
-				Code:    jrpc2.InternalError,
-				Message: ierr.Error(),
-			}
+		if !descending {
+			firstTxIdx = int(start.TransactionOrder)
+		} else if start.TransactionOrder > 0 {
+			lastTxIdx = min(int(start.TransactionOrder), txCount)
 		}
 	}
+	startTxIdx := firstTxIdx
+	if descending {
+		startTxIdx, step = lastTxIdx, -1
+	}
 
-	txCount := ledger.CountTransactions()
 	cursor := toid.New(int32(ledgerSeq), 0, 1)
-	for i := startTxIdx; i <= txCount; i++ {
+	if !descending {
+		// keep the position of the previous transaction if there are no more,
+		// so that paginating again doesn't return the ledger's transactions twice
+		cursor.TransactionOrder = int32(firstTxIdx - 1)
+	}
+	for i := startTxIdx; i >= firstTxIdx && i <= lastTxIdx; i += step {
 		cursor.TransactionOrder = int32(i)
 
+		if i == startTxIdx || descending {
+			if ierr := reader.Seek(i - 1); ierr != nil && !errors.Is(ierr, io.EOF) {
+				return nil, false, &jrpc2.Error{
+					Code:    jrpc2.InternalError,
+					Message: ierr.Error(),
+				}
+			}
+		}
 		ingestTx, err := reader.Read()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
@@ -167,6 +257,14 @@ func (h transactionsRPCHandler) processTransactionsInLedger(
 		if tx.Successful {
 			txInfo.Status = protocol.TransactionStatusSuccess
 		}
+		if includeEvents {
+			if txInfo.Events, err = transactionEvents(ledger, ingestTx, format); err != nil {
+				return nil, false, &jrpc2.Error{
+					Code:    jrpc2.InternalError,
+					Message: err.Error(),
+				}
+			}
+		}
 
 		*txns = append(*txns, txInfo)
 		if len(*txns) >= int(limit) {
@@ -177,6 +275,32 @@ func (h transactionsRPCHandler) processTransactionsInLedger(
 	return cursor, false, nil
 }
 
+// transactionEvents returns the contract and system events of a transaction,
+// with the ids getEvents gives them. Like getEvents, it excludes the
+// diagnostic events.
+func transactionEvents(ledger xdr.LedgerCloseMeta, ingestTx ingest.LedgerTransaction, format string,
+) ([]protocol.EventInfo, error) {
+	events, err := db.TransactionEvents(ingestTx)
+	if err != nil {
+		return nil, err
+	}
+	closedAt := time.Unix(ledger.LedgerCloseTime(), 0).UTC().Format(time.RFC3339)
+	txHash := ingestTx.Result.TransactionHash.HexString()
+	var infos []protocol.EventInfo
+	for index, event := range events {
+		if event.Event.Type == xdr.ContractEventTypeDiagnostic {
+			continue
+		}
+		cursor := protocol.Cursor{Ledger: ledger.LedgerSequence(), Tx: ingestTx.Index, Event: uint32(index)} //nolint:gosec
+		info, err := eventInfoForEvent(event, cursor, closedAt, txHash, format)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
 // getTransactionsByLedgerSequence fetches transactions between the start and end ledgers, inclusive of both.
 // The number of ledgers returned can be tuned using the pagination options - cursor and limit.
 func (h transactionsRPCHandler) getTransactionsByLedgerSequence(ctx context.Context,
@@ -201,7 +325,26 @@ func (h transactionsRPCHandler) getTransactionsByLedgerSequence(ctx context.Cont
 		}
 	}
 
-	err = request.IsValid(h.maxLimit, ledgerRange.ToLedgerSeqRange())
+	localLedgerRange := ledgerRange.ToLedgerSeqRange()
+	availableLedgerRange := localLedgerRange
+	if h.datastoreLedgerReader != nil {
+		dsRange, err := h.datastoreLedgerReader.GetAvailableLedgerRange(ctx)
+		if err != nil {
+			// log error but continue using local ledger range
+			h.logger.WithError(err).Error("failed to get available ledger range from datastore")
+		} else {
+			// extend available range to include datastore
+			availableLedgerRange.FirstLedger = min(dsRange.FirstLedger, availableLedgerRange.FirstLedger)
+		}
+	}
+
+	// When going backwards, start from the latest ledger by default
+	if request.IsDescending() && request.StartLedger == 0 &&
+		(request.Pagination == nil || request.Pagination.Cursor == "") {
+		request.StartLedger = ledgerRange.LastLedger.Sequence
+	}
+
+	err = request.IsValid(h.maxLimit, availableLedgerRange)
 	if err != nil {
 		return protocol.GetTransactionsResponse{}, &jrpc2.Error{
 			Code:    jrpc2.InvalidRequest,
@@ -209,50 +352,111 @@ func (h transactionsRPCHandler) getTransactionsByLedgerSequence(ctx context.Cont
 		}
 	}
 
-	start, limit, err := h.initializePagination(request)
+	start, limit, snapshotLedger, err := h.initializePagination(request)
 	if err != nil {
 		return protocol.GetTransactionsResponse{}, err
 	}
+	observeQueryAge(h.queryAge, ledgerRange.LastLedger.Sequence, uint32(max(start.LedgerSequence, 0)))
+	if snapshotLedger == 0 && request.Snapshot {
+		snapshotLedger = ledgerRange.LastLedger.Sequence
+	}
+	// the latest ledger, or the snapshot one, acts as the end of the range
+	lastLedger := ledgerRange.LastLedger.Sequence
+	if snapshotLedger != 0 {
+		lastLedger = min(snapshotLedger, lastLedger)
+	}
 
 	// Iterate through each ledger and its transactions until limit or end range is reached.
-	// The latest ledger acts as the end ledger range for the request.
+	// The latest ledger acts as the end ledger range for the request (or the oldest
+	// available ledger, when descending).
+	descending := request.IsDescending()
 	txns := make([]protocol.TransactionInfo, 0, limit)
 	var done bool
+	// ledgersScanned counts the ledgers whose transactions were examined
+	var ledgersScanned uint32
 	cursor := toid.New(0, 0, 0)
-	for ledgerSeq := start.LedgerSequence; ledgerSeq <= int32(ledgerRange.LastLedger.Sequence); ledgerSeq++ {
-		ledger, err := h.fetchLedgerData(ctx, uint32(ledgerSeq), readTx)
+	inRange := func(ledgerSeq uint32) bool {
+		if descending {
+			return ledgerSeq >= max(availableLedgerRange.FirstLedger, 1)
+		}
+		return ledgerSeq <= lastLedger
+	}
+	ledgerSeq := uint32(max(start.LedgerSequence, 0))
+	if descending {
+		ledgerSeq = min(ledgerSeq, lastLedger)
+	}
+	firstLedgerSeq := ledgerSeq
+	for !done && inRange(ledgerSeq) {
+		ledgers, err := h.fetchLedgers(ctx, ledgerSeq, readTx, localLedgerRange,
+			availableLedgerRange.FirstLedger, descending)
 		if err != nil {
 			return protocol.GetTransactionsResponse{}, err
 		}
 
-		cursor, done, err = h.processTransactionsInLedger(ledger, start, &txns, limit, request.Format)
-		if err != nil {
-			return protocol.GetTransactionsResponse{}, err
+		for _, ledger := range ledgers {
+			cursor, done, err = h.processTransactionsInLedger(ledger, start, &txns, limit, request.Format, descending,
+				request.IncludeEvents)
+			if err != nil {
+				return protocol.GetTransactionsResponse{}, err
+			}
+			ledgersScanned++
+			if done {
+				break
+			}
 		}
-		if done {
-			break
+		if descending {
+			ledgerSeq -= uint32(len(ledgers)) //nolint:gosec
+		} else {
+			ledgerSeq += uint32(len(ledgers)) //nolint:gosec
 		}
 	}
 
+	// the page is finalized when all the ledgers it covers precede the latest one
+	var finalized bool
+	switch {
+	case descending:
+		finalized = firstLedgerSeq < ledgerRange.LastLedger.Sequence
+	case done:
+		finalized = uint32(cursor.LedgerSequence) < ledgerRange.LastLedger.Sequence //nolint:gosec
+	default:
+		finalized = lastLedger < ledgerRange.LastLedger.Sequence
+	}
+
+	encodedCursor := protocol.EncodeSnapshotCursor(cursor.String(), snapshotLedger)
 	return protocol.GetTransactionsResponse{
 		Transactions:          txns,
 		LatestLedger:          ledgerRange.LastLedger.Sequence,
 		LatestLedgerCloseTime: ledgerRange.LastLedger.CloseTime,
 		OldestLedger:          ledgerRange.FirstLedger.Sequence,
 		OldestLedgerCloseTime: ledgerRange.FirstLedger.CloseTime,
-		Cursor:                cursor.String(),
+		Cursor:                encodedCursor,
+		LedgersScanned:        ledgersScanned,
+		Page: protocol.PageInfo{
+			Cursor: encodedCursor,
+			Limit:  limit,
+			// the transactions stop at the limit, or at the end of the range
+			HasMore:      done,
+			Finalized:    finalized,
+			OldestLedger: ledgerRange.FirstLedger.Sequence,
+			LatestLedger: ledgerRange.LastLedger.Sequence,
+		},
 	}, nil
 }
 
+// NewGetTransactionsHandler returns a jrpc2.Handler for the getTransactions method. Transactions
+// which predate the local retention window are read from the datastore, when configured.
 func NewGetTransactionsHandler(logger *log.Entry, ledgerReader db.LedgerReader, maxLimit,
-	defaultLimit uint, networkPassphrase string,
+	defaultLimit uint, networkPassphrase string, datastoreLedgerReader rpcdatastore.LedgerReader,
+	queryAge prometheus.Observer,
 ) jrpc2.Handler {
 	transactionsHandler := transactionsRPCHandler{
-		ledgerReader:      ledgerReader,
-		maxLimit:          maxLimit,
-		defaultLimit:      defaultLimit,
-		logger:            logger,
-		networkPassphrase: networkPassphrase,
+		ledgerReader:          ledgerReader,
+		datastoreLedgerReader: datastoreLedgerReader,
+		maxLimit:              maxLimit,
+		defaultLimit:          defaultLimit,
+		logger:                logger,
+		networkPassphrase:     networkPassphrase,
+		queryAge:              queryAge,
 	}
 
 	return handler.New(transactionsHandler.getTransactionsByLedgerSequence)
@@ -0,0 +1,144 @@
+package methods
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type transactionsByContractHandler struct {
+	logger       *log.Entry
+	reader       db.TransactionReader
+	ledgerReader db.LedgerReader
+	maxLimit     uint
+	defaultLimit uint
+}
+
+// initializePagination returns the position of the first transaction to
+// return and the maximum number of transactions to return.
+func (h transactionsByContractHandler) initializePagination(request protocol.GetTransactionsByContractRequest,
+) (toid.ID, uint, error) {
+	start := toid.New(int32(request.StartLedger), 1, 1)
+	limit := h.defaultLimit
+	if request.Pagination != nil {
+		if request.Pagination.Cursor != "" {
+			cursorInt, err := strconv.ParseInt(request.Pagination.Cursor, 10, 64)
+			if err != nil {
+				return toid.ID{}, 0, &jrpc2.Error{
+					Code:    jrpc2.InvalidParams,
+					Message: err.Error(),
+				}
+			}
+			*start = toid.Parse(cursorInt)
+			// increment tx index because, when paginating,
+			// we start with the item right after the cursor
+			start.TransactionOrder++
+		}
+		if request.Pagination.Limit > 0 {
+			limit = request.Pagination.Limit
+		}
+	}
+	return *start, limit, nil
+}
+
+func (h transactionsByContractHandler) getTransactionsByContract(
+	ctx context.Context, request protocol.GetTransactionsByContractRequest,
+) (protocol.GetTransactionsByContractResponse, error) {
+	ledgerRange, err := h.ledgerReader.GetLedgerRange(ctx)
+	if err != nil {
+		return protocol.GetTransactionsByContractResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	// The contract index follows the retention window of the database, so
+	// older transactions can't be served from the datastore
+	if err := request.IsValid(h.maxLimit, ledgerRange.ToLedgerSeqRange()); err != nil {
+		return protocol.GetTransactionsByContractResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+	rawContractID, err := strkey.Decode(strkey.VersionByteContract, request.ContractID)
+	if err != nil {
+		return protocol.GetTransactionsByContractResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+	var contractID xdr.ContractId
+	copy(contractID[:], rawContractID)
+
+	start, limit, err := h.initializePagination(request)
+	if err != nil {
+		return protocol.GetTransactionsByContractResponse{}, err
+	}
+	endLedger := ledgerRange.LastLedger.Sequence
+	if request.EndLedger != 0 {
+		endLedger = min(request.EndLedger, endLedger)
+	}
+
+	txs, err := h.reader.GetContractTransactions(ctx, contractID, start, endLedger, limit)
+	if err != nil {
+		h.logger.WithError(err).Errorf("failed to fetch the transactions of contract %s", request.ContractID)
+		return protocol.GetTransactionsByContractResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	response := protocol.GetTransactionsByContractResponse{
+		Transactions:          make([]protocol.TransactionInfo, 0, len(txs)),
+		LatestLedger:          ledgerRange.LastLedger.Sequence,
+		LatestLedgerCloseTime: ledgerRange.LastLedger.CloseTime,
+		OldestLedger:          ledgerRange.FirstLedger.Sequence,
+		OldestLedgerCloseTime: ledgerRange.FirstLedger.CloseTime,
+	}
+	for _, tx := range txs {
+		details, err := transactionDetails(tx, request.Format, "")
+		if err != nil {
+			return protocol.GetTransactionsByContractResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		response.Transactions = append(response.Transactions, protocol.TransactionInfo{
+			TransactionDetails: details,
+			LedgerCloseTime:    tx.Ledger.CloseTime,
+		})
+	}
+
+	// When the page is full, the next page starts after its last transaction.
+	// Otherwise, the range is exhausted and the next page starts after it.
+	cursor := toid.New(int32(endLedger)+1, 0, 1)
+	if last := len(txs) - 1; last >= 0 && uint(len(txs)) >= limit {
+		cursor = toid.New(int32(txs[last].Ledger.Sequence), txs[last].ApplicationOrder, 1)
+	}
+	response.Cursor = cursor.String()
+	return response, nil
+}
+
+// NewGetTransactionsByContractHandler returns a json rpc handler fetching the
+// transactions which invoked a contract, within the local retention window.
+func NewGetTransactionsByContractHandler(logger *log.Entry, reader db.TransactionReader,
+	ledgerReader db.LedgerReader, maxLimit, defaultLimit uint,
+) jrpc2.Handler {
+	handler := transactionsByContractHandler{
+		logger:       logger,
+		reader:       reader,
+		ledgerReader: ledgerReader,
+		maxLimit:     maxLimit,
+		defaultLimit: defaultLimit,
+	}
+	return NewHandler(handler.getTransactionsByContract)
+}
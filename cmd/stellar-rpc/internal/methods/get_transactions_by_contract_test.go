@@ -0,0 +1,116 @@
+package methods
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+// invokeContractMeta returns a ledger with a transaction invoking contractID.
+func invokeContractMeta(acctSeq uint32, contractID xdr.ContractId) xdr.LedgerCloseMeta {
+	envelope := txEnvelope(acctSeq)
+	envelope.V1.Tx.Operations = []xdr.Operation{{
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+				HostFunction: xdr.HostFunction{
+					Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+					InvokeContract: &xdr.InvokeContractArgs{
+						ContractAddress: xdr.ScAddress{
+							Type:       xdr.ScAddressTypeScAddressTypeContract,
+							ContractId: &contractID,
+						},
+						FunctionName: "increment",
+					},
+				},
+			},
+		},
+	}}
+	hash, err := network.HashTransactionInEnvelope(envelope, "passphrase")
+	if err != nil {
+		panic(err)
+	}
+
+	meta := txMeta(acctSeq, true)
+	meta.V1.TxProcessing[0].Result.TransactionHash = hash
+	(*meta.V1.TxSet.V1TxSet.Phases[0].V0Components)[0].TxsMaybeDiscountedFee.Txs[0] = envelope
+	return meta
+}
+
+func TestGetTransactionsByContract(t *testing.T) {
+	ctx := context.TODO()
+	store := db.NewMockTransactionStore("passphrase")
+	handler := transactionsByContractHandler{
+		logger:       log.DefaultLogger,
+		reader:       store,
+		ledgerReader: db.NewMockLedgerReader(store),
+		maxLimit:     10,
+		defaultLimit: 2,
+	}
+
+	contractID := xdr.ContractId{1}
+	contract := strkey.MustEncode(strkey.VersionByteContract, contractID[:])
+	require.NoError(t, store.InsertTransactions(invokeContractMeta(1, contractID)))
+	require.NoError(t, store.InsertTransactions(txMeta(2, true)))
+	require.NoError(t, store.InsertTransactions(invokeContractMeta(3, contractID)))
+	require.NoError(t, store.InsertTransactions(invokeContractMeta(4, xdr.ContractId{2})))
+	require.NoError(t, store.InsertTransactions(invokeContractMeta(5, contractID)))
+
+	_, err := handler.getTransactionsByContract(ctx, protocol.GetTransactionsByContractRequest{
+		ContractID: "CABC", StartLedger: 101,
+	})
+	require.ErrorContains(t, err, "contractId is invalid")
+	_, err = handler.getTransactionsByContract(ctx, protocol.GetTransactionsByContractRequest{
+		ContractID: contract, StartLedger: 101, EndLedger: 100,
+	})
+	require.ErrorContains(t, err, "endLedger (100) must not be before startLedger (101)")
+
+	// the first page is full, so the cursor points to its last transaction
+	resp, err := handler.getTransactionsByContract(ctx, protocol.GetTransactionsByContractRequest{
+		ContractID: contract, StartLedger: 101,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Transactions, 2)
+	assert.Equal(t, uint32(101), resp.Transactions[0].Ledger)
+	assert.Equal(t, uint32(103), resp.Transactions[1].Ledger)
+	assert.Equal(t, uint32(105), resp.LatestLedger)
+	assert.Equal(t, toid.New(103, 1, 1).String(), resp.Cursor)
+
+	// the last page points past the latest ledger
+	resp, err = handler.getTransactionsByContract(ctx, protocol.GetTransactionsByContractRequest{
+		ContractID: contract,
+		Pagination: &protocol.LedgerPaginationOptions{Cursor: resp.Cursor, Limit: 10},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Transactions, 1)
+	assert.Equal(t, uint32(105), resp.Transactions[0].Ledger)
+	assert.Equal(t, protocol.TransactionStatusSuccess, resp.Transactions[0].Status)
+	assert.Equal(t, toid.New(106, 0, 1).String(), resp.Cursor)
+
+	resp, err = handler.getTransactionsByContract(ctx, protocol.GetTransactionsByContractRequest{
+		ContractID: contract,
+		Pagination: &protocol.LedgerPaginationOptions{Cursor: resp.Cursor},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Transactions)
+
+	// the end ledger bounds the results
+	resp, err = handler.getTransactionsByContract(ctx, protocol.GetTransactionsByContractRequest{
+		ContractID: contract, StartLedger: 102, EndLedger: 104,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Transactions, 1)
+	assert.Equal(t, uint32(103), resp.Transactions[0].Ledger)
+	assert.Equal(t, toid.New(105, 0, 1).String(), resp.Cursor)
+}
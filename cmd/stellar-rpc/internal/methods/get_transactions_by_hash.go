@@ -0,0 +1,108 @@
+package methods
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+type transactionsByHashHandler struct {
+	logger       *log.Entry
+	reader       db.TransactionReader
+	ledgerReader db.LedgerReader
+	maxHashes    uint
+}
+
+func (h transactionsByHashHandler) getTransactionsByHash(
+	ctx context.Context, request protocol.GetTransactionsByHashRequest,
+) (protocol.GetTransactionsByHashResponse, error) {
+	if err := request.Validate(h.maxHashes); err != nil {
+		return protocol.GetTransactionsByHashResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
+		}
+	}
+
+	hashes := make([]xdr.Hash, 0, len(request.Hashes))
+	for i, hash := range request.Hashes {
+		txHash, err := parseTransactionHash(hash)
+		if err != nil {
+			return protocol.GetTransactionsByHashResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InvalidParams,
+				Message: fmt.Sprintf("hash %d: %v", i, err),
+			}
+		}
+		hashes = append(hashes, txHash)
+	}
+
+	storeRange, err := h.ledgerReader.GetLedgerRange(ctx)
+	if err != nil {
+		return protocol.GetTransactionsByHashResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: fmt.Sprintf("unable to get ledger range: %v", err),
+		}
+	}
+
+	txs, err := h.reader.GetTransactions(ctx, hashes)
+	if err != nil {
+		h.logger.WithError(err).Errorf("failed to fetch %d transactions", len(hashes))
+		return protocol.GetTransactionsByHashResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: err.Error(),
+		}
+	}
+
+	response := protocol.GetTransactionsByHashResponse{
+		Transactions:          make([]protocol.TransactionInfo, 0, len(hashes)),
+		LatestLedger:          storeRange.LastLedger.Sequence,
+		LatestLedgerCloseTime: storeRange.LastLedger.CloseTime,
+		OldestLedger:          storeRange.FirstLedger.Sequence,
+		OldestLedgerCloseTime: storeRange.FirstLedger.CloseTime,
+	}
+	for i, hash := range hashes {
+		tx, ok := txs[hash]
+		if !ok {
+			response.Transactions = append(response.Transactions, protocol.TransactionInfo{
+				TransactionDetails: protocol.TransactionDetails{
+					Status:          protocol.TransactionStatusNotFound,
+					TransactionHash: request.Hashes[i],
+				},
+			})
+			continue
+		}
+		details, err := transactionDetails(tx, request.Format, "")
+		if err != nil {
+			return protocol.GetTransactionsByHashResponse{}, &jrpc2.Error{
+				Code:    jrpc2.InternalError,
+				Message: err.Error(),
+			}
+		}
+		details.TransactionHash = request.Hashes[i]
+		response.Transactions = append(response.Transactions, protocol.TransactionInfo{
+			TransactionDetails: details,
+			LedgerCloseTime:    tx.Ledger.CloseTime,
+		})
+	}
+	return response, nil
+}
+
+// NewGetTransactionsByHashHandler returns a json rpc handler fetching several
+// transactions by hash at once
+func NewGetTransactionsByHashHandler(logger *log.Entry, reader db.TransactionReader,
+	ledgerReader db.LedgerReader, maxHashes uint,
+) jrpc2.Handler {
+	handler := transactionsByHashHandler{
+		logger:       logger,
+		reader:       reader,
+		ledgerReader: ledgerReader,
+		maxHashes:    maxHashes,
+	}
+	return NewHandler(handler.getTransactionsByHash)
+}
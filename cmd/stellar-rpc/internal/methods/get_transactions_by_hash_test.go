@@ -0,0 +1,71 @@
+package methods
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/log"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func TestGetTransactionsByHash(t *testing.T) {
+	ctx := context.TODO()
+	store := db.NewMockTransactionStore("passphrase")
+	handler := transactionsByHashHandler{
+		logger:       log.DefaultLogger,
+		reader:       store,
+		ledgerReader: db.NewMockLedgerReader(store),
+		maxHashes:    3,
+	}
+
+	_, err := handler.getTransactionsByHash(ctx, protocol.GetTransactionsByHashRequest{})
+	require.EqualError(t, err, "[-32602] hashes must not be empty")
+	_, err = handler.getTransactionsByHash(ctx, protocol.GetTransactionsByHashRequest{
+		Hashes: []string{"a", "b", "c", "d"},
+	})
+	require.EqualError(t, err, "[-32602] hashes must not contain more than 3 elements")
+	_, err = handler.getTransactionsByHash(ctx, protocol.GetTransactionsByHashRequest{
+		Hashes: []string{strings.Repeat("a", 64), "ab"},
+	})
+	require.EqualError(t, err, "[-32602] hash 1: unexpected hash length (2)")
+
+	require.NoError(t, store.InsertTransactions(txMeta(1, true)))
+	require.NoError(t, store.InsertTransactions(txMeta(2, false)))
+	hash1, hash2 := txHash(1), txHash(2)
+	missing := strings.Repeat("a", 64)
+	request := protocol.GetTransactionsByHashRequest{
+		Hashes: []string{hex.EncodeToString(hash2[:]), missing, hex.EncodeToString(hash1[:])},
+	}
+	resp, err := handler.getTransactionsByHash(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(102), resp.LatestLedger)
+	require.Len(t, resp.Transactions, 3)
+
+	// results are in the order of the request
+	assert.Equal(t, request.Hashes[0], resp.Transactions[0].TransactionHash)
+	assert.Equal(t, protocol.TransactionStatusFailed, resp.Transactions[0].Status)
+	assert.Equal(t, uint32(102), resp.Transactions[0].Ledger)
+	assert.Equal(t, protocol.TransactionInfo{
+		TransactionDetails: protocol.TransactionDetails{
+			Status:          protocol.TransactionStatusNotFound,
+			TransactionHash: missing,
+		},
+	}, resp.Transactions[1])
+	assert.Equal(t, request.Hashes[2], resp.Transactions[2].TransactionHash)
+	assert.Equal(t, protocol.TransactionStatusSuccess, resp.Transactions[2].Status)
+	assert.Equal(t, uint32(101), resp.Transactions[2].Ledger)
+
+	// they match getTransaction
+	single, err := GetTransaction(ctx, log.DefaultLogger, store, handler.ledgerReader,
+		protocol.GetTransactionRequest{Hash: request.Hashes[2]})
+	require.NoError(t, err)
+	assert.Equal(t, single.TransactionDetails, resp.Transactions[2].TransactionDetails)
+	assert.Equal(t, single.LedgerCloseTime, resp.Transactions[2].LedgerCloseTime)
+}
@@ -60,6 +60,10 @@ func TestGetTransactions_DefaultLimit(t *testing.T) { //nolint:dupl
 
 	// assert pagination
 	assert.Equal(t, toid.New(5, 2, 1).String(), response.Cursor)
+	assert.Equal(t, protocol.PageInfo{
+		Cursor: response.Cursor, Limit: 10, HasMore: true, Finalized: true, OldestLedger: 1, LatestLedger: 10,
+	}, response.Page)
+	assert.Equal(t, uint32(5), response.LedgersScanned)
 
 	// assert transactions result
 	assert.Len(t, response.Transactions, 10)
@@ -86,6 +90,9 @@ func TestGetTransactions_DefaultLimitExceedsLatestLedger(t *testing.T) { //nolin
 	assert.Equal(t, uint32(3), response.LatestLedger)
 	assert.Equal(t, int64(175), response.LatestLedgerCloseTime)
 	assert.Equal(t, toid.New(3, 2, 1).String(), response.Cursor)
+	assert.False(t, response.Page.HasMore)
+	assert.False(t, response.Page.Finalized)
+	assert.Equal(t, uint32(3), response.LedgersScanned)
 	assert.Len(t, response.Transactions, 6)
 	assert.Equal(t, expectedTransactionInfo, response.Transactions[0])
 }
@@ -144,6 +151,135 @@ func TestGetTransactions_CustomLimitAndCursor(t *testing.T) {
 	assert.Equal(t, uint32(3), response.Transactions[2].Ledger)
 }
 
+func TestGetTransactions_Descending(t *testing.T) {
+	testDB := setupDB(t, 10, 0)
+	handler := transactionsRPCHandler{
+		ledgerReader:      db.NewLedgerReader(testDB),
+		maxLimit:          100,
+		defaultLimit:      3,
+		networkPassphrase: NetworkPassphrase,
+	}
+
+	// without a start ledger, the latest ledger is used
+	response, err := handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		Order: protocol.OrderDescending,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 3)
+	assert.Equal(t, uint32(10), response.Transactions[0].Ledger)
+	assert.Equal(t, int32(2), response.Transactions[0].ApplicationOrder)
+	assert.Equal(t, uint32(10), response.Transactions[1].Ledger)
+	assert.Equal(t, int32(1), response.Transactions[1].ApplicationOrder)
+	assert.Equal(t, uint32(9), response.Transactions[2].Ledger)
+	assert.Equal(t, int32(2), response.Transactions[2].ApplicationOrder)
+	assert.Equal(t, toid.New(9, 2, 1).String(), response.Cursor)
+
+	// the cursor pages backwards
+	response, err = handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		Order:      protocol.OrderDescending,
+		Pagination: &protocol.LedgerPaginationOptions{Cursor: response.Cursor},
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 3)
+	assert.Equal(t, uint32(9), response.Transactions[0].Ledger)
+	assert.Equal(t, int32(1), response.Transactions[0].ApplicationOrder)
+	assert.Equal(t, uint32(8), response.Transactions[1].Ledger)
+	assert.Equal(t, uint32(8), response.Transactions[2].Ledger)
+	assert.Equal(t, toid.New(8, 1, 1).String(), response.Cursor)
+
+	// and stops at the oldest ledger
+	response, err = handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		StartLedger: 2,
+		Order:       protocol.OrderDescending,
+		Pagination:  &protocol.LedgerPaginationOptions{Limit: 10},
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 4)
+	assert.Equal(t, uint32(2), response.Transactions[0].Ledger)
+	assert.Equal(t, uint32(1), response.Transactions[3].Ledger)
+	assert.Equal(t, int32(1), response.Transactions[3].ApplicationOrder)
+	assert.Equal(t, toid.New(1, 1, 1).String(), response.Cursor)
+
+	_, err = handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		StartLedger: 1,
+		Order:       "sideways",
+	})
+	require.ErrorContains(t, err, "expected asc or desc for optional 'order'")
+}
+
+func TestGetTransactions_Snapshot(t *testing.T) {
+	testDB := setupDB(t, 10, 0)
+	handler := transactionsRPCHandler{
+		ledgerReader:      db.NewLedgerReader(testDB),
+		maxLimit:          100,
+		defaultLimit:      3,
+		networkPassphrase: NetworkPassphrase,
+	}
+
+	response, err := handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		StartLedger: 9,
+		Snapshot:    true,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 3)
+	assert.Equal(t, toid.New(10, 1, 1).String()+"@10", response.Cursor)
+
+	// ledgers ingested after the first page are left out
+	ledgerCloseMeta := createTestLedger(11)
+	tx, err := db.NewReadWriter(log.DefaultLogger, testDB, interfaces.MakeNoOpDeamon(), 150, 100, passphrase,
+		db.EventStorage{}, false, false).NewTx(context.TODO())
+	require.NoError(t, err)
+	require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
+	require.NoError(t, tx.Commit(ledgerCloseMeta))
+
+	response, err = handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		Pagination: &protocol.LedgerPaginationOptions{Cursor: response.Cursor},
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 1)
+	assert.Equal(t, uint32(10), response.Transactions[0].Ledger)
+	assert.Equal(t, uint32(11), response.LatestLedger)
+	assert.Equal(t, toid.New(10, 2, 1).String()+"@10", response.Cursor)
+
+	// and the last page doesn't move
+	response, err = handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		Pagination: &protocol.LedgerPaginationOptions{Cursor: response.Cursor},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, response.Transactions)
+	assert.Equal(t, toid.New(10, 2, 1).String()+"@10", response.Cursor)
+
+	_, err = handler.getTransactionsByLedgerSequence(context.TODO(), protocol.GetTransactionsRequest{
+		Pagination: &protocol.LedgerPaginationOptions{Cursor: toid.New(10, 2, 1).String() + "@x"},
+	})
+	require.ErrorContains(t, err, "invalid snapshot ledger")
+}
+
+func TestGetTransactions_CursorAtLastTransaction(t *testing.T) {
+	testDB := setupDB(t, 3, 0)
+	handler := transactionsRPCHandler{
+		ledgerReader:      db.NewLedgerReader(testDB),
+		maxLimit:          100,
+		defaultLimit:      10,
+		networkPassphrase: NetworkPassphrase,
+	}
+
+	// the cursor points at the last transaction of the latest ledger
+	cursor := toid.New(3, 2, 1).String()
+	request := protocol.GetTransactionsRequest{
+		Pagination: &protocol.LedgerPaginationOptions{
+			Cursor: cursor,
+		},
+	}
+
+	response, err := handler.getTransactionsByLedgerSequence(context.TODO(), request)
+	require.NoError(t, err)
+	assert.Empty(t, response.Transactions)
+	// the cursor doesn't move back to the start of the ledger, which would
+	// return its transactions again on the next page
+	assert.Equal(t, cursor, response.Cursor)
+}
+
 func TestGetTransactions_InvalidStartLedger(t *testing.T) {
 	testDB := setupDB(t, 3, 0)
 	handler := transactionsRPCHandler{
@@ -280,6 +416,112 @@ func TestGetTransactions_NoResults(t *testing.T) {
 	require.Empty(t, txns.Transactions)
 }
 
+func TestGetTransactions_FromDatastore(t *testing.T) {
+	ctx := context.TODO()
+	// the local database only contains ledgers 6 to 10
+	testDB := NewTestDB(t)
+	for sequence := uint32(6); sequence <= 10; sequence++ {
+		ledgerCloseMeta := createTestLedger(sequence)
+		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, interfaces.MakeNoOpDeamon(), 150, 100, passphrase,
+			db.EventStorage{}, false, false).NewTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
+		require.NoError(t, tx.Commit(ledgerCloseMeta))
+	}
+
+	datastoreLedgers := make([]xdr.LedgerCloseMeta, 0, 4)
+	for sequence := uint32(2); sequence <= 5; sequence++ {
+		datastoreLedgers = append(datastoreLedgers, createTestLedger(sequence))
+	}
+	mockStore := new(MockDatastoreReader)
+	mockStore.On("GetAvailableLedgerRange", ctx).
+		Return(protocol.LedgerSeqRange{FirstLedger: 1, LastLedger: 10}, nil)
+	mockStore.On("GetLedgers", ctx, uint32(2), uint32(5)).Return(datastoreLedgers, nil)
+
+	handler := transactionsRPCHandler{
+		ledgerReader:          db.NewLedgerReader(testDB),
+		datastoreLedgerReader: mockStore,
+		maxLimit:              100,
+		defaultLimit:          10,
+		networkPassphrase:     NetworkPassphrase,
+	}
+
+	response, err := handler.getTransactionsByLedgerSequence(ctx, protocol.GetTransactionsRequest{
+		StartLedger: 2,
+	})
+	require.NoError(t, err)
+	mockStore.AssertExpectations(t)
+
+	require.Len(t, response.Transactions, 10)
+	assert.Equal(t, uint32(2), response.Transactions[0].Ledger)
+	assert.Equal(t, uint32(6), response.Transactions[9].Ledger)
+	assert.Equal(t, toid.New(6, 2, 1).String(), response.Cursor)
+	// the oldest ledger reflects the local retention window
+	assert.Equal(t, uint32(6), response.OldestLedger)
+
+	// when descending, the batches end at the requested ledger
+	mockStore.On("GetLedgers", ctx, uint32(1), uint32(5)).Return(datastoreLedgers, nil)
+	response, err = handler.getTransactionsByLedgerSequence(ctx, protocol.GetTransactionsRequest{
+		StartLedger: 6,
+		Order:       protocol.OrderDescending,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 10)
+	assert.Equal(t, uint32(6), response.Transactions[0].Ledger)
+	assert.Equal(t, uint32(5), response.Transactions[2].Ledger)
+	assert.Equal(t, uint32(2), response.Transactions[9].Ledger)
+	assert.Equal(t, toid.New(2, 1, 1).String(), response.Cursor)
+
+	// without a datastore the old ledgers are out of range
+	handler.datastoreLedgerReader = nil
+	_, err = handler.getTransactionsByLedgerSequence(ctx, protocol.GetTransactionsRequest{
+		StartLedger: 2,
+	})
+	require.Error(t, err)
+}
+
+func TestGetTransactions_IncludeEvents(t *testing.T) {
+	ctx := context.TODO()
+	testDB := NewTestDB(t)
+	// ledger 101 has a transaction with a contract event, ledger 102 a failed one
+	for _, ledgerCloseMeta := range []xdr.LedgerCloseMeta{txMetaWithEvents(1, true), txMetaWithEvents(2, false)} {
+		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, interfaces.MakeNoOpDeamon(), 150, 100, passphrase,
+			db.EventStorage{}, false, false).NewTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
+		require.NoError(t, tx.Commit(ledgerCloseMeta))
+	}
+	handler := transactionsRPCHandler{
+		ledgerReader:      db.NewLedgerReader(testDB),
+		maxLimit:          100,
+		defaultLimit:      10,
+		networkPassphrase: NetworkPassphrase,
+	}
+
+	// the events are only attached when requested
+	response, err := handler.getTransactionsByLedgerSequence(ctx, protocol.GetTransactionsRequest{
+		StartLedger: 101,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 2)
+	assert.Empty(t, response.Transactions[0].Events)
+
+	response, err = handler.getTransactionsByLedgerSequence(ctx, protocol.GetTransactionsRequest{
+		StartLedger:   101,
+		IncludeEvents: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.Transactions, 2)
+	events := response.Transactions[0].Events
+	require.Len(t, events, 1)
+	assert.Equal(t, protocol.EventTypeContract, events[0].EventType)
+	assert.Equal(t, protocol.Cursor{Ledger: 101, Tx: 1}.String(), events[0].ID)
+	assert.Equal(t, response.Transactions[0].TransactionHash, events[0].TransactionHash)
+	assert.NotEmpty(t, events[0].ValueXDR)
+	// failed transactions have no events
+	assert.Empty(t, response.Transactions[1].Events)
+}
+
 // createTestLedger Creates a test ledger with 2 transactions
 func createTestLedger(sequence uint32) xdr.LedgerCloseMeta {
 	sequence -= 100
@@ -312,7 +554,8 @@ func setupDB(t *testing.T, numLedgers int, skipLedger int) *db.DB {
 			continue
 		}
 		ledgerCloseMeta := createTestLedger(uint32(sequence))
-		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, daemon, 150, 100, passphrase).NewTx(context.Background())
+		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, daemon, 150, 100, passphrase, db.EventStorage{}, false, false).
+			NewTx(context.Background())
 		require.NoError(t, err)
 		require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
 		require.NoError(t, tx.Commit(ledgerCloseMeta))
@@ -326,7 +569,8 @@ func setupDBNoTxs(t *testing.T, numLedgers int) *db.DB {
 	for sequence := 1; sequence <= numLedgers; sequence++ {
 		ledgerCloseMeta := createEmptyTestLedger(uint32(sequence))
 
-		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, daemon, 150, 100, passphrase).NewTx(context.Background())
+		tx, err := db.NewReadWriter(log.DefaultLogger, testDB, daemon, 150, 100, passphrase, db.EventStorage{}, false, false).
+			NewTx(context.Background())
 		require.NoError(t, err)
 		require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
 		require.NoError(t, tx.Commit(ledgerCloseMeta))
@@ -2,6 +2,7 @@ package methods
 
 import (
 	"context"
+	"time"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/creachadair/jrpc2/handler"
@@ -14,26 +15,40 @@ import (
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
+// NewGetVersionInfoHandler returns a json rpc handler for the getVersionInfo
+// method. Responses are cached for cacheTTL (or until a new ledger is ingested).
 func NewGetVersionInfoHandler(
 	logger *log.Entry,
 	ledgerReader db.LedgerReader,
 	daemon interfaces.Daemon,
+	cacheTTL time.Duration,
 ) jrpc2.Handler {
 	core := daemon.GetCore()
+	cache := newResponseCache[protocol.GetVersionInfoResponse](cacheTTL)
 
 	return handler.New(func(ctx context.Context) (protocol.GetVersionInfoResponse, error) {
-		captiveCoreVersion := core.GetCoreVersion()
-		protocolVersion, err := getProtocolVersion(ctx, ledgerReader)
+		var protocolVersion uint32
+		latestLedger, err := ledgerReader.GetLatestLedgerSequence(ctx)
+		if err == nil {
+			if response, ok := cache.get(latestLedger); ok {
+				return response, nil
+			}
+			protocolVersion, err = getLedgerProtocolVersion(ctx, ledgerReader, latestLedger)
+		}
 		if err != nil {
 			logger.WithError(err).Error("failed to fetch protocol version")
 		}
 
-		return protocol.GetVersionInfoResponse{
+		response := protocol.GetVersionInfoResponse{
 			Version:            config.Version,
 			CommitHash:         config.CommitHash,
 			BuildTimestamp:     config.BuildTimestamp,
-			CaptiveCoreVersion: captiveCoreVersion,
+			CaptiveCoreVersion: core.GetCoreVersion(),
 			ProtocolVersion:    protocolVersion,
-		}, nil
+		}
+		if err == nil {
+			cache.set(latestLedger, response)
+		}
+		return response, nil
 	})
 }
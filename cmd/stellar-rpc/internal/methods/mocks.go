@@ -55,6 +55,11 @@ func (m *MockLedgerReader) GetLatestLedgerSequence(ctx context.Context) (uint32,
 	return args.Get(0).(uint32), args.Error(1) //nolint:forcetypeassert
 }
 
+func (m *MockLedgerReader) GetLedgerGaps(ctx context.Context, limit uint) ([]db.LedgerGap, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]db.LedgerGap), args.Error(1) //nolint:forcetypeassert
+}
+
 type MockLedgerReaderTx struct {
 	mock.Mock
 }
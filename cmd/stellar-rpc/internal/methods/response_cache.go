@@ -0,0 +1,50 @@
+package methods
+
+import (
+	"sync"
+	"time"
+)
+
+// responseCache remembers the latest response of a handler for a short time.
+// The cached response is discarded as soon as a new ledger is ingested.
+type responseCache[T any] struct {
+	ttl       time.Duration
+	now       func() time.Time
+	lock      sync.Mutex
+	valid     bool
+	ledger    uint32
+	expiresAt time.Time
+	response  T
+}
+
+// newResponseCache creates a responseCache, a zero ttl disables caching.
+func newResponseCache[T any](ttl time.Duration) *responseCache[T] {
+	return &responseCache[T]{
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// get returns the cached response if it was computed for the given latest
+// ledger and hasn't expired.
+func (c *responseCache[T]) get(latestLedger uint32) (T, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.valid || c.ledger != latestLedger || !c.now().Before(c.expiresAt) {
+		var zero T
+		return zero, false
+	}
+	return c.response, true
+}
+
+func (c *responseCache[T]) set(latestLedger uint32, response T) {
+	if c.ttl == 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.valid = true
+	c.ledger = latestLedger
+	c.expiresAt = c.now().Add(c.ttl)
+	c.response = response
+}
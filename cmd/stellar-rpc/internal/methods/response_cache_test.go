@@ -0,0 +1,39 @@
+package methods
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	now := time.Unix(100, 0)
+	cache := newResponseCache[string](time.Second)
+	cache.now = func() time.Time { return now }
+
+	_, ok := cache.get(10)
+	require.False(t, ok)
+
+	cache.set(10, "a")
+	response, ok := cache.get(10)
+	require.True(t, ok)
+	assert.Equal(t, "a", response)
+
+	// a new ledger invalidates the response
+	_, ok = cache.get(11)
+	require.False(t, ok)
+
+	// and so does the TTL
+	now = now.Add(time.Second)
+	_, ok = cache.get(10)
+	require.False(t, ok)
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	cache := newResponseCache[string](0)
+	cache.set(10, "a")
+	_, ok := cache.get(10)
+	require.False(t, ok)
+}
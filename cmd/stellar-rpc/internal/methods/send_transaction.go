@@ -4,6 +4,11 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/creachadair/jrpc2"
 	"github.com/pkg/errors"
@@ -19,162 +24,420 @@ import (
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
-// NewSendTransactionHandler returns a submit transaction json rpc handler
+// maxIdempotencyKeys bounds the amount of memory used by the idempotency cache.
+// Once reached, the oldest keys are evicted to make room for the new ones.
+const maxIdempotencyKeys = 10_000
+
+type idempotencyEntry struct {
+	transaction string
+	// format is the xdrFormat of the response
+	format    string
+	response  protocol.SendTransactionResponse
+	expiresAt time.Time
+	// done is closed once the submission of the transaction completes, after
+	// which response is set if the submission is remembered
+	done       chan struct{}
+	remembered bool
+}
+
+// idempotencyCache remembers sendTransaction responses by idempotency key for
+// a fixed window of time. A key is recorded as soon as its transaction is
+// submitted, so that concurrent submissions with the same key wait for the
+// response of the first one instead of reaching stellar-core again.
+type idempotencyCache struct {
+	window  time.Duration
+	entries map[string]*idempotencyEntry
+	now     func() time.Time
+	lock    sync.Mutex
+}
+
+func newIdempotencyCache(window time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		window:  window,
+		entries: make(map[string]*idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// acquire returns the entry of the key, which is a new one (owned by the
+// caller, who must complete it) if the key is unknown or expired.
+func (c *idempotencyCache) acquire(key string, transaction string, format string) (*idempotencyEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	if entry, ok := c.entries[key]; ok && !now.After(entry.expiresAt) {
+		return entry, false
+	}
+	delete(c.entries, key)
+	if len(c.entries) >= maxIdempotencyKeys {
+		c.evict(now)
+	}
+	entry := &idempotencyEntry{
+		transaction: transaction,
+		format:      format,
+		expiresAt:   now.Add(c.window),
+		done:        make(chan struct{}),
+	}
+	c.entries[key] = entry
+	return entry, true
+}
+
+// evict removes the expired entries or, if there are none, the oldest entry.
+func (c *idempotencyCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest *idempotencyEntry
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		} else if oldest == nil || entry.expiresAt.Before(oldest.expiresAt) {
+			oldestKey, oldest = k, entry
+		}
+	}
+	if len(c.entries) >= maxIdempotencyKeys {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// complete records the response of an acquired entry, or forgets the key if
+// the response must not be remembered, and wakes up the waiting submissions.
+func (c *idempotencyCache) complete(key string, entry *idempotencyEntry,
+	response protocol.SendTransactionResponse, remember bool,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if remember {
+		entry.response = response
+		entry.remembered = true
+		entry.expiresAt = c.now().Add(c.window)
+	} else if c.entries[key] == entry {
+		delete(c.entries, key)
+	}
+	close(entry.done)
+}
+
+// SubmitRetryPolicy configures how submissions to stellar-core are retried
+// when they fail with a transient (network) error. Transactions rejected by
+// stellar-core are never retried.
+type SubmitRetryPolicy struct {
+	// MaxRetries is the maximum number of retries, 0 disables retrying.
+	MaxRetries uint
+	// Backoff is the delay before the first retry, doubled on every retry.
+	Backoff time.Duration
+	// Budget bounds the total time spent submitting, including retries. It
+	// should not exceed the execution duration limit of sendTransaction.
+	Budget time.Duration
+}
+
+// isTransientSubmitError returns true if the submission error is a
+// connectivity problem which is worth retrying.
+func isTransientSubmitError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// submitWithRetry submits the transaction to stellar-core, retrying transient
+// failures as configured by the policy.
+func submitWithRetry(
+	ctx context.Context,
+	logger *log.Entry,
+	submitter interfaces.CoreClient,
+	policy SubmitRetryPolicy,
+	transaction string,
+) (*proto.TXResponse, error) {
+	start := time.Now()
+	backoff := policy.Backoff
+	for attempt := uint(0); ; attempt++ {
+		resp, err := submitter.SubmitTransaction(ctx, transaction)
+		if err == nil || attempt >= policy.MaxRetries || ctx.Err() != nil || !isTransientSubmitError(err) {
+			return resp, err
+		}
+		if policy.Budget > 0 && time.Since(start)+backoff >= policy.Budget {
+			return resp, err
+		}
+		logger.WithError(err).
+			WithField("attempt", attempt+1).
+			Warn("transient error submitting transaction to stellar-core, retrying")
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// checkTransactionExpiry returns an error if the time bounds of the
+// transaction expired more than maxExpiredAge before the close time of the
+// latest ledger, in which case it can't be included anymore.
+func checkTransactionExpiry(envelope xdr.TransactionEnvelope, latestLedgerCloseTime int64,
+	maxExpiredAge time.Duration,
+) error {
+	timeBounds := envelope.Preconditions().TimeBounds
+	if timeBounds == nil || timeBounds.MaxTime == 0 {
+		return nil
+	}
+	maxTime := int64(timeBounds.MaxTime) //nolint:gosec
+	if maxTime+int64(maxExpiredAge.Seconds()) >= latestLedgerCloseTime {
+		return nil
+	}
+	return fmt.Errorf("transaction expired: its maxTime (%d) is before the close time of the latest ledger (%d)",
+		maxTime, latestLedgerCloseTime)
+}
+
+// NewSendTransactionHandler returns a submit transaction json rpc handler.
+// Submissions carrying an idempotency key are deduplicated for
+// idempotencyWindow (a zero window disables deduplication). Transactions
+// which expired more than maxExpiredAge ago are rejected without being
+// submitted (a zero age disables the check). The pending transactions are
+// added to submitted.
 func NewSendTransactionHandler(
 	daemon interfaces.Daemon,
 	logger *log.Entry,
 	ledgerReader db.LedgerReader,
 	passphrase string,
+	idempotencyWindow time.Duration,
+	retryPolicy SubmitRetryPolicy,
+	maxExpiredAge time.Duration,
+	submitted *SubmittedTransactions,
 ) jrpc2.Handler {
 	submitter := daemon.CoreClient()
-	return NewHandler(func(ctx context.Context, request protocol.SendTransactionRequest,
+	var cache *idempotencyCache
+	if idempotencyWindow > 0 {
+		cache = newIdempotencyCache(idempotencyWindow)
+	}
+	var send sendFunc = func(ctx context.Context, request protocol.SendTransactionRequest,
 	) (protocol.SendTransactionResponse, error) {
-		if err := protocol.IsValidFormat(request.Format); err != nil {
-			return protocol.SendTransactionResponse{}, &jrpc2.Error{
-				Code:    jrpc2.InvalidParams,
-				Message: err.Error(),
-			}
+		resp, err := sendTransaction(ctx, logger, submitter, retryPolicy, ledgerReader, passphrase, maxExpiredAge, request)
+		if err == nil && resp.Status == proto.TXStatusPending {
+			submitted.add(resp.Hash)
 		}
+		return resp, err
+	}
+	if cache != nil {
+		send = idempotentSendTransaction(cache, send)
+	}
+	return NewHandler(send)
+}
 
-		var envelope xdr.TransactionEnvelope
-		err := xdr.SafeUnmarshalBase64(request.Transaction, &envelope)
-		if err != nil {
-			return protocol.SendTransactionResponse{}, &jrpc2.Error{
-				Code:    jrpc2.InvalidParams,
-				Message: "invalid_xdr",
+type sendFunc func(ctx context.Context, request protocol.SendTransactionRequest,
+) (protocol.SendTransactionResponse, error)
+
+// idempotentSendTransaction deduplicates the submissions of send by idempotency key.
+func idempotentSendTransaction(cache *idempotencyCache, send sendFunc) sendFunc {
+	return func(ctx context.Context, request protocol.SendTransactionRequest,
+	) (protocol.SendTransactionResponse, error) {
+		if request.IdempotencyKey == "" {
+			return send(ctx, request)
+		}
+		// the remembered responses are encoded in the format of the first submission
+		format := request.Format
+		if format == "" {
+			format = protocol.FormatBase64
+		}
+		for {
+			entry, owner := cache.acquire(request.IdempotencyKey, request.Transaction, format)
+			if entry.transaction != request.Transaction {
+				return protocol.SendTransactionResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InvalidParams,
+					Message: "idempotency key was already used for a different transaction",
+				}
 			}
+			if entry.format != format {
+				return protocol.SendTransactionResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InvalidParams,
+					Message: "idempotency key was already used with a different xdrFormat",
+				}
+			}
+			if owner {
+				resp, err := send(ctx, request)
+				// TRY_AGAIN_LATER is transient, so the client must be able to resubmit.
+				cache.complete(request.IdempotencyKey, entry, resp,
+					err == nil && resp.Status != proto.TXStatusTryAgainLater)
+				return resp, err
+			}
+			select {
+			case <-entry.done:
+			case <-ctx.Done():
+				return protocol.SendTransactionResponse{}, ctx.Err()
+			}
+			if entry.remembered {
+				return entry.response, nil
+			}
+			// the first submission wasn't remembered, submit again
+		}
+	}
+}
+
+func sendTransaction(
+	ctx context.Context,
+	logger *log.Entry,
+	submitter interfaces.CoreClient,
+	retryPolicy SubmitRetryPolicy,
+	ledgerReader db.LedgerReader,
+	passphrase string,
+	maxExpiredAge time.Duration,
+	request protocol.SendTransactionRequest,
+) (protocol.SendTransactionResponse, error) {
+	if err := protocol.IsValidFormat(request.Format); err != nil {
+		return protocol.SendTransactionResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: err.Error(),
 		}
+	}
 
-		var hash [32]byte
-		hash, err = network.HashTransactionInEnvelope(envelope, passphrase)
-		if err != nil {
+	var envelope xdr.TransactionEnvelope
+	err := xdr.SafeUnmarshalBase64(request.Transaction, &envelope)
+	if err != nil {
+		return protocol.SendTransactionResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: "invalid_xdr",
+		}
+	}
+
+	var hash [32]byte
+	hash, err = network.HashTransactionInEnvelope(envelope, passphrase)
+	if err != nil {
+		return protocol.SendTransactionResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InvalidParams,
+			Message: "invalid_hash",
+		}
+	}
+	txHash := hex.EncodeToString(hash[:])
+
+	ledgerInfo, err := ledgerReader.GetLedgerRange(ctx)
+	if err != nil { // still not fatal
+		logger.WithError(err).
+			WithField("tx", request.Transaction).
+			Error("could not fetch ledger range")
+	}
+	latestLedgerInfo := ledgerInfo.LastLedger
+
+	// the check is skipped if the latest ledger is unknown
+	if maxExpiredAge > 0 && latestLedgerInfo.CloseTime > 0 {
+		if err := checkTransactionExpiry(envelope, latestLedgerInfo.CloseTime, maxExpiredAge); err != nil {
 			return protocol.SendTransactionResponse{}, &jrpc2.Error{
 				Code:    jrpc2.InvalidParams,
-				Message: "invalid_hash",
+				Message: err.Error(),
 			}
 		}
-		txHash := hex.EncodeToString(hash[:])
+	}
 
-		ledgerInfo, err := ledgerReader.GetLedgerRange(ctx)
-		if err != nil { // still not fatal
-			logger.WithError(err).
-				WithField("tx", request.Transaction).
-				Error("could not fetch ledger range")
+	resp, err := submitWithRetry(ctx, logger, submitter, retryPolicy, request.Transaction)
+	if err != nil {
+		logger.WithError(err).
+			WithField("tx", request.Transaction).
+			Error("could not submit transaction")
+		return protocol.SendTransactionResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: "could not submit transaction to stellar-core",
 		}
-		latestLedgerInfo := ledgerInfo.LastLedger
+	}
 
-		resp, err := submitter.SubmitTransaction(ctx, request.Transaction)
-		if err != nil {
-			logger.WithError(err).
-				WithField("tx", request.Transaction).
-				Error("could not submit transaction")
-			return protocol.SendTransactionResponse{}, &jrpc2.Error{
-				Code:    jrpc2.InternalError,
-				Message: "could not submit transaction to stellar-core",
-			}
+	// interpret response
+	if resp.IsException() {
+		logger.WithField("exception", resp.Exception).
+			WithField("tx", request.Transaction).Error("received exception from stellar core")
+		return protocol.SendTransactionResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: "received exception from stellar-core",
 		}
+	}
 
-		// interpret response
-		if resp.IsException() {
-			logger.WithField("exception", resp.Exception).
-				WithField("tx", request.Transaction).Error("received exception from stellar core")
-			return protocol.SendTransactionResponse{}, &jrpc2.Error{
-				Code:    jrpc2.InternalError,
-				Message: "received exception from stellar-core",
-			}
+	switch resp.Status {
+	case proto.TXStatusError:
+		errorResp := protocol.SendTransactionResponse{
+			Status:                resp.Status,
+			Hash:                  txHash,
+			LatestLedger:          latestLedgerInfo.Sequence,
+			LatestLedgerCloseTime: latestLedgerInfo.CloseTime,
 		}
 
-		switch resp.Status {
-		case proto.TXStatusError:
-			errorResp := protocol.SendTransactionResponse{
-				Status:                resp.Status,
-				Hash:                  txHash,
-				LatestLedger:          latestLedgerInfo.Sequence,
-				LatestLedgerCloseTime: latestLedgerInfo.CloseTime,
+		switch request.Format {
+		case protocol.FormatJSON:
+			errResult := xdr.TransactionResult{}
+			err = xdr.SafeUnmarshalBase64(resp.Error, &errResult)
+			if err != nil {
+				logger.WithField("tx", request.Transaction).
+					WithError(err).Error("Cannot decode error result")
+
+				return protocol.SendTransactionResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InternalError,
+					Message: errors.Wrap(err, "couldn't decode error").Error(),
+				}
 			}
 
-			switch request.Format {
-			case protocol.FormatJSON:
-				errResult := xdr.TransactionResult{}
-				err = xdr.SafeUnmarshalBase64(resp.Error, &errResult)
-				if err != nil {
-					logger.WithField("tx", request.Transaction).
-						WithError(err).Error("Cannot decode error result")
+			errorResp.ErrorResultJSON, err = xdr2json.ConvertInterface(errResult)
+			if err != nil {
+				logger.WithField("tx", request.Transaction).
+					WithError(err).Error("Cannot JSONify error result")
 
-					return protocol.SendTransactionResponse{}, &jrpc2.Error{
-						Code:    jrpc2.InternalError,
-						Message: errors.Wrap(err, "couldn't decode error").Error(),
-					}
+				return protocol.SendTransactionResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InternalError,
+					Message: errors.Wrap(err, "couldn't serialize error").Error(),
 				}
+			}
 
-				errorResp.ErrorResultJSON, err = xdr2json.ConvertInterface(errResult)
-				if err != nil {
-					logger.WithField("tx", request.Transaction).
-						WithError(err).Error("Cannot JSONify error result")
+			diagEvents := []xdr.DiagnosticEvent{}
+			err = xdr.SafeUnmarshalBase64(resp.DiagnosticEvents, &diagEvents)
+			if err != nil {
+				logger.WithField("tx", request.Transaction).
+					WithError(err).Error("Cannot decode events")
 
-					return protocol.SendTransactionResponse{}, &jrpc2.Error{
-						Code:    jrpc2.InternalError,
-						Message: errors.Wrap(err, "couldn't serialize error").Error(),
-					}
+				return protocol.SendTransactionResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InternalError,
+					Message: errors.Wrap(err, "couldn't decode events").Error(),
 				}
+			}
 
-				diagEvents := []xdr.DiagnosticEvent{}
-				err = xdr.SafeUnmarshalBase64(resp.DiagnosticEvents, &diagEvents)
+			errorResp.DiagnosticEventsJSON = make([]json.RawMessage, len(diagEvents))
+			for i, event := range diagEvents {
+				errorResp.DiagnosticEventsJSON[i], err = xdr2json.ConvertInterface(event)
 				if err != nil {
 					logger.WithField("tx", request.Transaction).
-						WithError(err).Error("Cannot decode events")
+						WithError(err).Errorf("Cannot decode event %d: %+v", i+1, event)
 
 					return protocol.SendTransactionResponse{}, &jrpc2.Error{
 						Code:    jrpc2.InternalError,
-						Message: errors.Wrap(err, "couldn't decode events").Error(),
-					}
-				}
-
-				errorResp.DiagnosticEventsJSON = make([]json.RawMessage, len(diagEvents))
-				for i, event := range diagEvents {
-					errorResp.DiagnosticEventsJSON[i], err = xdr2json.ConvertInterface(event)
-					if err != nil {
-						logger.WithField("tx", request.Transaction).
-							WithError(err).Errorf("Cannot decode event %d: %+v", i+1, event)
-
-						return protocol.SendTransactionResponse{}, &jrpc2.Error{
-							Code:    jrpc2.InternalError,
-							Message: errors.Wrapf(err, "couldn't decode event #%d", i+1).Error(),
-						}
+						Message: errors.Wrapf(err, "couldn't decode event #%d", i+1).Error(),
 					}
 				}
+			}
 
-			default:
-				events, err := proto.DiagnosticEventsToSlice(resp.DiagnosticEvents)
-				if err != nil {
-					logger.WithField("tx", request.Transaction).Error("Cannot decode diagnostic events:", err)
-					return protocol.SendTransactionResponse{}, &jrpc2.Error{
-						Code:    jrpc2.InternalError,
-						Message: "could not decode diagnostic events",
-					}
+		default:
+			events, err := proto.DiagnosticEventsToSlice(resp.DiagnosticEvents)
+			if err != nil {
+				logger.WithField("tx", request.Transaction).Error("Cannot decode diagnostic events:", err)
+				return protocol.SendTransactionResponse{}, &jrpc2.Error{
+					Code:    jrpc2.InternalError,
+					Message: "could not decode diagnostic events",
 				}
-
-				errorResp.ErrorResultXDR = resp.Error
-				errorResp.DiagnosticEventsXDR = events
 			}
 
-			return errorResp, nil
+			errorResp.ErrorResultXDR = resp.Error
+			errorResp.DiagnosticEventsXDR = events
+		}
 
-		case proto.TXStatusPending, proto.TXStatusDuplicate, proto.TXStatusTryAgainLater:
-			return protocol.SendTransactionResponse{
-				Status:                resp.Status,
-				Hash:                  txHash,
-				LatestLedger:          latestLedgerInfo.Sequence,
-				LatestLedgerCloseTime: latestLedgerInfo.CloseTime,
-			}, nil
+		return errorResp, nil
 
-		default:
-			logger.WithField("status", resp.Status).
-				WithField("tx", request.Transaction).Error("Unrecognized stellar-core status response")
-			return protocol.SendTransactionResponse{}, &jrpc2.Error{
-				Code:    jrpc2.InternalError,
-				Message: "invalid status from stellar-core",
-			}
+	case proto.TXStatusPending, proto.TXStatusDuplicate, proto.TXStatusTryAgainLater:
+		return protocol.SendTransactionResponse{
+			Status:                resp.Status,
+			Hash:                  txHash,
+			LatestLedger:          latestLedgerInfo.Sequence,
+			LatestLedgerCloseTime: latestLedgerInfo.CloseTime,
+		}, nil
+
+	default:
+		logger.WithField("status", resp.Status).
+			WithField("tx", request.Transaction).Error("Unrecognized stellar-core status response")
+		return protocol.SendTransactionResponse{}, &jrpc2.Error{
+			Code:    jrpc2.InternalError,
+			Message: "invalid status from stellar-core",
 		}
-	})
+	}
 }
@@ -0,0 +1,206 @@
+package methods
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	proto "github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func TestIdempotencyCacheExpiry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cache := newIdempotencyCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	resp := protocol.SendTransactionResponse{Status: "PENDING", Hash: "abc"}
+	entry, owner := cache.acquire("key", "tx", protocol.FormatBase64)
+	require.True(t, owner)
+	cache.complete("key", entry, resp, true)
+
+	entry, owner = cache.acquire("key", "tx", protocol.FormatBase64)
+	require.False(t, owner)
+	assert.Equal(t, "tx", entry.transaction)
+	assert.True(t, entry.remembered)
+	assert.Equal(t, resp, entry.response)
+
+	now = now.Add(10 * time.Second)
+	_, owner = cache.acquire("key", "tx", protocol.FormatBase64)
+	assert.False(t, owner)
+
+	now = now.Add(time.Second)
+	_, owner = cache.acquire("key", "tx", protocol.FormatBase64)
+	assert.True(t, owner)
+	assert.Len(t, cache.entries, 1)
+}
+
+func TestIdempotencyCacheForget(t *testing.T) {
+	cache := newIdempotencyCache(10 * time.Second)
+	entry, owner := cache.acquire("key", "tx", protocol.FormatBase64)
+	require.True(t, owner)
+
+	// a duplicate waits for the first submission
+	duplicate, owner := cache.acquire("key", "tx", protocol.FormatBase64)
+	require.False(t, owner)
+	require.Same(t, entry, duplicate)
+
+	cache.complete("key", entry, protocol.SendTransactionResponse{}, false)
+	<-duplicate.done
+	assert.False(t, duplicate.remembered)
+	_, owner = cache.acquire("key", "tx", protocol.FormatBase64)
+	assert.True(t, owner)
+}
+
+func TestIdempotencyCacheLimit(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cache := newIdempotencyCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < maxIdempotencyKeys; i++ {
+		entry, _ := cache.acquire(strconv.Itoa(i), "tx", protocol.FormatBase64)
+		cache.complete(strconv.Itoa(i), entry, protocol.SendTransactionResponse{}, true)
+		now = now.Add(time.Millisecond)
+	}
+	require.Len(t, cache.entries, maxIdempotencyKeys)
+
+	// The cache is full and nothing has expired, so the oldest key is evicted.
+	_, owner := cache.acquire("new", "tx", protocol.FormatBase64)
+	require.True(t, owner)
+	assert.Len(t, cache.entries, maxIdempotencyKeys)
+	assert.NotContains(t, cache.entries, "0")
+	assert.Contains(t, cache.entries, "1")
+
+	// Once the old entries expire they are purged to make room.
+	now = now.Add(time.Minute)
+	_, owner = cache.acquire("newer", "tx", protocol.FormatBase64)
+	require.True(t, owner)
+	assert.Len(t, cache.entries, 1)
+}
+
+func TestIdempotentSendTransaction(t *testing.T) {
+	var submissions atomic.Int32
+	release := make(chan struct{})
+	send := func(context.Context, protocol.SendTransactionRequest) (protocol.SendTransactionResponse, error) {
+		submissions.Add(1)
+		<-release
+		return protocol.SendTransactionResponse{Status: proto.TXStatusPending, Hash: "abc"}, nil
+	}
+	handler := idempotentSendTransaction(newIdempotencyCache(time.Minute), send)
+
+	request := protocol.SendTransactionRequest{Transaction: "tx", IdempotencyKey: "key"}
+	var wg sync.WaitGroup
+	responses := make([]protocol.SendTransactionResponse, 4)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := handler(context.Background(), request)
+			assert.NoError(t, err)
+			responses[i] = resp
+		}()
+	}
+	require.Eventually(t, func() bool { return submissions.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// concurrent submissions with the same key only reach core once
+	assert.Equal(t, int32(1), submissions.Load())
+	for _, resp := range responses {
+		assert.Equal(t, "abc", resp.Hash)
+	}
+
+	// the remembered response is in the format of the first submission
+	request.Format = protocol.FormatJSON
+	_, err := handler(context.Background(), request)
+	var jrpcErr *jrpc2.Error
+	require.ErrorAs(t, err, &jrpcErr)
+	assert.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+	request.Format = protocol.FormatBase64
+	resp, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.Hash)
+	assert.Equal(t, int32(1), submissions.Load())
+
+	request.Transaction = "other"
+	_, err = handler(context.Background(), request)
+	require.Error(t, err)
+}
+
+type flakyCoreClient struct {
+	errs     []error
+	attempts int
+}
+
+func (c *flakyCoreClient) Info(context.Context) (*proto.InfoResponse, error) {
+	return &proto.InfoResponse{}, nil
+}
+
+func (c *flakyCoreClient) SubmitTransaction(context.Context, string) (*proto.TXResponse, error) {
+	c.attempts++
+	if c.attempts <= len(c.errs) {
+		return nil, c.errs[c.attempts-1]
+	}
+	return &proto.TXResponse{Status: proto.TXStatusPending}, nil
+}
+
+func TestSubmitWithRetry(t *testing.T) {
+	refused := fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+	timeout := &url.Error{Op: "Post", URL: "http://core", Err: context.DeadlineExceeded}
+	policy := SubmitRetryPolicy{MaxRetries: 2, Backoff: time.Millisecond, Budget: time.Second}
+
+	// transient errors are retried
+	client := &flakyCoreClient{errs: []error{refused, timeout}}
+	resp, err := submitWithRetry(context.Background(), log.DefaultLogger, client, policy, "tx")
+	require.NoError(t, err)
+	assert.Equal(t, proto.TXStatusPending, resp.Status)
+	assert.Equal(t, 3, client.attempts)
+
+	// up to the maximum amount of retries
+	client = &flakyCoreClient{errs: []error{refused, refused, refused}}
+	_, err = submitWithRetry(context.Background(), log.DefaultLogger, client, policy, "tx")
+	require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	assert.Equal(t, 3, client.attempts)
+
+	// other errors are not retried
+	client = &flakyCoreClient{errs: []error{errors.New("bad response")}}
+	_, err = submitWithRetry(context.Background(), log.DefaultLogger, client, policy, "tx")
+	require.Error(t, err)
+	assert.Equal(t, 1, client.attempts)
+
+	// retries do not exceed the budget
+	client = &flakyCoreClient{errs: []error{refused, refused}}
+	policy.Backoff = time.Second
+	_, err = submitWithRetry(context.Background(), log.DefaultLogger, client, policy, "tx")
+	require.Error(t, err)
+	assert.Equal(t, 1, client.attempts)
+}
+
+func TestCheckTransactionExpiry(t *testing.T) {
+	envelope := txEnvelope(1)
+	// no time bounds
+	require.NoError(t, checkTransactionExpiry(envelope, 1000, time.Second))
+
+	envelope.V1.Tx.Cond = xdr.NewPreconditionsWithTimeBounds(&xdr.TimeBounds{MaxTime: 900})
+	require.Error(t, checkTransactionExpiry(envelope, 1000, time.Second))
+	require.NoError(t, checkTransactionExpiry(envelope, 1000, 2*time.Minute))
+	require.NoError(t, checkTransactionExpiry(envelope, 800, time.Second))
+
+	// no upper time bound
+	envelope.V1.Tx.Cond = xdr.NewPreconditionsWithTimeBounds(&xdr.TimeBounds{MinTime: 900})
+	require.NoError(t, checkTransactionExpiry(envelope, 1000, time.Second))
+}
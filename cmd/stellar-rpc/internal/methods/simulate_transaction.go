@@ -253,33 +253,138 @@ func formatResponse(preflight preflight.Preflight,
 	return simResp, nil
 }
 
-// NewSimulateTransactionHandler returns a JSON rpc handler to run preflight simulations
+// SimulationBudgetLimits bounds the budget overrides (see
+// protocol.ResourceConfig) accepted by simulateTransaction. A zero limit
+// disables the corresponding override.
+type SimulationBudgetLimits struct {
+	MaxInstructionLimit uint64
+	MaxMemoryLimit      uint64
+}
+
+func (l SimulationBudgetLimits) validate(config protocol.ResourceConfig) error {
+	if config.InstructionLimit > l.MaxInstructionLimit {
+		if l.MaxInstructionLimit == 0 {
+			return errors.New("instructionLimit overrides are disabled")
+		}
+		return fmt.Errorf("instructionLimit must not exceed %d", l.MaxInstructionLimit)
+	}
+	if config.MemoryLimit > l.MaxMemoryLimit {
+		if l.MaxMemoryLimit == 0 {
+			return errors.New("memoryLimit overrides are disabled")
+		}
+		return fmt.Errorf("memoryLimit must not exceed %d", l.MaxMemoryLimit)
+	}
+	return nil
+}
+
+// snapshotLedger returns the ledger whose state is queried (e.g. the
+// transaction is simulated against): the latest ledger, unless atLedger is set,
+// in which case it must be one of the snapshotLedgers latest ledgers (whose
+// state is retained by captive core).
+func snapshotLedger(latestLedger, atLedger, snapshotLedgers uint32) (uint32, error) {
+	if atLedger == 0 {
+		return latestLedger, nil
+	}
+	oldestLedger := uint32(1)
+	if latestLedger > snapshotLedgers {
+		oldestLedger = latestLedger - snapshotLedgers + 1
+	}
+	if atLedger < oldestLedger || atLedger > latestLedger {
+		return 0, fmt.Errorf("atLedger must be between %d and %d (the state of older ledgers isn't retained)",
+			oldestLedger, latestLedger)
+	}
+	return atLedger, nil
+}
+
+// The reasons (in the data of the invalid params errors) for rejecting the
+// simulateTransaction envelopes, with the strict validation.
+const (
+	envelopeErrorBase64    = "base64"
+	envelopeErrorXDR       = "xdr"
+	envelopeErrorOperation = "operation"
+)
+
+func invalidEnvelopeError(reason string, message string) *jrpc2.Error {
+	data, _ := json.Marshal(map[string]string{"reason": reason})
+	return &jrpc2.Error{
+		Code:    jrpc2.InvalidParams,
+		Message: "invalid transaction: " + message,
+		Data:    data,
+	}
+}
+
+// decodeSimulationEnvelope decodes the transaction envelope of a
+// simulateTransaction request, after removing its whitespace (e.g. line
+// breaks), and checks that it holds a single operation which can be simulated.
+// The error tells which of the base64 encoding, the XDR or the operation is
+// invalid.
+func decodeSimulationEnvelope(encoded string) (xdr.TransactionEnvelope, error) {
+	var txEnvelope xdr.TransactionEnvelope
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return txEnvelope, invalidEnvelopeError(envelopeErrorBase64, "not valid base64: "+err.Error())
+	}
+	if err := xdr.SafeUnmarshal(raw, &txEnvelope); err != nil {
+		return txEnvelope, invalidEnvelopeError(envelopeErrorXDR, "not a valid TransactionEnvelope: "+err.Error())
+	}
+	operations := txEnvelope.Operations()
+	if len(operations) != 1 {
+		return txEnvelope, invalidEnvelopeError(envelopeErrorOperation,
+			fmt.Sprintf("it must contain a single operation, not %d", len(operations)))
+	}
+	switch opType := operations[0].Body.Type; opType {
+	case xdr.OperationTypeInvokeHostFunction:
+	case xdr.OperationTypeExtendFootprintTtl, xdr.OperationTypeRestoreFootprint:
+		if txEnvelope.Type != xdr.EnvelopeTypeEnvelopeTypeTx || txEnvelope.V1.Tx.Ext.V != 1 {
+			return txEnvelope, invalidEnvelopeError(envelopeErrorOperation,
+				opType.String()+" operations require the SorobanTransactionData to be set")
+		}
+	default:
+		return txEnvelope, invalidEnvelopeError(envelopeErrorOperation,
+			"unsupported operation type: "+opType.String())
+	}
+	return txEnvelope, nil
+}
+
+// NewSimulateTransactionHandler returns a json rpc handler simulating
+// transactions. The transactions can be simulated against the state of the
+// snapshotLedgers latest ledgers. With strictValidation, the malformed
+// transactions are rejected before simulating them, with an invalid params
+// error (see decodeSimulationEnvelope) rather than an error in the response.
 func NewSimulateTransactionHandler(logger *log.Entry,
 	ledgerReader db.LedgerReader,
 	coreClient interfaces.FastCoreClient, getter PreflightGetter,
+	budgetLimits SimulationBudgetLimits,
+	snapshotLedgers uint32,
+	strictValidation bool,
 ) jrpc2.Handler {
 	return NewHandler(func(ctx context.Context, request protocol.SimulateTransactionRequest,
-	) protocol.SimulateTransactionResponse {
+	) (protocol.SimulateTransactionResponse, error) {
 		if err := protocol.IsValidFormat(request.Format); err != nil {
-			return protocol.SimulateTransactionResponse{Error: err.Error()}
+			return protocol.SimulateTransactionResponse{Error: err.Error()}, nil
 		}
 		var txEnvelope xdr.TransactionEnvelope
-		if err := xdr.SafeUnmarshalBase64(request.Transaction, &txEnvelope); err != nil {
+		if strictValidation {
+			var err error
+			if txEnvelope, err = decodeSimulationEnvelope(request.Transaction); err != nil {
+				return protocol.SimulateTransactionResponse{}, err
+			}
+		} else if err := xdr.SafeUnmarshalBase64(request.Transaction, &txEnvelope); err != nil {
 			logger.WithError(err).WithField("request", request).
 				Info("could not unmarshal simulate transaction envelope")
 			return protocol.SimulateTransactionResponse{
 				Error: "Could not unmarshal transaction",
-			}
+			}, nil
 		}
 		if len(txEnvelope.Operations()) != 1 {
 			return protocol.SimulateTransactionResponse{
 				Error: "Transaction contains more than one operation",
-			}
+			}, nil
 		}
 		op := txEnvelope.Operations()[0]
 
 		if err := validateAuthMode(op.Body, &request.AuthMode); err != nil {
-			return protocol.SimulateTransactionResponse{Error: err.Error()}
+			return protocol.SimulateTransactionResponse{Error: err.Error()}, nil
 		}
 
 		var sourceAccount xdr.AccountId
@@ -297,35 +402,48 @@ func NewSimulateTransactionHandler(logger *log.Entry,
 				return protocol.SimulateTransactionResponse{
 					Error: "To perform a SimulateTransaction for ExtendFootprintTtl or RestoreFootprint operations," +
 						" SorobanTransactionData must be provided",
-				}
+				}, nil
 			}
 			footprint = txEnvelope.V1.Tx.Ext.SorobanData.Resources.Footprint
 
 		default:
 			return protocol.SimulateTransactionResponse{
 				Error: "Transaction contains unsupported operation type: " + op.Body.Type.String(),
-			}
+			}, nil
 		}
 
 		latestLedger, err := ledgerReader.GetLatestLedgerSequence(ctx)
 		if err != nil {
 			return protocol.SimulateTransactionResponse{
 				Error: err.Error(),
-			}
+			}, nil
 		}
-		bucketListSize, protocolVersion, err := getBucketListSizeAndProtocolVersion(ctx, ledgerReader, latestLedger)
+		ledger, err := snapshotLedger(latestLedger, request.AtLedger, snapshotLedgers)
 		if err != nil {
 			return protocol.SimulateTransactionResponse{
 				Error:        err.Error(),
 				LatestLedger: latestLedger,
-			}
+			}, nil
+		}
+		bucketListSize, protocolVersion, err := getBucketListSizeAndProtocolVersion(ctx, ledgerReader, ledger)
+		if err != nil {
+			return protocol.SimulateTransactionResponse{
+				Error:        err.Error(),
+				LatestLedger: latestLedger,
+			}, nil
 		}
 
 		resourceConfig := protocol.DefaultResourceConfig()
 		if request.ResourceConfig != nil {
 			resourceConfig = *request.ResourceConfig
 		}
-		ledgerEntryGetter := ledgerentries.NewLedgerEntryAtGetter(coreClient, latestLedger)
+		if err := budgetLimits.validate(resourceConfig); err != nil {
+			return protocol.SimulateTransactionResponse{
+				Error:        err.Error(),
+				LatestLedger: latestLedger,
+			}, nil
+		}
+		ledgerEntryGetter := ledgerentries.NewLedgerEntryAtGetter(coreClient, ledger)
 
 		params := preflight.GetterParameters{
 			BucketListSize:    bucketListSize,
@@ -336,14 +454,14 @@ func NewSimulateTransactionHandler(logger *log.Entry,
 			AuthMode:          request.AuthMode,
 			ProtocolVersion:   protocolVersion,
 			LedgerEntryGetter: ledgerEntryGetter,
-			LedgerSeq:         latestLedger,
+			LedgerSeq:         ledger,
 		}
 		result, err := getter.GetPreflight(ctx, params)
 		if err != nil {
 			return protocol.SimulateTransactionResponse{
 				Error:        err.Error(),
 				LatestLedger: latestLedger,
-			}
+			}, nil
 		}
 
 		simResp, err := formatResponse(result, request.Format, latestLedger)
@@ -351,9 +469,9 @@ func NewSimulateTransactionHandler(logger *log.Entry,
 			return protocol.SimulateTransactionResponse{
 				Error:        err.Error(),
 				LatestLedger: latestLedger,
-			}
+			}, nil
 		}
-		return simResp
+		return simResp, nil
 	})
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/creachadair/jrpc2"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stellar/go/xdr"
@@ -112,3 +113,77 @@ func TestLedgerEntryChange(t *testing.T) {
 		}
 	}
 }
+
+func TestSimulationBudgetLimits(t *testing.T) {
+	limits := SimulationBudgetLimits{MaxInstructionLimit: 1000, MaxMemoryLimit: 2000}
+	require.NoError(t, limits.validate(protocol.DefaultResourceConfig()))
+	require.NoError(t, limits.validate(protocol.ResourceConfig{InstructionLimit: 1000, MemoryLimit: 2000}))
+	require.EqualError(t, limits.validate(protocol.ResourceConfig{InstructionLimit: 1001}),
+		"instructionLimit must not exceed 1000")
+	require.EqualError(t, limits.validate(protocol.ResourceConfig{MemoryLimit: 2001}),
+		"memoryLimit must not exceed 2000")
+
+	limits = SimulationBudgetLimits{}
+	require.NoError(t, limits.validate(protocol.DefaultResourceConfig()))
+	require.EqualError(t, limits.validate(protocol.ResourceConfig{InstructionLimit: 1}),
+		"instructionLimit overrides are disabled")
+}
+
+func TestSnapshotLedger(t *testing.T) {
+	for _, tc := range []struct {
+		latestLedger, atLedger, snapshotLedgers uint32
+		expected                                uint32
+	}{
+		{100, 0, 4, 100},
+		{100, 100, 4, 100},
+		{100, 97, 4, 97},
+		{3, 1, 4, 1},
+	} {
+		ledger, err := snapshotLedger(tc.latestLedger, tc.atLedger, tc.snapshotLedgers)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, ledger)
+	}
+
+	_, err := snapshotLedger(100, 96, 4)
+	require.EqualError(t, err, "atLedger must be between 97 and 100 (the state of older ledgers isn't retained)")
+	_, err = snapshotLedger(100, 101, 4)
+	require.Error(t, err)
+}
+
+func TestDecodeSimulationEnvelope(t *testing.T) {
+	envelope := txEnvelope(1)
+	envelope.V1.Tx.Operations = []xdr.Operation{{Body: xdr.OperationBody{
+		Type:               xdr.OperationTypeRestoreFootprint,
+		RestoreFootprintOp: &xdr.RestoreFootprintOp{},
+	}}}
+	encoded, err := xdr.MarshalBase64(envelope)
+	require.NoError(t, err)
+
+	// the whitespace is ignored
+	decoded, err := decodeSimulationEnvelope(encoded[:10] + "\n" + encoded[10:] + " ")
+	require.NoError(t, err)
+	require.Equal(t, xdr.OperationTypeRestoreFootprint, decoded.Operations()[0].Body.Type)
+
+	requireReason := func(encoded string, reason string) {
+		_, err := decodeSimulationEnvelope(encoded)
+		var jrpcErr *jrpc2.Error
+		require.ErrorAs(t, err, &jrpcErr)
+		require.Equal(t, jrpc2.InvalidParams, jrpcErr.Code)
+		require.JSONEq(t, `{"reason": "`+reason+`"}`, string(jrpcErr.Data))
+	}
+	requireReason("not base64!", envelopeErrorBase64)
+	requireReason(base64.StdEncoding.EncodeToString([]byte("not xdr")), envelopeErrorXDR)
+
+	envelope.V1.Tx.Ext = xdr.TransactionExt{}
+	encoded, err = xdr.MarshalBase64(envelope)
+	require.NoError(t, err)
+	requireReason(encoded, envelopeErrorOperation)
+
+	envelope.V1.Tx.Operations = []xdr.Operation{{Body: xdr.OperationBody{
+		Type:           xdr.OperationTypeBumpSequence,
+		BumpSequenceOp: &xdr.BumpSequenceOp{},
+	}}}
+	encoded, err = xdr.MarshalBase64(envelope)
+	require.NoError(t, err)
+	requireReason(encoded, envelopeErrorOperation)
+}
@@ -0,0 +1,92 @@
+package methods
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stellar/go/support/log"
+)
+
+// maxSubmittedTransactions bounds the amount of memory used to track the
+// submitted transactions. Once reached, the oldest transactions are evicted to
+// make room for the new ones.
+const maxSubmittedTransactions = 10_000
+
+type submittedTransaction struct {
+	hash      string
+	expiresAt time.Time
+}
+
+// SubmittedTransactions tracks the hashes of the transactions recently
+// accepted by stellar-core through sendTransaction, so that getTransaction
+// can report them as pending (instead of not found) until they are ingested.
+//
+// A nil *SubmittedTransactions tracks nothing.
+type SubmittedTransactions struct {
+	window time.Duration
+	logger *log.Entry
+	// expiration times by transaction hash
+	hashes map[string]time.Time
+	// the additions, oldest first. Since the window is fixed, they expire in
+	// order. An addition is stale if its hash was removed or added again.
+	additions []submittedTransaction
+	now       func() time.Time
+	lock      sync.Mutex
+}
+
+// NewSubmittedTransactions returns a tracker remembering the submitted
+// transactions for the given window.
+func NewSubmittedTransactions(window time.Duration, logger *log.Entry) *SubmittedTransactions {
+	return &SubmittedTransactions{
+		window: window,
+		logger: logger,
+		hashes: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+func (s *SubmittedTransactions) add(hash string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	for len(s.additions) > 0 {
+		oldest := s.additions[0]
+		expired := now.After(oldest.expiresAt)
+		if !expired && len(s.additions) < maxSubmittedTransactions {
+			break
+		}
+		s.additions = s.additions[1:]
+		if expiresAt, ok := s.hashes[oldest.hash]; !ok || !expiresAt.Equal(oldest.expiresAt) {
+			continue
+		}
+		delete(s.hashes, oldest.hash)
+		if !expired {
+			s.logger.WithField("hash", oldest.hash).
+				Debug("too many submitted transactions, the oldest one is no longer tracked as pending")
+		}
+	}
+	expiresAt := now.Add(s.window)
+	s.hashes[hash] = expiresAt
+	s.additions = append(s.additions, submittedTransaction{hash: hash, expiresAt: expiresAt})
+}
+
+// pending tells whether the transaction was submitted within the window.
+func (s *SubmittedTransactions) pending(hash string) bool {
+	if s == nil {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	expiresAt, ok := s.hashes[hash]
+	if !ok {
+		return false
+	}
+	if s.now().After(expiresAt) {
+		delete(s.hashes, hash)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package methods
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/support/log"
+)
+
+func TestSubmittedTransactions(t *testing.T) {
+	var disabled *SubmittedTransactions
+	disabled.add("abc")
+	assert.False(t, disabled.pending("abc"))
+
+	now := time.Unix(1000, 0)
+	submitted := NewSubmittedTransactions(10*time.Second, log.DefaultLogger)
+	submitted.now = func() time.Time { return now }
+	submitted.add("abc")
+	assert.True(t, submitted.pending("abc"))
+	assert.False(t, submitted.pending("def"))
+
+	now = now.Add(10 * time.Second)
+	assert.True(t, submitted.pending("abc"))
+	now = now.Add(time.Second)
+	assert.False(t, submitted.pending("abc"))
+	assert.Empty(t, submitted.hashes)
+}
+
+func TestSubmittedTransactionsLimit(t *testing.T) {
+	now := time.Unix(1000, 0)
+	submitted := NewSubmittedTransactions(10*time.Second, log.DefaultLogger)
+	submitted.now = func() time.Time { return now }
+	for i := 0; i < maxSubmittedTransactions; i++ {
+		submitted.add(strconv.Itoa(i))
+		now = now.Add(time.Millisecond)
+	}
+	// re-adding a hash extends its window
+	submitted.add("0")
+	assert.Len(t, submitted.hashes, maxSubmittedTransactions)
+
+	// nothing has expired, so the oldest hash is evicted
+	submitted.add("new")
+	assert.Len(t, submitted.hashes, maxSubmittedTransactions)
+	assert.True(t, submitted.pending("new"))
+	assert.True(t, submitted.pending("0"))
+	assert.False(t, submitted.pending("1"))
+	assert.True(t, submitted.pending("2"))
+	assert.LessOrEqual(t, len(submitted.additions), maxSubmittedTransactions)
+
+	// once the old hashes expire they are purged
+	now = now.Add(time.Minute)
+	submitted.add("newer")
+	assert.Len(t, submitted.hashes, 1)
+	assert.Len(t, submitted.additions, 1)
+	assert.True(t, submitted.pending("newer"))
+}
@@ -0,0 +1,131 @@
+package methods
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/xdr2json"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+func bigIntFromParts(hi *big.Int, lowerParts ...xdr.Uint64) *big.Int {
+	result := new(big.Int).Set(hi)
+	for _, part := range lowerParts {
+		result.Lsh(result, 64) //nolint:mnd
+		result.Or(result, new(big.Int).SetUint64(uint64(part)))
+	}
+	return result
+}
+
+// typedScValFallback returns the xdr2json representation of the ScVal,
+// without the type wrapping it.
+func typedScValFallback(typeName string, val xdr.ScVal) (protocol.TypedScVal, error) {
+	raw, err := xdr2json.ConvertInterface(val)
+	if err != nil {
+		return protocol.TypedScVal{}, err
+	}
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		// not wrapped (e.g. a unit variant), use it as is
+		return protocol.TypedScVal{Type: typeName, Value: raw}, nil //nolint:nilerr
+	}
+	for _, value := range wrapped {
+		return protocol.TypedScVal{Type: typeName, Value: value}, nil
+	}
+	return protocol.TypedScVal{Type: typeName, Value: raw}, nil
+}
+
+// decodeTypedScVal decodes an ScVal into its type tag and native JSON value.
+//
+//nolint:cyclop,funlen
+func decodeTypedScVal(val xdr.ScVal) (protocol.TypedScVal, error) {
+	switch val.Type {
+	case xdr.ScValTypeScvBool:
+		return protocol.TypedScVal{Type: "bool", Value: val.MustB()}, nil
+	case xdr.ScValTypeScvVoid:
+		return protocol.TypedScVal{Type: "void"}, nil
+	case xdr.ScValTypeScvU32:
+		return protocol.TypedScVal{Type: "u32", Value: uint32(val.MustU32())}, nil
+	case xdr.ScValTypeScvI32:
+		return protocol.TypedScVal{Type: "i32", Value: int32(val.MustI32())}, nil
+	case xdr.ScValTypeScvU64:
+		return protocol.TypedScVal{Type: "u64", Value: strconv.FormatUint(uint64(val.MustU64()), 10)}, nil
+	case xdr.ScValTypeScvI64:
+		return protocol.TypedScVal{Type: "i64", Value: strconv.FormatInt(int64(val.MustI64()), 10)}, nil
+	case xdr.ScValTypeScvTimepoint:
+		return protocol.TypedScVal{Type: "timepoint", Value: strconv.FormatUint(uint64(val.MustTimepoint()), 10)}, nil
+	case xdr.ScValTypeScvDuration:
+		return protocol.TypedScVal{Type: "duration", Value: strconv.FormatUint(uint64(val.MustDuration()), 10)}, nil
+	case xdr.ScValTypeScvU128:
+		parts := val.MustU128()
+		value := bigIntFromParts(new(big.Int).SetUint64(uint64(parts.Hi)), parts.Lo)
+		return protocol.TypedScVal{Type: "u128", Value: value.String()}, nil
+	case xdr.ScValTypeScvI128:
+		parts := val.MustI128()
+		value := bigIntFromParts(big.NewInt(int64(parts.Hi)), parts.Lo)
+		return protocol.TypedScVal{Type: "i128", Value: value.String()}, nil
+	case xdr.ScValTypeScvU256:
+		parts := val.MustU256()
+		value := bigIntFromParts(new(big.Int).SetUint64(uint64(parts.HiHi)), parts.HiLo, parts.LoHi, parts.LoLo)
+		return protocol.TypedScVal{Type: "u256", Value: value.String()}, nil
+	case xdr.ScValTypeScvI256:
+		parts := val.MustI256()
+		value := bigIntFromParts(big.NewInt(int64(parts.HiHi)), parts.HiLo, parts.LoHi, parts.LoLo)
+		return protocol.TypedScVal{Type: "i256", Value: value.String()}, nil
+	case xdr.ScValTypeScvBytes:
+		return protocol.TypedScVal{Type: "bytes", Value: hex.EncodeToString(val.MustBytes())}, nil
+	case xdr.ScValTypeScvString:
+		return protocol.TypedScVal{Type: "string", Value: string(val.MustStr())}, nil
+	case xdr.ScValTypeScvSymbol:
+		return protocol.TypedScVal{Type: "symbol", Value: string(val.MustSym())}, nil
+	case xdr.ScValTypeScvAddress:
+		address, err := val.MustAddress().String()
+		if err != nil {
+			return protocol.TypedScVal{}, err
+		}
+		return protocol.TypedScVal{Type: "address", Value: address}, nil
+	case xdr.ScValTypeScvVec:
+		elements := []protocol.TypedScVal{}
+		if vec := val.MustVec(); vec != nil {
+			for _, element := range *vec {
+				decoded, err := decodeTypedScVal(element)
+				if err != nil {
+					return protocol.TypedScVal{}, err
+				}
+				elements = append(elements, decoded)
+			}
+		}
+		return protocol.TypedScVal{Type: "vec", Value: elements}, nil
+	case xdr.ScValTypeScvMap:
+		entries := []protocol.TypedScMapEntry{}
+		if m := val.MustMap(); m != nil {
+			for _, entry := range *m {
+				key, err := decodeTypedScVal(entry.Key)
+				if err != nil {
+					return protocol.TypedScVal{}, err
+				}
+				value, err := decodeTypedScVal(entry.Val)
+				if err != nil {
+					return protocol.TypedScVal{}, err
+				}
+				entries = append(entries, protocol.TypedScMapEntry{Key: key, Value: value})
+			}
+		}
+		return protocol.TypedScVal{Type: "map", Value: entries}, nil
+	case xdr.ScValTypeScvError:
+		return typedScValFallback("error", val)
+	case xdr.ScValTypeScvContractInstance:
+		return typedScValFallback("contract_instance", val)
+	case xdr.ScValTypeScvLedgerKeyContractInstance:
+		return typedScValFallback("ledger_key_contract_instance", val)
+	case xdr.ScValTypeScvLedgerKeyNonce:
+		return typedScValFallback("ledger_key_nonce", val)
+	default:
+		return protocol.TypedScVal{}, fmt.Errorf("unknown ScVal type: %d", val.Type)
+	}
+}
@@ -0,0 +1,47 @@
+package methods
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+)
+
+func TestDecodeTypedScVal(t *testing.T) {
+	symbol := xdr.ScSymbol("transfer")
+	i128 := xdr.Int128Parts{Hi: -1, Lo: 0}
+	u128 := xdr.UInt128Parts{Hi: 1, Lo: 2}
+	contractID := xdr.ContractId{}
+	address := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID}
+	bytes := xdr.ScBytes{0xde, 0xad}
+	key := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &symbol}
+	vec := xdr.ScVec{
+		{Type: xdr.ScValTypeScvI128, I128: &i128},
+		{Type: xdr.ScValTypeScvU128, U128: &u128},
+		{Type: xdr.ScValTypeScvAddress, Address: &address},
+		{Type: xdr.ScValTypeScvBytes, Bytes: &bytes},
+	}
+	vecPtr := &vec
+	scMap := &xdr.ScMap{{Key: key, Val: xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &vecPtr}}}
+	val := xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &scMap}
+
+	typed, err := decodeTypedScVal(val)
+	require.NoError(t, err)
+	encoded, err := json.Marshal(typed)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "map",
+		"value": [{
+			"key": {"type": "symbol", "value": "transfer"},
+			"value": {"type": "vec", "value": [
+				{"type": "i128", "value": "-18446744073709551616"},
+				{"type": "u128", "value": "18446744073709551618"},
+				{"type": "address", "value": "CAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABSC4"},
+				{"type": "bytes", "value": "dead"}
+			]}
+		}]
+	}`, string(encoded))
+}
@@ -2,8 +2,13 @@ package methods
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stellar/go/xdr"
+
 	"github.com/stellar/stellar-rpc/cmd/stellar-rpc/internal/db"
 )
 
@@ -15,21 +20,59 @@ func getProtocolVersion(
 	if err != nil {
 		return 0, err
 	}
+	return getLedgerProtocolVersion(ctx, ledgerReader, latestLedger)
+}
 
-	// obtain bucket size
-	closeMeta, ok, err := ledgerReader.GetLedger(ctx, latestLedger)
+// getLedgerProtocolVersion returns the protocol version of the given (stored) ledger.
+func getLedgerProtocolVersion(
+	ctx context.Context,
+	ledgerReader db.LedgerReader,
+	latestLedger uint32,
+) (uint32, error) {
+	header, err := getLedgerHeader(ctx, ledgerReader, latestLedger)
 	if err != nil {
 		return 0, err
 	}
+	return uint32(header.LedgerVersion), nil
+}
+
+// getLedgerHeader returns the header of the given (stored) ledger.
+func getLedgerHeader(
+	ctx context.Context,
+	ledgerReader db.LedgerReader,
+	latestLedger uint32,
+) (xdr.LedgerHeader, error) {
+	closeMeta, ok, err := ledgerReader.GetLedger(ctx, latestLedger)
+	if err != nil {
+		return xdr.LedgerHeader{}, err
+	}
 	if !ok {
-		return 0, fmt.Errorf("missing meta for latest ledger (%d)", latestLedger)
+		return xdr.LedgerHeader{}, fmt.Errorf("missing meta for latest ledger (%d)", latestLedger)
 	}
 	switch closeMeta.V {
 	case 1:
-		return uint32(closeMeta.V1.LedgerHeader.Header.LedgerVersion), nil
+		return closeMeta.V1.LedgerHeader.Header, nil
 	case 2:
-		return uint32(closeMeta.V2.LedgerHeader.Header.LedgerVersion), nil
+		return closeMeta.V2.LedgerHeader.Header, nil
 	default:
-		return 0, fmt.Errorf("latest ledger (%d) meta has unexpected version (%d)", latestLedger, closeMeta.V)
+		return xdr.LedgerHeader{}, fmt.Errorf("latest ledger (%d) meta has unexpected version (%d)",
+			latestLedger, closeMeta.V)
+	}
+}
+
+// metaHash returns the hex-encoded SHA-256 of a binary LedgerCloseMeta, which
+// clients can use to check the meta against other data sources.
+func metaHash(closeMetaB []byte) string {
+	hash := sha256.Sum256(closeMetaB)
+	return hex.EncodeToString(hash[:])
+}
+
+// observeQueryAge records, in queryAge (if set), the age of the data requested
+// by a query starting at startLedger, i.e. the number of ledgers between
+// startLedger and the latest ledger.
+func observeQueryAge(queryAge prometheus.Observer, latestLedger uint32, startLedger uint32) {
+	if queryAge == nil || startLedger > latestLedger {
+		return
 	}
+	queryAge.Observe(float64(latestLedger - startLedger))
 }
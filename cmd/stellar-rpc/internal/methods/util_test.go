@@ -25,7 +25,8 @@ func BenchmarkGetProtocolVersion(b *testing.B) {
 	assert.False(b, exists)
 
 	ledgerSequence := uint32(1)
-	tx, err := db.NewReadWriter(log.DefaultLogger, dbx, daemon, 150, 15, "passphrase").NewTx(context.Background())
+	tx, err := db.NewReadWriter(log.DefaultLogger, dbx, daemon, 150, 15, "passphrase", db.EventStorage{}, false, false).
+		NewTx(context.Background())
 	require.NoError(b, err)
 	ledgerCloseMeta := createMockLedgerCloseMeta(ledgerSequence)
 	require.NoError(b, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
@@ -50,7 +51,8 @@ func TestGetProtocolVersion(t *testing.T) {
 	assert.False(t, exists)
 
 	ledgerSequence := uint32(1)
-	tx, err := db.NewReadWriter(log.DefaultLogger, dbx, daemon, 150, 15, "passphrase").NewTx(context.Background())
+	tx, err := db.NewReadWriter(log.DefaultLogger, dbx, daemon, 150, 15, "passphrase", db.EventStorage{}, false, false).
+		NewTx(context.Background())
 	require.NoError(t, err)
 	ledgerCloseMeta := createMockLedgerCloseMeta(ledgerSequence)
 	require.NoError(t, tx.LedgerWriter().InsertLedger(ledgerCloseMeta))
@@ -90,3 +92,22 @@ func NewTestDB(tb testing.TB) *db.DB {
 	})
 	return dbConn
 }
+
+type recordingObserver struct {
+	values []float64
+}
+
+func (o *recordingObserver) Observe(value float64) {
+	o.values = append(o.values, value)
+}
+
+func TestObserveQueryAge(t *testing.T) {
+	observer := &recordingObserver{}
+	observeQueryAge(observer, 100, 40)
+	observeQueryAge(observer, 100, 100)
+	// the queries starting after the latest ledger aren't recorded
+	observeQueryAge(observer, 100, 101)
+	assert.Equal(t, []float64{60, 0}, observer.values)
+
+	observeQueryAge(nil, 100, 40)
+}
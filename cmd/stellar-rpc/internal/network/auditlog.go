@@ -0,0 +1,269 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+)
+
+// auditLogBufferSize is the number of records which can be waiting to be
+// written before new ones are dropped.
+const auditLogBufferSize = 4096
+
+// AuditLogger records every http request served (its time, client, JSON-RPC
+// calls and, optionally, the request and response bodies) and every JSON-RPC
+// call handled (its time, request id, method and status) to a dedicated
+// output, independently of the log level.
+//
+// The records are written asynchronously, so a slow output doesn't slow the
+// requests down. Instead, the records are dropped when the output can't keep
+// up.
+//
+// The http requests are recorded apart from the calls because the JSON-RPC
+// handlers have no access to the http request (and, thus, to the client IP),
+// and because some requests are rejected before reaching them.
+type AuditLogger struct {
+	out              io.WriteCloser
+	clientIPResolver ClientIPResolver
+	includeBodies    bool
+	droppedCounter   increasingCounter
+	logger           *log.Entry
+
+	lock    sync.RWMutex
+	closed  bool
+	records chan auditRecord
+	done    chan struct{}
+}
+
+const (
+	auditEntryTypeHTTP = "http"
+	auditEntryTypeCall = "call"
+)
+
+// auditRecord is a record waiting to be encoded, away from the request.
+type auditRecord interface {
+	entry(includeBodies bool) any
+}
+
+type httpAuditRecord struct {
+	time         time.Time
+	client       string
+	statusCode   int
+	duration     time.Duration
+	requestBody  []byte
+	responseBody []byte
+}
+
+type callAuditRecord struct {
+	time      time.Time
+	requestID string
+	method    string
+	status    string
+	duration  time.Duration
+}
+
+type auditCall struct {
+	ID     string `json:"id,omitempty"`
+	Method string `json:"method"`
+}
+
+type auditEntry struct {
+	Type     string      `json:"type"`
+	Time     string      `json:"time"`
+	Client   string      `json:"client"`
+	Status   int         `json:"status"`
+	Duration string      `json:"duration"`
+	Calls    []auditCall `json:"calls,omitempty"`
+	Request  any         `json:"request,omitempty"`
+	Response any         `json:"response,omitempty"`
+}
+
+type auditCallEntry struct {
+	Type string `json:"type"`
+	Time string `json:"time"`
+	// RequestID is the id of the call in the RequestRegistry (and the logs)
+	RequestID string `json:"requestId"`
+	Method    string `json:"method"`
+	Status    string `json:"status"`
+	Duration  string `json:"duration"`
+}
+
+// NewAuditLogger creates an AuditLogger writing JSON lines to out, which is
+// closed when the AuditLogger is.
+func NewAuditLogger(
+	out io.WriteCloser,
+	clientIPResolver ClientIPResolver,
+	includeBodies bool,
+	droppedCounter increasingCounter,
+	logger *log.Entry,
+) *AuditLogger {
+	a := &AuditLogger{
+		out:              out,
+		clientIPResolver: clientIPResolver,
+		includeBodies:    includeBodies,
+		droppedCounter:   droppedCounter,
+		logger:           logger,
+		records:          make(chan auditRecord, auditLogBufferSize),
+		done:             make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AuditLogger) run() {
+	defer close(a.done)
+	for record := range a.records {
+		line, err := json.Marshal(record.entry(a.includeBodies))
+		if err != nil {
+			a.logger.WithError(err).Warn("could not encode audit record")
+			continue
+		}
+		if _, err := a.out.Write(append(line, '\n')); err != nil {
+			a.logger.WithError(err).Warn("could not write audit record")
+		}
+	}
+}
+
+// jsonBody returns the body as raw JSON when it is valid JSON and as a string
+// otherwise, so that it can be embedded in the record.
+func jsonBody(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	return string(body)
+}
+
+func (record httpAuditRecord) entry(includeBodies bool) any {
+	entry := auditEntry{
+		Type:     auditEntryTypeHTTP,
+		Time:     record.time.UTC().Format(time.RFC3339Nano),
+		Client:   record.client,
+		Status:   record.statusCode,
+		Duration: record.duration.String(),
+	}
+	// an invalid body is rejected by the server, so there are no calls to record
+	if requests, err := jrpc2.ParseRequests(record.requestBody); err == nil {
+		for _, request := range requests {
+			entry.Calls = append(entry.Calls, auditCall{ID: request.ID, Method: request.Method})
+		}
+	}
+	if includeBodies {
+		entry.Request = jsonBody(record.requestBody)
+		entry.Response = jsonBody(record.responseBody)
+	}
+	return entry
+}
+
+func (record callAuditRecord) entry(bool) any {
+	return auditCallEntry{
+		Type:      auditEntryTypeCall,
+		Time:      record.time.UTC().Format(time.RFC3339Nano),
+		RequestID: record.requestID,
+		Method:    record.method,
+		Status:    record.status,
+		Duration:  record.duration.String(),
+	}
+}
+
+// RecordCall records a JSON-RPC call, with the id it is logged with. It is a
+// no-op on a nil AuditLogger.
+func (a *AuditLogger) RecordCall(requestID string, method string, status string, startTime time.Time,
+	duration time.Duration,
+) {
+	if a == nil {
+		return
+	}
+	a.record(callAuditRecord{
+		time:      startTime,
+		requestID: requestID,
+		method:    method,
+		status:    status,
+		duration:  duration,
+	})
+}
+
+func (a *AuditLogger) record(record auditRecord) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.records <- record:
+	default:
+		a.droppedCounter.Inc()
+	}
+}
+
+// Close writes the pending records and closes the output.
+func (a *AuditLogger) Close() error {
+	a.lock.Lock()
+	if a.closed {
+		a.lock.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.records)
+	a.lock.Unlock()
+	<-a.done
+	return a.out.Close()
+}
+
+type auditResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	// body is nil when the response body isn't recorded
+	body *bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *auditResponseWriter) Write(p []byte) (int, error) {
+	if w.body != nil {
+		w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Handler returns a handler which records the requests served by downstream.
+func (a *AuditLogger) Handler(downstream http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		startTime := time.Now()
+		var requestBody bytes.Buffer
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(req.Body, &requestBody), req.Body}
+		writer := &auditResponseWriter{ResponseWriter: res, statusCode: http.StatusOK}
+		if a.includeBodies {
+			writer.body = &bytes.Buffer{}
+		}
+
+		downstream.ServeHTTP(writer, req)
+
+		record := httpAuditRecord{
+			time:        startTime,
+			client:      a.clientIPResolver.ClientIP(req),
+			statusCode:  writer.statusCode,
+			duration:    time.Since(startTime),
+			requestBody: requestBody.Bytes(),
+		}
+		if writer.body != nil {
+			record.responseBody = writer.body.Bytes()
+		}
+		a.record(record)
+	})
+}
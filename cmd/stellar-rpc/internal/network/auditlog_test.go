@@ -0,0 +1,145 @@
+package network
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditEntries[T any](t *testing.T, path string) []T {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var entries []T
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry T
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+func TestAuditLogger(t *testing.T) {
+	downstream := &TestingHandlerWrapper{f: func(res http.ResponseWriter, req *http.Request) {
+		_, err := io.ReadAll(req.Body)
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = res.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}}
+	for _, includeBodies := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		out, err := OpenRotatingFile(path, 1024*1024, 1)
+		require.NoError(t, err)
+		counter := &TestingCounter{}
+		auditLogger := NewAuditLogger(out, NewClientIPResolver("", nil), includeBodies, counter,
+			makeTestLogCounter().Entry())
+
+		req := httptest.NewRequest(http.MethodPost, "/",
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"getHealth"}`))
+		req.RemoteAddr = "1.2.3.4:1234"
+		recorder := httptest.NewRecorder()
+		auditLogger.Handler(downstream).ServeHTTP(recorder, req)
+		assert.Equal(t, `{"jsonrpc":"2.0","id":1,"result":"ok"}`, recorder.Body.String())
+		require.NoError(t, auditLogger.Close())
+
+		entries := readAuditEntries[auditEntry](t, path)
+		require.Len(t, entries, 1)
+		assert.Equal(t, auditEntryTypeHTTP, entries[0].Type)
+		assert.Equal(t, "1.2.3.4", entries[0].Client)
+		assert.Equal(t, http.StatusOK, entries[0].Status)
+		assert.Equal(t, []auditCall{{ID: "1", Method: "getHealth"}}, entries[0].Calls)
+		if includeBodies {
+			assert.Equal(t, map[string]any{"jsonrpc": "2.0", "id": 1.0, "method": "getHealth"}, entries[0].Request)
+			assert.Equal(t, map[string]any{"jsonrpc": "2.0", "id": 1.0, "result": "ok"}, entries[0].Response)
+		} else {
+			assert.Nil(t, entries[0].Request)
+			assert.Nil(t, entries[0].Response)
+		}
+		assert.Zero(t, counter.count)
+	}
+}
+
+func TestAuditLoggerRecordCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	out, err := OpenRotatingFile(path, 1024*1024, 1)
+	require.NoError(t, err)
+	auditLogger := NewAuditLogger(out, NewClientIPResolver("", nil), true, &TestingCounter{},
+		makeTestLogCounter().Entry())
+	startTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	auditLogger.RecordCall("42", "getHealth", "ok", startTime, time.Second)
+	require.NoError(t, auditLogger.Close())
+
+	assert.Equal(t, []auditCallEntry{{
+		Type:      auditEntryTypeCall,
+		Time:      "2024-01-02T03:04:05Z",
+		RequestID: "42",
+		Method:    "getHealth",
+		Status:    "ok",
+		Duration:  "1s",
+	}}, readAuditEntries[auditCallEntry](t, path))
+
+	// the calls aren't recorded without an audit log
+	var disabled *AuditLogger
+	disabled.RecordCall("42", "getHealth", "ok", startTime, time.Second)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	file, err := OpenRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	for suffix, expected := range map[string]string{
+		"":   "dddddd\n",
+		".1": "cccccc\n",
+		".2": "bbbbbb\n",
+	} {
+		content, err := os.ReadFile(path + suffix)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	// reopening appends to the existing file
+	file, err = OpenRotatingFile(path, 20, 2)
+	require.NoError(t, err)
+	_, err = file.Write([]byte("eeeeee\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "dddddd\neeeeee\n", string(content))
+}
+
+func TestRotatingFileFailedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	file, err := OpenRotatingFile(path, 10, 1)
+	require.NoError(t, err)
+	_, err = file.Write([]byte("aaaaaa\n"))
+	require.NoError(t, err)
+
+	// the file can't be moved to its backup, so it is kept in use
+	require.NoError(t, os.Remove(path))
+	_, err = file.Write([]byte("bbbbbb\n"))
+	require.ErrorContains(t, err, "could not rotate")
+	require.NoError(t, file.Close())
+}
@@ -1,8 +1,10 @@
 package network
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"sync/atomic"
@@ -30,9 +32,47 @@ type backlogQLimiter struct {
 	logger       *log.Entry
 }
 
+// RequestPriority is the priority of a request in the global backlog queue.
+// When the queue nears its limit, the requests of a higher priority are still
+// admitted after the ones of a lower priority start being rejected.
+type RequestPriority int
+
+const (
+	RequestPriorityLow RequestPriority = iota
+	RequestPriorityNormal
+	RequestPriorityHigh
+)
+
+// requestPriorityLimitPercents is the percentage of the queue limit which can be
+// filled by the requests of each priority, reserving the rest of the queue for
+// the higher priorities.
+var requestPriorityLimitPercents = map[RequestPriority]uint64{
+	RequestPriorityLow:    80,
+	RequestPriorityNormal: 90,
+	RequestPriorityHigh:   100,
+}
+
+// ParseRequestPriority parses a priority name (low, normal or high).
+func ParseRequestPriority(name string) (RequestPriority, error) {
+	switch name {
+	case "low":
+		return RequestPriorityLow, nil
+	case "normal":
+		return RequestPriorityNormal, nil
+	case "high":
+		return RequestPriorityHigh, nil
+	default:
+		return 0, fmt.Errorf("unknown request priority %q (must be low, normal or high)", name)
+	}
+}
+
 type backlogHTTPQLimiter struct {
 	httpDownstreamHandler http.Handler
 	backlogQLimiter
+	// methodPriorities are the priorities of the JSON-RPC methods (the
+	// other methods have a normal priority), nil if the requests aren't
+	// prioritized
+	methodPriorities map[string]RequestPriority
 }
 
 func MakeHTTPBacklogQueueLimiter(downstream http.Handler, gauge gauge, limit uint64, logger *log.Entry) *backlogHTTPQLimiter {
@@ -46,6 +86,61 @@ func MakeHTTPBacklogQueueLimiter(downstream http.Handler, gauge gauge, limit uin
 	}
 }
 
+// MakeHTTPPriorityBacklogQueueLimiter is like MakeHTTPBacklogQueueLimiter,
+// but the requests are prioritized by their JSON-RPC methods. The requests are
+// only decoded when the queue nears its limit.
+func MakeHTTPPriorityBacklogQueueLimiter(downstream http.Handler, gauge gauge, limit uint64,
+	methodPriorities map[string]RequestPriority, logger *log.Entry,
+) *backlogHTTPQLimiter {
+	limiter := MakeHTTPBacklogQueueLimiter(downstream, gauge, limit, logger)
+	limiter.methodPriorities = methodPriorities
+	return limiter
+}
+
+func (q *backlogHTTPQLimiter) priorityLimit(priority RequestPriority) uint64 {
+	if q.methodPriorities == nil {
+		return q.limit
+	}
+	return q.limit * requestPriorityLimitPercents[priority] / 100 //nolint:mnd
+}
+
+// requestPriority returns the priority of a request, which is the lowest
+// priority of its calls for batches. The request body is restored.
+func (q *backlogHTTPQLimiter) requestPriority(req *http.Request) RequestPriority {
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+	if err != nil {
+		return RequestPriorityNormal
+	}
+	// invalid requests are rejected downstream, their priority doesn't matter
+	calls, err := jrpc2.ParseRequests(body)
+	if err != nil || len(calls) == 0 {
+		return RequestPriorityNormal
+	}
+	result := RequestPriorityHigh
+	for _, call := range calls {
+		priority, ok := q.methodPriorities[call.Method]
+		if !ok {
+			priority = RequestPriorityNormal
+		}
+		result = min(result, priority)
+	}
+	return result
+}
+
+// admit tells whether a request can be admitted, given the number of pending
+// requests including it.
+func (q *backlogHTTPQLimiter) admit(req *http.Request, pending uint64) bool {
+	if pending > q.limit {
+		return false
+	}
+	if pending <= q.priorityLimit(RequestPriorityLow) {
+		// there is room for all priorities, no need to decode the request
+		return true
+	}
+	return pending <= q.priorityLimit(q.requestPriority(req))
+}
+
 type backlogJrpcQLimiter struct {
 	jrpcDownstreamHandler jrpc2.Handler
 	backlogQLimiter
@@ -68,7 +163,7 @@ func (q *backlogHTTPQLimiter) ServeHTTP(res http.ResponseWriter, req *http.Reque
 		q.httpDownstreamHandler.ServeHTTP(res, req)
 		return
 	}
-	if newPending := atomic.AddUint64(&q.pending, 1); newPending > q.limit {
+	if newPending := atomic.AddUint64(&q.pending, 1); !q.admit(req, newPending) {
 		// we've reached our queue limit - let the caller know we're too busy.
 		atomic.AddUint64(&q.pending, ^uint64(0))
 		res.WriteHeader(http.StatusServiceUnavailable)
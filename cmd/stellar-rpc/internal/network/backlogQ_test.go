@@ -2,13 +2,17 @@ package network
 
 import (
 	"context"
+	"io"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 
 	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -235,3 +239,39 @@ func TestBacklogQueueLimiter_JrpcBlocking(t *testing.T) {
 		require.Zero(t, int(testGauge.count))
 	}
 }
+
+func TestBacklogQueueLimiter_HttpPriorities(t *testing.T) {
+	var bodies []string
+	downstream := &TestingHandlerWrapper{f: func(res http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}}
+	limiter := MakeHTTPPriorityBacklogQueueLimiter(downstream, &TestingGauge{}, 10,
+		map[string]RequestPriority{"getHealth": RequestPriorityHigh, "simulateTransaction": RequestPriorityLow},
+		makeTestLogCounter().Entry())
+
+	serve := func(pending uint64, body string) int {
+		limiter.pending = pending
+		recorder := httptest.NewRecorder()
+		limiter.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return recorder.Code
+	}
+	simulate := `{"jsonrpc":"2.0","id":1,"method":"simulateTransaction"}`
+	latestLedger := `{"jsonrpc":"2.0","id":1,"method":"getLatestLedger"}`
+	health := `{"jsonrpc":"2.0","id":1,"method":"getHealth"}`
+	batch := `[` + health + `,` + simulate + `]`
+
+	// below 80% of the limit, all the requests are admitted
+	assert.Equal(t, http.StatusOK, serve(7, simulate))
+	// low priority requests are rejected above 80% of the limit
+	assert.Equal(t, http.StatusServiceUnavailable, serve(8, simulate))
+	assert.Equal(t, http.StatusServiceUnavailable, serve(8, batch))
+	assert.Equal(t, http.StatusOK, serve(8, latestLedger))
+	// normal priority requests are rejected above 90% of the limit
+	assert.Equal(t, http.StatusServiceUnavailable, serve(9, latestLedger))
+	assert.Equal(t, http.StatusOK, serve(9, health))
+	assert.Equal(t, http.StatusServiceUnavailable, serve(10, health))
+	// the downstream handler gets the whole body
+	assert.Equal(t, []string{simulate, latestLedger, health}, bodies)
+}
@@ -0,0 +1,123 @@
+package network
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+)
+
+// httpCacheControl sets the caching headers of the responses of the list
+// methods (e.g. getEvents), whose finalized pages can be cached.
+type httpCacheControl struct {
+	httpDownstreamHandler http.Handler
+	methods               map[string]bool
+	maxAge                time.Duration
+}
+
+// MakeHTTPCacheControl creates a handler which lets proxies cache (for maxAge)
+// the responses of the given methods whose page is finalized (see
+// protocol.PageInfo), identified by an ETag. The other responses of those
+// methods must be revalidated. Only the single (i.e. not batched) requests are
+// cached. A zero maxAge disables caching.
+func MakeHTTPCacheControl(downstream http.Handler, methods []string, maxAge time.Duration) http.Handler {
+	if maxAge <= 0 {
+		return downstream
+	}
+	cache := &httpCacheControl{
+		httpDownstreamHandler: downstream,
+		methods:               make(map[string]bool, len(methods)),
+		maxAge:                maxAge,
+	}
+	for _, method := range methods {
+		cache.methods[method] = true
+	}
+	return cache
+}
+
+// cacheableRequest returns the request, if it's a single call of a cacheable
+// method. The request body is restored.
+func (c *httpCacheControl) cacheableRequest(req *http.Request) (*jrpc2.ParsedRequest, bool) {
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+	if err != nil || bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		return nil, false
+	}
+	calls, err := jrpc2.ParseRequests(body)
+	if err != nil || len(calls) != 1 || calls[0].Error != nil || !c.methods[calls[0].Method] {
+		return nil, false
+	}
+	return calls[0], true
+}
+
+// etag identifies the response to a request. Finalized pages only change in
+// their metadata (e.g. the latest ledger), so the tag is weak and only depends
+// on the request.
+func etag(call *jrpc2.ParsedRequest) string {
+	hash := sha256.New()
+	for _, part := range []string{call.Method, call.ID, string(call.Params)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// finalizedResponse tells whether the body is the response of a finalized
+// page.
+func finalizedResponse(body []byte) bool {
+	var response struct {
+		Result struct {
+			Page struct {
+				Finalized bool `json:"finalized"`
+			} `json:"page"`
+		} `json:"result"`
+	}
+	return json.Unmarshal(body, &response) == nil && response.Result.Page.Finalized
+}
+
+func (c *httpCacheControl) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	call, ok := c.cacheableRequest(req)
+	if !ok {
+		c.httpDownstreamHandler.ServeHTTP(res, req)
+		return
+	}
+	writer := makeBufferedResponseWriter(res)
+	c.httpDownstreamHandler.ServeHTTP(writer, req)
+
+	header := writer.Header()
+	ok = writer.statusCode == 0 || writer.statusCode == http.StatusOK
+	if !ok || !finalizedResponse(writer.buffer) {
+		header.Set("Cache-Control", "no-cache")
+		writer.WriteOut(req.Context(), res)
+		return
+	}
+	tag := etag(call)
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(c.maxAge.Seconds())))
+	header.Set("ETag", tag)
+	if matchesETag(req.Header.Get("If-None-Match"), tag) {
+		header.Del("Content-Length")
+		header.Del("Content-Type")
+		writer.statusCode = http.StatusNotModified
+		writer.buffer = nil
+	}
+	writer.WriteOut(req.Context(), res)
+}
+
+// matchesETag tells whether an If-None-Match header value matches the tag,
+// with the weak comparison.
+func matchesETag(ifNoneMatch string, tag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
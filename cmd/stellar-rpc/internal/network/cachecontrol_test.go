@@ -0,0 +1,71 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheControl(t *testing.T) {
+	finalized := true
+	handler := &TestingHandlerWrapper{f: func(res http.ResponseWriter, _ *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		if finalized {
+			res.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"page": {"finalized": true}}}`)) //nolint:errcheck
+		} else {
+			res.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"page": {}}}`)) //nolint:errcheck
+		}
+	}}
+	cache := MakeHTTPCacheControl(handler, []string{"getEvents"}, time.Hour)
+
+	request := func(body string, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		cache.ServeHTTP(recorder, req)
+		return recorder
+	}
+	getEvents := `{"jsonrpc": "2.0", "id": 1, "method": "getEvents", "params": {"startLedger": 1}}`
+
+	// the finalized pages are cacheable
+	recorder := request(getEvents, "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "public, max-age=3600", recorder.Header().Get("Cache-Control"))
+	tag := recorder.Header().Get("ETag")
+	require.NotEmpty(t, tag)
+	assert.Contains(t, recorder.Body.String(), "finalized")
+
+	// and revalidated with their tag, which depends on the request
+	recorder = request(getEvents, tag)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+	recorder = request(`{"jsonrpc": "2.0", "id": 1, "method": "getEvents", "params": {"startLedger": 2}}`, tag)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.NotEqual(t, tag, recorder.Header().Get("ETag"))
+
+	// the other pages aren't
+	finalized = false
+	recorder = request(getEvents, tag)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "no-cache", recorder.Header().Get("Cache-Control"))
+	assert.Empty(t, recorder.Header().Get("ETag"))
+
+	// and neither are the other methods and the batches
+	finalized = true
+	recorder = request(`{"jsonrpc": "2.0", "id": 1, "method": "getHealth"}`, "")
+	assert.Empty(t, recorder.Header().Get("Cache-Control"))
+	recorder = request("["+getEvents+"]", "")
+	assert.Empty(t, recorder.Header().Get("Cache-Control"))
+}
+
+func TestCacheControlDisabled(t *testing.T) {
+	handler := &TestingHandlerWrapper{f: func(http.ResponseWriter, *http.Request) {}}
+	require.Same(t, handler, MakeHTTPCacheControl(handler, []string{"getEvents"}, 0))
+}
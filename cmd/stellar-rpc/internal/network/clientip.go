@@ -0,0 +1,87 @@
+package network
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultClientIPHeader is the header the client IP is taken from when
+// trusted proxies are configured without a header.
+const DefaultClientIPHeader = "X-Forwarded-For"
+
+// ClientIPResolver extracts the IP of the client which sent an http request,
+// taking the proxies in front of the server into account.
+type ClientIPResolver struct {
+	header         string
+	trustedProxies []netip.Prefix
+}
+
+// NewClientIPResolver creates a ClientIPResolver.
+//
+// The client IP is the connection's remote address, unless the connection
+// comes from a trusted proxy. Then, the addresses of header (X-Forwarded-For by
+// default) are walked from the last one, skipping the trusted proxies, and the
+// first untrusted address is the client IP. This prevents clients from
+// spoofing their IP by sending the header themselves. Without trusted proxies,
+// the header is never used.
+func NewClientIPResolver(header string, trustedProxies []netip.Prefix) ClientIPResolver {
+	if header == "" && len(trustedProxies) > 0 {
+		header = DefaultClientIPHeader
+	}
+	return ClientIPResolver{
+		header:         header,
+		trustedProxies: trustedProxies,
+	}
+}
+
+func (r ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range r.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerAddresses returns the comma-separated addresses of all the header
+// lines, in order.
+func (r ClientIPResolver) headerAddresses(req *http.Request) []string {
+	var addresses []string
+	for _, value := range req.Header.Values(r.header) {
+		for _, address := range strings.Split(value, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
+
+// ClientIP returns the IP of the client which sent the request.
+func (r ClientIPResolver) ClientIP(req *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		remoteIP = req.RemoteAddr
+	}
+	hop, err := netip.ParseAddr(remoteIP)
+	if err != nil || !r.isTrusted(hop) {
+		return remoteIP
+	}
+	addresses := r.headerAddresses(req)
+	for i := len(addresses) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(addresses[i])
+		if err != nil {
+			// the header can't be trusted beyond this point, so the last
+			// trusted proxy is the best we know about the client
+			break
+		}
+		hop = addr.Unmap()
+		if !r.isTrusted(hop) {
+			break
+		}
+	}
+	return hop.String()
+}
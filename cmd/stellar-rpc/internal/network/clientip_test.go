@@ -0,0 +1,55 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	trusting := NewClientIPResolver("", []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.1/32"),
+		netip.MustParsePrefix("fd00::/8"),
+	})
+	untrusting := NewClientIPResolver("X-Forwarded-For", nil)
+	direct := NewClientIPResolver("", nil)
+
+	for _, testCase := range []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		resolver     ClientIPResolver
+		expected     string
+	}{
+		{"no header", "1.2.3.4:1234", nil, trusting, "1.2.3.4"},
+		{"untrusted peer spoofing the header", "1.2.3.4:1234", []string{"5.6.7.8"}, trusting, "1.2.3.4"},
+		{"trusted proxy", "10.0.0.1:1234", []string{"5.6.7.8"}, trusting, "5.6.7.8"},
+		{"trusted proxy without header", "10.0.0.1:1234", nil, trusting, "10.0.0.1"},
+		{
+			"client spoofing the header behind a trusted proxy", "10.0.0.1:1234",
+			[]string{"6.6.6.6, 5.6.7.8"}, trusting, "5.6.7.8",
+		},
+		{"chain of trusted proxies", "10.0.0.1:1234", []string{"5.6.7.8, 192.168.1.1, 10.1.2.3"}, trusting, "5.6.7.8"},
+		{"several header lines", "10.0.0.1:1234", []string{"6.6.6.6, 5.6.7.8", "10.1.2.3"}, trusting, "5.6.7.8"},
+		{"only trusted proxies", "10.0.0.1:1234", []string{"10.1.2.3, 192.168.1.1"}, trusting, "10.1.2.3"},
+		{"invalid address", "10.0.0.1:1234", []string{"5.6.7.8, garbage, 10.1.2.3"}, trusting, "10.1.2.3"},
+		{"ipv6 proxy", "[fd00::1]:1234", []string{"2001:db8::1"}, trusting, "2001:db8::1"},
+		{"ipv4-mapped proxy", "[::ffff:10.0.0.1]:1234", []string{"5.6.7.8"}, trusting, "5.6.7.8"},
+		{"untrusted neighbour of a single trusted IP", "192.168.1.2:1234", []string{"5.6.7.8"}, trusting, "192.168.1.2"},
+		{"header without trusted proxies", "1.2.3.4:1234", []string{"6.6.6.6, 5.6.7.8"}, untrusting, "1.2.3.4"},
+		{"no header configured", "1.2.3.4:1234", []string{"5.6.7.8"}, direct, "1.2.3.4"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = testCase.remoteAddr
+			for _, value := range testCase.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			assert.Equal(t, testCase.expected, testCase.resolver.ClientIP(req))
+		})
+	}
+}
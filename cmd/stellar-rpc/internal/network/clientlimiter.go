@@ -0,0 +1,85 @@
+package network
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/stellar/go/support/log"
+)
+
+// ClientConcurrencyNoLimit disables the per-client concurrency limit.
+const ClientConcurrencyNoLimit = 0
+
+// httpClientConcurrencyLimiter bounds the number of concurrent http requests
+// of every client IP. Unlike the backlog queue limiters, it applies before the
+// JSON-RPC requests are parsed.
+type httpClientConcurrencyLimiter struct {
+	httpDownstreamHandler http.Handler
+	limit                 uint
+	clientIPResolver      ClientIPResolver
+	rejectedCounter       increasingCounter
+	logger                *log.Entry
+
+	lock    sync.Mutex
+	pending map[string]uint
+}
+
+// MakeHTTPClientConcurrencyLimiter creates a handler which rejects the requests
+// of clients which already have limit requests in flight, with a 429 status.
+// Clients are identified by the IP returned by clientIPResolver.
+func MakeHTTPClientConcurrencyLimiter(
+	downstream http.Handler,
+	limit uint,
+	clientIPResolver ClientIPResolver,
+	rejectedCounter increasingCounter,
+	logger *log.Entry,
+) http.Handler {
+	if limit == ClientConcurrencyNoLimit {
+		return downstream
+	}
+	return &httpClientConcurrencyLimiter{
+		httpDownstreamHandler: downstream,
+		limit:                 limit,
+		clientIPResolver:      clientIPResolver,
+		rejectedCounter:       rejectedCounter,
+		logger:                logger,
+		pending:               map[string]uint{},
+	}
+}
+
+func (l *httpClientConcurrencyLimiter) acquire(ip string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.pending[ip] >= l.limit {
+		return false
+	}
+	l.pending[ip]++
+	return true
+}
+
+func (l *httpClientConcurrencyLimiter) release(ip string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.pending[ip] <= 1 {
+		delete(l.pending, ip)
+		return
+	}
+	l.pending[ip]--
+}
+
+func (l *httpClientConcurrencyLimiter) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	ip := l.clientIPResolver.ClientIP(req)
+	if !l.acquire(ip) {
+		if l.rejectedCounter != nil {
+			l.rejectedCounter.Inc()
+		}
+		if l.logger != nil {
+			l.logger.Debugf("Rejecting request from %s, which has %d requests in flight", ip, l.limit)
+		}
+		res.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	defer l.release(ip)
+
+	l.httpDownstreamHandler.ServeHTTP(res, req)
+}
@@ -0,0 +1,76 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientConcurrencyLimiter(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocking := &TestingHandlerWrapper{f: func(res http.ResponseWriter, _ *http.Request) {
+		started <- struct{}{}
+		<-release
+		res.WriteHeader(http.StatusOK)
+	}}
+	counter := &TestingCounter{}
+	resolver := NewClientIPResolver("X-Forwarded-For", []netip.Prefix{netip.MustParsePrefix("10.0.0.2/32")})
+	limiter := MakeHTTPClientConcurrencyLimiter(blocking, 1, resolver, counter, nil)
+
+	request := func(remoteAddr string, forwardedFor string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		return req
+	}
+
+	done := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		limiter.ServeHTTP(recorder, request("10.0.0.1:1234", ""))
+		done <- recorder.Code
+	}()
+	<-started
+
+	// a second request from the same IP is rejected, regardless of the port
+	recorder := httptest.NewRecorder()
+	limiter.ServeHTTP(recorder, request("10.0.0.1:4321", ""))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.EqualValues(t, 1, counter.count)
+
+	// and so is a request forwarded by a proxy on behalf of the same IP
+	recorder = httptest.NewRecorder()
+	limiter.ServeHTTP(recorder, request("10.0.0.2:1234", "1.2.3.4, 10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.EqualValues(t, 2, counter.count)
+
+	// requests from other IPs aren't affected
+	go func() {
+		recorder := httptest.NewRecorder()
+		limiter.ServeHTTP(recorder, request("10.0.0.2:1234", "10.0.0.3"))
+		done <- recorder.Code
+	}()
+	<-started
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-done)
+	require.Equal(t, http.StatusOK, <-done)
+
+	// once the requests complete, the IP can send requests again
+	recorder = httptest.NewRecorder()
+	go func() { <-started }()
+	limiter.ServeHTTP(recorder, request("10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestClientConcurrencyLimiterDisabled(t *testing.T) {
+	handler := &TestingHandlerWrapper{f: func(http.ResponseWriter, *http.Request) {}}
+	require.Same(t, handler, MakeHTTPClientConcurrencyLimiter(handler, ClientConcurrencyNoLimit, ClientIPResolver{}, nil, nil))
+}
@@ -0,0 +1,86 @@
+package network
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+)
+
+// httpCORSMethodFilter restricts the JSON-RPC methods which can be called from
+// cross-origin (browser) requests.
+type httpCORSMethodFilter struct {
+	httpDownstreamHandler http.Handler
+	allowedMethods        map[string]bool
+	logger                *log.Entry
+}
+
+// MakeHTTPCORSMethodFilter creates a handler which rejects the cross-origin
+// requests calling a JSON-RPC method outside of allowedMethods, with a 403
+// status. Batches are rejected if any of their calls is. The same-origin
+// requests (and the requests without an Origin header) are not restricted, and
+// neither is any method when allowedMethods is empty.
+func MakeHTTPCORSMethodFilter(downstream http.Handler, allowedMethods []string, logger *log.Entry) http.Handler {
+	if len(allowedMethods) == 0 {
+		return downstream
+	}
+	filter := &httpCORSMethodFilter{
+		httpDownstreamHandler: downstream,
+		allowedMethods:        make(map[string]bool, len(allowedMethods)),
+		logger:                logger,
+	}
+	for _, method := range allowedMethods {
+		filter.allowedMethods[method] = true
+	}
+	return filter
+}
+
+// isCrossOrigin tells whether the request was sent by a browser from another
+// origin than the server.
+func isCrossOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	originURL, err := url.Parse(origin)
+	return err != nil || originURL.Host != req.Host
+}
+
+// forbiddenMethod returns the first method called by the request which isn't
+// allowed, if any. The request body is restored.
+func (f *httpCORSMethodFilter) forbiddenMethod(req *http.Request) (string, bool) {
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), req.Body))
+	if err != nil {
+		return "", false
+	}
+	// invalid requests are rejected downstream
+	calls, err := jrpc2.ParseRequests(body)
+	if err != nil {
+		return "", false
+	}
+	for _, call := range calls {
+		if !f.allowedMethods[call.Method] {
+			return call.Method, true
+		}
+	}
+	return "", false
+}
+
+func (f *httpCORSMethodFilter) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodOptions && isCrossOrigin(req) {
+		if method, forbidden := f.forbiddenMethod(req); forbidden {
+			if f.logger != nil {
+				f.logger.WithField("method", method).WithField("origin", req.Header.Get("Origin")).
+					Debug("rejecting cross-origin request")
+			}
+			http.Error(res, "method "+method+" can't be called from cross-origin requests", http.StatusForbidden)
+			return
+		}
+	}
+	f.httpDownstreamHandler.ServeHTTP(res, req)
+}
@@ -0,0 +1,59 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSMethodFilter(t *testing.T) {
+	calls := 0
+	handler := &TestingHandlerWrapper{f: func(res http.ResponseWriter, _ *http.Request) {
+		calls++
+		res.WriteHeader(http.StatusOK)
+	}}
+	filter := MakeHTTPCORSMethodFilter(handler, []string{"simulateTransaction"}, nil)
+
+	request := func(origin string, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "http://rpc.example.com/", strings.NewReader(body))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		return req
+	}
+	simulate := `{"jsonrpc": "2.0", "id": 1, "method": "simulateTransaction"}`
+	send := `{"jsonrpc": "2.0", "id": 2, "method": "sendTransaction"}`
+
+	// the allowed methods can be called from any origin
+	recorder := httptest.NewRecorder()
+	filter.ServeHTTP(recorder, request("https://app.example.org", simulate))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// the others are rejected for cross-origin requests, including in batches
+	recorder = httptest.NewRecorder()
+	filter.ServeHTTP(recorder, request("https://app.example.org", send))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "sendTransaction")
+	recorder = httptest.NewRecorder()
+	filter.ServeHTTP(recorder, request("https://app.example.org", "["+simulate+","+send+"]"))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, 1, calls)
+
+	// but not for same-origin requests and requests without an origin
+	recorder = httptest.NewRecorder()
+	filter.ServeHTTP(recorder, request("http://rpc.example.com", send))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder = httptest.NewRecorder()
+	filter.ServeHTTP(recorder, request("", send))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestCORSMethodFilterDisabled(t *testing.T) {
+	handler := &TestingHandlerWrapper{f: func(http.ResponseWriter, *http.Request) {}}
+	require.Same(t, handler, MakeHTTPCORSMethodFilter(handler, nil, nil))
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+const jsonContentType = "application/json; charset=utf-8"
+
+type httpJSONResponseFormatter struct {
+	httpDownstreamHandler http.Handler
+	pretty                bool
+}
+
+// MakeHTTPJSONResponseFormatter creates a handler which sets the charset of the
+// JSON responses of downstream (to utf-8) and, when pretty is set, indents them.
+func MakeHTTPJSONResponseFormatter(downstream http.Handler, pretty bool) http.Handler {
+	return &httpJSONResponseFormatter{
+		httpDownstreamHandler: downstream,
+		pretty:                pretty,
+	}
+}
+
+type jsonResponseWriter struct {
+	http.ResponseWriter
+	pretty      bool
+	wroteHeader bool
+	// buffering is set when the body is held back to be indented
+	buffering  bool
+	statusCode int
+	buffer     bytes.Buffer
+}
+
+func (w *jsonResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	header := w.Header()
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	header.Set("Content-Type", jsonContentType)
+	if w.pretty {
+		w.buffering = true
+		w.statusCode = statusCode
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *jsonResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		return w.buffer.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// flush writes the held back body, indented.
+func (w *jsonResponseWriter) flush() {
+	if !w.buffering {
+		return
+	}
+	body := w.buffer.Bytes()
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err == nil {
+		body = append(indented.Bytes(), '\n')
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	// the request is already finalized, the error wouldn't help
+	w.ResponseWriter.Write(body) //nolint:errcheck
+}
+
+func (f *httpJSONResponseFormatter) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	writer := &jsonResponseWriter{ResponseWriter: res, pretty: f.pretty}
+	f.httpDownstreamHandler.ServeHTTP(writer, req)
+	writer.flush()
+}
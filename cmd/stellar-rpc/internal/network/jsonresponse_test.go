@@ -0,0 +1,52 @@
+package network
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONResponseFormatter(t *testing.T) {
+	downstream := &TestingHandlerWrapper{f: func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/text" {
+			http.Error(res, "bad request", http.StatusBadRequest)
+			return
+		}
+		body := `{"jsonrpc":"2.0","id":1,"result":{"status":"healthy"}}`
+		res.Header().Set("Content-Type", "application/json")
+		res.Header().Set("Content-Length", "55")
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte(body))
+	}}
+
+	recorder := httptest.NewRecorder()
+	MakeHTTPJSONResponseFormatter(downstream, false).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `{"jsonrpc":"2.0","id":1,"result":{"status":"healthy"}}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	MakeHTTPJSONResponseFormatter(downstream, true).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	expected := `{
+  "jsonrpc": "2.0",
+  "id": 1,
+  "result": {
+    "status": "healthy"
+  }
+}
+`
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, expected, recorder.Body.String())
+	assert.Equal(t, strconv.Itoa(len(expected)), recorder.Header().Get("Content-Length"))
+
+	// other responses are left untouched
+	recorder = httptest.NewRecorder()
+	MakeHTTPJSONResponseFormatter(downstream, true).ServeHTTP(recorder,
+		httptest.NewRequest(http.MethodPost, "/text", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "bad request\n", recorder.Body.String())
+}
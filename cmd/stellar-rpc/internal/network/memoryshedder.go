@@ -0,0 +1,87 @@
+package network
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/creachadair/jrpc2"
+
+	"github.com/stellar/go/support/log"
+)
+
+// memorySampleInterval bounds how often runtime.ReadMemStats is called, since
+// it briefly stops the world.
+const memorySampleInterval = time.Second
+
+// MemoryLoadShedder rejects requests while the heap size is above a
+// configured threshold, giving the node a chance to recover instead of
+// running out of memory.
+type MemoryLoadShedder struct {
+	threshold      uint64
+	sampleInterval time.Duration
+	readHeap       func() uint64
+	shedCounter    increasingCounter
+	logger         *log.Entry
+
+	lock       sync.Mutex
+	lastSample time.Time
+	overloaded bool
+}
+
+func MakeMemoryLoadShedder(threshold uint64, shedCounter increasingCounter, logger *log.Entry) *MemoryLoadShedder {
+	return &MemoryLoadShedder{
+		threshold:      threshold,
+		sampleInterval: memorySampleInterval,
+		readHeap:       readHeapAlloc,
+		shedCounter:    shedCounter,
+		logger:         logger,
+	}
+}
+
+func readHeapAlloc() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func (s *MemoryLoadShedder) isOverloaded() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	if !s.lastSample.IsZero() && now.Sub(s.lastSample) < s.sampleInterval {
+		return s.overloaded
+	}
+	s.lastSample = now
+	heap := s.readHeap()
+	overloaded := heap > s.threshold
+	if overloaded != s.overloaded && s.logger != nil {
+		if overloaded {
+			s.logger.Warnf("Heap size of %d bytes exceeds the threshold of %d bytes, shedding expensive requests", heap, s.threshold)
+		} else {
+			s.logger.Infof("Heap size of %d bytes is back under the threshold of %d bytes, no longer shedding requests", heap, s.threshold)
+		}
+	}
+	s.overloaded = overloaded
+	return overloaded
+}
+
+// WrapJrpcHandler returns a handler which rejects requests with
+// ErrServerUnderMemoryPressure while the heap is above the threshold.
+func (s *MemoryLoadShedder) WrapJrpcHandler(downstream jrpc2.Handler) jrpc2.Handler {
+	return func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		if s.isOverloaded() {
+			if s.shedCounter != nil {
+				s.shedCounter.Inc()
+			}
+			return nil, ErrServerUnderMemoryPressure
+		}
+		return downstream(ctx, req)
+	}
+}
+
+var ErrServerUnderMemoryPressure = jrpc2.Error{
+	Code:    -32004,
+	Message: "server is under memory pressure, try again later",
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"context"
+	"testing"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLoadShedder(t *testing.T) {
+	heap := uint64(100)
+	counter := TestingCounter{}
+	logCounter := makeTestLogCounter()
+	shedder := MakeMemoryLoadShedder(200, &counter, logCounter.Entry())
+	shedder.sampleInterval = 0
+	shedder.readHeap = func() uint64 { return heap }
+
+	calls := 0
+	handler := shedder.WrapJrpcHandler(func(context.Context, *jrpc2.Request) (interface{}, error) {
+		calls++
+		return "ok", nil
+	})
+
+	res, err := handler(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "ok", res)
+
+	heap = 300
+	_, err = handler(context.Background(), nil)
+	require.Equal(t, ErrServerUnderMemoryPressure, err)
+	require.Equal(t, 1, calls)
+	require.Equal(t, int64(1), counter.count)
+
+	heap = 150
+	_, err = handler(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestMemoryLoadShedderSampleInterval(t *testing.T) {
+	samples := 0
+	shedder := MakeMemoryLoadShedder(200, nil, nil)
+	shedder.readHeap = func() uint64 {
+		samples++
+		return 300
+	}
+
+	handler := shedder.WrapJrpcHandler(func(context.Context, *jrpc2.Request) (interface{}, error) {
+		return nil, nil
+	})
+	for range 10 {
+		_, err := handler(context.Background(), nil)
+		require.Equal(t, ErrServerUnderMemoryPressure, err)
+	}
+	require.Equal(t, 1, samples)
+}
@@ -0,0 +1,129 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxInflightParamsLength bounds the size of the request params kept for
+// in-flight requests, some of them (e.g. transaction envelopes) can be large.
+const maxInflightParamsLength = 256
+
+// ErrRequestCanceledByAdmin is the cancellation cause of requests canceled
+// through the RequestRegistry.
+var ErrRequestCanceledByAdmin = errors.New("request canceled by admin")
+
+// InflightRequest describes a request which is currently being served.
+type InflightRequest struct {
+	ID        string    `json:"id"`
+	Method    string    `json:"method"`
+	StartTime time.Time `json:"startTime"`
+	Elapsed   string    `json:"elapsed"`
+	Params    string    `json:"params,omitempty"`
+}
+
+type registeredRequest struct {
+	method    string
+	startTime time.Time
+	params    string
+	cancel    context.CancelCauseFunc
+}
+
+// RequestRegistry keeps track of the in-flight JSON RPC requests, so that
+// operators can inspect them and cancel the ones which are stuck.
+type RequestRegistry struct {
+	lock     sync.Mutex
+	requests map[string]registeredRequest
+	now      func() time.Time
+}
+
+func MakeRequestRegistry() *RequestRegistry {
+	return &RequestRegistry{
+		requests: map[string]registeredRequest{},
+		now:      time.Now,
+	}
+}
+
+// Register records a new in-flight request. The returned context is canceled
+// when Cancel is called with the same id. The returned function must be called
+// once the request is done.
+func (r *RequestRegistry) Register(
+	ctx context.Context, id string, method string, params string,
+) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if len(params) > maxInflightParamsLength {
+		params = params[:maxInflightParamsLength] + "..."
+	}
+	r.lock.Lock()
+	r.requests[id] = registeredRequest{
+		method:    method,
+		startTime: r.now(),
+		params:    params,
+		cancel:    cancel,
+	}
+	r.lock.Unlock()
+	return ctx, func() {
+		r.lock.Lock()
+		delete(r.requests, id)
+		r.lock.Unlock()
+		cancel(nil)
+	}
+}
+
+// List returns the in-flight requests, oldest first.
+func (r *RequestRegistry) List() []InflightRequest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := r.now()
+	result := make([]InflightRequest, 0, len(r.requests))
+	for id, request := range r.requests {
+		result = append(result, InflightRequest{
+			ID:        id,
+			Method:    request.method,
+			StartTime: request.startTime,
+			Elapsed:   now.Sub(request.startTime).String(),
+			Params:    request.params,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartTime.Before(result[j].StartTime)
+	})
+	return result
+}
+
+// Cancel cancels the context of the in-flight request with the given id. It
+// returns false if there is no such request.
+func (r *RequestRegistry) Cancel(id string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	request, ok := r.requests[id]
+	if ok {
+		request.cancel(ErrRequestCanceledByAdmin)
+	}
+	return ok
+}
+
+// ListHandler serves the in-flight requests as a JSON array.
+func (r *RequestRegistry) ListHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(r.List())
+}
+
+// CancelHandler cancels the in-flight request given by the id query parameter.
+func (r *RequestRegistry) CancelHandler(w http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "missing id parameter", http.StatusBadRequest)
+		return
+	}
+	if !r.Cancel(id) {
+		http.Error(w, "no in-flight request with id "+id, http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
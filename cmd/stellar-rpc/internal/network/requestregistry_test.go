@@ -0,0 +1,71 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestRegistry(t *testing.T) {
+	registry := MakeRequestRegistry()
+	now := time.Unix(1000, 0)
+	registry.now = func() time.Time { return now }
+
+	ctx1, done1 := registry.Register(context.Background(), "1", "getEvents", "{}")
+	now = now.Add(time.Second)
+	ctx2, done2 := registry.Register(context.Background(), "2", "sendTransaction", strings.Repeat("a", 1000))
+	now = now.Add(time.Second)
+
+	requests := registry.List()
+	require.Len(t, requests, 2)
+	require.Equal(t, InflightRequest{
+		ID:        "1",
+		Method:    "getEvents",
+		StartTime: time.Unix(1000, 0),
+		Elapsed:   "2s",
+		Params:    "{}",
+	}, requests[0])
+	require.Equal(t, "2", requests[1].ID)
+	require.Len(t, requests[1].Params, maxInflightParamsLength+len("..."))
+
+	require.True(t, registry.Cancel("2"))
+	require.ErrorIs(t, ctx2.Err(), context.Canceled)
+	require.ErrorIs(t, context.Cause(ctx2), ErrRequestCanceledByAdmin)
+	require.NoError(t, ctx1.Err())
+	require.False(t, registry.Cancel("3"))
+
+	done2()
+	done1()
+	require.Empty(t, registry.List())
+	require.False(t, registry.Cancel("1"))
+}
+
+func TestRequestRegistryHandlers(t *testing.T) {
+	registry := MakeRequestRegistry()
+	ctx, done := registry.Register(context.Background(), "7", "getTransactions", "")
+	defer done()
+
+	recorder := httptest.NewRecorder()
+	registry.ListHandler(recorder, httptest.NewRequest(http.MethodGet, "/requests", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var requests []InflightRequest
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &requests))
+	require.Len(t, requests, 1)
+	require.Equal(t, "getTransactions", requests[0].Method)
+
+	recorder = httptest.NewRecorder()
+	registry.CancelHandler(recorder, httptest.NewRequest(http.MethodPost, "/requests/cancel?id=8", nil))
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	require.NoError(t, ctx.Err())
+
+	recorder = httptest.NewRecorder()
+	registry.CancelHandler(recorder, httptest.NewRequest(http.MethodPost, "/requests/cancel?id=7", nil))
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	require.Error(t, ctx.Err())
+}
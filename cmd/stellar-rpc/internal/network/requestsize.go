@@ -0,0 +1,81 @@
+package network
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/stellar/go/support/log"
+)
+
+// httpRequestSizeMonitor warns about the large requests which are still
+// served, so that the clients getting close to the request size limits can be
+// spotted before their requests are rejected.
+type httpRequestSizeMonitor struct {
+	httpDownstreamHandler http.Handler
+	warningThreshold      int64
+	clientIPResolver      ClientIPResolver
+	warningCounter        increasingCounter
+	logger                *log.Entry
+}
+
+// MakeHTTPRequestSizeMonitor creates a handler which logs a warning (and
+// increases warningCounter) for every request whose body exceeds
+// warningThreshold bytes. The requests rejected by the size limit of an
+// upstream http.MaxBytesHandler aren't reported. A non-positive threshold
+// disables the warnings.
+func MakeHTTPRequestSizeMonitor(
+	downstream http.Handler,
+	warningThreshold int64,
+	clientIPResolver ClientIPResolver,
+	warningCounter increasingCounter,
+	logger *log.Entry,
+) http.Handler {
+	if warningThreshold <= 0 {
+		return downstream
+	}
+	return &httpRequestSizeMonitor{
+		httpDownstreamHandler: downstream,
+		warningThreshold:      warningThreshold,
+		clientIPResolver:      clientIPResolver,
+		warningCounter:        warningCounter,
+		logger:                logger,
+	}
+}
+
+// countingReadCloser counts the bytes read from a request body.
+type countingReadCloser struct {
+	io.ReadCloser
+	count int64
+	// tooLarge is set once the body exceeded the limit of an
+	// http.MaxBytesReader
+	tooLarge bool
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.count += int64(n)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		r.tooLarge = true
+	}
+	return n, err
+}
+
+func (m *httpRequestSizeMonitor) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	body := &countingReadCloser{ReadCloser: req.Body}
+	req.Body = body
+	m.httpDownstreamHandler.ServeHTTP(res, req)
+
+	// the body is read by the downstream handlers
+	if body.tooLarge || body.count <= m.warningThreshold {
+		return
+	}
+	m.warningCounter.Inc()
+	if m.logger != nil {
+		m.logger.WithField("size", body.count).
+			WithField("threshold", m.warningThreshold).
+			WithField("client", m.clientIPResolver.ClientIP(req)).
+			Warn("request size surpassed the warning threshold")
+	}
+}
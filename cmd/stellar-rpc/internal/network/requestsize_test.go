@@ -0,0 +1,46 @@
+package network
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestSizeMonitor(t *testing.T) {
+	reading := &TestingHandlerWrapper{f: func(res http.ResponseWriter, req *http.Request) {
+		if _, err := io.ReadAll(req.Body); err != nil {
+			res.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}}
+	counter := &TestingCounter{}
+	monitor := MakeHTTPRequestSizeMonitor(reading, 10, ClientIPResolver{}, counter, nil)
+	handler := http.MaxBytesHandler(monitor, 20)
+
+	serve := func(body string) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return recorder.Code
+	}
+
+	// requests up to the threshold aren't reported
+	assert.Equal(t, http.StatusOK, serve(strings.Repeat("a", 10)))
+	assert.EqualValues(t, 0, counter.count)
+
+	// larger requests are served, but reported
+	assert.Equal(t, http.StatusOK, serve(strings.Repeat("a", 15)))
+	assert.EqualValues(t, 1, counter.count)
+
+	// the requests rejected by the size limit aren't reported
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve(strings.Repeat("a", 25)))
+	assert.EqualValues(t, 1, counter.count)
+}
+
+func TestRequestSizeMonitorDisabled(t *testing.T) {
+	handler := &TestingHandlerWrapper{f: func(http.ResponseWriter, *http.Request) {}}
+	require.Same(t, handler, MakeHTTPRequestSizeMonitor(handler, 0, ClientIPResolver{}, nil, nil))
+}
@@ -0,0 +1,95 @@
+package network
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is a file writer which rotates the file when it reaches a
+// maximum size. The rotated files are suffixed with .1 (the most recent),
+// .2, ... and only the latest maxBackups of them are kept.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups uint
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens (appending to) or creates the file at path.
+func OpenRotatingFile(path string, maxSize int64, maxBackups uint) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *RotatingFile) backupPath(i uint) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+// rotate moves the file out of the way and opens a new one. The current file
+// is only closed once the new one is open, so that a failed rotation leaves
+// the current file in use.
+func (f *RotatingFile) rotate() error {
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil {
+			return err
+		}
+	} else {
+		for i := f.maxBackups - 1; i > 0; i-- {
+			err := os.Rename(f.backupPath(i), f.backupPath(i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(f.path, f.backupPath(1)); err != nil {
+			return err
+		}
+	}
+	current := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	return current.Close()
+}
+
+// Write writes p to the file, rotating the file first if p would make it
+// exceed its maximum size. Writes are never split across files.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, fmt.Errorf("could not rotate %s: %w", f.path, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.file.Close()
+}
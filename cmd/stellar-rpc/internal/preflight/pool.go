@@ -3,6 +3,7 @@ package preflight
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -35,11 +36,13 @@ type workerRequest struct {
 type WorkerPool struct {
 	networkPassphrase          string
 	enableDebug                bool
+	timeout                    time.Duration
 	logger                     *log.Entry
 	isClosed                   atomic.Bool
 	requestChan                chan workerRequest
 	concurrentRequestsMetric   prometheus.Gauge
 	errorFullCounter           prometheus.Counter
+	timeoutCounter             prometheus.Counter
 	durationMetric             *prometheus.SummaryVec
 	ledgerEntriesFetchedMetric prometheus.Summary
 	wg                         sync.WaitGroup
@@ -52,12 +55,20 @@ type WorkerPoolConfig struct {
 	EnableDebug       bool
 	NetworkPassphrase string
 	Logger            *log.Entry
+	// Timeout bounds every preflight, independently of the deadline of the
+	// request. 0 disables it.
+	Timeout time.Duration
 }
 
+// ErrPreflightTimeout is returned when a preflight exceeds the preflight
+// timeout of the pool.
+var ErrPreflightTimeout = errors.New("preflight timed out")
+
 func NewPreflightWorkerPool(cfg WorkerPoolConfig) *WorkerPool {
 	preflightWP := WorkerPool{
 		networkPassphrase: cfg.NetworkPassphrase,
 		enableDebug:       cfg.EnableDebug,
+		timeout:           cfg.Timeout,
 		logger:            cfg.Logger,
 		requestChan:       make(chan workerRequest, cfg.JobQueueCapacity),
 	}
@@ -81,6 +92,12 @@ func NewPreflightWorkerPool(cfg WorkerPoolConfig) *WorkerPool {
 		Name:      "queue_full_errors",
 		Help:      "number of preflight full queue errors",
 	})
+	preflightWP.timeoutCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: cfg.Daemon.MetricsNamespace(),
+		Subsystem: "preflight_pool",
+		Name:      "timeouts",
+		Help:      "number of preflight requests which exceeded the preflight timeout",
+	})
 	preflightWP.durationMetric = prometheus.NewSummaryVec(prometheus.SummaryOpts{
 		Namespace:  cfg.Daemon.MetricsNamespace(),
 		Subsystem:  "preflight_pool",
@@ -99,6 +116,7 @@ func NewPreflightWorkerPool(cfg WorkerPoolConfig) *WorkerPool {
 		requestQueueMetric,
 		preflightWP.concurrentRequestsMetric,
 		preflightWP.errorFullCounter,
+		preflightWP.timeoutCounter,
 		preflightWP.durationMetric,
 		preflightWP.ledgerEntriesFetchedMetric,
 	)
@@ -112,9 +130,16 @@ func NewPreflightWorkerPool(cfg WorkerPoolConfig) *WorkerPool {
 func (pwp *WorkerPool) work() {
 	defer pwp.wg.Done()
 	for request := range pwp.requestChan {
+		// The request may have exceeded its deadline while queued, in which
+		// case there is no point in simulating it (and querying core for the
+		// ledger entries it needs).
+		if err := request.ctx.Err(); err != nil {
+			request.resultChan <- workerResult{Preflight{}, err}
+			continue
+		}
 		pwp.concurrentRequestsMetric.Inc()
 		startTime := time.Now()
-		preflight, err := GetPreflight(request.ctx, request.params)
+		preflight, err := pwp.getPreflight(request.ctx, request.params)
 		status := "ok"
 		if err != nil {
 			status = "error"
@@ -127,6 +152,26 @@ func (pwp *WorkerPool) work() {
 	}
 }
 
+// getPreflight runs a preflight, bounded by the preflight timeout.
+//
+// The simulation itself (in the host) can't be interrupted, but the ledger
+// entries it reads fail to load after the timeout, which makes a slow
+// simulation (e.g. reading many entries) finish and free the worker sooner.
+func (pwp *WorkerPool) getPreflight(ctx context.Context, params Parameters) (Preflight, error) {
+	if pwp.timeout == 0 {
+		return GetPreflight(ctx, params)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, pwp.timeout)
+	defer cancel()
+	preflight, err := GetPreflight(timeoutCtx, params)
+	if ctx.Err() == nil && errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		// the result may be based on ledger entries which couldn't be loaded
+		pwp.timeoutCounter.Inc()
+		return Preflight{}, fmt.Errorf("%w after %s", ErrPreflightTimeout, pwp.timeout)
+	}
+	return preflight, err
+}
+
 func (pwp *WorkerPool) Close() {
 	if !pwp.isClosed.CompareAndSwap(false, true) {
 		// it was already closed
@@ -209,3 +254,27 @@ func (pwp *WorkerPool) GetPreflight(ctx context.Context, params GetterParameters
 		return Preflight{}, ErrPreflightQueueFull
 	}
 }
+
+type ResourceFeeGetterParameters struct {
+	BucketListSize    uint64
+	Resources         TransactionResources
+	ProtocolVersion   uint32
+	LedgerEntryGetter ledgerentries.LedgerEntryGetter
+	LedgerSeq         uint32
+}
+
+// GetResourceFee computes a resource fee (see GetResourceFee) directly, without
+// going through the workers, since it doesn't run any contract code.
+func (pwp *WorkerPool) GetResourceFee(ctx context.Context, params ResourceFeeGetterParameters) (int64, error) {
+	if pwp.isClosed.Load() {
+		return 0, errors.New("preflight worker pool is closed")
+	}
+	return GetResourceFee(ctx, Parameters{
+		Logger:            pwp.logger,
+		NetworkPassphrase: pwp.networkPassphrase,
+		LedgerEntryGetter: params.LedgerEntryGetter,
+		LedgerSeq:         params.LedgerSeq,
+		BucketListSize:    params.BucketListSize,
+		ProtocolVersion:   params.ProtocolVersion,
+	}, params.Resources)
+}
@@ -19,6 +19,7 @@ import "C"
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/cgo"
 	"time"
@@ -108,6 +109,20 @@ type Parameters struct {
 	ProtocolVersion   uint32
 }
 
+// TransactionResources are the resources (declared in the
+// SorobanTransactionData) which the resource fee of a transaction is computed
+// from.
+type TransactionResources struct {
+	Instructions uint32
+	// ReadEntries doesn't count the written entries
+	ReadEntries             uint32
+	WriteEntries            uint32
+	ReadBytes               uint32
+	WriteBytes              uint32
+	ContractEventsSizeBytes uint32
+	TransactionSizeBytes    uint32
+}
+
 type XDRDiff struct {
 	Before []byte // optional before XDR
 	After  []byte // optional after XDR
@@ -232,6 +247,8 @@ func getInvokeHostFunctionPreflight(ctx context.Context, params Parameters) (Pre
 	defer handle.Delete()
 	resourceConfig := C.resource_config_t{
 		instruction_leeway: C.uint64_t(params.ResourceConfig.InstructionLeeway),
+		instruction_limit:  C.uint64_t(params.ResourceConfig.InstructionLimit),
+		memory_limit:       C.uint64_t(params.ResourceConfig.MemoryLimit),
 	}
 
 	// Convert string to enum integer (see shared.rs::AuthMode) for FFI boundary.
@@ -262,6 +279,40 @@ func getInvokeHostFunctionPreflight(ctx context.Context, params Parameters) (Pre
 	return GoPreflight(res), nil
 }
 
+// GetResourceFee computes the resource fee of a transaction with the given
+// resources, using the fee configuration of the network at params.LedgerSeq
+// (the CONFIG_SETTING ledger entries, obtained from params.LedgerEntryGetter)
+// and the fee model of params.ProtocolVersion. Only the ledger parameters of
+// params are used. The rent fee of the written entries isn't included.
+func GetResourceFee(ctx context.Context, params Parameters, resources TransactionResources) (int64, error) {
+	ssh := snapshotSourceHandle{
+		ledgerEntryGetter: params.LedgerEntryGetter,
+		ctx:               ctx,
+		logger:            params.Logger,
+	}
+	handle := cgo.NewHandle(ssh)
+	defer handle.Delete()
+
+	res := C.preflight_resource_fee(
+		C.uintptr_t(handle),
+		C.transaction_resources_t{
+			instructions:               C.uint32_t(resources.Instructions),
+			read_entries:               C.uint32_t(resources.ReadEntries),
+			write_entries:              C.uint32_t(resources.WriteEntries),
+			read_bytes:                 C.uint32_t(resources.ReadBytes),
+			write_bytes:                C.uint32_t(resources.WriteBytes),
+			contract_events_size_bytes: C.uint32_t(resources.ContractEventsSizeBytes),
+			transaction_size_bytes:     C.uint32_t(resources.TransactionSizeBytes),
+		},
+		getLedgerInfo(params),
+	)
+	result := GoPreflight(res)
+	if result.Error != "" {
+		return 0, errors.New(result.Error)
+	}
+	return result.MinFee, nil
+}
+
 func GoPreflight(result *C.preflight_result_t) Preflight {
 	defer C.free_preflight_result(result)
 
@@ -0,0 +1,23 @@
+package rpcdatastore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stellar/go/support/datastore"
+)
+
+// CheckConnectivity probes the datastore by looking up the object holding the
+// first ledger it's assumed to contain, so that misconfigured credentials or
+// buckets are detected before serving requests.
+func CheckConnectivity(ctx context.Context, dataStore datastore.DataStore) error {
+	key := dataStore.GetSchema().GetObjectKeyFromSequenceNumber(datastoreFirstLedger)
+	exists, err := dataStore.Exists(ctx, key)
+	if err != nil {
+		return fmt.Errorf("could not reach the datastore: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("the datastore doesn't contain ledger %d (object %q)", datastoreFirstLedger, key)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package rpcdatastore
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/datastore"
+)
+
+func TestCheckConnectivity(t *testing.T) {
+	ctx := t.Context()
+	schema := datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 1}
+	key := schema.GetObjectKeyFromSequenceNumber(datastoreFirstLedger)
+
+	store := new(datastore.MockDataStore)
+	store.On("GetSchema").Return(schema)
+	store.On("Exists", ctx, key).Return(true, nil).Once()
+	require.NoError(t, CheckConnectivity(ctx, store))
+
+	store.On("Exists", ctx, key).Return(false, nil).Once()
+	require.ErrorContains(t, CheckConnectivity(ctx, store), "doesn't contain ledger 2")
+
+	store.On("Exists", ctx, mock.Anything).Return(false, errors.New("permission denied")).Once()
+	require.ErrorContains(t, CheckConnectivity(ctx, store), "could not reach the datastore: permission denied")
+	store.AssertExpectations(t)
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/stellar/stellar-rpc/protocol"
 )
 
+// datastoreFirstLedger is the first ledger the datastore is assumed to hold.
+const datastoreFirstLedger = 2
+
 // LedgerBackendFactory creates a new ledger backend.
 type LedgerBackendFactory interface {
 	NewBufferedBackend(
@@ -88,6 +91,6 @@ func (r *ledgerReader) GetLedgers(ctx context.Context, start, end uint32) ([]xdr
 // TODO: Support fetching the actual range from the datastore.
 func (r *ledgerReader) GetAvailableLedgerRange(_ context.Context) (protocol.LedgerSeqRange, error) {
 	return protocol.LedgerSeqRange{
-		FirstLedger: 2, // Assume datastore holds all ledgers from genesis.
+		FirstLedger: datastoreFirstLedger, // Assume datastore holds all ledgers from genesis.
 	}, nil
 }
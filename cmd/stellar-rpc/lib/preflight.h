@@ -31,8 +31,20 @@ typedef struct xdr_diff_vector_t {
 
 typedef struct resource_config_t {
     uint64_t instruction_leeway; // Allow this many extra instructions when budgeting
+    uint64_t instruction_limit; // Override the network's per-transaction instruction limit (0 means no override)
+    uint64_t memory_limit; // Override the network's per-transaction memory limit (0 means no override)
 } resource_config_t;
 
+typedef struct transaction_resources_t {
+    uint32_t instructions;
+    uint32_t read_entries; // Number of ledger entries read (not counting the written ones)
+    uint32_t write_entries;
+    uint32_t read_bytes;
+    uint32_t write_bytes;
+    uint32_t contract_events_size_bytes;
+    uint32_t transaction_size_bytes;
+} transaction_resources_t;
+
 typedef struct preflight_result_t {
     char             *error; // Error string in case of error, otherwise null
     xdr_vector_t      auth; // array of SorobanAuthorizationEntries
@@ -60,6 +72,10 @@ preflight_result_t *preflight_footprint_ttl_op(uintptr_t   handle, // Go Handle
                                                const xdr_t footprint, // LedgerFootprint XDR
                                                const ledger_info_t ledger_info);
 
+// Only the error and min_fee fields of the result are set
+preflight_result_t *preflight_resource_fee(uintptr_t handle, // Go Handle to forward to SnapshotSourceGet
+                                           const transaction_resources_t resources,
+                                           const ledger_info_t ledger_info);
 
 // LedgerKey XDR to LedgerEntry XDR AND TTL
 typedef struct ledger_entry_and_ttl_t {
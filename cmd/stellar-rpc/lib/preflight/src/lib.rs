@@ -60,6 +60,22 @@ mod curr {
             shared::get_fallible_from_go_ledger_storage(self, key.as_ref())
         }
     }
+
+    // Protocol 23 only charges the reads of the entries which aren't in memory
+    // (i.e. the classic and the archived entries), hence the renamed fields.
+    pub(crate) fn transaction_resources(
+        resources: &crate::CTransactionResources,
+    ) -> soroban_env_host::fees::TransactionResources {
+        soroban_env_host::fees::TransactionResources {
+            instructions: resources.instructions,
+            disk_read_entries: resources.read_entries,
+            write_entries: resources.write_entries,
+            disk_read_bytes: resources.read_bytes,
+            write_bytes: resources.write_bytes,
+            contract_events_size_bytes: resources.contract_events_size_bytes,
+            transaction_size_bytes: resources.transaction_size_bytes,
+        }
+    }
 }
 
 #[path = "."]
@@ -85,6 +101,20 @@ mod prev {
             shared::get_fallible_from_go_ledger_storage(self, key.as_ref())
         }
     }
+
+    pub(crate) fn transaction_resources(
+        resources: &crate::CTransactionResources,
+    ) -> soroban_env_host::fees::TransactionResources {
+        soroban_env_host::fees::TransactionResources {
+            instructions: resources.instructions,
+            read_entries: resources.read_entries,
+            write_entries: resources.write_entries,
+            read_bytes: resources.read_bytes,
+            write_bytes: resources.write_bytes,
+            contract_events_size_bytes: resources.contract_events_size_bytes,
+            transaction_size_bytes: resources.transaction_size_bytes,
+        }
+    }
 }
 
 use std::cell::RefCell;
@@ -147,6 +177,22 @@ impl Default for CXDRDiffVector {
 #[derive(Copy, Clone)]
 pub struct CResourceConfig {
     pub instruction_leeway: u64,
+    // Override the network's per-transaction limits during simulation (0 means no override)
+    pub instruction_limit: u64,
+    pub memory_limit: u64,
+}
+
+#[repr(C)]
+#[derive(Copy, Clone)]
+pub struct CTransactionResources {
+    pub instructions: u32,
+    // Number of ledger entries read (not counting the written ones)
+    pub read_entries: u32,
+    pub write_entries: u32,
+    pub read_bytes: u32,
+    pub write_bytes: u32,
+    pub contract_events_size_bytes: u32,
+    pub transaction_size_bytes: u32,
 }
 
 #[repr(C)]
@@ -259,6 +305,24 @@ pub extern "C" fn preflight_footprint_ttl_op(
     }))
 }
 
+#[no_mangle]
+pub extern "C" fn preflight_resource_fee(
+    handle: libc::uintptr_t, // Go Handle to forward to SnapshotSourceGet and SnapshotSourceHas
+    resources: CTransactionResources,
+    ledger_info: CLedgerInfo,
+) -> *mut CPreflightResult {
+    let proto = ledger_info.protocol_version;
+    catch_preflight_panic(Box::new(move || {
+        if proto <= prev::PROTOCOL {
+            prev::shared::preflight_resource_fee_or_maybe_panic(handle, resources, ledger_info)
+        } else if proto == curr::PROTOCOL {
+            curr::shared::preflight_resource_fee_or_maybe_panic(handle, resources, ledger_info)
+        } else {
+            bail!("unsupported protocol version: {}", proto)
+        }
+    }))
+}
+
 fn preflight_error(str: String) -> CPreflightResult {
     let c_str = CString::new(str).unwrap();
     CPreflightResult {
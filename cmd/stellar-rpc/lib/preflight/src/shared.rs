@@ -12,6 +12,7 @@
 // `soroban_env_host` or `soroban_simulation` from `super::` rather than
 // `crate::`.
 use super::soroban_env_host::e2e_invoke::RecordingInvocationAuthMode;
+use super::soroban_env_host::fees::compute_transaction_resource_fee;
 use super::soroban_env_host::xdr::{
     AccountId, ExtendFootprintTtlOp, InvokeHostFunctionOp, LedgerEntry, LedgerFootprint, LedgerKey,
     OperationBody, ReadXdr, ScErrorCode, ScErrorType, SorobanTransactionData, WriteXdr,
@@ -30,8 +31,8 @@ use super::soroban_simulation::{AutoRestoringSnapshotSource, NetworkConfig};
 
 use crate::{
     anyhow, extract_error_string, from_c_string, from_c_xdr, string_to_c, vec_to_c_array,
-    CLedgerInfo, CPreflightResult, CResourceConfig, CXDRDiff, CXDRDiffVector, CXDRVector, Digest,
-    GoLedgerStorage, Result, Sha256, CXDR,
+    CLedgerInfo, CPreflightResult, CResourceConfig, CTransactionResources, CXDRDiff, CXDRDiffVector,
+    CXDRVector, Digest, GoLedgerStorage, Result, Sha256, CXDR,
 };
 use std::convert::TryFrom;
 use std::ptr::null_mut;
@@ -127,8 +128,16 @@ pub(crate) fn preflight_invoke_hf_op_or_maybe_panic(
         AccountId::from_xdr(unsafe { from_c_xdr(source_account) }, DEFAULT_XDR_RW_LIMITS).unwrap();
 
     let go_storage = Rc::new(GoLedgerStorage::new(handle));
-    let network_config =
+    let mut network_config =
         NetworkConfig::load_from_snapshot(go_storage.as_ref(), c_ledger_info.bucket_list_size)?;
+    // The budget overrides only apply to the simulation, the network limits are
+    // still enforced when submitting the transaction.
+    if resource_config.instruction_limit > 0 {
+        network_config.tx_max_instructions = i64::try_from(resource_config.instruction_limit)?;
+    }
+    if resource_config.memory_limit > 0 {
+        network_config.tx_memory_limit = u32::try_from(resource_config.memory_limit)?;
+    }
     let ledger_info = fill_ledger_info(c_ledger_info, &network_config);
     let auto_restore_snapshot = Rc::new(AutoRestoringSnapshotSource::new(
         go_storage.clone(),
@@ -271,6 +280,28 @@ fn preflight_restore_op(
     )
 }
 
+// Computes the resource fee of a transaction with the given resources, with the
+// fee configuration of the network (i.e. its current CONFIG_SETTING ledger
+// entries). The rent fee of the written entries, which depends on each entry,
+// isn't included.
+pub(crate) fn preflight_resource_fee_or_maybe_panic(
+    handle: libc::uintptr_t,
+    resources: CTransactionResources,
+    c_ledger_info: CLedgerInfo,
+) -> Result<CPreflightResult> {
+    let go_storage = GoLedgerStorage::new(handle);
+    let network_config =
+        NetworkConfig::load_from_snapshot(&go_storage, c_ledger_info.bucket_list_size)?;
+    let (non_refundable_fee, refundable_fee) = compute_transaction_resource_fee(
+        &super::transaction_resources(&resources),
+        &network_config.fee_configuration,
+    );
+    Ok(CPreflightResult {
+        min_fee: non_refundable_fee.saturating_add(refundable_fee),
+        ..Default::default()
+    })
+}
+
 // TODO: We could use something like https://github.com/sonos/ffi-convert-rs
 //       to replace all the free_* , *_to_c and from_c_* functions by implementations of CDrop,
 //       CReprOf and AsRust
@@ -8,6 +8,9 @@ import (
 const (
 	FormatBase64 = "base64"
 	FormatJSON   = "json"
+	// FormatNone omits the value altogether, it's only supported for
+	// 'resultMetaFormat'.
+	FormatNone = "none"
 )
 
 var errInvalidFormat = fmt.Errorf(
@@ -24,3 +27,19 @@ func IsValidFormat(format string) error {
 	}
 	return nil
 }
+
+var errInvalidResultMetaFormat = fmt.Errorf(
+	"expected %s for optional 'resultMetaFormat'",
+	strings.Join([]string{FormatBase64, FormatJSON, FormatNone}, ", "))
+
+func IsValidResultMetaFormat(format string) error {
+	switch format {
+	case "":
+	case FormatJSON:
+	case FormatBase64:
+	case FormatNone:
+	default:
+		return fmt.Errorf("got '%s': %w", format, errInvalidResultMetaFormat)
+	}
+	return nil
+}
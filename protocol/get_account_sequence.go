@@ -0,0 +1,18 @@
+package protocol
+
+const GetAccountSequenceMethodName = "getAccountSequence"
+
+type GetAccountSequenceRequest struct {
+	// AccountID is the strkey (G...) of the account.
+	AccountID string `json:"accountId"`
+}
+
+type GetAccountSequenceResponse struct {
+	// Exists is false when the account doesn't exist, in which case Sequence
+	// is zero.
+	Exists bool `json:"exists"`
+	// Sequence is the current sequence number of the account, the next
+	// transaction of the account must use Sequence+1.
+	Sequence     int64  `json:"sequence,string"`
+	LatestLedger uint32 `json:"latestLedger"`
+}
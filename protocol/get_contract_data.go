@@ -0,0 +1,115 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/strkey"
+)
+
+const GetContractDataMethodName = "getContractData"
+
+const (
+	// ContractDataKeyTypeSymbol makes the keys ScSymbol values. It's the
+	// default.
+	ContractDataKeyTypeSymbol = "symbol"
+	// ContractDataKeyTypeString makes the keys ScString values.
+	ContractDataKeyTypeString = "string"
+
+	// ContractDataDurabilityPersistent reads persistent entries. It's the
+	// default.
+	ContractDataDurabilityPersistent = "persistent"
+	// ContractDataDurabilityTemporary reads temporary entries.
+	ContractDataDurabilityTemporary = "temporary"
+)
+
+// GetContractDataRequest is the request for reading the contract data entries
+// stored under symbol (or string) keys, without building the ledger keys
+// client-side.
+//
+// Without keys, the request lists all the live entries of the contract (under
+// any key), page by page. Listing needs the contract data index of the server
+// (see the index-contract-data option).
+type GetContractDataRequest struct {
+	// ContractID is the strkey (C...) of the contract.
+	ContractID string   `json:"contractId"`
+	Keys       []string `json:"keys,omitempty"`
+	// KeyType is either ContractDataKeyTypeSymbol (the default) or
+	// ContractDataKeyTypeString.
+	KeyType string `json:"keyType,omitempty"`
+	// Durability is either ContractDataDurabilityPersistent or
+	// ContractDataDurabilityTemporary. It defaults to persistent when reading
+	// keys, and to both durabilities when listing.
+	Durability string `json:"durability,omitempty"`
+	Format     string `json:"xdrFormat,omitempty"`
+	// Pagination pages through the entries when listing. The limit caps the
+	// number of keys scanned, so a page may hold fewer entries (e.g. when
+	// some expired).
+	Pagination *LedgerPaginationOptions `json:"pagination,omitempty"`
+}
+
+// Listing tells whether the request lists all the entries of the contract.
+func (req GetContractDataRequest) Listing() bool {
+	return len(req.Keys) == 0
+}
+
+// IsValid checks the validity of the request parameters.
+func (req GetContractDataRequest) IsValid(maxKeys uint) error {
+	if _, err := strkey.Decode(strkey.VersionByteContract, req.ContractID); err != nil {
+		return fmt.Errorf("contractId is invalid: %w", err)
+	}
+	if req.Listing() {
+		if req.Pagination != nil && req.Pagination.Limit > maxKeys {
+			return fmt.Errorf("limit must not exceed %d", maxKeys)
+		}
+	} else if req.Pagination != nil {
+		return errors.New("pagination only applies when listing the entries (without keys)")
+	}
+	if uint(len(req.Keys)) > maxKeys {
+		return fmt.Errorf("key count (%d) exceeds maximum supported (%d)", len(req.Keys), maxKeys)
+	}
+	switch req.KeyType {
+	case "", ContractDataKeyTypeSymbol, ContractDataKeyTypeString:
+	default:
+		return fmt.Errorf("keyType must be either '%s' or '%s'", ContractDataKeyTypeSymbol, ContractDataKeyTypeString)
+	}
+	switch req.Durability {
+	case "", ContractDataDurabilityPersistent, ContractDataDurabilityTemporary:
+	default:
+		return fmt.Errorf("durability must be either '%s' or '%s'",
+			ContractDataDurabilityPersistent, ContractDataDurabilityTemporary)
+	}
+	return IsValidFormat(req.Format)
+}
+
+type ContractDataResult struct {
+	// Key is the requested key. When listing, it's the symbol (or string) of
+	// the key, if any.
+	Key string `json:"key"`
+	// KeyXDR is the base64-encoded xdr.ScVal of the key, only set when
+	// listing.
+	KeyXDR  string          `json:"keyXdr,omitempty"`
+	KeyJSON json.RawMessage `json:"keyJson,omitempty"`
+	// Durability is the durability of the entry, only set when listing.
+	Durability string `json:"durability,omitempty"`
+	// ValueXDR is the base64-encoded xdr.ScVal stored under the key.
+	ValueXDR  string          `json:"valueXdr,omitempty"`
+	ValueJSON json.RawMessage `json:"valueJson,omitempty"`
+	// Last modified ledger for this entry.
+	LastModifiedLedger uint32 `json:"lastModifiedLedgerSeq"`
+	// The ledger sequence until the entry is live.
+	LiveUntilLedgerSeq *uint32 `json:"liveUntilLedgerSeq,omitempty"`
+}
+
+type GetContractDataResponse struct {
+	// Entries contains the entries which exist, in the order of the request
+	// keys (or of their XDR-encoded ledger keys, when listing). All of them
+	// are read from the same ledger.
+	Entries []ContractDataResult `json:"entries"`
+	// Sequence number of the ledger the entries were read at.
+	LatestLedger uint32 `json:"latestLedger"`
+	// Cursor is the cursor to request the next page with when listing. It's
+	// empty once all the entries were listed.
+	Cursor string `json:"cursor,omitempty"`
+}
@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+const GetContractInterfaceMethodName = "getContractInterface"
+
+// GetContractInterfaceRequest identifies the contract code to inspect, either
+// through a deployed contract or directly through the hash of its Wasm.
+// Exactly one of ContractID and WasmHash must be set.
+type GetContractInterfaceRequest struct {
+	// ContractID is the strkey (C...) of a deployed contract.
+	ContractID string `json:"contractId,omitempty"`
+	// WasmHash is the hex-encoded hash of an uploaded contract Wasm.
+	WasmHash string `json:"wasmHash,omitempty"`
+	Format   string `json:"xdrFormat,omitempty"`
+}
+
+func (req GetContractInterfaceRequest) Validate() error {
+	if (req.ContractID == "") == (req.WasmHash == "") {
+		return errors.New("exactly one of contractId and wasmHash must be set")
+	}
+	return IsValidFormat(req.Format)
+}
+
+// GetContractInterfaceResponse contains the entries decoded from the custom
+// sections of a contract's Wasm. Sections missing from the Wasm result in
+// empty lists rather than an error.
+type GetContractInterfaceResponse struct {
+	// WasmHash is the hex-encoded hash of the inspected Wasm.
+	WasmHash string `json:"wasmHash"`
+	// Archived is true if the contract code (or the contract instance, when
+	// queried by contract ID) is archived and must be restored before use.
+	// The interface is still returned for archived code.
+	Archived bool `json:"archived"`
+
+	// SpecXDR contains the base64-encoded xdr.ScSpecEntry values of the
+	// contractspecv0 section, i.e. the contract's functions and types.
+	SpecXDR  []string          `json:"specXdr,omitempty"`
+	SpecJSON []json.RawMessage `json:"specJson,omitempty"`
+	// MetaXDR contains the base64-encoded xdr.ScMetaEntry values of the
+	// contractmetav0 section.
+	MetaXDR  []string          `json:"metaXdr,omitempty"`
+	MetaJSON []json.RawMessage `json:"metaJson,omitempty"`
+	// EnvMetaXDR contains the base64-encoded xdr.ScEnvMetaEntry values of the
+	// contractenvmetav0 section.
+	EnvMetaXDR  []string          `json:"envMetaXdr,omitempty"`
+	EnvMetaJSON []json.RawMessage `json:"envMetaJson,omitempty"`
+
+	LatestLedger uint32 `json:"latestLedger"`
+}
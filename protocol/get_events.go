@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,10 +19,15 @@ const (
 	MaxContractIDsLimit = 5
 	MinTopicCount       = 1
 	MaxTopicCount       = 4
+	MaxSegmentValues    = 5
 	WildCardExactOne    = "*"
 	WildCardZeroOrMore  = "**"
 )
 
+// MaxStateChangesEventsLimit bounds the page size of the getEvents requests
+// including state changes, which are expensive to compute.
+const MaxStateChangesEventsLimit = 100
+
 type EventInfo struct {
 	EventType      string `json:"type"`
 	Ledger         int32  `json:"ledger"`
@@ -41,6 +47,37 @@ type EventInfo struct {
 	// ValueXDR is a base64-encoded ScVal
 	ValueXDR  string          `json:"value,omitempty"`
 	ValueJSON json.RawMessage `json:"valueJson,omitempty"`
+
+	// TopicTyped and ValueTyped are the topic and value decoded into native
+	// JSON values, only set in the JSON format.
+	TopicTyped []TypedScVal `json:"topicTyped,omitempty"`
+	ValueTyped *TypedScVal  `json:"valueTyped,omitempty"`
+
+	// StateChanges are the ledger entry changes of the operation which
+	// emitted the event, only set when requested.
+	StateChanges []LedgerEntryChange `json:"stateChanges,omitempty"`
+
+	// InContractCreation is set when the event was emitted by an operation
+	// creating a contract (i.e. while running its constructor), only set when
+	// requested.
+	InContractCreation bool `json:"inContractCreation,omitempty"`
+}
+
+// TypedScVal is an ScVal decoded into a native JSON value, tagged with the
+// ScVal type (e.g. "u32", "i128", "symbol", "address").
+//
+// The 64-bit and larger integers are decimal strings (so that they don't lose
+// precision), the addresses are strkeys, the bytes are hex-encoded, the vecs
+// are lists of TypedScVals and the maps are lists of TypedScMapEntries. The
+// remaining types hold their xdr2json representation.
+type TypedScVal struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type TypedScMapEntry struct {
+	Key   TypedScVal `json:"key"`
+	Value TypedScVal `json:"value"`
 }
 
 const (
@@ -146,18 +183,85 @@ func (e EventTypeSet) matches(event xdr.ContractEvent) bool {
 	return ok
 }
 
+// EventFilter selects contract events. The matching rules are:
+//
+//   - A request matches an event if any of its filters matches it (OR across
+//     filters). A request without filters matches all the events.
+//   - A filter matches an event if its type, contract ID and topics all match
+//     (AND across fields). Empty fields match any event.
+//   - The topics match if any of the filter's topic filters matches (OR across
+//     topic filters).
+//   - A topic filter matches if every segment matches the topic at the same
+//     position (AND across positions), see TopicFilter.Matches.
+//   - A segment matches if it is a wildcard, or if the topic equals its ScVal
+//     or any of its alternative ScVals (OR within a position).
 type EventFilter struct {
 	EventType   EventTypeSet  `json:"type,omitempty"`
 	ContractIDs []string      `json:"contractIds,omitempty"`
 	Topics      []TopicFilter `json:"topics,omitempty"`
 }
 
+// EventsGroupByTransaction is the GetEventsRequest.GroupBy value which groups
+// the events by their parent transaction.
+const EventsGroupByTransaction = "transaction"
+
+// The GetEventsRequest.Aggregate values, which count the matching events (in
+// GetEventsResponse.Aggregates) rather than returning them:
+//   - EventsAggregateByContract counts the events per contract id.
+//   - EventsAggregateByName counts the events per event name, i.e. per first
+//     topic (usually a symbol).
+const (
+	EventsAggregateByContract = "contract"
+	EventsAggregateByName     = "name"
+)
+
+// MaxEventAggregates bounds the number of counts returned by the aggregating
+// getEvents requests, which return the largest ones.
+const MaxEventAggregates = 100
+
+// MaxTransactionHashesLimit bounds the number of transaction hashes the
+// getEvents requests can be filtered by.
+const MaxTransactionHashesLimit = 100
+
 type GetEventsRequest struct {
 	StartLedger uint32             `json:"startLedger,omitempty"`
 	EndLedger   uint32             `json:"endLedger,omitempty"`
 	Filters     []EventFilter      `json:"filters"`
 	Pagination  *PaginationOptions `json:"pagination,omitempty"`
 	Format      string             `json:"xdrFormat,omitempty"`
+	// GroupBy, when set to EventsGroupByTransaction, makes the response
+	// return the events grouped by transaction (in GetEventsResponse.Transactions)
+	// and the pagination limit apply to transactions rather than events.
+	GroupBy string `json:"groupBy,omitempty"`
+	// Ledger restricts the request to the events of a single ledger. It is
+	// equivalent to StartLedger=Ledger and EndLedger=Ledger+1, and cannot be
+	// combined with them.
+	Ledger uint32 `json:"ledger,omitempty"`
+	// IncludeStateChanges adds, to every event, the ledger entry changes of
+	// the operation which emitted it. The page size is then limited to
+	// MaxStateChangesEventsLimit.
+	IncludeStateChanges bool `json:"includeStateChanges,omitempty"`
+	// IncludeContractCreation flags (with EventInfo.InContractCreation) the
+	// events emitted by a transaction creating a contract, which only runs
+	// contract code (the constructor) from protocol 22 onwards. The contracts
+	// deployed from within a contract invocation aren't detected.
+	IncludeContractCreation bool `json:"includeContractCreation,omitempty"`
+	// Snapshot caps the pagination to the latest ledger at the time of the
+	// first page, which is encoded in the returned cursor, so that the pages
+	// give a consistent point-in-time view.
+	Snapshot bool `json:"snapshot,omitempty"`
+	// Aggregate (EventsAggregateByContract or EventsAggregateByName) makes the
+	// response count the matching events of the ledger range rather than
+	// return them, with the pagination limit bounding the number of counts
+	// (to MaxEventAggregates). Only the retention window can be aggregated,
+	// and the filters are restricted to what can be counted exactly (see
+	// validateAggregateFilters).
+	Aggregate string `json:"aggregate,omitempty"`
+	// TransactionHashes restricts the events to the ones emitted by any of
+	// the (hex-encoded) transactions, which must be within the retention
+	// window (see GetEventsResponse.UnknownTransactionHashes). The filters
+	// still apply to their events.
+	TransactionHashes []string `json:"txHashes,omitempty"`
 }
 
 func (g *GetEventsRequest) Valid(maxLimit uint) error {
@@ -165,8 +269,20 @@ func (g *GetEventsRequest) Valid(maxLimit uint) error {
 		return err
 	}
 
-	// Validate the paging limit (if it exists)
-	if g.Pagination != nil && g.Pagination.Cursor != nil {
+	if g.GroupBy != "" && g.GroupBy != EventsGroupByTransaction {
+		return fmt.Errorf("groupBy must be either empty or '%s'", EventsGroupByTransaction)
+	}
+
+	if g.Ledger != 0 {
+		if g.StartLedger != 0 || g.EndLedger != 0 {
+			return errors.New("ledger and ledger ranges cannot both be set")
+		}
+		// Paginating is allowed, as long as it doesn't leave the ledger
+		if g.Pagination != nil && g.Pagination.Cursor != nil && g.Pagination.Cursor.Ledger != g.Ledger {
+			return errors.New("cursor must be within the requested ledger")
+		}
+	} else if g.Pagination != nil && g.Pagination.Cursor != nil {
+		// Validate the paging limit (if it exists)
 		if g.StartLedger != 0 || g.EndLedger != 0 {
 			return errors.New("ledger ranges and cursor cannot both be set")
 		}
@@ -177,25 +293,93 @@ func (g *GetEventsRequest) Valid(maxLimit uint) error {
 	if g.Pagination != nil && g.Pagination.Limit > maxLimit {
 		return fmt.Errorf("limit must not exceed %d", maxLimit)
 	}
+	if g.IncludeStateChanges && g.Pagination != nil && g.Pagination.Limit > MaxStateChangesEventsLimit {
+		return fmt.Errorf("limit must not exceed %d when including state changes", MaxStateChangesEventsLimit)
+	}
+	if g.Aggregate != "" {
+		if err := g.validateAggregate(); err != nil {
+			return err
+		}
+	}
+	if err := g.validateTransactionHashes(); err != nil {
+		return err
+	}
+
+	return validateFilters(g.Filters)
+}
+
+func (g *GetEventsRequest) validateTransactionHashes() error {
+	if len(g.TransactionHashes) > MaxTransactionHashesLimit {
+		return fmt.Errorf("txHashes must not contain more than %d elements", MaxTransactionHashesLimit)
+	}
+	if len(g.TransactionHashes) > 0 && g.Aggregate != "" {
+		return errors.New("txHashes cannot be combined with aggregate")
+	}
+	for i, hash := range g.TransactionHashes {
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != 32 {
+			return fmt.Errorf("txHash %d invalid: must be a hex-encoded 32-byte hash", i+1)
+		}
+	}
+	return nil
+}
+
+func (g *GetEventsRequest) validateAggregate() error {
+	if g.Aggregate != EventsAggregateByContract && g.Aggregate != EventsAggregateByName {
+		return fmt.Errorf("aggregate must be either empty, '%s' or '%s'",
+			EventsAggregateByContract, EventsAggregateByName)
+	}
+	if g.GroupBy != "" || g.IncludeStateChanges || g.IncludeContractCreation {
+		return errors.New("aggregate cannot be combined with groupBy, includeStateChanges or includeContractCreation")
+	}
+	if g.Pagination != nil && g.Pagination.Limit > MaxEventAggregates {
+		return fmt.Errorf("limit must not exceed %d when aggregating", MaxEventAggregates)
+	}
+	return validateAggregateFilters(g.Filters)
+}
+
+// validateAggregateFilters checks that the events matching the filters are
+// exactly those selected by the database query (see EventReader), since
+// the aggregates are counted by the database. So there can be at most one
+// filter, and its topic filters can only constrain the first topic, followed
+// by the "**" wildcard (e.g. ["transfer", "**"]).
+func validateAggregateFilters(filters []EventFilter) error {
+	if len(filters) > 1 {
+		return errors.New("maximum 1 filter when aggregating")
+	}
+	for _, filter := range filters {
+		for i, topic := range filter.Topics {
+			if !topic.hasTrailingZeroOrMoreWildcard() || len(topic) > 2 ||
+				(len(topic) == 2 && topic[0].Wildcard != nil) {
+				return fmt.Errorf("topic %d invalid: only the first topic can be matched when aggregating", i+1)
+			}
+		}
+	}
+	return nil
+}
 
-	// Validate filters
-	if len(g.Filters) > MaxFiltersLimit {
+func validateFilters(filters []EventFilter) error {
+	if len(filters) > MaxFiltersLimit {
 		return errors.New("maximum 5 filters per request")
 	}
-	for i, filter := range g.Filters {
+	for i, filter := range filters {
 		if err := filter.Valid(); err != nil {
 			return fmt.Errorf("filter %d invalid: %w", i+1, err)
 		}
 	}
-
 	return nil
 }
 
 func (g *GetEventsRequest) Matches(event xdr.DiagnosticEvent) bool {
-	if len(g.Filters) == 0 {
+	return matchesAnyFilter(g.Filters, event)
+}
+
+// matchesAnyFilter returns true if the event matches at least one of the
+// filters, or if there are no filters at all.
+func matchesAnyFilter(filters []EventFilter, event xdr.DiagnosticEvent) bool {
+	if len(filters) == 0 {
 		return true
 	}
-	for _, filter := range g.Filters {
+	for _, filter := range filters {
 		if filter.Matches(event) {
 			return true
 		}
@@ -316,9 +500,13 @@ func (t TopicFilter) Matches(event []xdr.ScVal) bool {
 	return true
 }
 
+// SegmentFilter matches a single topic of an event. It is either a wildcard,
+// an exact ScVal or a list of alternative ScVals (encoded as a JSON array),
+// any of which matches.
 type SegmentFilter struct {
-	Wildcard *string    `json:"-"`
-	ScVal    *xdr.ScVal `json:"-"`
+	Wildcard *string     `json:"-"`
+	ScVal    *xdr.ScVal  `json:"-"`
+	ScVals   []xdr.ScVal `json:"-"`
 }
 
 func (s *SegmentFilter) Matches(segment xdr.ScVal) bool {
@@ -329,6 +517,8 @@ func (s *SegmentFilter) Matches(segment xdr.ScVal) bool {
 		if !s.ScVal.Equals(segment) {
 			return false
 		}
+	case len(s.ScVals) > 0:
+		return slices.ContainsFunc(s.ScVals, segment.Equals)
 	default:
 		panic("invalid segmentFilter")
 	}
@@ -336,18 +526,37 @@ func (s *SegmentFilter) Matches(segment xdr.ScVal) bool {
 	return true
 }
 
+// Values returns the ScVals matched by the segment, which is empty for
+// wildcards.
+func (s *SegmentFilter) Values() []xdr.ScVal {
+	if s.ScVal != nil {
+		return []xdr.ScVal{*s.ScVal}
+	}
+	return s.ScVals
+}
+
 func isValidWildCard(wildcard string) bool {
 	return wildcard == WildCardExactOne || wildcard == WildCardZeroOrMore
 }
 
 func (s *SegmentFilter) Valid() error {
-	if s.Wildcard != nil && s.ScVal != nil {
-		return errors.New("cannot set both wildcard and scval")
+	set := 0
+	for _, isSet := range []bool{s.Wildcard != nil, s.ScVal != nil, s.ScVals != nil} {
+		if isSet {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.New("must set only one of wildcard, scval or scval list")
 	}
-	if s.Wildcard == nil && s.ScVal == nil {
+	if set == 0 {
 		return errors.New("must set either wildcard or scval")
 	}
 
+	if s.ScVals != nil && (len(s.ScVals) == 0 || len(s.ScVals) > MaxSegmentValues) {
+		return fmt.Errorf("scval list must have between 1 and %d values", MaxSegmentValues)
+	}
+
 	if s.Wildcard != nil && !isValidWildCard(*s.Wildcard) {
 		return errors.New("wildcard must be '*' or '**'")
 	}
@@ -358,6 +567,20 @@ func (s *SegmentFilter) Valid() error {
 func (s *SegmentFilter) UnmarshalJSON(p []byte) error {
 	s.Wildcard = nil
 	s.ScVal = nil
+	s.ScVals = nil
+
+	var list []string
+	if err := json.Unmarshal(p, &list); err == nil {
+		s.ScVals = make([]xdr.ScVal, 0, len(list))
+		for _, item := range list {
+			var out xdr.ScVal
+			if err := xdr.SafeUnmarshalBase64(item, &out); err != nil {
+				return err
+			}
+			s.ScVals = append(s.ScVals, out)
+		}
+		return nil
+	}
 
 	var tmp string
 	if err := json.Unmarshal(p, &tmp); err != nil {
@@ -384,6 +607,18 @@ func (s SegmentFilter) MarshalJSON() ([]byte, error) {
 		return json.Marshal(*s.Wildcard)
 	}
 
+	if s.ScVals != nil {
+		list := make([]string, 0, len(s.ScVals))
+		for _, scVal := range s.ScVals {
+			scv, err := xdr.MarshalBase64(scVal)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, scv)
+		}
+		return json.Marshal(list)
+	}
+
 	scv, err := xdr.MarshalBase64(s.ScVal)
 	if err != nil {
 		return nil, err
@@ -394,10 +629,45 @@ func (s SegmentFilter) MarshalJSON() ([]byte, error) {
 type PaginationOptions struct {
 	Cursor *Cursor `json:"cursor,omitempty"`
 	Limit  uint    `json:"limit,omitempty"`
+	// SnapshotLedger caps the pages to the ledgers up to it, it's encoded in
+	// the cursor (see EncodeSnapshotCursor).
+	SnapshotLedger uint32 `json:"-"`
+}
+
+// TransactionEvents are the events of a single transaction, in order.
+type TransactionEvents struct {
+	TransactionHash string      `json:"txHash"`
+	Ledger          int32       `json:"ledger"`
+	LedgerClosedAt  string      `json:"ledgerClosedAt"`
+	TxIndex         uint32      `json:"transactionIndex"`
+	Events          []EventInfo `json:"events"`
+}
+
+// EventAggregate is the number of matching events of a contract (ContractID)
+// or with a name (Topic), depending on the aggregation.
+type EventAggregate struct {
+	// ContractID is empty for the events without a contract (e.g. the system
+	// events).
+	ContractID string `json:"contractId,omitempty"`
+	// Topic is the base64-encoded first topic (an ScVal) of the events, and
+	// Name is its value when it is a symbol or a string.
+	Topic string `json:"topic,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Count uint64 `json:"count"`
 }
 
 type GetEventsResponse struct {
 	Events []EventInfo `json:"events"`
+	// Transactions is only populated when grouping by transaction, in which
+	// case Events is empty.
+	Transactions []TransactionEvents `json:"transactions,omitempty"`
+	// Aggregates is only populated when aggregating, in which case Events is
+	// empty. The counts are sorted in decreasing order.
+	Aggregates []EventAggregate `json:"aggregates,omitempty"`
+	// UnknownTransactionHashes are the requested transaction hashes which
+	// aren't within the retention window (either because they are older or
+	// because they don't exist), whose events therefore can't be returned.
+	UnknownTransactionHashes []string `json:"unknownTxHashes,omitempty"`
 	// Cursor represents last populated event ID if total events reach the limit
 	// or end of the search window
 	Cursor string `json:"cursor"`
@@ -406,4 +676,12 @@ type GetEventsResponse struct {
 	OldestLedger          uint32 `json:"oldestLedger"`
 	LatestLedgerCloseTime int64  `json:"latestLedgerCloseTime,string"`
 	OldestLedgerCloseTime int64  `json:"oldestLedgerCloseTime,string"`
+	// FromDatastore is set when (some of) the events predate the retention
+	// window and were extracted from the ledgers of the datastore.
+	FromDatastore bool `json:"fromDatastore,omitempty"`
+	// LedgersScanned is the number of ledgers examined to produce the page,
+	// which tells how selective the filters of the query were.
+	LedgersScanned uint32 `json:"ledgersScanned,omitempty"`
+	// Page is the pagination metadata, which is common to the list methods.
+	Page PageInfo `json:"page"`
 }
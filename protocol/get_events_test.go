@@ -232,6 +232,38 @@ func TestTopicFilterMatches(t *testing.T) {
 			},
 		},
 
+		// Alternatives
+		{
+			name: "[ScSymbol(transfer), ScU64(64)]",
+			filter: []SegmentFilter{
+				{ScVals: []xdr.ScVal{transfer, number}},
+			},
+			includes: []xdr.ScVec{
+				{transfer},
+				{number},
+			},
+			excludes: []xdr.ScVec{
+				{},
+				{transfer, number},
+			},
+		},
+		{
+			name: "ScSymbol(transfer), [ScSymbol(transfer), ScU64(64)]",
+			filter: []SegmentFilter{
+				{ScVal: &transfer},
+				{ScVals: []xdr.ScVal{transfer, number}},
+			},
+			includes: []xdr.ScVec{
+				{transfer, transfer},
+				{transfer, number},
+			},
+			excludes: []xdr.ScVec{
+				{number, number},
+				{number, transfer},
+				{transfer},
+			},
+		},
+
 		// Star
 		{
 			name: "*",
@@ -649,12 +681,42 @@ func TestGetEventsRequestValid(t *testing.T) {
 		Pagination:  &PaginationOptions{Limit: 1001},
 	}).Valid(1000), "limit must not exceed 1000")
 
+	require.NoError(t, (&GetEventsRequest{
+		StartLedger: 1,
+		Filters:     []EventFilter{},
+		GroupBy:     EventsGroupByTransaction,
+	}).Valid(1000))
+
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger: 1,
+		Filters:     []EventFilter{},
+		GroupBy:     "ledger",
+	}).Valid(1000), "groupBy must be either empty or 'transaction'")
+
 	require.EqualError(t, (&GetEventsRequest{
 		StartLedger: 0,
 		Filters:     []EventFilter{},
 		Pagination:  nil,
 	}).Valid(1000), "startLedger must be positive")
 
+	require.NoError(t, (&GetEventsRequest{
+		Ledger:     5,
+		Filters:    []EventFilter{},
+		Pagination: &PaginationOptions{Cursor: &Cursor{Ledger: 5, Event: 3}},
+	}).Valid(1000))
+
+	require.EqualError(t, (&GetEventsRequest{
+		Ledger:      5,
+		StartLedger: 5,
+		Filters:     []EventFilter{},
+	}).Valid(1000), "ledger and ledger ranges cannot both be set")
+
+	require.EqualError(t, (&GetEventsRequest{
+		Ledger:     5,
+		Filters:    []EventFilter{},
+		Pagination: &PaginationOptions{Cursor: &Cursor{Ledger: 6}},
+	}).Valid(1000), "cursor must be within the requested ledger")
+
 	require.EqualError(t, (&GetEventsRequest{
 		StartLedger: 1,
 		Filters: []EventFilter{
@@ -834,6 +896,55 @@ func TestGetEventsRequestValid(t *testing.T) {
 		Pagination: nil,
 	}).Valid(1000), "filter 1 invalid: topic 1 invalid: "+
 		"segment 1 invalid: wildcard '**' is only allowed as the last segment")
+
+	transfer := xdr.ScSymbol("transfer")
+	transferSegment := SegmentFilter{ScVal: &xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &transfer}}
+	require.NoError(t, (&GetEventsRequest{
+		StartLedger: 1,
+		Filters: []EventFilter{{Topics: []TopicFilter{
+			{transferSegment, {Wildcard: &wildCardZeroOrMore}},
+		}}},
+		Aggregate: EventsAggregateByContract,
+	}).Valid(1000))
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger: 1,
+		Aggregate:   "ledger",
+	}).Valid(1000), "aggregate must be either empty, 'contract' or 'name'")
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger: 1,
+		Aggregate:   EventsAggregateByName,
+		Pagination:  &PaginationOptions{Limit: MaxEventAggregates + 1},
+	}).Valid(1000), "limit must not exceed 100 when aggregating")
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger: 1,
+		Filters:     []EventFilter{{}, {}},
+		Aggregate:   EventsAggregateByName,
+	}).Valid(1000), "maximum 1 filter when aggregating")
+	// the events with exactly one topic can't be counted by the database
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger: 1,
+		Filters:     []EventFilter{{Topics: []TopicFilter{{transferSegment}}}},
+		Aggregate:   EventsAggregateByName,
+	}).Valid(1000), "topic 1 invalid: only the first topic can be matched when aggregating")
+
+	txHash := strings.Repeat("ab", 32)
+	require.NoError(t, (&GetEventsRequest{
+		StartLedger:       1,
+		TransactionHashes: []string{txHash, strings.Repeat("cd", 32)},
+	}).Valid(1000))
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger:       1,
+		TransactionHashes: []string{txHash, "abcd"},
+	}).Valid(1000), "txHash 2 invalid: must be a hex-encoded 32-byte hash")
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger:       1,
+		TransactionHashes: make([]string, MaxTransactionHashesLimit+1),
+	}).Valid(1000), "txHashes must not contain more than 100 elements")
+	require.EqualError(t, (&GetEventsRequest{
+		StartLedger:       1,
+		TransactionHashes: []string{txHash},
+		Aggregate:         EventsAggregateByContract,
+	}).Valid(1000), "txHashes cannot be combined with aggregate")
 }
 
 func TestEventFilterSerialization(t *testing.T) {
@@ -857,6 +968,7 @@ func TestEventFilterSerialization(t *testing.T) {
 	}{
 		{SegmentFilter{Wildcard: &wc}, `"*"`},
 		{SegmentFilter{ScVal: &scv}, fmt.Sprintf(`"%s"`, b64)},
+		{SegmentFilter{ScVals: []xdr.ScVal{scv, scv}}, fmt.Sprintf(`["%s","%s"]`, b64, b64)},
 	} {
 		filter := EventFilter{Topics: []TopicFilter{{testCase.Filter}}}
 
@@ -867,8 +979,15 @@ func TestEventFilterSerialization(t *testing.T) {
 		f, err := json.Marshal(filter)
 		require.NoError(t, err)
 		require.JSONEq(t, fmt.Sprintf(`{"topics":[[%s]]}`, string(b)), string(f))
+
+		var decoded SegmentFilter
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		require.Equal(t, testCase.Filter, decoded)
 	}
 
+	_, err = json.Marshal(SegmentFilter{ScVals: []xdr.ScVal{}})
+	require.Error(t, err)
+
 	_, err = json.Marshal(SegmentFilter{})
 	require.Error(t, err)
 }
@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"github.com/stellar/go/xdr"
+)
+
+const GetEventsTipMethodName = "getEventsTip"
+
+// GetEventsTipRequest takes the same filters as GetEventsRequest and looks up
+// the most recent matching event.
+type GetEventsTipRequest struct {
+	Filters []EventFilter `json:"filters"`
+	// Cursor optionally restricts the search to events after the given cursor,
+	// e.g. the last event the client has already processed.
+	Cursor *Cursor `json:"cursor,omitempty"`
+}
+
+func (g *GetEventsTipRequest) Valid() error {
+	return validateFilters(g.Filters)
+}
+
+func (g *GetEventsTipRequest) Matches(event xdr.DiagnosticEvent) bool {
+	return matchesAnyFilter(g.Filters, event)
+}
+
+// EventTip identifies an event without including its body.
+type EventTip struct {
+	ID             string `json:"id"`
+	Ledger         uint32 `json:"ledger"`
+	LedgerClosedAt string `json:"ledgerClosedAt"`
+}
+
+type GetEventsTipResponse struct {
+	// Tip is the most recent matching event, or null if no event in the
+	// retention window (after the request cursor, if any) matched.
+	Tip *EventTip `json:"tip"`
+
+	LatestLedger          uint32 `json:"latestLedger"`
+	OldestLedger          uint32 `json:"oldestLedger"`
+	LatestLedgerCloseTime int64  `json:"latestLedgerCloseTime,string"`
+	OldestLedgerCloseTime int64  `json:"oldestLedgerCloseTime,string"`
+}
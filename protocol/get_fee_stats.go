@@ -1,7 +1,30 @@
 package protocol
 
+import "fmt"
+
 const GetFeeStatsMethodName = "getFeeStats"
 
+type GetFeeStatsRequest struct {
+	// IncludeLedgerFees adds the fee parameters of the latest ledger to the
+	// response.
+	IncludeLedgerFees bool `json:"includeLedgerFees,omitempty"`
+	// InclusionProbability (between 0 and 1, e.g. 0.9) adds the fees
+	// recommended to reach that probability of inclusion to the response.
+	InclusionProbability float64 `json:"inclusionProbability,omitempty"`
+	// SuccessfulOnly makes the fee distributions (and the recommended fees)
+	// only account for the successful transactions. By default, they account
+	// for all the transactions, including the failed ones, which pay fees too.
+	SuccessfulOnly bool `json:"successfulOnly,omitempty"`
+}
+
+// IsValid checks the validity of the request parameters.
+func (req GetFeeStatsRequest) IsValid() error {
+	if req.InclusionProbability < 0 || req.InclusionProbability > 1 {
+		return fmt.Errorf("inclusionProbability must be between 0 and 1, got %v", req.InclusionProbability)
+	}
+	return nil
+}
+
 type FeeDistribution struct {
 	Max              uint64 `json:"max,string"`
 	Min              uint64 `json:"min,string"`
@@ -21,8 +44,37 @@ type FeeDistribution struct {
 	LedgerCount      uint32 `json:"ledgerCount"`
 }
 
+// LedgerFees are the fee parameters of a ledger, as found in its header.
+type LedgerFees struct {
+	// BaseFee is the fee per operation, in stroops.
+	BaseFee uint32 `json:"baseFee"`
+	// BaseReserve is the reserve per ledger entry of accounts, in stroops.
+	BaseReserve     uint32 `json:"baseReserve"`
+	ProtocolVersion uint32 `json:"protocolVersion"`
+}
+
+// RecommendedFees are the inclusion fees (in stroops, per operation for
+// classic transactions) estimated to give a transaction the requested
+// probability of inclusion.
+//
+// They are derived from the fee distributions of the response, assuming that
+// the demand stays as it was in their window and that, under surge pricing,
+// transactions are included in fee order: a fee at the p-th percentile of the
+// recently included transactions outbids a fraction p of them. They are an
+// estimate, not a guarantee, and are the minimum base fee (100 stroops) when
+// no transactions were included in the window.
+type RecommendedFees struct {
+	InclusionProbability float64 `json:"inclusionProbability"`
+	SorobanInclusionFee  uint64  `json:"sorobanInclusionFee,string"`
+	InclusionFee         uint64  `json:"inclusionFee,string"`
+}
+
 type GetFeeStatsResponse struct {
 	SorobanInclusionFee FeeDistribution `json:"sorobanInclusionFee"`
 	InclusionFee        FeeDistribution `json:"inclusionFee"`
 	LatestLedger        uint32          `json:"latestLedger"`
+	// LatestLedgerFees is only set when requested with IncludeLedgerFees.
+	LatestLedgerFees *LedgerFees `json:"latestLedgerFees,omitempty"`
+	// RecommendedFees is only set when requested with InclusionProbability.
+	RecommendedFees *RecommendedFees `json:"recommendedFees,omitempty"`
 }
@@ -2,9 +2,18 @@ package protocol
 
 const GetHealthMethodName = "getHealth"
 
+// InitializingErrorCode is the JSON-RPC error code returned by getHealth and
+// the data methods until the server completes its initial sync with the
+// network. Until then, the data served would lag behind the network, so empty
+// results couldn't be told apart from missing data.
+const InitializingErrorCode = -32002
+
 type GetHealthResponse struct {
 	Status                string `json:"status"`
 	LatestLedger          uint32 `json:"latestLedger"`
 	OldestLedger          uint32 `json:"oldestLedger"`
 	LedgerRetentionWindow uint32 `json:"ledgerRetentionWindow"`
+	// CoreCircuitBreaker is the state (closed, half-open or open) of the
+	// circuit breaker around the captive-core query server, if enabled.
+	CoreCircuitBreaker string `json:"coreCircuitBreaker,omitempty"`
 }
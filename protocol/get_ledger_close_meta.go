@@ -0,0 +1,19 @@
+package protocol
+
+const GetLedgerCloseMetaMethodName = "getLedgerCloseMeta"
+
+// GetLedgerCloseMetaRequest is the request for the raw LedgerCloseMeta of a
+// single ledger (which can be several megabytes).
+type GetLedgerCloseMetaRequest struct {
+	Sequence uint32 `json:"sequence"`
+}
+
+type GetLedgerCloseMetaResponse struct {
+	Sequence uint32 `json:"sequence"`
+	// LedgerCloseMeta is the base64-encoded xdr.LedgerCloseMeta, as ingested.
+	LedgerCloseMeta string `json:"metadataXdr"`
+	LatestLedger    uint32 `json:"latestLedger"`
+	// OldestLedger is the oldest ledger available, including the ones only
+	// available in the datastore.
+	OldestLedger uint32 `json:"oldestLedger"`
+}
@@ -7,6 +7,14 @@ const GetLedgerEntriesMethodName = "getLedgerEntries"
 type GetLedgerEntriesRequest struct {
 	Keys   []string `json:"keys"`
 	Format string   `json:"xdrFormat,omitempty"`
+	// Keyed makes the response return the entries in EntriesByKey, rather
+	// than in Entries.
+	Keyed bool `json:"keyed,omitempty"`
+	// AtLedger, when set, returns the entries as of the given ledger instead
+	// of the latest one, so that the entries read by several requests are
+	// consistent. It is bound to the recent ledgers whose state is retained by
+	// captive core.
+	AtLedger uint32 `json:"atLedger,omitempty"`
 }
 
 type LedgerEntryResult struct {
@@ -25,6 +33,13 @@ type LedgerEntryResult struct {
 type GetLedgerEntriesResponse struct {
 	// All found ledger entries.
 	Entries []LedgerEntryResult `json:"entries"`
+	// EntriesByKey maps every requested key (as given in the request) to its
+	// ledger entry, or to null when it wasn't found. Only set for keyed
+	// requests.
+	EntriesByKey map[string]*LedgerEntryResult `json:"entriesByKey,omitempty"`
 	// Sequence number of the latest ledger at time of request.
 	LatestLedger uint32 `json:"latestLedger"`
+	// Ledger is the ledger whose state the entries reflect. Only set for the
+	// requests with AtLedger.
+	Ledger uint32 `json:"ledger,omitempty"`
 }
@@ -23,6 +23,14 @@ type GetLedgersRequest struct {
 	StartLedger uint32                   `json:"startLedger"`
 	Pagination  *LedgerPaginationOptions `json:"pagination,omitempty"`
 	Format      string                   `json:"xdrFormat,omitempty"`
+	// IncludeCounts requests per-ledger transaction and operation counts.
+	// It is opt-in because computing them requires decoding each ledger.
+	IncludeCounts bool `json:"includeCounts,omitempty"`
+	// IncludeMetaHash requests the SHA-256 of each ledger's raw LedgerCloseMeta XDR.
+	IncludeMetaHash bool `json:"includeMetaHash,omitempty"`
+	// IncludeResultCodes requests a per-ledger histogram of the transaction
+	// result codes. It is opt-in because it requires decoding each ledger.
+	IncludeResultCodes bool `json:"includeResultCodes,omitempty"`
 }
 
 // validate checks the validity of the request parameters.
@@ -46,6 +54,26 @@ type LedgerInfo struct {
 
 	LedgerMetadata     string          `json:"metadataXdr"`
 	LedgerMetadataJSON json.RawMessage `json:"metadataJson,omitempty"`
+
+	// LedgerMetadataHash is the hex-encoded SHA-256 of the binary
+	// LedgerCloseMeta XDR, only present when IncludeMetaHash is set in the request.
+	LedgerMetadataHash string `json:"metadataHash,omitempty"`
+
+	// Counts is only present when IncludeCounts is set in the request.
+	Counts *LedgerCounts `json:"counts,omitempty"`
+
+	// ResultCodes maps the transaction result codes (e.g. "txSUCCESS",
+	// "txBAD_SEQ") to the number of transactions of the ledger with that
+	// result. It is only present when IncludeResultCodes is set in the request.
+	ResultCodes map[string]uint32 `json:"resultCodes,omitempty"`
+}
+
+// LedgerCounts summarizes the transactions included in a ledger.
+type LedgerCounts struct {
+	TransactionCount           uint32 `json:"transactionCount"`
+	OperationCount             uint32 `json:"operationCount"`
+	SuccessfulTransactionCount uint32 `json:"successfulTransactionCount"`
+	FailedTransactionCount     uint32 `json:"failedTransactionCount"`
 }
 
 // GetLedgersResponse encapsulates the response structure for getLedgers queries.
@@ -56,6 +84,8 @@ type GetLedgersResponse struct {
 	OldestLedger          uint32       `json:"oldestLedger"`
 	OldestLedgerCloseTime int64        `json:"oldestLedgerCloseTime"`
 	Cursor                string       `json:"cursor"`
+	// Page is the pagination metadata, which is common to the list methods.
+	Page PageInfo `json:"page"`
 }
 
 // IsLedgerWithinRange checks whether the request start ledger/cursor is within
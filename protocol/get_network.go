@@ -8,4 +8,14 @@ type GetNetworkResponse struct {
 	FriendbotURL    string `json:"friendbotUrl,omitempty"`
 	Passphrase      string `json:"passphrase"`
 	ProtocolVersion int    `json:"protocolVersion"`
+	// LatestLedger is the ledger whose header holds the network parameters
+	// below, which can change with the network upgrades.
+	LatestLedger uint32 `json:"latestLedger"`
+	// BaseFee is the minimum fee per operation, in stroops.
+	BaseFee uint32 `json:"baseFee"`
+	// BaseReserve is the reserve per account entry, in stroops, from which
+	// the minimum balances are computed.
+	BaseReserve uint32 `json:"baseReserve"`
+	// MaxTxSetSize is the maximum number of (classic) operations per ledger.
+	MaxTxSetSize uint32 `json:"maxTxSetSize"`
 }
@@ -0,0 +1,143 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	GetOperationsMethodName   = "getOperations"
+	MaxOperationTypesLimit    = 10
+	MaxOperationAccountsLimit = 10
+)
+
+// OperationTypeNames maps the operation types to their names in the
+// getOperations requests and responses.
+//
+//nolint:gochecknoglobals
+var OperationTypeNames = map[xdr.OperationType]string{
+	xdr.OperationTypeCreateAccount:                 "create_account",
+	xdr.OperationTypePayment:                       "payment",
+	xdr.OperationTypePathPaymentStrictReceive:      "path_payment_strict_receive",
+	xdr.OperationTypeManageSellOffer:               "manage_sell_offer",
+	xdr.OperationTypeCreatePassiveSellOffer:        "create_passive_sell_offer",
+	xdr.OperationTypeSetOptions:                    "set_options",
+	xdr.OperationTypeChangeTrust:                   "change_trust",
+	xdr.OperationTypeAllowTrust:                    "allow_trust",
+	xdr.OperationTypeAccountMerge:                  "account_merge",
+	xdr.OperationTypeInflation:                     "inflation",
+	xdr.OperationTypeManageData:                    "manage_data",
+	xdr.OperationTypeBumpSequence:                  "bump_sequence",
+	xdr.OperationTypeManageBuyOffer:                "manage_buy_offer",
+	xdr.OperationTypePathPaymentStrictSend:         "path_payment_strict_send",
+	xdr.OperationTypeCreateClaimableBalance:        "create_claimable_balance",
+	xdr.OperationTypeClaimClaimableBalance:         "claim_claimable_balance",
+	xdr.OperationTypeBeginSponsoringFutureReserves: "begin_sponsoring_future_reserves",
+	xdr.OperationTypeEndSponsoringFutureReserves:   "end_sponsoring_future_reserves",
+	xdr.OperationTypeRevokeSponsorship:             "revoke_sponsorship",
+	xdr.OperationTypeClawback:                      "clawback",
+	xdr.OperationTypeClawbackClaimableBalance:      "clawback_claimable_balance",
+	xdr.OperationTypeSetTrustLineFlags:             "set_trust_line_flags",
+	xdr.OperationTypeLiquidityPoolDeposit:          "liquidity_pool_deposit",
+	xdr.OperationTypeLiquidityPoolWithdraw:         "liquidity_pool_withdraw",
+	xdr.OperationTypeInvokeHostFunction:            "invoke_host_function",
+	xdr.OperationTypeExtendFootprintTtl:            "extend_footprint_ttl",
+	xdr.OperationTypeRestoreFootprint:              "restore_footprint",
+}
+
+// ParseOperationType returns the operation type of the given name (see
+// OperationTypeNames).
+func ParseOperationType(name string) (xdr.OperationType, error) {
+	for opType, opName := range OperationTypeNames {
+		if opName == name {
+			return opType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown operation type: %q", name)
+}
+
+// GetOperationsRequest is the request for fetching the operations of the
+// transactions within a range of ledgers. It requires the operation
+// ingestion to be enabled.
+type GetOperationsRequest struct {
+	StartLedger uint32 `json:"startLedger"`
+	// EndLedger is the last ledger (inclusive) to search. It defaults to the
+	// latest ledger.
+	EndLedger uint32 `json:"endLedger,omitempty"`
+	// Types selects the operations of any of the types (see
+	// OperationTypeNames). All the types are selected if empty.
+	Types []string `json:"types,omitempty"`
+	// Accounts selects the operations whose source account (G...) is any of
+	// the accounts. All the accounts are selected if empty.
+	Accounts   []string                 `json:"accounts,omitempty"`
+	Pagination *LedgerPaginationOptions `json:"pagination,omitempty"`
+	Format     string                   `json:"xdrFormat,omitempty"`
+}
+
+// IsValid checks the validity of the request parameters.
+func (req GetOperationsRequest) IsValid(maxLimit uint, ledgerRange LedgerSeqRange) error {
+	if req.EndLedger != 0 && req.EndLedger < req.StartLedger {
+		return fmt.Errorf("endLedger (%d) must not be before startLedger (%d)", req.EndLedger, req.StartLedger)
+	}
+	if len(req.Types) > MaxOperationTypesLimit {
+		return fmt.Errorf("maximum %d operation types per request", MaxOperationTypesLimit)
+	}
+	for _, name := range req.Types {
+		if _, err := ParseOperationType(name); err != nil {
+			return err
+		}
+	}
+	if len(req.Accounts) > MaxOperationAccountsLimit {
+		return fmt.Errorf("maximum %d accounts per request", MaxOperationAccountsLimit)
+	}
+	for i, account := range req.Accounts {
+		if !strkey.IsValidEd25519PublicKey(account) {
+			return fmt.Errorf("account %d invalid", i+1)
+		}
+	}
+	return errors.Join(
+		ValidatePagination(req.StartLedger, req.Pagination, maxLimit, ledgerRange),
+		IsValidFormat(req.Format),
+	) // nils will coalesce
+}
+
+// OperationInfo is an operation of an ingested transaction.
+type OperationInfo struct {
+	// ID is the TOID of the operation, which can be used as a cursor.
+	ID               string `json:"id"`
+	Ledger           uint32 `json:"ledger"`
+	LedgerCloseTime  int64  `json:"createdAt"`
+	ApplicationOrder int32  `json:"applicationOrder"`
+	// OperationIndex is the (0-based) index of the operation in its
+	// transaction.
+	OperationIndex  int32  `json:"operationIndex"`
+	TransactionHash string `json:"txHash"`
+	// Type is the name of the operation type (see OperationTypeNames).
+	Type string `json:"type"`
+	// SourceAccount is the source account of the operation (or of its
+	// transaction, if the operation doesn't override it).
+	SourceAccount string `json:"sourceAccount"`
+	// Successful tells whether the transaction of the operation succeeded.
+	Successful bool `json:"successful"`
+	// Body holds the parameters of the operation (an xdr.OperationBody).
+	BodyXDR  string          `json:"bodyXdr,omitempty"`
+	BodyJSON json.RawMessage `json:"bodyJson,omitempty"`
+}
+
+// GetOperationsResponse contains the matching operations, in application
+// order. When there are no more operations in the requested range, Cursor
+// points past EndLedger, so that it can be used to poll for later operations.
+type GetOperationsResponse struct {
+	Operations            []OperationInfo `json:"operations"`
+	LatestLedger          uint32          `json:"latestLedger"`
+	LatestLedgerCloseTime int64           `json:"latestLedgerCloseTimestamp"`
+	OldestLedger          uint32          `json:"oldestLedger"`
+	OldestLedgerCloseTime int64           `json:"oldestLedgerCloseTimestamp"`
+	Cursor                string          `json:"cursor"`
+	// Page is the pagination metadata, which is common to the list methods.
+	Page PageInfo `json:"page"`
+}
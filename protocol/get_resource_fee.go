@@ -0,0 +1,34 @@
+package protocol
+
+const GetResourceFeeMethodName = "getResourceFee"
+
+// GetResourceFeeRequest describes the resources of a Soroban transaction, as
+// declared in its SorobanTransactionData.
+type GetResourceFeeRequest struct {
+	Instructions uint32 `json:"instructions,omitempty"`
+	// ReadEntries is the number of read-only ledger entries of the footprint
+	// (the written entries are accounted for by WriteEntries). Since protocol
+	// 23, only the entries which aren't live Soroban state (i.e. the classic
+	// and the archived entries) are charged as reads.
+	ReadEntries  uint32 `json:"readEntries,omitempty"`
+	WriteEntries uint32 `json:"writeEntries,omitempty"`
+	// ReadBytes is the size of the entries read (including the written ones).
+	ReadBytes  uint32 `json:"readBytes,omitempty"`
+	WriteBytes uint32 `json:"writeBytes,omitempty"`
+	// ContractEventsSizeBytes is the size of the XDR of the contract events
+	// emitted by the transaction.
+	ContractEventsSizeBytes uint32 `json:"contractEventsSizeBytes,omitempty"`
+	// TransactionSizeBytes is the size of the XDR of the transaction envelope.
+	TransactionSizeBytes uint32 `json:"transactionSizeBytes,omitempty"`
+}
+
+// GetResourceFeeResponse is the resource fee of the resources, computed with
+// the fee parameters of the network (its CONFIG_SETTING ledger entries) at the
+// latest ledger and the fee model of its protocol version. Unlike the
+// minResourceFee returned by simulateTransaction, it doesn't include the rent
+// fee of the written entries, which depends on each entry.
+type GetResourceFeeResponse struct {
+	ResourceFee     int64  `json:"resourceFee,string"`
+	LatestLedger    uint32 `json:"latestLedger"`
+	ProtocolVersion uint32 `json:"protocolVersion"`
+}
@@ -1,5 +1,7 @@
 package protocol
 
+import "encoding/json"
+
 const (
 	GetTransactionMethodName = "getTransaction"
 	// TransactionStatusSuccess indicates the transaction was included in the ledger and
@@ -11,6 +13,11 @@ const (
 	// TransactionStatusFailed indicates the transaction was included in the ledger and
 	// it was executed with an error.
 	TransactionStatusFailed = "FAILED"
+	// TransactionStatusPending indicates the transaction was not found in
+	// Stellar-RPC's transaction store yet, but it was recently submitted
+	// through this instance. It is only reported when enabled in the
+	// configuration.
+	TransactionStatusPending = "PENDING"
 )
 
 // GetTransactionResponse is the response for the Stellar-RPC getTransaction() endpoint
@@ -32,9 +39,132 @@ type GetTransactionResponse struct {
 	// bug in which `createdAt` in getTransactions is encoded as a number
 	// whereas in getTransaction (singular) it's encoded as a string.
 	LedgerCloseTime int64 `json:"createdAt,string"`
+	// LedgerMetadataHash is the hex-encoded SHA-256 of the binary
+	// LedgerCloseMeta XDR of the ledger including the transaction. It is only
+	// present when IncludeMetaHash is set in the request.
+	LedgerMetadataHash string `json:"ledgerMetadataHash,omitempty"`
+	// MetaDiagnosticEventsJSON holds the diagnostic events of the
+	// transaction's result meta (unlike DiagnosticEventsJSON, which merges all
+	// the events of the transaction). Each element is the JSON representation
+	// of an xdr.DiagnosticEvent, i.e. an object with an
+	// `in_successful_contract_call` boolean and the `event` (an
+	// xdr.ContractEvent, whose type is usually `diagnostic`). It is only
+	// present in the JSON format and when IncludeDiagnosticEvents is set in
+	// the request.
+	MetaDiagnosticEventsJSON []json.RawMessage `json:"metaDiagnosticEventsJson,omitempty"`
+	// Signatures are the decoded signatures of the transaction envelope. They
+	// are only present when IncludeSignatures is set in the request.
+	Signatures *TransactionSignatures `json:"signatures,omitempty"`
+	// FeeBreakdown splits the fee charged for a Soroban transaction. It is
+	// only present for Soroban transactions, when IncludeFeeBreakdown is set
+	// in the request.
+	FeeBreakdown *TransactionFeeBreakdown `json:"feeBreakdown,omitempty"`
+	// Invocation is the decoded host function of an InvokeHostFunction
+	// transaction. It is only present when IncludeInvocation is set in the
+	// request.
+	Invocation *HostFunctionInvocation `json:"invocation,omitempty"`
+}
+
+// The HostFunctionInvocation.Type values.
+const (
+	HostFunctionTypeInvokeContract   = "invoke_contract"
+	HostFunctionTypeCreateContract   = "create_contract"
+	HostFunctionTypeCreateContractV2 = "create_contract_v2"
+	HostFunctionTypeUploadWasm       = "upload_wasm"
+)
+
+// HostFunctionInvocation is the decoded host function invoked by a
+// transaction, whose fields depend on its Type.
+type HostFunctionInvocation struct {
+	Type string `json:"type"`
+	// ContractID and FunctionName are the invoked contract (strkey) and
+	// function, for HostFunctionTypeInvokeContract.
+	ContractID   string `json:"contractId,omitempty"`
+	FunctionName string `json:"functionName,omitempty"`
+	// Args are the arguments of the invoked function, or of the constructor
+	// for HostFunctionTypeCreateContractV2. Each one is a base64-encoded
+	// xdr.ScVal (ArgsXDR) or, in the JSON format, its JSON representation
+	// (ArgsJSON) and its decoded native value (ArgsTyped).
+	ArgsXDR   []string          `json:"argsXdr,omitempty"`
+	ArgsJSON  []json.RawMessage `json:"argsJson,omitempty"`
+	ArgsTyped []TypedScVal      `json:"argsTyped,omitempty"`
+	// Deployer (strkey) and Salt (hex) derive the address of the contracts
+	// created from an address.
+	Deployer string `json:"deployer,omitempty"`
+	Salt     string `json:"salt,omitempty"`
+	// Asset is the asset (e.g. "native" or "USDC:G...") of the created Stellar
+	// asset contracts.
+	Asset string `json:"asset,omitempty"`
+	// WasmHash is the hex-encoded hash of the code of the created contracts,
+	// or of the uploaded one.
+	WasmHash string `json:"wasmHash,omitempty"`
+}
+
+// TransactionFeeBreakdown splits the fee charged for a Soroban transaction
+// between its inclusion fee and its resource fee. All the amounts are in
+// stroops.
+type TransactionFeeBreakdown struct {
+	FeeCharged   int64 `json:"feeCharged,string"`
+	InclusionFee int64 `json:"inclusionFee,string"`
+	// NonRefundableResourceFee is the resource fee charged for the CPU
+	// instructions, the read/written bytes and the transaction size.
+	NonRefundableResourceFee int64 `json:"nonRefundableResourceFee,string"`
+	// RefundableResourceFee is the resource fee charged for the rent and the
+	// events, which includes RentFee.
+	RefundableResourceFee int64 `json:"refundableResourceFee,string"`
+	RentFee               int64 `json:"rentFee,string"`
+	// RefundedFee is the part of the resource fee declared in the transaction
+	// which wasn't charged.
+	RefundedFee int64 `json:"refundedFee,string"`
+}
+
+// TransactionSignatures are the signatures of a transaction envelope. For fee
+// bump transactions, the inner transaction signatures are reported
+// separately from the (outer) fee bump ones.
+type TransactionSignatures struct {
+	// EnvelopeHash is the hex-encoded hash of the transaction of the envelope
+	// (i.e. of the fee bump transaction for fee bumps).
+	EnvelopeHash string               `json:"envelopeHash"`
+	Signatures   []DecoratedSignature `json:"signatures"`
+	// InnerHash and InnerSignatures are only present for fee bump transactions.
+	InnerHash       string               `json:"innerHash,omitempty"`
+	InnerSignatures []DecoratedSignature `json:"innerSignatures,omitempty"`
+}
+
+// DecoratedSignature is a signature along with the hint of its signer.
+type DecoratedSignature struct {
+	// Hint is the hex-encoded last 4 bytes of the signer's public key.
+	Hint string `json:"hint"`
+	// Signature is the base64-encoded signature.
+	Signature string `json:"signature"`
 }
 
 type GetTransactionRequest struct {
 	Hash   string `json:"hash"`
 	Format string `json:"xdrFormat,omitempty"`
+	// IncludeMetaHash requests the SHA-256 of the raw LedgerCloseMeta XDR of
+	// the ledger including the transaction.
+	IncludeMetaHash bool `json:"includeMetaHash,omitempty"`
+	// ResultMetaFormat is the format of the result meta (base64, json or none
+	// to omit it). It defaults to Format.
+	ResultMetaFormat string `json:"resultMetaFormat,omitempty"`
+	// IncludeDiagnosticEvents requests the decoded diagnostic events of the
+	// transaction in the JSON format (see MetaDiagnosticEventsJSON). It
+	// defaults to true and is ignored in the XDR format.
+	IncludeDiagnosticEvents *bool `json:"includeDiagnosticEvents,omitempty"`
+	// IncludeSignatures requests the decoded signatures of the transaction
+	// envelope.
+	IncludeSignatures bool `json:"includeSignatures,omitempty"`
+	// IncludeFeeBreakdown requests the split of the fee charged for Soroban
+	// transactions (see TransactionFeeBreakdown).
+	IncludeFeeBreakdown bool `json:"includeFeeBreakdown,omitempty"`
+	// IncludeInvocation requests the decoded host function of InvokeHostFunction
+	// transactions (see HostFunctionInvocation).
+	IncludeInvocation bool `json:"includeInvocation,omitempty"`
+}
+
+// DiagnosticEventsIncluded tells whether the decoded diagnostic events are
+// requested.
+func (r GetTransactionRequest) DiagnosticEventsIncluded() bool {
+	return r.Format == FormatJSON && (r.IncludeDiagnosticEvents == nil || *r.IncludeDiagnosticEvents)
 }
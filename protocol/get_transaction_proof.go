@@ -0,0 +1,42 @@
+package protocol
+
+import "encoding/json"
+
+const GetTransactionProofMethodName = "getTransactionProof"
+
+type GetTransactionProofRequest struct {
+	Hash   string `json:"hash"`
+	Format string `json:"xdrFormat,omitempty"`
+}
+
+// GetTransactionProofResponse locates a transaction within the ledger which
+// included it, so that its inclusion can be verified independently: the
+// ledger hash is the SHA-256 of the (XDR-encoded) ledger header, which can be
+// cross-checked against another source (e.g. a history archive or another
+// RPC), and the header's txSetResultHash commits to the results of all the
+// transactions of the ledger, in application order.
+type GetTransactionProofResponse struct {
+	// LatestLedger is the latest ledger stored in Stellar-RPC.
+	LatestLedger uint32 `json:"latestLedger"`
+	// OldestLedger is the oldest ledger stored in Stellar-RPC.
+	OldestLedger uint32 `json:"oldestLedger"`
+
+	// Status is one of TransactionStatusSuccess, TransactionStatusFailed or
+	// TransactionStatusNotFound. The fields below are only present if the
+	// transaction was found.
+	Status          string `json:"status"`
+	TransactionHash string `json:"txHash"`
+	// Ledger is the sequence of the ledger which included the transaction.
+	Ledger uint32 `json:"ledger,omitempty"`
+	// ApplicationOrder is the (1-based) index of the transaction within the
+	// ledger, in application order.
+	ApplicationOrder int32 `json:"applicationOrder,omitempty"`
+	// LedgerHash is the hex-encoded hash of the ledger.
+	LedgerHash string `json:"ledgerHash,omitempty"`
+	// LedgerHeader is the xdr.LedgerHeader of the ledger.
+	LedgerHeaderXDR  string          `json:"ledgerHeaderXdr,omitempty"`
+	LedgerHeaderJSON json.RawMessage `json:"ledgerHeaderJson,omitempty"`
+	// Result is the xdr.TransactionResult of the transaction.
+	ResultXDR  string          `json:"resultXdr,omitempty"`
+	ResultJSON json.RawMessage `json:"resultJson,omitempty"`
+}
@@ -9,9 +9,27 @@ const GetTransactionsMethodName = "getTransactions"
 
 // GetTransactionsRequest represents the request parameters for fetching transactions within a range of ledgers.
 type GetTransactionsRequest struct {
+	// StartLedger is the first ledger to scan. With OrderDescending, the
+	// ledgers are scanned backwards from it and it defaults to the latest
+	// ledger.
 	StartLedger uint32                   `json:"startLedger"`
 	Pagination  *LedgerPaginationOptions `json:"pagination,omitempty"`
 	Format      string                   `json:"xdrFormat,omitempty"`
+	// Order is either OrderAscending (the default) or OrderDescending.
+	Order string `json:"order,omitempty"`
+	// Snapshot caps the pagination to the latest ledger at the time of the
+	// first page, which is encoded in the returned cursor, so that the pages
+	// give a consistent point-in-time view.
+	Snapshot bool `json:"snapshot,omitempty"`
+	// IncludeEvents attaches the contract and system events of every
+	// transaction (see TransactionInfo.Events), which can make the response
+	// much larger.
+	IncludeEvents bool `json:"includeEvents,omitempty"`
+}
+
+// IsDescending returns whether the newest transactions are requested first.
+func (req GetTransactionsRequest) IsDescending() bool {
+	return req.Order == OrderDescending
 }
 
 // IsValid checks the validity of the request parameters.
@@ -19,6 +37,7 @@ func (req GetTransactionsRequest) IsValid(maxLimit uint, ledgerRange LedgerSeqRa
 	return errors.Join(
 		ValidatePagination(req.StartLedger, req.Pagination, maxLimit, ledgerRange),
 		IsValidFormat(req.Format),
+		IsValidOrder(req.Order),
 	) // nils will coalesce
 }
 
@@ -57,6 +76,10 @@ type TransactionInfo struct {
 	// LedgerCloseTime is the unix timestamp of when the transaction was
 	// included in the ledger.
 	LedgerCloseTime int64 `json:"createdAt"`
+	// Events are the contract and system events emitted by the transaction,
+	// as returned by getEvents, when IncludeEvents is set. Like with getEvents,
+	// the diagnostic events are excluded and failed transactions have none.
+	Events []EventInfo `json:"events,omitempty"`
 }
 
 // GetTransactionsResponse encapsulates the response structure for getTransactions queries.
@@ -67,4 +90,8 @@ type GetTransactionsResponse struct {
 	OldestLedger          uint32            `json:"oldestLedger"`
 	OldestLedgerCloseTime int64             `json:"oldestLedgerCloseTimestamp"`
 	Cursor                string            `json:"cursor"`
+	// LedgersScanned is the number of ledgers examined to produce the page.
+	LedgersScanned uint32 `json:"ledgersScanned,omitempty"`
+	// Page is the pagination metadata, which is common to the list methods.
+	Page PageInfo `json:"page"`
 }
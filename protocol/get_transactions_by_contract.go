@@ -0,0 +1,51 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/strkey"
+)
+
+const GetTransactionsByContractMethodName = "getTransactionsByContract"
+
+// GetTransactionsByContractRequest is the request for fetching the
+// transactions which invoked a contract, either directly or through an
+// authorized sub-invocation, within a range of ledgers.
+type GetTransactionsByContractRequest struct {
+	// ContractID is the strkey (C...) of the contract.
+	ContractID  string `json:"contractId"`
+	StartLedger uint32 `json:"startLedger"`
+	// EndLedger is the last ledger (inclusive) to search. It defaults to the
+	// latest ledger.
+	EndLedger  uint32                   `json:"endLedger,omitempty"`
+	Pagination *LedgerPaginationOptions `json:"pagination,omitempty"`
+	Format     string                   `json:"xdrFormat,omitempty"`
+}
+
+// IsValid checks the validity of the request parameters.
+func (req GetTransactionsByContractRequest) IsValid(maxLimit uint, ledgerRange LedgerSeqRange) error {
+	if _, err := strkey.Decode(strkey.VersionByteContract, req.ContractID); err != nil {
+		return fmt.Errorf("contractId is invalid: %w", err)
+	}
+	if req.EndLedger != 0 && req.EndLedger < req.StartLedger {
+		return fmt.Errorf("endLedger (%d) must not be before startLedger (%d)", req.EndLedger, req.StartLedger)
+	}
+	return errors.Join(
+		ValidatePagination(req.StartLedger, req.Pagination, maxLimit, ledgerRange),
+		IsValidFormat(req.Format),
+	) // nils will coalesce
+}
+
+// GetTransactionsByContractResponse contains the transactions which invoked the
+// contract, in application order. When there are no more transactions in the
+// requested range, Cursor points past EndLedger, so that it can be used to
+// poll for later transactions.
+type GetTransactionsByContractResponse struct {
+	Transactions          []TransactionInfo `json:"transactions"`
+	LatestLedger          uint32            `json:"latestLedger"`
+	LatestLedgerCloseTime int64             `json:"latestLedgerCloseTimestamp"`
+	OldestLedger          uint32            `json:"oldestLedger"`
+	OldestLedgerCloseTime int64             `json:"oldestLedgerCloseTimestamp"`
+	Cursor                string            `json:"cursor"`
+}
@@ -0,0 +1,36 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+)
+
+const GetTransactionsByHashMethodName = "getTransactionsByHash"
+
+// GetTransactionsByHashRequest is the request for fetching several
+// transactions by hash at once.
+type GetTransactionsByHashRequest struct {
+	Hashes []string `json:"hashes"`
+	Format string   `json:"xdrFormat,omitempty"`
+}
+
+func (req GetTransactionsByHashRequest) Validate(maxHashes uint) error {
+	if len(req.Hashes) == 0 {
+		return errors.New("hashes must not be empty")
+	}
+	if uint(len(req.Hashes)) > maxHashes {
+		return fmt.Errorf("hashes must not contain more than %d elements", maxHashes)
+	}
+	return IsValidFormat(req.Format)
+}
+
+// GetTransactionsByHashResponse contains one entry per requested hash, in the
+// same order as the request. Transactions which are not found have Status
+// TransactionStatusNotFound and only their hash set.
+type GetTransactionsByHashResponse struct {
+	Transactions          []TransactionInfo `json:"transactions"`
+	LatestLedger          uint32            `json:"latestLedger"`
+	LatestLedgerCloseTime int64             `json:"latestLedgerCloseTime,string"`
+	OldestLedger          uint32            `json:"oldestLedger"`
+	OldestLedgerCloseTime int64             `json:"oldestLedgerCloseTime,string"`
+}
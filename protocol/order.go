@@ -0,0 +1,19 @@
+package protocol
+
+import "fmt"
+
+const (
+	// OrderAscending returns the oldest items first. It's the default.
+	OrderAscending = "asc"
+	// OrderDescending returns the newest items first.
+	OrderDescending = "desc"
+)
+
+func IsValidOrder(order string) error {
+	switch order {
+	case "", OrderAscending, OrderDescending:
+		return nil
+	default:
+		return fmt.Errorf("got '%s': expected %s or %s for optional 'order'", order, OrderAscending, OrderDescending)
+	}
+}
@@ -0,0 +1,24 @@
+package protocol
+
+// PageInfo is the pagination metadata of the responses of the list methods
+// (getEvents, getTransactions, getLedgers and getOperations), which is the
+// same across them, unlike their historical top-level fields.
+type PageInfo struct {
+	// Cursor is the cursor to request the next page with (the same as the
+	// Cursor of the response).
+	Cursor string `json:"cursor"`
+	// Limit is the maximum number of items of the page, once the defaults and
+	// the caps of the server are applied.
+	Limit uint `json:"limit"`
+	// HasMore tells whether more items may follow in the requested range.
+	// When false, the range was exhausted and Cursor can be used to poll for
+	// the later items.
+	HasMore bool `json:"hasMore"`
+	// Finalized tells whether the page only covers ledgers before the latest
+	// one, in which case it won't change as ledgers are closed (and it can be
+	// cached).
+	Finalized bool `json:"finalized,omitempty"`
+	// OldestLedger and LatestLedger are the range of ledgers available.
+	OldestLedger uint32 `json:"oldestLedger"`
+	LatestLedger uint32 `json:"latestLedger"`
+}
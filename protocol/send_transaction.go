@@ -37,4 +37,10 @@ type SendTransactionRequest struct {
 	// Transaction is the base64 encoded transaction envelope.
 	Transaction string `json:"transaction"`
 	Format      string `json:"xdrFormat,omitempty"`
+	// IdempotencyKey is an optional client-chosen key. Repeated submissions
+	// with the same key (and the same transaction) within the server's
+	// idempotency window return the prior result instead of being forwarded
+	// to stellar-core again. Reusing a key with a different xdrFormat is
+	// rejected.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 }
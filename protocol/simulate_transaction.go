@@ -20,10 +20,20 @@ type SimulateTransactionRequest struct {
 	ResourceConfig *ResourceConfig `json:"resourceConfig,omitempty"`
 	AuthMode       string          `json:"authMode,omitempty"`
 	Format         string          `json:"xdrFormat,omitempty"`
+	// AtLedger, when set, simulates the transaction against the ledger entries
+	// as of the given (past) ledger, instead of the latest one. It is bound to
+	// the recent ledgers whose state is retained by captive core.
+	AtLedger uint32 `json:"atLedger,omitempty"`
 }
 
 type ResourceConfig struct {
 	InstructionLeeway uint64 `json:"instructionLeeway"`
+	// InstructionLimit and MemoryLimit, when non-zero, override the network's
+	// per-transaction CPU instruction and memory limits. They are meant for
+	// debugging contracts which run out of budget, as they only apply to the
+	// simulation: submitted transactions are still subject to the network limits.
+	InstructionLimit uint64 `json:"instructionLimit,omitempty"`
+	MemoryLimit      uint64 `json:"memoryLimit,omitempty"`
 }
 
 func DefaultResourceConfig() ResourceConfig {
@@ -0,0 +1,75 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// snapshotCursorSeparator separates the position from the snapshot ledger in
+// the cursors of snapshot-consistent pagination, e.g.
+// "0000000433791696896-0000000000@1234".
+const snapshotCursorSeparator = "@"
+
+// EncodeSnapshotCursor appends the snapshot ledger to a cursor, so that the
+// following pages are capped to it. A snapshotLedger of 0 leaves the cursor
+// untouched.
+func EncodeSnapshotCursor(cursor string, snapshotLedger uint32) string {
+	if snapshotLedger == 0 {
+		return cursor
+	}
+	return cursor + snapshotCursorSeparator + strconv.FormatUint(uint64(snapshotLedger), 10)
+}
+
+// DecodeSnapshotCursor splits a cursor encoded with EncodeSnapshotCursor into
+// its position and snapshot ledger, which is 0 for plain cursors.
+func DecodeSnapshotCursor(cursor string) (string, uint32, error) {
+	position, ledger, found := strings.Cut(cursor, snapshotCursorSeparator)
+	if !found {
+		return cursor, 0, nil
+	}
+	snapshotLedger, err := strconv.ParseUint(ledger, 10, 32)
+	if err != nil || snapshotLedger == 0 {
+		return "", 0, fmt.Errorf("invalid snapshot ledger in cursor %s", cursor)
+	}
+	return position, uint32(snapshotLedger), nil
+}
+
+type paginationOptionsJSON struct {
+	Cursor string `json:"cursor,omitempty"`
+	Limit  uint   `json:"limit,omitempty"`
+}
+
+// MarshalJSON encodes the snapshot ledger, if any, in the cursor.
+func (p PaginationOptions) MarshalJSON() ([]byte, error) {
+	var raw paginationOptionsJSON
+	if p.Cursor != nil {
+		raw.Cursor = EncodeSnapshotCursor(p.Cursor.String(), p.SnapshotLedger)
+	}
+	raw.Limit = p.Limit
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes the snapshot ledger, if any, from the cursor.
+func (p *PaginationOptions) UnmarshalJSON(b []byte) error {
+	var raw paginationOptionsJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*p = PaginationOptions{Limit: raw.Limit}
+	if raw.Cursor == "" {
+		return nil
+	}
+	position, snapshotLedger, err := DecodeSnapshotCursor(raw.Cursor)
+	if err != nil {
+		return err
+	}
+	cursor, err := ParseCursor(position)
+	if err != nil {
+		return err
+	}
+	p.Cursor = &cursor
+	p.SnapshotLedger = snapshotLedger
+	return nil
+}
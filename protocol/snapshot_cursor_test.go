@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotCursor(t *testing.T) {
+	assert.Equal(t, "123", EncodeSnapshotCursor("123", 0))
+	assert.Equal(t, "123@45", EncodeSnapshotCursor("123", 45))
+
+	position, snapshotLedger, err := DecodeSnapshotCursor("123@45")
+	require.NoError(t, err)
+	assert.Equal(t, "123", position)
+	assert.Equal(t, uint32(45), snapshotLedger)
+
+	position, snapshotLedger, err = DecodeSnapshotCursor("123")
+	require.NoError(t, err)
+	assert.Equal(t, "123", position)
+	assert.Zero(t, snapshotLedger)
+
+	for _, cursor := range []string{"123@", "123@0", "123@-1", "123@abc"} {
+		_, _, err = DecodeSnapshotCursor(cursor)
+		require.Error(t, err, cursor)
+	}
+}
+
+func TestPaginationOptionsJSON(t *testing.T) {
+	cursor := Cursor{Ledger: 5, Tx: 1, Event: 2}
+	options := PaginationOptions{Cursor: &cursor, Limit: 10, SnapshotLedger: 7}
+	encoded, err := json.Marshal(options)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"cursor": "`+cursor.String()+`@7", "limit": 10}`, string(encoded))
+
+	var decoded PaginationOptions
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, options, decoded)
+
+	// plain cursors are still supported
+	require.NoError(t, json.Unmarshal([]byte(`{"cursor": "`+cursor.String()+`"}`), &decoded))
+	assert.Equal(t, PaginationOptions{Cursor: &cursor}, decoded)
+
+	encoded, err = json.Marshal(PaginationOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"limit": 1}`, string(encoded))
+}